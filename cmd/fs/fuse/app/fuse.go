@@ -18,6 +18,7 @@ package app
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"strings"
 	"time"
@@ -80,6 +81,7 @@ func Init() error {
 	opts.IgnoreSecurityLabels = fuseConf.IgnoreSecurityLabels
 	opts.DisableXAttrs = fuseConf.DisableXAttrs
 	opts.AllowOther = fuseConf.AllowOther
+	opts.EnableLocks = fuseConf.EnableLocks
 	if err := InitVFS(); err != nil {
 		log.Errorf("init vfs failed: %v", err)
 		return err
@@ -216,13 +218,48 @@ func InitVFS() error {
 		vfs.WithMemorySize(config.FuseConf.Fuse.MemorySize),
 		vfs.WithMemoryExpire(config.FuseConf.Fuse.MemoryExpire),
 		vfs.WithBlockSize(config.FuseConf.Fuse.BlockSize),
+		vfs.WithReadAhead(config.FuseConf.Fuse.ReadAhead),
 		vfs.WithDiskCachePath(config.FuseConf.Fuse.DiskCachePath),
 		vfs.WithDiskExpire(config.FuseConf.Fuse.DiskExpire),
+		vfs.WithMetaDriver(config.FuseConf.Fuse.Meta.Driver),
+		vfs.WithMetaCachePath(config.FuseConf.Fuse.Meta.CachePath),
+		vfs.WithMetaCacheExpire(config.FuseConf.Fuse.Meta.CacheExpire),
+		vfs.WithMetaMountPoint(fuseConf.MountPoint),
+		vfs.WithSharedLocks(!fuseConf.Local && fuseConf.SharedLocks),
 	)
 
 	if _, err := vfs.InitVFS(fsMeta, links, true, vfsConfig); err != nil {
 		log.Errorf("init vfs failed: %v", err)
 		return err
 	}
+	if loadFile := config.FuseConf.Fuse.Meta.LoadFile; loadFile != "" {
+		if err := loadMeta(loadFile); err != nil {
+			log.Errorf("load meta from file[%s] failed: %v", loadFile, err)
+			return err
+		}
+	}
 	return nil
 }
+
+// loadMeta 使用DumpMeta导出的文件替换持久化的meta，需要在挂载前调用
+func loadMeta(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return vfs.GetVFS().Meta.LoadMeta(f)
+}
+
+// DumpMeta 将持久化的meta导出到文件，可以通过--meta-load-file在其他机器或挂载点上加载
+func DumpMeta(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err = vfs.GetVFS().Meta.DumpMeta(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
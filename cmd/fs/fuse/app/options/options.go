@@ -63,11 +63,25 @@ func (f *FuseOption) AddFlagSet(fs *pflag.FlagSet) {
 	fs.IntVar(&fuseConf.LinkUpdateInterval, "link-update-interval", fuseConf.LinkUpdateInterval, "The link update interval")
 	fs.StringVar(&fuseConf.LinkMetaDirPrefix, "link-meta-dir-prefix", fuseConf.LinkMetaDirPrefix, "The link meta dir prefix")
 	fs.BoolVar(&fuseConf.SkipCheckLinks, "skip-check-links", fuseConf.SkipCheckLinks, "Skip check links")
+	fs.BoolVar(&fuseConf.EnableLocks, "enable-locks", fuseConf.EnableLocks, "Enable flock and posix locks")
+	fs.BoolVar(&fuseConf.SharedLocks, "shared-locks", fuseConf.SharedLocks,
+		"Share locks with other mounts of the file system by pfs server, locks are kept in memory of a single pfs server")
 	fs.DurationVar(&fuseConf.MemoryExpire, "mem-cache-expire", fuseConf.MemoryExpire, "The fuse memory data cache expire")
 	fs.IntVar(&fuseConf.MemorySize, "mem-size", fuseConf.MemorySize, "the number of cache item in mem cache")
 	fs.DurationVar(&fuseConf.DiskExpire, "disk-cache-expire", fuseConf.DiskExpire, "The fuse disk data cache expire")
 	fs.IntVar(&fuseConf.BlockSize, "block-size", fuseConf.BlockSize, "The fuse block size")
+	fs.IntVar(&fuseConf.ReadAhead, "read-ahead", fuseConf.ReadAhead,
+		"The number of blocks prefetched into cache on sequential read, 0 means no prefetch")
 	fs.StringVar(&fuseConf.DiskCachePath, "disk-cache-path", fuseConf.DiskCachePath, "The disk cache path")
+	fs.StringVar(&fuseConf.Meta.Driver, "meta-driver", fuseConf.Meta.Driver, "The meta driver, mem or bolt")
+	fs.StringVar(&fuseConf.Meta.CachePath, "meta-cache-path", fuseConf.Meta.CachePath,
+		"The path of persistent meta, which has a store for each fs and mount point")
+	fs.DurationVar(&fuseConf.Meta.CacheExpire, "meta-cache-expire", fuseConf.Meta.CacheExpire,
+		"The expire of attributes and entries cached by persistent meta")
+	fs.StringVar(&fuseConf.Meta.LoadFile, "meta-load-file", fuseConf.Meta.LoadFile,
+		"The file dumped by --meta-dump-file, loaded into persistent meta before mount")
+	fs.StringVar(&fuseConf.Meta.DumpFile, "meta-dump-file", fuseConf.Meta.DumpFile,
+		"The file persistent meta is dumped to after umount")
 }
 
 func (f *FuseOption) InitFlag(fs *pflag.FlagSet) {
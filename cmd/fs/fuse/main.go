@@ -26,6 +26,7 @@ import (
 
 	"paddleflow/cmd/fs/fuse/app"
 	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/fs/client/base"
 	"paddleflow/pkg/fs/client/vfs"
 )
 
@@ -64,4 +65,16 @@ func main() {
 		os.Exit(-1)
 	}
 	server.Wait()
+
+	if dumpFile := config.FuseConf.Fuse.Meta.DumpFile; dumpFile != "" {
+		if err := app.DumpMeta(dumpFile); err != nil {
+			log.Errorf("dump meta to file[%s] failed: %v", dumpFile, err)
+		}
+	}
+
+	if !config.FuseConf.Fuse.Local && config.FuseConf.Fuse.SharedLocks {
+		if err := base.Client.ReleaseLocks(); err != nil {
+			log.Errorf("release shared locks failed: %v", err)
+		}
+	}
 }
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The PaddlePaddle Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CleanPodPolicy describes how to deal with pods when the job is finished.
+type CleanPodPolicy string
+
+const (
+	CleanAlways       CleanPodPolicy = "Always"
+	CleanNever        CleanPodPolicy = "Never"
+	CleanOnFailure    CleanPodPolicy = "OnFailure"
+	CleanOnCompletion CleanPodPolicy = "OnCompletion"
+)
+
+// Intranet describes the network between pods of the job.
+type Intranet string
+
+const (
+	PodIP       Intranet = "PodIP"
+	Service     Intranet = "Service"
+	HostNetwork Intranet = "Host"
+)
+
+// PaddleJobMode describes the training mode of the job.
+type PaddleJobMode string
+
+const (
+	PaddleJobModePS         PaddleJobMode = "PS"
+	PaddleJobModeCollective PaddleJobMode = "Collective"
+	PaddleJobModeSingle     PaddleJobMode = "Single"
+)
+
+// PaddleJobPhase is the phase of the job.
+type PaddleJobPhase string
+
+const (
+	Starting    PaddleJobPhase = "Starting"
+	Pending     PaddleJobPhase = "Pending"
+	Scaling     PaddleJobPhase = "Scaling"
+	Aborting    PaddleJobPhase = "Aborting"
+	Aborted     PaddleJobPhase = "Aborted"
+	Running     PaddleJobPhase = "Running"
+	Restarting  PaddleJobPhase = "Restarting"
+	Completing  PaddleJobPhase = "Completing"
+	Completed   PaddleJobPhase = "Completed"
+	Terminating PaddleJobPhase = "Terminating"
+	Terminated  PaddleJobPhase = "Terminated"
+	Failed      PaddleJobPhase = "Failed"
+	Succeed     PaddleJobPhase = "Succeed"
+	Unknown     PaddleJobPhase = "Unknown"
+)
+
+// ElasticStatus is the status of elastic training.
+type ElasticStatus string
+
+const (
+	ElasticStatusNone ElasticStatus = "NONE"
+	ElasticStatusING  ElasticStatus = "ING"
+	ElasticStatusDone ElasticStatus = "DONE"
+	ElasticStatusErr  ElasticStatus = "ERROR"
+)
+
+// SchedulingPolicy embed schedule policy of volcano
+type SchedulingPolicy struct {
+	MinAvailable  *int32              `json:"minAvailable,omitempty"`
+	Queue         string              `json:"queue,omitempty"`
+	PriorityClass string              `json:"priorityClass,omitempty"`
+	MinResources  corev1.ResourceList `json:"minResources,omitempty"`
+}
+
+// SampleSetRef references a sample set for data cache.
+type SampleSetRef struct {
+	// Name of the SampleSet.
+	Name string `json:"name"`
+	// Mount Path of SampleSet volume.
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// ResourceSpec describes a role of the job, e.g. ps or worker.
+type ResourceSpec struct {
+	// Replicas replica
+	Replicas int `json:"replicas,omitempty"`
+	// Requests set the minimal replicas of server to be run
+	Requests *int `json:"requests,omitempty"`
+	// Limits set the maximal replicas of server to be run, elastic is auto enabled if limits is set larger than 0
+	Limits *int `json:"limits,omitempty"`
+	// Template specifies the podspec of a server
+	Template corev1.PodTemplateSpec `json:"template,omitempty"`
+}
+
+// PaddleJobSpec defines the desired state of PaddleJob
+type PaddleJobSpec struct {
+	// CleanPodPolicy defines whether to clean pod after job finished
+	CleanPodPolicy CleanPodPolicy `json:"cleanPodPolicy,omitempty"`
+	// Intranet defines the communication mode inter pods : PodIP, Service or Host
+	Intranet Intranet `json:"intranet,omitempty"`
+	// WithGloo indicate whether enable gloo, 0/1/2 for disable/enable for worker/enable for server
+	WithGloo *int `json:"withGloo,omitempty"`
+	// SampleSetRef defines the sample data set used for training and its mount path in worker pods
+	SampleSetRef *SampleSetRef `json:"sampleSetRef,omitempty"`
+	// SchedulingPolicy defines the policy related to scheduling, for volcano
+	SchedulingPolicy *SchedulingPolicy `json:"schedulingPolicy,omitempty"`
+	// Elastic indicate the elastic level
+	Elastic *int `json:"elastic,omitempty"`
+	// PS[erver] describes the spec of server base on pod template
+	PS *ResourceSpec `json:"ps,omitempty"`
+	// Worker describes the spec of worker base on pod template
+	Worker *ResourceSpec `json:"worker,omitempty"`
+	// Heter describes the spec of heter worker base on pod template
+	Heter *ResourceSpec `json:"heter,omitempty"`
+}
+
+// ResourceStatus is the status of pods of a role.
+type ResourceStatus struct {
+	// Pending
+	Pending int `json:"pending,omitempty"`
+	// Starting
+	Starting int `json:"starting,omitempty"`
+	// Running
+	Running int `json:"running,omitempty"`
+	// Failed
+	Failed int `json:"failed,omitempty"`
+	// Success
+	Succeeded int `json:"succeeded,omitempty"`
+	// Unknown
+	Unknown int `json:"unknown,omitempty"`
+	// A list of pointer to pods
+	Refs []corev1.ObjectReference `json:"refs,omitempty"`
+}
+
+// PaddleJobStatus defines the observed state of PaddleJob
+type PaddleJobStatus struct {
+	// The phase of PaddleJob.
+	Phase PaddleJobPhase `json:"phase,omitempty"`
+	// Mode indicates in which the PaddleJob run with : PS/Collective/Single
+	Mode PaddleJobMode `json:"mode,omitempty"`
+	// ResourceStatues of ps
+	PS *ResourceStatus `json:"ps,omitempty"`
+	// ResourceStatues of worker
+	Worker *ResourceStatus `json:"worker,omitempty"`
+	// ResourceStatues of heter
+	Heter *ResourceStatus `json:"heter,omitempty"`
+	// Elastic status
+	Elastic ElasticStatus `json:"elastic,omitempty"`
+	// StartTime indicate when the job started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime indicate when the job finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// ObservedGeneration is the generation observed by the operator
+	ObservedGeneration int `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// PaddleJob is the Schema for the paddlejobs API
+type PaddleJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PaddleJobSpec   `json:"spec,omitempty"`
+	Status PaddleJobStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// PaddleJobList contains a list of PaddleJob
+type PaddleJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PaddleJob `json:"items"`
+}
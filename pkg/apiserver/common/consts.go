@@ -24,6 +24,7 @@ const (
 	PrefixCache    = "cch-"
 	PrefixGrant    = "grant"
 	PrefixCluster  = "cluster"
+	PrefixWebhook  = "wh-"
 
 	ResourceTypeRun           = "run"
 	ResourceTypeRunCache      = "run_cache"
@@ -34,6 +35,9 @@ const (
 	ResourceTypeImage         = "image"
 	ResourceTypePipeline      = "pipeline"
 	ResourceTypeCluster       = "cluster"
+	ResourceTypeJob           = "job"
+	ResourceTypeJobTemplate   = "job_template"
+	ResourceTypeWebhook       = "webhook"
 
 	HeaderKeyRequestID     = "x-pf-request-id"
 	HeaderKeyUserName      = "x-pf-user-name"
@@ -61,17 +61,29 @@ const (
 	QueueNameNotFound         = "QueueNameNotFound"
 	QueueResourceNotMatch     = "QueueResourceNotMatch"
 	QueueIsNotClosed          = "QueueIsNotClosed"
+	QueueResourceNotEnough    = "QueueResourceNotEnough"
+	InvalidReclaimPolicy      = "InvalidReclaimPolicy"
 
 	GrantResourceTypeNotFound = "GrantResourceTypeNotFound"
 	GrantNotFound             = "GrantNotFound"
 	GrantAlreadyExist         = "GrantAlreadyExist"
 	GrantRootActionNotSupport = "GrantRootActionNotSupport"
 
-	RunNameDuplicated     = "RunNameDuplicated"
-	RunNotFound           = "RunNotFound"
-	PipelineNotFound      = "PipelineNotFound"
-	RunCacheNotFound      = "RunCacheNotFound"
-	ArtifactEventNotFound = "ArtifactEventNotFound"
+	RunNameDuplicated       = "RunNameDuplicated"
+	RunNotFound             = "RunNotFound"
+	RunStepNotFound         = "RunStepNotFound"
+	PipelineNotFound        = "PipelineNotFound"
+	PipelineVersionNotFound = "PipelineVersionNotFound"
+	RunCacheNotFound        = "RunCacheNotFound"
+	ArtifactEventNotFound   = "ArtifactEventNotFound"
+
+	JobNotFound = "JobNotFound"
+
+	JobTemplateNotFound   = "JobTemplateNotFound"
+	JobTemplateDuplicated = "JobTemplateDuplicated"
+	InvalidJobTemplate    = "InvalidJobTemplate"
+
+	WebhookNotFound = "WebhookNotFound"
 
 	FlavourNotFound = "FlavourNotFound"
 
@@ -116,12 +128,24 @@ var errorHTTPStatus = map[string]int{
 	QueueNameNotFound:         http.StatusBadRequest,
 	QueueResourceNotMatch:     http.StatusBadRequest,
 	QueueIsNotClosed:          http.StatusBadRequest,
+	QueueResourceNotEnough:    http.StatusBadRequest,
+	InvalidReclaimPolicy:      http.StatusBadRequest,
+
+	RunNameDuplicated:       http.StatusBadRequest,
+	RunNotFound:             http.StatusNotFound,
+	RunStepNotFound:         http.StatusNotFound,
+	PipelineNotFound:        http.StatusBadRequest,
+	PipelineVersionNotFound: http.StatusNotFound,
+	RunCacheNotFound:        http.StatusBadRequest,
+	ArtifactEventNotFound:   http.StatusBadRequest,
+
+	JobNotFound: http.StatusNotFound,
 
-	RunNameDuplicated:     http.StatusBadRequest,
-	RunNotFound:           http.StatusNotFound,
-	PipelineNotFound:      http.StatusBadRequest,
-	RunCacheNotFound:      http.StatusBadRequest,
-	ArtifactEventNotFound: http.StatusBadRequest,
+	JobTemplateNotFound:   http.StatusNotFound,
+	JobTemplateDuplicated: http.StatusBadRequest,
+	InvalidJobTemplate:    http.StatusBadRequest,
+
+	WebhookNotFound: http.StatusNotFound,
 
 	GrantResourceTypeNotFound: http.StatusBadRequest,
 	GrantNotFound:             http.StatusBadRequest,
@@ -169,12 +193,24 @@ var errorMessage = map[string]string{
 	QueueNameNotFound:         "QueueName does not exist",
 	QueueResourceNotMatch:     "Queue resource is not match",
 	QueueIsNotClosed:          "Queue should be closed before delete",
+	QueueResourceNotEnough:    "Resource request of job exceeds capacity of queue",
+	InvalidReclaimPolicy:      "Reclaim policy is invalid",
+
+	RunNameDuplicated:       "Run name already exists",
+	RunNotFound:             "RunID not found",
+	RunStepNotFound:         "Step of run not found",
+	PipelineNotFound:        "Pipeline not found",
+	PipelineVersionNotFound: "Pipeline version not found",
+	RunCacheNotFound:        "RunCache not found",
+	ArtifactEventNotFound:   "ArtifactEvent not found",
+
+	JobNotFound: "JobID not found",
+
+	JobTemplateNotFound:   "Job template not found",
+	JobTemplateDuplicated: "Job template already exists",
+	InvalidJobTemplate:    "Job template is invalid",
 
-	RunNameDuplicated:     "Run name already exists",
-	RunNotFound:           "RunID not found",
-	PipelineNotFound:      "Pipeline not found",
-	RunCacheNotFound:      "RunCache not found",
-	ArtifactEventNotFound: "ArtifactEvent not found",
+	WebhookNotFound: "WebhookID not found",
 
 	GrantResourceTypeNotFound: "This kind of resource is not exist",
 	GrantNotFound:             "Grant not found. check the user and resource",
@@ -25,6 +25,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/apiserver/models"
@@ -105,12 +106,9 @@ func GetK8sClient(clusterName, credential string) (*kubernetes.Clientset, error)
 		return clientSet, nil
 	}
 
-	// credential base64 string 解码成 []byte
-	credentialBytes, decodeErr := base64.StdEncoding.DecodeString(credential)
-	if decodeErr != nil {
-		errMsg := fmt.Sprintf("decode cluster[%s] credential base64 string error! msg: %s",
-			clusterName, decodeErr.Error())
-		return nil, errors.New(errMsg)
+	credentialBytes, err := decodeCredential(clusterName, credential)
+	if err != nil {
+		return nil, err
 	}
 
 	clientSet, err := GetK8sClientFromKubeConfigBytes(credentialBytes)
@@ -123,6 +121,26 @@ func GetK8sClient(clusterName, credential string) (*kubernetes.Clientset, error)
 	return clientSet, nil
 }
 
+// GetKubeConfig 根据集群的 credential 获取访问集群的 rest config，用于创建 dynamic client 等其他类型的 client
+func GetKubeConfig(clusterName, credential string) (*rest.Config, error) {
+	credentialBytes, err := decodeCredential(clusterName, credential)
+	if err != nil {
+		return nil, err
+	}
+	return config.InitKubeConfigFromBytes(credentialBytes)
+}
+
+// decodeCredential credential base64 string 解码成 []byte
+func decodeCredential(clusterName, credential string) ([]byte, error) {
+	credentialBytes, decodeErr := base64.StdEncoding.DecodeString(credential)
+	if decodeErr != nil {
+		errMsg := fmt.Sprintf("decode cluster[%s] credential base64 string error! msg: %s",
+			clusterName, decodeErr.Error())
+		return nil, errors.New(errMsg)
+	}
+	return credentialBytes, nil
+}
+
 func validateClusterStatus(clusterStatus string) error {
 	validClusterStatusList := []string{
 		models.ClusterStatusOnLine,
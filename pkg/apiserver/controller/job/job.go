@@ -0,0 +1,246 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	pferrors "paddleflow/pkg/common/errors"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/fs/server/utils/fs"
+	"paddleflow/pkg/job"
+)
+
+// CreateJobRequest 单独提交的任务，队列、类型、模式、规格等均通过env传递，与pipeline中的step保持一致
+type CreateJobRequest struct {
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Command  string            `json:"command"`
+	FsName   string            `json:"fsname"`
+	UserName string            `json:"username,omitempty"` // optional, only for root user
+	Env      map[string]string `json:"env"`
+}
+
+type CreateJobResponse struct {
+	JobID string `json:"jobID"`
+}
+
+type JobBrief struct {
+	ID           string `json:"jobID"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	UserName     string `json:"username"`
+	QueueName    string `json:"queueName"`
+	Message      string `json:"message"`
+	Status       string `json:"status"`
+	CreateTime   string `json:"createTime"`
+	ActivateTime string `json:"activateTime"`
+}
+
+type ListJobResponse struct {
+	common.MarkerInfo
+	JobList []JobBrief `json:"jobList"`
+}
+
+func (b *JobBrief) modelToListResp(job models.Job) {
+	b.ID = job.ID
+	b.Name = job.Config.Name
+	b.Type = job.Type
+	b.UserName = job.UserName
+	b.QueueName = job.QueueName
+	b.Message = job.Message
+	b.Status = string(job.Status)
+	b.CreateTime = job.CreatedAt.Format("2006-01-02 15:04:05")
+	if job.ActivatedAt.Valid {
+		b.ActivateTime = job.ActivatedAt.Time.Format("2006-01-02 15:04:05")
+	}
+}
+
+func CreateJob(ctx *logger.RequestContext, request *CreateJobRequest) (CreateJobResponse, error) {
+	userName := ctx.UserName
+	if common.IsRootUser(ctx.UserName) && request.UserName != "" {
+		// root user can submit job on behalf of other users
+		userName = request.UserName
+	}
+	env := make(map[string]string, len(request.Env)+2)
+	for k, v := range request.Env {
+		env[k] = v
+	}
+	// user and fs are determined by request context, not by env
+	env[schema.EnvJobUserName] = userName
+	if request.FsName != "" {
+		env[schema.EnvJobFsID] = fs.ID(userName, request.FsName)
+	} else {
+		delete(env, schema.EnvJobFsID)
+	}
+	conf := &models.Conf{
+		Name:    request.Name,
+		Image:   request.Image,
+		Command: request.Command,
+		Env:     env,
+	}
+	jobID, err := job.CreateJob(conf)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		if pfErr, ok := err.(*pferrors.PFError); ok {
+			switch pfErr.Code {
+			case pferrors.InvalidJobConf:
+				ctx.ErrorCode = common.InappropriateJSON
+			case pferrors.QueueResourceNotEnough:
+				ctx.ErrorCode = common.QueueResourceNotEnough
+			}
+		}
+		ctx.Logging().Errorf("create job failed. error:%s", err.Error())
+		return CreateJobResponse{}, err
+	}
+	ctx.Logging().Debugf("create job successful. jobID:%s", jobID)
+	return CreateJobResponse{JobID: jobID}, nil
+}
+
+func ListJob(ctx *logger.RequestContext, marker string, maxKeys int, userFilter, queueFilter, statusFilter []string) (ListJobResponse, error) {
+	ctx.Logging().Debugf("begin list job.")
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]",
+				marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return ListJobResponse{}, err
+		}
+	}
+	// normal user list its own
+	if !common.IsRootUser(ctx.UserName) {
+		userFilter = []string{ctx.UserName}
+	}
+	jobList, err := models.ListJob(ctx.Logging(), pk, maxKeys, userFilter, queueFilter, statusFilter)
+	if err != nil {
+		ctx.Logging().Errorf("models list job failed. err:[%s]", err.Error())
+		ctx.ErrorCode = common.InternalError
+		return ListJobResponse{}, err
+	}
+	listJobResponse := ListJobResponse{JobList: []JobBrief{}}
+
+	// get next marker
+	listJobResponse.IsTruncated = false
+	if len(jobList) > 0 {
+		job := jobList[len(jobList)-1]
+		if !isLastJobPk(ctx, job.Pk) {
+			nextMarker, err := common.EncryptPk(job.Pk)
+			if err != nil {
+				ctx.Logging().Errorf("EncryptPk error. pk:[%d] error:[%s]",
+					job.Pk, err.Error())
+				ctx.ErrorCode = common.InternalError
+				return ListJobResponse{}, err
+			}
+			listJobResponse.NextMarker = nextMarker
+			listJobResponse.IsTruncated = true
+		}
+	}
+	listJobResponse.MaxKeys = maxKeys
+	for _, job := range jobList {
+		briefJob := JobBrief{}
+		briefJob.modelToListResp(job)
+		listJobResponse.JobList = append(listJobResponse.JobList, briefJob)
+	}
+	return listJobResponse, nil
+}
+
+func isLastJobPk(ctx *logger.RequestContext, pk int64) bool {
+	lastJob, err := models.GetLastJob(ctx.Logging())
+	if err != nil {
+		ctx.Logging().Errorf("get last job failed. error:[%s]", err.Error())
+	}
+	if lastJob.Pk == pk {
+		return true
+	}
+	return false
+}
+
+func GetJobByID(ctx *logger.RequestContext, jobID string) (models.Job, error) {
+	ctx.Logging().Debugf("begin get job by id. jobID:%s", jobID)
+	jobInfo, err := job.GetJobByID(jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.JobNotFound
+			ctx.Logging().Errorln(err.Error())
+			return models.Job{}, common.NotFoundError(common.ResourceTypeJob, jobID)
+		}
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get job[%s] failed. error:%s", jobID, err.Error())
+		return models.Job{}, err
+	}
+	if !common.IsRootUser(ctx.UserName) && ctx.UserName != jobInfo.UserName {
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeJob, jobID)
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorln(err.Error())
+		return models.Job{}, err
+	}
+	return jobInfo, nil
+}
+
+func StopJob(ctx *logger.RequestContext, jobID string) error {
+	ctx.Logging().Debugf("begin stop job. jobID:%s", jobID)
+	jobInfo, err := GetJobByID(ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("stop job[%s] failed when getting job. error: %v", jobID, err)
+		return err
+	}
+	if jobInfo.Status == schema.StatusJobTerminating || job.IsImmutableJobStatus(jobInfo.Status) {
+		err := fmt.Errorf("cannot stop job[%s] as job is already in status[%s]", jobID, jobInfo.Status)
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if err := job.StopJobByID(jobID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("stop job[%s] failed. error:%s", jobID, err.Error())
+		return err
+	}
+	ctx.Logging().Debugf("stop job succeed. jobID:%s", jobID)
+	return nil
+}
+
+func DeleteJob(ctx *logger.RequestContext, jobID string) error {
+	ctx.Logging().Debugf("begin delete job: %s", jobID)
+	jobInfo, err := GetJobByID(ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("delete job[%s] failed when getting job. error: %v", jobID, err)
+		return err
+	}
+	// check final status
+	if !job.IsImmutableJobStatus(jobInfo.Status) {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err := fmt.Errorf("job[%s] is in status[%s]. only jobs in final status can be deleted", jobID, jobInfo.Status)
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if err := job.DeleteJobByID(jobID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("delete job[%s] failed. error:%s", jobID, err.Error())
+		return err
+	}
+	ctx.Logging().Debugf("delete job succeed. jobID:%s", jobID)
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"context"
+	"io"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job"
+)
+
+// GetJobLog 获取任务各个task、各个container的分页日志
+func GetJobLog(ctx *logger.RequestContext, jobID string, request schema.JobLogRequest) (schema.JobLogInfo, error) {
+	ctx.Logging().Debugf("begin get job log. jobID:%s request:%+v", jobID, request)
+	jobInfo, err := GetJobByID(ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("get log of job[%s] failed when getting job. error: %v", jobID, err)
+		return schema.JobLogInfo{}, err
+	}
+	jobLogInfo, err := job.GetJobLog(jobInfo, request)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get log of job[%s] failed. error:%s", jobID, err.Error())
+		return schema.JobLogInfo{}, err
+	}
+	return jobLogInfo, nil
+}
+
+// GetJobLogStream 持续获取任务某个container的日志，streamCtx 结束（如客户端断开连接）时日志流会被关闭，调用方负责关闭返回的stream
+func GetJobLogStream(ctx *logger.RequestContext, streamCtx context.Context, jobID string, request schema.JobLogRequest) (io.ReadCloser, error) {
+	ctx.Logging().Debugf("begin get job log stream. jobID:%s request:%+v", jobID, request)
+	jobInfo, err := GetJobByID(ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("get log stream of job[%s] failed when getting job. error: %v", jobID, err)
+		return nil, err
+	}
+	stream, err := job.GetJobLogStream(streamCtx, jobInfo, request)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get log stream of job[%s] failed. error:%s", jobID, err.Error())
+		return nil, err
+	}
+	return stream, nil
+}
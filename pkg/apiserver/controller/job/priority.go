@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job"
+)
+
+type UpdateJobPriorityRequest struct {
+	Priority string `json:"priority"`
+}
+
+type PendingJobBrief struct {
+	JobBrief
+	Priority string `json:"priority"`
+	Position int    `json:"position"`
+}
+
+type ListPendingJobResponse struct {
+	QueueName string            `json:"queueName"`
+	JobList   []PendingJobBrief `json:"jobList"`
+}
+
+// UpdateJobPriority 修改排队中任务的优先级，无需重新提交任务
+func UpdateJobPriority(ctx *logger.RequestContext, jobID string, request *UpdateJobPriorityRequest) error {
+	ctx.Logging().Debugf("begin update priority of job. jobID:%s priority:%s", jobID, request.Priority)
+	if err := job.ValidateJobPriority(request.Priority); err != nil {
+		ctx.ErrorCode = common.InappropriateJSON
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	jobInfo, err := GetJobByID(ctx, jobID)
+	if err != nil {
+		ctx.Logging().Errorf("update priority of job[%s] failed when getting job. error: %v", jobID, err)
+		return err
+	}
+	if jobInfo.Status != schema.StatusJobPending {
+		err := fmt.Errorf("job[%s] is in status[%s]. only pending jobs can be updated priority", jobID, jobInfo.Status)
+		ctx.ErrorCode = common.ActionNotAllowed
+		ctx.Logging().Errorln(err.Error())
+		return err
+	}
+	if err := job.UpdateJobPriority(jobID, request.Priority); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("update priority of job[%s] failed. error:%s", jobID, err.Error())
+		return err
+	}
+	ctx.Logging().Debugf("update priority of job succeed. jobID:%s", jobID)
+	return nil
+}
+
+// ListPendingJob 按调度顺序列出队列中排队的任务
+func ListPendingJob(ctx *logger.RequestContext, queueName string) (ListPendingJobResponse, error) {
+	ctx.Logging().Debugf("begin list pending job of queue. queueName:%s", queueName)
+	if !models.HasAccessToResource(ctx, common.ResourceTypeQueue, queueName) {
+		ctx.ErrorCode = common.AccessDenied
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, queueName)
+		ctx.Logging().Errorln(err.Error())
+		return ListPendingJobResponse{}, err
+	}
+	if _, err := models.GetQueueByName(ctx, queueName); err != nil {
+		ctx.ErrorCode = common.QueueNameNotFound
+		return ListPendingJobResponse{}, fmt.Errorf("queueName[%s] is not found", queueName)
+	}
+	jobs, err := job.ListPendingJob(queueName)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("list pending job of queue[%s] failed. error:%s", queueName, err.Error())
+		return ListPendingJobResponse{}, err
+	}
+	response := ListPendingJobResponse{
+		QueueName: queueName,
+		JobList:   make([]PendingJobBrief, 0, len(jobs)),
+	}
+	for i, jobInfo := range jobs {
+		brief := PendingJobBrief{Position: i + 1}
+		brief.modelToListResp(jobInfo)
+		brief.Priority = jobInfo.Config.Env[schema.EnvJobPriority]
+		if brief.Priority == "" {
+			brief.Priority = schema.EnvJobNormalPriority
+		}
+		response.JobList = append(response.JobList, brief)
+	}
+	return response, nil
+}
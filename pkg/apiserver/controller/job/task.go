@@ -0,0 +1,44 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+)
+
+type ListJobTaskResponse struct {
+	JobID    string           `json:"jobID"`
+	TaskList []models.JobTask `json:"taskList"`
+}
+
+// ListJobTask 获取任务各个pod的状态及最近的事件
+func ListJobTask(ctx *logger.RequestContext, jobID string) (ListJobTaskResponse, error) {
+	ctx.Logging().Debugf("begin list task of job. jobID:%s", jobID)
+	if _, err := GetJobByID(ctx, jobID); err != nil {
+		ctx.Logging().Errorf("list task of job[%s] failed when getting job. error: %v", jobID, err)
+		return ListJobTaskResponse{}, err
+	}
+	tasks, err := models.ListTaskByJobID(ctx.Logging(), jobID)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("list task of job[%s] failed. error:%s", jobID, err.Error())
+		return ListJobTaskResponse{}, err
+	}
+	return ListJobTaskResponse{JobID: jobID, TaskList: tasks}, nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job"
+)
+
+// CreateJobTemplateRequest 创建任务模板，QueueName为空表示全局模板
+type CreateJobTemplateRequest struct {
+	Name        string `json:"name"`
+	QueueName   string `json:"queueName,omitempty"`
+	JobType     string `json:"jobType"`
+	Description string `json:"description,omitempty"`
+	Content     string `json:"content"`
+}
+
+type CreateJobTemplateResponse struct {
+	Name      string `json:"name"`
+	QueueName string `json:"queueName,omitempty"`
+}
+
+type UpdateJobTemplateRequest struct {
+	Description string `json:"description,omitempty"`
+	Content     string `json:"content"`
+}
+
+type ListJobTemplateResponse struct {
+	common.MarkerInfo
+	TemplateList []models.JobTemplate `json:"templateList"`
+}
+
+func validateJobTemplateContent(ctx *logger.RequestContext, jobType, content string) error {
+	if err := job.ValidateJobTemplate(jobType, []byte(content)); err != nil {
+		ctx.ErrorCode = common.InvalidJobTemplate
+		ctx.Logging().Errorf("validate job template failed. error: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// CreateJobTemplate 创建任务模板，仅限管理员操作
+func CreateJobTemplate(ctx *logger.RequestContext, request *CreateJobTemplateRequest) (CreateJobTemplateResponse, error) {
+	ctx.Logging().Debugf("begin create job template. name:%s queueName:%s", request.Name, request.QueueName)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("create job template failed. error: admin is needed.")
+		return CreateJobTemplateResponse{}, errors.New("create job template failed")
+	}
+	if !schema.CheckReg(request.Name, common.RegPatternTemplateName) {
+		ctx.ErrorCode = common.InvalidNamePattern
+		err := common.InvalidNamePatternError(request.Name, common.ResourceTypeJobTemplate, common.RegPatternTemplateName)
+		ctx.Logging().Errorf("create job template failed. error: %v", err)
+		return CreateJobTemplateResponse{}, err
+	}
+	if request.QueueName != "" && !models.IsQueueExist(ctx, request.QueueName) {
+		ctx.ErrorCode = common.QueueNameNotFound
+		return CreateJobTemplateResponse{}, fmt.Errorf("queueName[%s] is not found", request.QueueName)
+	}
+	if err := validateJobTemplateContent(ctx, request.JobType, request.Content); err != nil {
+		return CreateJobTemplateResponse{}, err
+	}
+	if _, err := models.GetJobTemplate(ctx, request.Name, request.QueueName); err == nil {
+		ctx.ErrorCode = common.JobTemplateDuplicated
+		return CreateJobTemplateResponse{}, fmt.Errorf("job template[%s] of queue[%s] already exists", request.Name, request.QueueName)
+	}
+
+	template := &models.JobTemplate{
+		Name:        request.Name,
+		QueueName:   request.QueueName,
+		JobType:     request.JobType,
+		Description: request.Description,
+		Content:     request.Content,
+		UserName:    ctx.UserName,
+	}
+	if err := models.CreateJobTemplate(ctx, template); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return CreateJobTemplateResponse{}, err
+	}
+	ctx.Logging().Debugf("create job template succeed. name:%s queueName:%s", request.Name, request.QueueName)
+	return CreateJobTemplateResponse{Name: template.Name, QueueName: template.QueueName}, nil
+}
+
+// GetJobTemplate 获取任务模板，全局模板对所有用户可见，队列模板仅对有队列权限的用户可见
+func GetJobTemplate(ctx *logger.RequestContext, name, queueName string) (models.JobTemplate, error) {
+	ctx.Logging().Debugf("begin get job template. name:%s queueName:%s", name, queueName)
+	if queueName != "" && !models.HasAccessToResource(ctx, common.ResourceTypeQueue, queueName) {
+		ctx.ErrorCode = common.AccessDenied
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, queueName)
+		ctx.Logging().Errorln(err.Error())
+		return models.JobTemplate{}, err
+	}
+	template, err := models.GetJobTemplate(ctx, name, queueName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.JobTemplateNotFound
+			return models.JobTemplate{}, common.NotFoundError(common.ResourceTypeJobTemplate, name)
+		}
+		ctx.ErrorCode = common.InternalError
+		return models.JobTemplate{}, err
+	}
+	return template, nil
+}
+
+// ListJobTemplate 列出任务模板，普通用户只能看到全局模板及有权限队列的模板
+func ListJobTemplate(ctx *logger.RequestContext, marker string, maxKeys int, queueName string) (ListJobTemplateResponse, error) {
+	ctx.Logging().Debugf("begin list job template.")
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]", marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return ListJobTemplateResponse{}, err
+		}
+	}
+	var queueFilter []string
+	if queueName != "" {
+		if !models.HasAccessToResource(ctx, common.ResourceTypeQueue, queueName) {
+			ctx.ErrorCode = common.AccessDenied
+			err := common.NoAccessError(ctx.UserName, common.ResourceTypeQueue, queueName)
+			ctx.Logging().Errorln(err.Error())
+			return ListJobTemplateResponse{}, err
+		}
+		queueFilter = []string{queueName}
+	} else if !common.IsRootUser(ctx.UserName) {
+		queues, err := models.ListQueue(ctx, 0, 0, "")
+		if err != nil {
+			ctx.ErrorCode = common.InternalError
+			return ListJobTemplateResponse{}, err
+		}
+		queueFilter = []string{""}
+		for _, queue := range queues {
+			queueFilter = append(queueFilter, queue.Name)
+		}
+	}
+
+	templates, err := models.ListJobTemplate(ctx, pk, maxKeys, queueFilter)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		return ListJobTemplateResponse{}, err
+	}
+	response := ListJobTemplateResponse{TemplateList: templates}
+	response.MaxKeys = maxKeys
+	if len(templates) > 0 {
+		lastPk := templates[len(templates)-1].Pk
+		lastTemplate, err := models.GetLastJobTemplate(ctx)
+		if err == nil && lastTemplate.Pk != lastPk {
+			nextMarker, err := common.EncryptPk(lastPk)
+			if err != nil {
+				ctx.Logging().Errorf("EncryptPk error. pk:[%d] error:[%s]", lastPk, err.Error())
+				ctx.ErrorCode = common.InternalError
+				return ListJobTemplateResponse{}, err
+			}
+			response.NextMarker = nextMarker
+			response.IsTruncated = true
+		}
+	}
+	return response, nil
+}
+
+// UpdateJobTemplate 修改任务模板内容，仅限管理员操作，已提交的任务不受影响
+func UpdateJobTemplate(ctx *logger.RequestContext, name, queueName string, request *UpdateJobTemplateRequest) error {
+	ctx.Logging().Debugf("begin update job template. name:%s queueName:%s", name, queueName)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("update job template failed. error: admin is needed.")
+		return errors.New("update job template failed")
+	}
+	template, err := GetJobTemplate(ctx, name, queueName)
+	if err != nil {
+		return err
+	}
+	if err := validateJobTemplateContent(ctx, template.JobType, request.Content); err != nil {
+		return err
+	}
+	template.Content = request.Content
+	if request.Description != "" {
+		template.Description = request.Description
+	}
+	if err := models.UpdateJobTemplate(ctx, &template); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
+
+// DeleteJobTemplate 删除任务模板，仅限管理员操作
+func DeleteJobTemplate(ctx *logger.RequestContext, name, queueName string) error {
+	ctx.Logging().Debugf("begin delete job template. name:%s queueName:%s", name, queueName)
+	if !common.IsRootUser(ctx.UserName) {
+		ctx.ErrorCode = common.OnlyRootAllowed
+		ctx.Logging().Errorln("delete job template failed. error: admin is needed.")
+		return errors.New("delete job template failed")
+	}
+	if _, err := GetJobTemplate(ctx, name, queueName); err != nil {
+		return err
+	}
+	if err := models.DeleteJobTemplate(ctx, name, queueName); err != nil {
+		ctx.ErrorCode = common.InternalError
+		return err
+	}
+	return nil
+}
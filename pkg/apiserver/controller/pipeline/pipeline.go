@@ -41,8 +41,9 @@ type CreatePipelineRequest struct {
 }
 
 type CreatePipelineResponse struct {
-	ID   string `json:"pipelineID"`
-	Name string `json:"name"`
+	ID      string `json:"pipelineID"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
 }
 
 type ListPipelineResponse struct {
@@ -84,6 +85,18 @@ func CreatePipeline(ctx *logger.RequestContext, request CreatePipelineRequest) (
 		ctx.Logging().Errorf("validate pipeline failed. err:%v", err)
 		return CreatePipelineResponse{}, err
 	}
+	// pipeline with the same name exists in fs, add a new version to it
+	var existPpl *models.Pipeline
+	if wfs.Name != "" {
+		ppl, err := models.GetPipelineByNameAndFs(fsID, wfs.Name)
+		if err == nil {
+			existPpl = &ppl
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.InternalError
+			ctx.Logging().Errorf("get pipeline[%s] in fs[%s] failed. err:%v", wfs.Name, fsID, err)
+			return CreatePipelineResponse{}, err
+		}
+	}
 
 	// create Pipeline in db after yaml validated
 	ppl := models.Pipeline{
@@ -101,6 +114,22 @@ func CreatePipeline(ctx *logger.RequestContext, request CreatePipelineRequest) (
 		return CreatePipelineResponse{}, err
 	}
 
+	if existPpl != nil {
+		version, err := models.CreatePipelineVersion(ctx.Logging(), existPpl, ppl.PipelineYaml, ppl.PipelineMd5)
+		if err != nil {
+			ctx.Logging().Errorf("create new version of pipeline[%s] failed. error:%s", existPpl.ID, err.Error())
+			ctx.ErrorCode = common.InternalError
+			return CreatePipelineResponse{}, err
+		}
+		ctx.Logging().Debugf("create version[%d] of pipeline[%s] successful", version, existPpl.ID)
+		response := CreatePipelineResponse{
+			ID:      existPpl.ID,
+			Name:    existPpl.Name,
+			Version: version,
+		}
+		return response, nil
+	}
+
 	pipelineID, err := models.CreatePipeline(ctx.Logging(), &ppl)
 	if err != nil {
 		ctx.Logging().Errorf("create run failed inserting db. error:%s", err.Error())
@@ -109,8 +138,9 @@ func CreatePipeline(ctx *logger.RequestContext, request CreatePipelineRequest) (
 	}
 	ctx.Logging().Debugf("create pipeline[%s] successful", pipelineID)
 	response := CreatePipelineResponse{
-		ID:   pipelineID,
-		Name: wfs.Name,
+		ID:      pipelineID,
+		Name:    wfs.Name,
+		Version: ppl.Version,
 	}
 	return response, nil
 }
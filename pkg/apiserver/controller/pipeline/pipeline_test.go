@@ -25,6 +25,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
+	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/apiserver/handler"
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/common/database/db_fake"
@@ -118,4 +119,77 @@ func TestListPipeline(t *testing.T) {
 	b, _ := json.Marshal(resp)
 	println("")
 	fmt.Printf("%s\n", b)
-}
\ No newline at end of file
+}
+func TestPipelineVersion(t *testing.T) {
+	db_fake.InitFakeDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	yamls := map[string]string{
+		"v1.yaml": "name: mockPplName\nentry_points:\n  main:\n    command: echo v1\n",
+		"v2.yaml": "name: mockPplName\nentry_points:\n  main:\n    command: echo v2\n",
+	}
+	ValidateWorkflowForPipeline = func(ppl models.Pipeline) error { return nil }
+	handler.ReadFileFromFs = func(fsID, runYamlPath string, logEntry *log.Entry) ([]byte, error) {
+		return []byte(yamls[runYamlPath]), nil
+	}
+
+	createPplReq := CreatePipelineRequest{
+		FsName:   MockFsName,
+		YamlPath: "v1.yaml",
+	}
+	resp1, err := CreatePipeline(ctx, createPplReq)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp1.Version)
+
+	// create with the same name adds a new version
+	createPplReq.YamlPath = "v2.yaml"
+	resp2, err := CreatePipeline(ctx, createPplReq)
+	assert.Nil(t, err)
+	assert.Equal(t, resp1.ID, resp2.ID)
+	assert.Equal(t, 2, resp2.Version)
+
+	// the same yaml as latest version is rejected
+	_, err = CreatePipeline(ctx, createPplReq)
+	assert.NotNil(t, err)
+
+	ppl, err := GetPipelineByID(ctx, resp1.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, ppl.Version)
+	assert.Equal(t, yamls["v2.yaml"], ppl.PipelineYaml)
+
+	listResp, err := ListPipelineVersion(ctx, resp1.ID, "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.VersionList))
+	assert.True(t, listResp.IsTruncated)
+	listResp, err = ListPipelineVersion(ctx, resp1.ID, listResp.NextMarker, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.VersionList))
+	assert.Equal(t, 2, listResp.VersionList[0].Version)
+	assert.False(t, listResp.IsTruncated)
+
+	pplVersion, err := GetPipelineVersion(ctx, resp1.ID, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, yamls["v1.yaml"], pplVersion.PipelineYaml)
+
+	_, err = GetPipelineVersion(ctx, resp1.ID, 3)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.PipelineVersionNotFound, ctx.ErrorCode)
+
+	diffResp, err := DiffPipelineVersion(ctx, resp1.ID, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, " name: mockPplName\n entry_points:\n   main:\n-    command: echo v1\n+    command: echo v2\n \n", diffResp.Diff)
+
+	// versions are deleted with pipeline
+	err = DeletePipeline(ctx, resp1.ID)
+	assert.Nil(t, err)
+	versionList, err := models.ListPipelineVersion(ctx.Logging(), resp1.ID, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(versionList))
+}
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, " a\n", diffLines("a", "a"))
+	assert.Equal(t, "-a\n+b\n", diffLines("a", "b"))
+	assert.Equal(t, " a\n+b\n c\n", diffLines("a\nc", "a\nb\nc"))
+	assert.Equal(t, " a\n-b\n c\n", diffLines("a\nb\nc", "a\nc"))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+)
+
+type ListPipelineVersionResponse struct {
+	common.MarkerInfo
+	VersionList []models.PipelineVersion `json:"versionList"`
+}
+
+type DiffPipelineVersionResponse struct {
+	PipelineID  string `json:"pipelineID"`
+	FromVersion int    `json:"fromVersion"`
+	ToVersion   int    `json:"toVersion"`
+	Diff        string `json:"diff"` // 行级别的 diff，"-" 开头为仅存在于 fromVersion 的行，"+" 开头为仅存在于 toVersion 的行
+}
+
+func ListPipelineVersion(ctx *logger.RequestContext, pipelineID, marker string, maxKeys int) (ListPipelineVersionResponse, error) {
+	ctx.Logging().Debugf("begin list versions of pipeline[%s].", pipelineID)
+	if _, err := GetPipelineByID(ctx, pipelineID); err != nil {
+		ctx.Logging().Errorf("list versions of pipeline[%s] failed when getting pipeline. error: %v", pipelineID, err)
+		return ListPipelineVersionResponse{}, err
+	}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]",
+				marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return ListPipelineVersionResponse{}, err
+		}
+	}
+	versionList, err := models.ListPipelineVersion(ctx.Logging(), pipelineID, pk, maxKeys)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models list versions of pipeline[%s] failed. err: %v", pipelineID, err)
+		return ListPipelineVersionResponse{}, err
+	}
+	response := ListPipelineVersionResponse{VersionList: versionList}
+
+	// get next marker
+	response.IsTruncated = false
+	if len(versionList) > 0 {
+		pplVersion := versionList[len(versionList)-1]
+		lastVersion, err := models.GetLastPipelineVersion(ctx.Logging(), pipelineID)
+		if err != nil {
+			ctx.Logging().Errorf("get last version of pipeline[%s] failed. error:[%s]", pipelineID, err.Error())
+		}
+		if lastVersion.Pk != pplVersion.Pk {
+			nextMarker, err := common.EncryptPk(pplVersion.Pk)
+			if err != nil {
+				ctx.Logging().Errorf("EncryptPk error. pk:[%d] error:[%s]",
+					pplVersion.Pk, err.Error())
+				ctx.ErrorCode = common.InternalError
+				return ListPipelineVersionResponse{}, err
+			}
+			response.NextMarker = nextMarker
+			response.IsTruncated = true
+		}
+	}
+	response.MaxKeys = maxKeys
+	return response, nil
+}
+
+func GetPipelineVersion(ctx *logger.RequestContext, pipelineID string, version int) (models.PipelineVersion, error) {
+	ctx.Logging().Debugf("begin get version[%d] of pipeline[%s].", version, pipelineID)
+	if _, err := GetPipelineByID(ctx, pipelineID); err != nil {
+		ctx.Logging().Errorf("get version of pipeline[%s] failed when getting pipeline. error: %v", pipelineID, err)
+		return models.PipelineVersion{}, err
+	}
+	return getPipelineVersion(ctx, pipelineID, version)
+}
+
+func getPipelineVersion(ctx *logger.RequestContext, pipelineID string, version int) (models.PipelineVersion, error) {
+	pplVersion, err := models.GetPipelineVersion(ctx.Logging(), pipelineID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.PipelineVersionNotFound
+			err := fmt.Errorf("version[%d] of pipeline[%s] not found", version, pipelineID)
+			ctx.Logging().Errorln(err.Error())
+			return models.PipelineVersion{}, err
+		}
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get version[%d] of pipeline[%s] failed. err: %v", version, pipelineID, err)
+		return models.PipelineVersion{}, err
+	}
+	return pplVersion, nil
+}
+
+func DiffPipelineVersion(ctx *logger.RequestContext, pipelineID string, fromVersion, toVersion int) (DiffPipelineVersionResponse, error) {
+	ctx.Logging().Debugf("begin diff version[%d] and version[%d] of pipeline[%s].", fromVersion, toVersion, pipelineID)
+	if _, err := GetPipelineByID(ctx, pipelineID); err != nil {
+		ctx.Logging().Errorf("diff versions of pipeline[%s] failed when getting pipeline. error: %v", pipelineID, err)
+		return DiffPipelineVersionResponse{}, err
+	}
+	from, err := getPipelineVersion(ctx, pipelineID, fromVersion)
+	if err != nil {
+		return DiffPipelineVersionResponse{}, err
+	}
+	to, err := getPipelineVersion(ctx, pipelineID, toVersion)
+	if err != nil {
+		return DiffPipelineVersionResponse{}, err
+	}
+	response := DiffPipelineVersionResponse{
+		PipelineID:  pipelineID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Diff:        diffLines(from.PipelineYaml, to.PipelineYaml),
+	}
+	return response, nil
+}
+
+// diffLines 基于最长公共子序列逐行比较两个文本，返回所有行，不同的行以 "-"/"+" 标记，相同的行以 " " 开头
+func diffLines(from, to string) string {
+	a, b := strings.Split(from, "\n"), strings.Split(to, "\n")
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
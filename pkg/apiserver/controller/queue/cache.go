@@ -26,10 +26,10 @@ import (
 
 type Cache struct {
 	vcQueueInformer vcqueueinformer.QueueInformer
-	client          *vcclientset.Clientset
+	client          vcclientset.Interface
 }
 
-func (qc *Cache) Initialize(client *vcclientset.Clientset) error {
+func (qc *Cache) Initialize(client vcclientset.Interface) error {
 	qc.client = client
 	qc.vcQueueInformer = vcinformer.NewSharedInformerFactory(client, 0).Scheduling().V1beta1().Queues()
 	qc.vcQueueInformer.Informer().AddEventHandler(toolscache.ResourceEventHandlerFuncs{
@@ -40,7 +40,7 @@ func (qc *Cache) Initialize(client *vcclientset.Clientset) error {
 	return nil
 }
 
-func NewQueueCache(client *vcclientset.Clientset) (*Cache, error) {
+func NewQueueCache(client vcclientset.Interface) (*Cache, error) {
 	qc := Cache{}
 	err := qc.Initialize(client)
 	if err != nil {
@@ -20,12 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	vcclientset "volcano.sh/apis/pkg/client/clientset/versioned"
 
 	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/cluster"
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/common/config"
 	"paddleflow/pkg/common/database"
@@ -46,10 +48,66 @@ type ListQueueResponse struct {
 
 var GlobalVCQueue *VCQueue
 
+// 注册集群的 VCQueue，集群的 credential 改变后重新创建
+type clusterVCQueue struct {
+	credential string
+	vcQueue    *VCQueue
+}
+
+var (
+	clusterVCQueues   = map[string]*clusterVCQueue{}
+	clusterVCQueuesMu sync.Mutex
+	// 创建注册集群的 VCQueue，单测中可以替换
+	newClusterVCQueue = func(clusterInfo models.ClusterInfo) (*VCQueue, error) {
+		kubeConfig, err := cluster.GetKubeConfig(clusterInfo.Name, clusterInfo.Credential)
+		if err != nil {
+			return nil, err
+		}
+		client, err := vcclientset.NewForConfig(kubeConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewVCQueue(client), nil
+	}
+)
+
 func Init(client *vcclientset.Clientset) {
 	GlobalVCQueue = NewVCQueue(client)
 }
 
+// getVCQueue 获取队列所在集群的 VCQueue，未指定集群的队列使用 server 所在的集群
+func getVCQueue(ctx *logger.RequestContext, clusterName string) (*VCQueue, error) {
+	if clusterName == "" {
+		return GlobalVCQueue, nil
+	}
+	clusterInfo, err := models.GetClusterByName(ctx, clusterName)
+	if err != nil {
+		ctx.ErrorCode = common.ClusterNameNotFound
+		return nil, fmt.Errorf("get cluster[%s] failed. error: %v", clusterName, err)
+	}
+	if clusterInfo.Status != models.ClusterStatusOnLine {
+		ctx.ErrorCode = common.ActionNotAllowed
+		return nil, fmt.Errorf("cluster[%s] is %s", clusterName, clusterInfo.Status)
+	}
+
+	clusterVCQueuesMu.Lock()
+	defer clusterVCQueuesMu.Unlock()
+	cached, found := clusterVCQueues[clusterName]
+	if found && cached.credential == clusterInfo.Credential {
+		return cached.vcQueue, nil
+	}
+	vcQueue, err := newClusterVCQueue(clusterInfo)
+	if err != nil || vcQueue == nil {
+		ctx.ErrorCode = common.InternalError
+		return nil, fmt.Errorf("init vc queue of cluster[%s] failed. error: %v", clusterName, err)
+	}
+	clusterVCQueues[clusterName] = &clusterVCQueue{
+		credential: clusterInfo.Credential,
+		vcQueue:    vcQueue,
+	}
+	return vcQueue, nil
+}
+
 func ListQueue(ctx *logger.RequestContext, marker string, maxKeys int, name string) (ListQueueResponse, error) {
 	ctx.Logging().Debugf("begin list queue.")
 	listQueueResponse := ListQueueResponse{}
@@ -162,9 +220,22 @@ func CreateQueue(ctx *logger.RequestContext, queueInfo *models.QueueInfo) (Creat
 		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
 		return CreateQueueResponse{}, err
 	}
+	if queueInfo.ReclaimPolicy != nil {
+		if err := queueInfo.ReclaimPolicy.Validate(); err != nil {
+			ctx.ErrorCode = common.InvalidReclaimPolicy
+			ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+			return CreateQueueResponse{}, err
+		}
+	}
+
+	vcQueue, err := getVCQueue(ctx, queueInfo.ClusterName)
+	if err != nil {
+		ctx.Logging().Errorf("create queue failed. error: %s", err.Error())
+		return CreateQueueResponse{}, err
+	}
 
 	queue := models.Queue{QueueInfo: *queueInfo, Status: common.StatusQueueCreating}
-	err := models.CreateQueue(ctx, &queue)
+	err = models.CreateQueue(ctx, &queue)
 	if err != nil {
 		ctx.Logging().Errorf("create queue failed. error:%s", err.Error())
 		if database.GetErrorCode(err) == database.ErrorKeyIsDuplicated {
@@ -175,9 +246,9 @@ func CreateQueue(ctx *logger.RequestContext, queueInfo *models.QueueInfo) (Creat
 		return CreateQueueResponse{}, err
 	}
 
-	err = GlobalVCQueue.CreateQueue(&queue)
+	err = vcQueue.CreateQueue(&queue)
 	if err != nil {
-		ctx.Logging().Errorf("GlobalVCQueue create queue failed. error:%s", err.Error())
+		ctx.Logging().Errorf("vc queue of cluster[%s] create queue failed. error:%s", queue.ClusterName, err.Error())
 		ctx.ErrorCode = common.QueueResourceNotMatch
 		ctx.ErrorMessage = err.Error()
 		deleteErr := models.DeleteQueue(ctx, queue.Name)
@@ -230,7 +301,12 @@ func CloseQueue(ctx *logger.RequestContext, queueName string) error {
 		return fmt.Errorf("queueName[%s] is not found.\n", queueName)
 	}
 
-	err = GlobalVCQueue.CloseQueue(&queue)
+	vcQueue, err := getVCQueue(ctx, queue.ClusterName)
+	if err != nil {
+		log.Errorf("close queue failed. queueName:[%s] error:[%s]", queueName, err.Error())
+		return err
+	}
+	err = vcQueue.CloseQueue(&queue)
 	if err != nil {
 		ctx.ErrorCode = common.InternalError
 		log.Errorf("close queue failed. queueName:[%s] error:[%s]", queueName, err.Error())
@@ -266,7 +342,12 @@ func DeleteQueue(ctx *logger.RequestContext, queueName string) error {
 		return fmt.Errorf("queueName[%s] is not closed.\n", queueName)
 	}
 
-	err = GlobalVCQueue.DeleteQueue(&queue)
+	vcQueue, err := getVCQueue(ctx, queue.ClusterName)
+	if err != nil {
+		log.Errorf("delete queue failed. queueName:[%s] error:[%s]", queueName, err.Error())
+		return err
+	}
+	err = vcQueue.DeleteQueue(&queue)
 	if err != nil {
 		ctx.ErrorCode = common.InternalError
 		log.Errorf("delete queue failed. queueName:[%s] error:[%s]", queueName, err.Error())
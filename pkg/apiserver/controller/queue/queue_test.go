@@ -0,0 +1,78 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vcfake "volcano.sh/apis/pkg/client/clientset/versioned/fake"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/database/db_fake"
+	"paddleflow/pkg/common/logger"
+)
+
+const MockRootUser = "root"
+
+func TestQueueOfRegisteredCluster(t *testing.T) {
+	db_fake.InitFakeDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	defaultClient := vcfake.NewSimpleClientset()
+	clusterClient := vcfake.NewSimpleClientset()
+	GlobalVCQueue = &VCQueue{client: defaultClient}
+	newClusterVCQueue = func(clusterInfo models.ClusterInfo) (*VCQueue, error) {
+		assert.Equal(t, "gpu-1", clusterInfo.Name)
+		return &VCQueue{client: clusterClient}, nil
+	}
+	clusterInfo := models.ClusterInfo{ID: "cluster-1", Name: "gpu-1", Status: models.ClusterStatusOnLine, Credential: "credential-1"}
+	assert.Nil(t, models.CreateCluster(ctx, &clusterInfo))
+
+	// 队列在所属的集群中创建，而不是 server 所在的集群
+	queueInfo := &models.QueueInfo{Name: "q-gpu-1", Namespace: "default", ClusterName: "gpu-1", Cpu: "10", Mem: "20Gi"}
+	_, err := CreateQueue(ctx, queueInfo)
+	assert.Nil(t, err)
+	_, err = clusterClient.SchedulingV1beta1().Queues().Get(context.TODO(), "q-gpu-1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	_, err = defaultClient.SchedulingV1beta1().Queues().Get(context.TODO(), "q-gpu-1", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// 关闭及删除队列同样作用于队列所属的集群
+	assert.Nil(t, CloseQueue(ctx, "q-gpu-1"))
+	commands, err := clusterClient.BusV1alpha1().Commands("default").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(commands.Items))
+	assert.Nil(t, DeleteQueue(ctx, "q-gpu-1"))
+	_, err = clusterClient.SchedulingV1beta1().Queues().Get(context.TODO(), "q-gpu-1", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// 集群下线后，不能在该集群中创建队列
+	clusterInfo.Status = models.ClusterStatusOffLine
+	assert.Nil(t, models.UpdateCluster(ctx, clusterInfo.ID, &clusterInfo))
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	queueInfo = &models.QueueInfo{Name: "q-gpu-2", Namespace: "default", ClusterName: "gpu-1", Cpu: "10", Mem: "20Gi"}
+	_, err = CreateQueue(ctx, queueInfo)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+	assert.False(t, models.IsQueueExist(ctx, "q-gpu-2"))
+}
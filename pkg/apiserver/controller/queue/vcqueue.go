@@ -35,11 +35,11 @@ import (
 )
 
 type VCQueue struct {
-	client *vcclientset.Clientset
+	client vcclientset.Interface
 	cache  *Cache
 }
 
-func NewVCQueue(client *vcclientset.Clientset) *VCQueue {
+func NewVCQueue(client vcclientset.Interface) *VCQueue {
 	qc, err := NewQueueCache(client)
 	if err != nil {
 		log.Errorf("new vc queue failed. ")
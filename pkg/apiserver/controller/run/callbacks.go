@@ -24,6 +24,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/webhook"
 	"paddleflow/pkg/apiserver/handler"
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/common/database"
@@ -104,6 +105,14 @@ func UpdateRunByWfEvent(id string, event interface{}) bool {
 		logging.Errorf("update run[%s] in db failed. error: %v", id, err)
 		return false
 	}
+	if status != prevRun.Status {
+		preStatus := prevRun.Status
+		prevRun.Status, prevRun.Runtime = status, runtime
+		if message != "" {
+			prevRun.Message = message
+		}
+		webhook.NotifyRunStatus(prevRun, preStatus)
+	}
 	return true
 }
 
@@ -164,6 +173,7 @@ func handleImageCallbackFunc(imageInfo handler.ImageInfo, err error) error {
 }
 
 func updateRunStatusAndMsg(id, status, msg string) error {
+	prevRun, getErr := models.GetRunByID(logger.LoggerForRun(id), id)
 	updateRun := models.Run{
 		Status:  status,
 		Message: msg,
@@ -172,6 +182,11 @@ func updateRunStatusAndMsg(id, status, msg string) error {
 		logger.LoggerForRun(id).Errorf("update with status[%s] in db failed. error: %v", status, err)
 		return err
 	}
+	if getErr == nil && status != prevRun.Status {
+		preStatus := prevRun.Status
+		prevRun.Status, prevRun.Message = status, msg
+		webhook.NotifyRunStatus(prevRun, preStatus)
+	}
 	return nil
 }
 
@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package run
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/pipeline"
+)
+
+// GetRunStepJobID 获取run中某个step对应的job，用于查询step的日志
+// loop step 的每次迭代对应一个 job，需要通过 loopIndex 指定查询第几次迭代，loopIndex 小于 0 表示未指定
+func GetRunStepJobID(ctx *logger.RequestContext, runID, stepName string, loopIndex int) (string, error) {
+	ctx.Logging().Debugf("begin get job of run step. runID:%s step:%s loopIndex:%d", runID, stepName, loopIndex)
+	run, err := GetRunByID(ctx, runID)
+	if err != nil {
+		ctx.Logging().Errorf("get job of run[%s] step[%s] failed when getting run. error: %v", runID, stepName, err)
+		return "", err
+	}
+	jobView, found := run.Runtime[stepName]
+	if !found {
+		ctx.ErrorCode = common.RunStepNotFound
+		err := fmt.Errorf("step[%s] of run[%s] not found or not started yet", stepName, runID)
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+	// 本地执行的 step 以 server 的子进程运行，没有对应的 job，日志写在 server 本地的文件中
+	wfs := schema.WorkflowSource{}
+	if err := yaml.Unmarshal([]byte(run.RunYaml), &wfs); err == nil && wfs.Executor == pipeline.WfExecutorLocal {
+		ctx.ErrorCode = common.ActionNotAllowed
+		err := fmt.Errorf("step[%s] of run[%s] runs with executor[%s], its log can only be found in the log dir of server",
+			stepName, runID, pipeline.WfExecutorLocal)
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+	if len(jobView.LoopJobs) > 0 {
+		if loopIndex < 0 || loopIndex >= len(jobView.LoopJobs) {
+			ctx.ErrorCode = common.InvalidURI
+			err := fmt.Errorf("step[%s] of run[%s] is a loop step with %d jobs, loopIndex should be an integer between 0~%d",
+				stepName, runID, len(jobView.LoopJobs), len(jobView.LoopJobs)-1)
+			ctx.Logging().Errorln(err.Error())
+			return "", err
+		}
+		jobView = jobView.LoopJobs[loopIndex]
+	} else if loopIndex >= 0 {
+		ctx.ErrorCode = common.InvalidURI
+		err := fmt.Errorf("step[%s] of run[%s] is not a loop step, loopIndex should not be set", stepName, runID)
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+	if jobView.JobID == "" {
+		ctx.ErrorCode = common.RunStepNotFound
+		err := fmt.Errorf("step[%s] of run[%s] not found or not started yet", stepName, runID)
+		ctx.Logging().Errorln(err.Error())
+		return "", err
+	}
+	return jobView.JobID, nil
+}
@@ -48,9 +48,10 @@ type CreateRunRequest struct {
 	// run workflow source. priority: RunYamlRaw > PipelineID > RunYamlPath
 	// 为了防止字符串或者不同的http客户端对run.yaml
 	// 格式中的特殊字符串做特殊过滤处理导致yaml文件不正确，因此采用runYamlRaw采用base64编码传输
-	RunYamlRaw  string `json:"runYamlRaw,omitempty"`  // optional. one of 3 sources of run. high priority
-	PipelineID  string `json:"pipelineID,omitempty"`  // optional. one of 3 sources of run. medium priority
-	RunYamlPath string `json:"runYamlPath,omitempty"` // optional. one of 3 sources of run. low priority
+	RunYamlRaw      string `json:"runYamlRaw,omitempty"`      // optional. one of 3 sources of run. high priority
+	PipelineID      string `json:"pipelineID,omitempty"`      // optional. one of 3 sources of run. medium priority
+	PipelineVersion int    `json:"pipelineVersion,omitempty"` // optional. only with pipelineID, use latest version if not specified
+	RunYamlPath     string `json:"runYamlPath,omitempty"`     // optional. one of 3 sources of run. low priority
 }
 
 type CreateRunResponse struct {
@@ -58,15 +59,16 @@ type CreateRunResponse struct {
 }
 
 type RunBrief struct {
-	ID           string `json:"runID"`
-	Name         string `json:"name"`
-	Source       string `json:"source"` // pipelineID or yamlPath
-	UserName     string `json:"username"`
-	FsName       string `json:"fsname"`
-	Message      string `json:"runMsg"`
-	Status       string `json:"status"`
-	CreateTime   string `json:"createTime"`
-	ActivateTime string `json:"activateTime"`
+	ID              string `json:"runID"`
+	Name            string `json:"name"`
+	Source          string `json:"source"` // pipelineID or yamlPath
+	PipelineVersion int    `json:"pipelineVersion,omitempty"`
+	UserName        string `json:"username"`
+	FsName          string `json:"fsname"`
+	Message         string `json:"runMsg"`
+	Status          string `json:"status"`
+	CreateTime      string `json:"createTime"`
+	ActivateTime    string `json:"activateTime"`
 }
 
 type ListRunResponse struct {
@@ -78,6 +80,7 @@ func (b *RunBrief) modelToListResp(run models.Run) {
 	b.ID = run.ID
 	b.Name = run.Name
 	b.Source = run.Source
+	b.PipelineVersion = run.PipelineVersion
 	b.UserName = run.UserName
 	b.FsName = run.FsName
 	b.Message = run.Message
@@ -86,8 +89,10 @@ func (b *RunBrief) modelToListResp(run models.Run) {
 	b.ActivateTime = run.ActivateTime
 }
 
-func buildWorkflowSource(ctx *logger.RequestContext, req CreateRunRequest, fsID string) (schema.WorkflowSource, string, string, error) {
+// buildWorkflowSource returns wfs, source, runYaml and the version of pipeline if run is created by pipeline
+func buildWorkflowSource(ctx *logger.RequestContext, req CreateRunRequest, fsID string) (schema.WorkflowSource, string, string, int, error) {
 	var source, runYaml string
+	var pplVersion int
 	// retrieve source and runYaml
 	if req.RunYamlRaw != "" { // high priority: wfs delivered by request
 		// base64 decode
@@ -95,7 +100,7 @@ func buildWorkflowSource(ctx *logger.RequestContext, req CreateRunRequest, fsID
 		sDec, err := base64.StdEncoding.DecodeString(req.RunYamlRaw)
 		if err != nil {
 			ctx.Logging().Errorf("Decode raw runyaml is [%s] failed. err:%v", req.RunYamlRaw, err)
-			return schema.WorkflowSource{}, "", "", err
+			return schema.WorkflowSource{}, "", "", 0, err
 		}
 		runYaml = string(sDec)
 		source = common.GetMD5Hash(sDec)
@@ -103,16 +108,31 @@ func buildWorkflowSource(ctx *logger.RequestContext, req CreateRunRequest, fsID
 		ppl, err := models.GetPipelineByID(req.PipelineID)
 		if err != nil {
 			ctx.Logging().Errorf("GetPipelineByID[%s] failed. err:%v", req.PipelineID, err)
-			return schema.WorkflowSource{}, "", "", err
+			return schema.WorkflowSource{}, "", "", 0, err
 		}
 		if !common.IsRootUser(ctx.UserName) && ppl.UserName != ctx.UserName {
 			ctx.ErrorCode = common.AccessDenied
 			err := common.NoAccessError(ctx.UserName, common.ResourceTypePipeline, ppl.ID)
 			ctx.Logging().Errorf("buildWorkflowSource[%s] failed. err:%v", req.PipelineID, err)
-			return schema.WorkflowSource{}, "", "", err
+			return schema.WorkflowSource{}, "", "", 0, err
 		}
 		runYaml = ppl.PipelineYaml
 		source = ppl.ID
+		pplVersion = ppl.Version
+		// run with a history version of pipeline
+		if req.PipelineVersion != 0 && req.PipelineVersion != ppl.Version {
+			pv, err := models.GetPipelineVersion(ctx.Logging(), ppl.ID, req.PipelineVersion)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					ctx.ErrorCode = common.PipelineVersionNotFound
+					err = fmt.Errorf("version[%d] of pipeline[%s] not found", req.PipelineVersion, ppl.ID)
+				}
+				ctx.Logging().Errorf("buildWorkflowSource[%s] failed. err:%v", req.PipelineID, err)
+				return schema.WorkflowSource{}, "", "", 0, err
+			}
+			runYaml = pv.PipelineYaml
+			pplVersion = pv.Version
+		}
 	} else { // low priority: wfs in fs, read from runYamlPath
 		runYamlPath := req.RunYamlPath
 		if runYamlPath == "" {
@@ -122,7 +142,7 @@ func buildWorkflowSource(ctx *logger.RequestContext, req CreateRunRequest, fsID
 		if err != nil {
 			ctx.ErrorCode = common.IOOperationFailure
 			ctx.Logging().Errorf("readFileFromFs from[%s] failed. err:%v", fsID, err)
-			return schema.WorkflowSource{}, "", "", err
+			return schema.WorkflowSource{}, "", "", 0, err
 		}
 		source = runYamlPath
 		runYaml = string(runYamlByte)
@@ -131,9 +151,9 @@ func buildWorkflowSource(ctx *logger.RequestContext, req CreateRunRequest, fsID
 	wfs, err := runYamlAndReqToWfs(ctx, runYaml, req)
 	if err != nil {
 		ctx.Logging().Errorf("runYamlAndReqToWfs failed. err:%v", err)
-		return schema.WorkflowSource{}, "", "", err
+		return schema.WorkflowSource{}, "", "", 0, err
 	}
-	return wfs, source, runYaml, nil
+	return wfs, source, runYaml, pplVersion, nil
 }
 
 func runYamlAndReqToWfs(ctx *logger.RequestContext, runYaml string, req CreateRunRequest) (schema.WorkflowSource, error) {
@@ -167,7 +187,7 @@ func CreateRun(ctx *logger.RequestContext, request *CreateRunRequest) (CreateRun
 	// TODO:// validate flavour
 	// TODO:// validate queue
 
-	wfs, source, runYaml, err := buildWorkflowSource(ctx, *request, fsID)
+	wfs, source, runYaml, pplVersion, err := buildWorkflowSource(ctx, *request, fsID)
 	if err != nil {
 		ctx.Logging().Errorf("buildWorkflowSource failed. error:%v", err)
 		return CreateRunResponse{}, err
@@ -182,18 +202,19 @@ func CreateRun(ctx *logger.RequestContext, request *CreateRunRequest) (CreateRun
 	}
 	// create run in db after run.yaml validated
 	run := models.Run{
-		ID:             "", // to be back filled according to db pk
-		Name:           wfs.Name,
-		Source:         source,
-		UserName:       ctx.UserName,
-		FsName:         request.FsName,
-		FsID:           fsID,
-		Description:    request.Description,
-		Param:          request.Parameters,
-		RunYaml:        runYaml,
-		WorkflowSource: wfs, // DockerEnv has not been replaced. done in func handleImageAndStartWf
-		Entry:          request.Entry,
-		Status:         common.StatusRunInitiating,
+		ID:              "", // to be back filled according to db pk
+		Name:            wfs.Name,
+		Source:          source,
+		PipelineVersion: pplVersion,
+		UserName:        ctx.UserName,
+		FsName:          request.FsName,
+		FsID:            fsID,
+		Description:     request.Description,
+		Param:           request.Parameters,
+		RunYaml:         runYaml,
+		WorkflowSource:  wfs, // DockerEnv has not been replaced. done in func handleImageAndStartWf
+		Entry:           request.Entry,
+		Status:          common.StatusRunInitiating,
 	}
 	if err := run.Encode(); err != nil {
 		ctx.Logging().Errorf("encode run failed. error:%s", err.Error())
@@ -543,11 +564,14 @@ func resetRunSteps(run *models.Run) error {
 	for stepName, jobView := range run.Runtime {
 		if jobView.Status == schema.StatusJobCancelled ||
 			jobView.Status == schema.StatusJobFailed ||
+			jobView.Status == schema.StatusJobTimeout ||
 			jobView.Status == schema.StatusJobTerminated {
 			jobView.JobID = ""
 			jobView.Status = ""
 			jobView.StartTime = ""
 			jobView.EndTime = ""
+			jobView.LoopJobs = nil
+			jobView.Attempts = nil
 
 			run.Runtime[stepName] = jobView
 		}
@@ -557,6 +581,10 @@ func resetRunSteps(run *models.Run) error {
 			logger.LoggerForRun(run.ID).Errorf(err.Error())
 			return err
 		}
+		// post_process step 需要根据重试后主 DAG 的最终状态重新运行
+		if _, ok := run.WorkflowSource.PostProcess[stepName]; ok {
+			delete(run.Runtime, stepName)
+		}
 	}
 	if err := run.Encode(); err != nil {
 		logger.LoggerForRun(run.ID).Errorf("reset run steps encode failure. err: %v", err)
@@ -196,3 +196,106 @@ func TestCallback(t *testing.T) {
 	assert.False(t, updatedRun.ActivatedAt.Valid)
 	assert.Empty(t, updatedRun.ActivateTime)
 }
+
+func TestBuildWorkflowSourceWithPipelineVersion(t *testing.T) {
+	db_fake.InitFakeDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+
+	ppl := models.Pipeline{
+		Name:         "mockPpl",
+		FsID:         MockFsID1,
+		FsName:       "fsname",
+		UserName:     MockRootUser,
+		PipelineYaml: "name: v1\n",
+		PipelineMd5:  "md5_1",
+	}
+	pplID, err := models.CreatePipeline(ctx.Logging(), &ppl)
+	assert.Nil(t, err)
+	_, err = models.CreatePipelineVersion(ctx.Logging(), &ppl, "name: v2\n", "md5_2")
+	assert.Nil(t, err)
+
+	// latest version by default
+	req := CreateRunRequest{PipelineID: pplID}
+	wfs, source, runYaml, version, err := buildWorkflowSource(ctx, req, MockFsID1)
+	assert.Nil(t, err)
+	assert.Equal(t, pplID, source)
+	assert.Equal(t, "v2", wfs.Name)
+	assert.Equal(t, "name: v2\n", runYaml)
+	assert.Equal(t, 2, version)
+
+	req.PipelineVersion = 1
+	wfs, _, runYaml, version, err = buildWorkflowSource(ctx, req, MockFsID1)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", wfs.Name)
+	assert.Equal(t, "name: v1\n", runYaml)
+	assert.Equal(t, 1, version)
+
+	req.PipelineVersion = 3
+	_, _, _, _, err = buildWorkflowSource(ctx, req, MockFsID1)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.PipelineVersionNotFound, ctx.ErrorCode)
+}
+
+func TestGetRunStepJobID(t *testing.T) {
+	db_fake.InitFakeDB()
+	ctx := &logger.RequestContext{UserName: MockRootUser}
+	var err error
+	run1 := getMockRun1()
+	run1.Runtime = schema.RuntimeView{
+		"main":    schema.JobView{JobID: "job-000001", Status: schema.StatusJobRunning},
+		"pending": schema.JobView{Status: schema.StatusJobPending},
+		"loop": schema.JobView{Status: schema.StatusJobRunning, LoopJobs: []schema.JobView{
+			{JobID: "job-000002", Status: schema.StatusJobSucceeded},
+			{JobID: "job-000003", Status: schema.StatusJobRunning},
+		}},
+	}
+	run1.ID, err = models.CreateRun(ctx.Logging(), &run1)
+	assert.Nil(t, err)
+
+	jobID, err := GetRunStepJobID(ctx, run1.ID, "main", -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "job-000001", jobID)
+
+	// step has no job yet
+	_, err = GetRunStepJobID(ctx, run1.ID, "pending", -1)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.RunStepNotFound, ctx.ErrorCode)
+
+	// step not exist
+	_, err = GetRunStepJobID(ctx, run1.ID, "not-exist", -1)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.RunStepNotFound, ctx.ErrorCode)
+
+	// loop step: job of each iteration is specified by loopIndex
+	jobID, err = GetRunStepJobID(ctx, run1.ID, "loop", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "job-000003", jobID)
+
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	_, err = GetRunStepJobID(ctx, run1.ID, "loop", -1)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	_, err = GetRunStepJobID(ctx, run1.ID, "loop", 2)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	_, err = GetRunStepJobID(ctx, run1.ID, "main", 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidURI, ctx.ErrorCode)
+
+	// step of local executor has no job
+	run2 := getMockRun1()
+	run2.RunYaml = "name: local\nexecutor: local\nentry_points:\n  main:\n    command: echo\n"
+	run2.Runtime = schema.RuntimeView{
+		"main": schema.JobView{JobID: "local-000001", Status: schema.StatusJobRunning},
+	}
+	run2.ID, err = models.CreateRun(ctx.Logging(), &run2)
+	assert.Nil(t, err)
+	ctx = &logger.RequestContext{UserName: MockRootUser}
+	_, err = GetRunStepJobID(ctx, run2.ID, "main", -1)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.ActionNotAllowed, ctx.ErrorCode)
+}
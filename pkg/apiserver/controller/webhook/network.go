@@ -0,0 +1,163 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"paddleflow/pkg/common/config"
+)
+
+// 默认不允许推送的内网地址段，防止通过 webhook 访问 apiserver 所在的内部网络（SSRF）
+var privateNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+// lookupIP 解析 webhook 的域名，便于单测替换
+var lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return net.DefaultResolver.LookupIPAddr(ctx, host)
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// allowedHosts 返回配置中允许推送的域名、ip 或网段，用于放行内网中的 webhook 接收方
+func allowedHosts() []string {
+	if config.GlobalServerConfig == nil {
+		return nil
+	}
+	return config.GlobalServerConfig.ApiServer.WebhookAllowedHosts
+}
+
+func isAllowedHost(host string) bool {
+	for _, allowed := range allowedHosts() {
+		if strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllowedIP(ip net.IP) bool {
+	for _, allowed := range allowedHosts() {
+		allowed = strings.TrimSpace(allowed)
+		if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(ip) {
+			return true
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIP 拒绝回环、链路本地、内网等地址，除非在配置中被允许
+func checkIP(ip net.IP) error {
+	if isAllowedIP(ip) {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("address[%s] of webhook is not allowed", ip)
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("address[%s] of webhook is not allowed", ip)
+		}
+	}
+	return nil
+}
+
+// checkHost 解析 webhook 的域名，所有解析结果都必须是允许推送的地址
+func checkHost(ctx context.Context, host string) error {
+	if isAllowedHost(host) {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(ip)
+	}
+	addrs, err := lookupIP(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve host[%s] of webhook failed: %v", host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("resolve host[%s] of webhook failed: no address", host)
+	}
+	for _, addr := range addrs {
+		if err := checkIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialContext 建立连接时再次校验实际连接的地址，防止域名在注册后被解析到内网地址（DNS rebinding）
+func dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !isAllowedHost(host) {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				return fmt.Errorf("address[%s] of webhook is not an ip", ipStr)
+			}
+			return checkIP(ip)
+		}
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// newDeliveryClient 推送通知使用的 http client，不使用代理，以保证连接的地址经过校验
+func newDeliveryClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+const (
+	HeaderKeyWebhookID = "x-pf-webhook-id"
+	HeaderKeyEvent     = "x-pf-event"
+	HeaderKeySignature = "x-pf-signature" // sha256=<hex(hmac-sha256(secret, body))>
+
+	EventRunStatus = "run_status"
+
+	// 推送失败时记录到推送日志中的响应内容的最大长度
+	maxDeliveryResponseSize = 1024
+)
+
+var (
+	// 推送失败时的最大尝试次数，以及首次重试前的等待时间，之后每次等待时间翻倍
+	DeliveryMaxAttempts   = 3
+	DeliveryRetryInterval = time.Second
+
+	deliveryClient = newDeliveryClient()
+
+	// 每个 webhook 待推送的通知队列，同一个 webhook 的通知由一个协程按照状态变化的顺序依次推送
+	deliveryQueues   = map[string][]deliveryTask{}
+	deliveryQueuesMu sync.Mutex
+)
+
+// deliveryTask 一次待推送的通知
+type deliveryTask struct {
+	logEntry *log.Entry
+	webhook  models.Webhook
+	runID    string
+	status   string
+	body     []byte
+}
+
+// RunStatusPayload run 状态变化时推送的内容
+type RunStatusPayload struct {
+	Event     string                 `json:"event"`
+	RunID     string                 `json:"runID"`
+	Name      string                 `json:"name"`
+	Source    string                 `json:"source"`
+	UserName  string                 `json:"username"`
+	Status    string                 `json:"status"`
+	PreStatus string                 `json:"preStatus"`
+	Message   string                 `json:"message"`
+	Steps     map[string]StepSummary `json:"steps"`
+	Timestamp string                 `json:"timestamp"`
+}
+
+// StepSummary step 的运行概况，不包含 env 等可能含有敏感信息的字段
+type StepSummary struct {
+	JobID      string           `json:"jobID"`
+	Status     schema.JobStatus `json:"status"`
+	StartTime  string           `json:"startTime"`
+	EndTime    string           `json:"endTime"`
+	JobMessage string           `json:"jobMessage"`
+}
+
+func newRunStatusPayload(run models.Run, preStatus string) RunStatusPayload {
+	payload := RunStatusPayload{
+		Event:     EventRunStatus,
+		RunID:     run.ID,
+		Name:      run.Name,
+		Source:    run.Source,
+		UserName:  run.UserName,
+		Status:    run.Status,
+		PreStatus: preStatus,
+		Message:   run.Message,
+		Steps:     map[string]StepSummary{},
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	for name, jobView := range run.Runtime {
+		payload.Steps[name] = StepSummary{
+			JobID:      jobView.JobID,
+			Status:     jobView.Status,
+			StartTime:  jobView.StartTime,
+			EndTime:    jobView.EndTime,
+			JobMessage: jobView.JobMessage,
+		}
+	}
+	return payload
+}
+
+// Sign 使用 webhook 的 secret 对推送内容签名，接收方可以据此校验请求来源
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NotifyRunStatus run 状态变化后，异步向 run 及其所属 pipeline 上注册的 webhook 推送通知
+func NotifyRunStatus(run models.Run, preStatus string) {
+	logEntry := logger.LoggerForRun(run.ID)
+	// run.Source 为 pipelineID 或者 yaml 路径，为 yaml 路径时不会匹配到 pipeline 上的 webhook
+	whList, err := models.ListWebhookForRun(logEntry, run.ID, run.Source)
+	if err != nil {
+		logEntry.Errorf("list webhook for run[%s] failed. error:%v", run.ID, err)
+		return
+	}
+	if len(whList) == 0 {
+		return
+	}
+	body, err := json.Marshal(newRunStatusPayload(run, preStatus))
+	if err != nil {
+		logEntry.Errorf("marshal webhook payload for run[%s] failed. error:%v", run.ID, err)
+		return
+	}
+	for _, wh := range whList {
+		enqueueDelivery(deliveryTask{logEntry: logEntry, webhook: wh, runID: run.ID, status: run.Status, body: body})
+	}
+}
+
+// enqueueDelivery 将通知加入 webhook 的推送队列，队列没有在推送的协程时启动一个
+func enqueueDelivery(task deliveryTask) {
+	deliveryQueuesMu.Lock()
+	defer deliveryQueuesMu.Unlock()
+	queue, running := deliveryQueues[task.webhook.ID]
+	deliveryQueues[task.webhook.ID] = append(queue, task)
+	if !running {
+		go runDeliveryQueue(task.webhook.ID)
+	}
+}
+
+// runDeliveryQueue 依次推送 webhook 队列中的通知，队列为空时退出
+func runDeliveryQueue(webhookID string) {
+	for {
+		deliveryQueuesMu.Lock()
+		queue := deliveryQueues[webhookID]
+		if len(queue) == 0 {
+			delete(deliveryQueues, webhookID)
+			deliveryQueuesMu.Unlock()
+			return
+		}
+		task := queue[0]
+		deliveryQueues[webhookID] = queue[1:]
+		deliveryQueuesMu.Unlock()
+
+		deliver(task.logEntry, task.webhook, task.runID, task.status, task.body)
+	}
+}
+
+// deliver 推送通知，失败时按照指数退避重试，并记录推送结果
+func deliver(logEntry *log.Entry, wh models.Webhook, runID, status string, body []byte) {
+	delivery := models.WebhookDelivery{
+		WebhookID: wh.ID,
+		RunID:     runID,
+		Status:    status,
+		Payload:   string(body),
+	}
+	interval := DeliveryRetryInterval
+	for attempt := 1; attempt <= DeliveryMaxAttempts; attempt++ {
+		delivery.Attempts = attempt
+		code, err := post(wh, body)
+		delivery.ResponseCode = code
+		if err == nil {
+			delivery.Success = true
+			delivery.Message = ""
+			break
+		}
+		delivery.Message = err.Error()
+		logEntry.Warnf("deliver run[%s] status[%s] to webhook[%s] failed [%d] times. error:%v",
+			runID, status, wh.ID, attempt, err)
+		if attempt < DeliveryMaxAttempts {
+			time.Sleep(interval)
+			interval *= 2
+		}
+	}
+	if err := models.CreateWebhookDelivery(logEntry, &delivery); err != nil {
+		logEntry.Errorf("log delivery of webhook[%s] for run[%s] failed. error:%v", wh.ID, runID, err)
+	}
+}
+
+func post(wh models.Webhook, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderKeyWebhookID, wh.ID)
+	req.Header.Set(HeaderKeyEvent, EventRunStatus)
+	if wh.Secret != "" {
+		req.Header.Set(HeaderKeySignature, Sign(wh.Secret, body))
+	}
+	resp, err := deliveryClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxDeliveryResponseSize))
+		return resp.StatusCode, fmt.Errorf("webhook responded with status code[%d], body[%s]", resp.StatusCode, respBody)
+	}
+	return resp.StatusCode, nil
+}
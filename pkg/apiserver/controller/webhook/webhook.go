@@ -0,0 +1,271 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+)
+
+type CreateWebhookRequest struct {
+	ResourceType string `json:"resourceType"` // run or pipeline
+	ResourceID   string `json:"resourceID"`
+	URL          string `json:"url"`
+	Secret       string `json:"secret,omitempty"` // optional, 用于对推送内容签名
+}
+
+type CreateWebhookResponse struct {
+	WebhookID string `json:"webhookID"`
+}
+
+type ListWebhookResponse struct {
+	common.MarkerInfo
+	WebhookList []models.Webhook `json:"webhookList"`
+}
+
+type ListWebhookDeliveryResponse struct {
+	common.MarkerInfo
+	DeliveryList []models.WebhookDelivery `json:"deliveryList"`
+}
+
+func CreateWebhook(ctx *logger.RequestContext, request *CreateWebhookRequest) (CreateWebhookResponse, error) {
+	if err := validateWebhookURL(request.URL); err != nil {
+		ctx.ErrorCode = common.InvalidHTTPRequest
+		ctx.Logging().Errorln(err.Error())
+		return CreateWebhookResponse{}, err
+	}
+	if err := checkResourceAccess(ctx, request.ResourceType, request.ResourceID); err != nil {
+		ctx.Logging().Errorf("check access of %s[%s] failed. error:%v", request.ResourceType, request.ResourceID, err)
+		return CreateWebhookResponse{}, err
+	}
+
+	wh := models.Webhook{
+		ID:           "", // to be back-filled according to db pk
+		ResourceType: request.ResourceType,
+		ResourceID:   request.ResourceID,
+		URL:          request.URL,
+		Secret:       request.Secret,
+		UserName:     ctx.UserName,
+	}
+	webhookID, err := models.CreateWebhook(ctx.Logging(), &wh)
+	if err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("create webhook failed inserting db. error:%s", err.Error())
+		return CreateWebhookResponse{}, err
+	}
+	ctx.Logging().Debugf("create webhook[%s] successful", webhookID)
+	return CreateWebhookResponse{WebhookID: webhookID}, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("url[%s] of webhook not correct, should be an http or https address", rawURL)
+	}
+	if err := checkHost(context.Background(), u.Hostname()); err != nil {
+		return fmt.Errorf("url[%s] of webhook not correct: %v", rawURL, err)
+	}
+	return nil
+}
+
+// checkResourceAccess 校验 webhook 注册的 run 或 pipeline 是否存在，以及用户是否有权限
+func checkResourceAccess(ctx *logger.RequestContext, resourceType, resourceID string) error {
+	var owner string
+	switch resourceType {
+	case common.ResourceTypeRun:
+		run, err := models.GetRunByID(ctx.Logging(), resourceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.ErrorCode = common.RunNotFound
+				return common.NotFoundError(common.ResourceTypeRun, resourceID)
+			}
+			ctx.ErrorCode = common.InternalError
+			return err
+		}
+		owner = run.UserName
+	case common.ResourceTypePipeline:
+		ppl, err := models.GetPipelineByID(resourceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.ErrorCode = common.PipelineNotFound
+				return common.NotFoundError(common.ResourceTypePipeline, resourceID)
+			}
+			ctx.ErrorCode = common.InternalError
+			return err
+		}
+		owner = ppl.UserName
+	default:
+		ctx.ErrorCode = common.InvalidHTTPRequest
+		return fmt.Errorf("resourceType[%s] of webhook not correct, should be one of [%s, %s]",
+			resourceType, common.ResourceTypeRun, common.ResourceTypePipeline)
+	}
+	if !common.IsRootUser(ctx.UserName) && ctx.UserName != owner {
+		ctx.ErrorCode = common.AccessDenied
+		return common.NoAccessError(ctx.UserName, resourceType, resourceID)
+	}
+	return nil
+}
+
+func ListWebhook(ctx *logger.RequestContext, marker string, maxKeys int, resourceType, resourceID string) (ListWebhookResponse, error) {
+	ctx.Logging().Debugf("begin list webhook.")
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]",
+				marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return ListWebhookResponse{}, err
+		}
+	}
+	// normal user list its own
+	var userFilter []string
+	if !common.IsRootUser(ctx.UserName) {
+		userFilter = []string{ctx.UserName}
+	}
+	whList, err := models.ListWebhook(ctx.Logging(), pk, maxKeys, userFilter, resourceType, resourceID)
+	if err != nil {
+		ctx.Logging().Errorf("models list webhook failed. err:[%s]", err.Error())
+		ctx.ErrorCode = common.InternalError
+		return ListWebhookResponse{}, err
+	}
+	listWebhookResponse := ListWebhookResponse{WebhookList: whList}
+
+	// get next marker
+	listWebhookResponse.IsTruncated = false
+	if len(whList) > 0 {
+		wh := whList[len(whList)-1]
+		if !isLastWebhookPk(ctx, wh.Pk) {
+			nextMarker, err := common.EncryptPk(wh.Pk)
+			if err != nil {
+				ctx.Logging().Errorf("EncryptPk error. pk:[%d] error:[%s]",
+					wh.Pk, err.Error())
+				ctx.ErrorCode = common.InternalError
+				return ListWebhookResponse{}, err
+			}
+			listWebhookResponse.NextMarker = nextMarker
+			listWebhookResponse.IsTruncated = true
+		}
+	}
+	listWebhookResponse.MaxKeys = maxKeys
+	return listWebhookResponse, nil
+}
+
+func isLastWebhookPk(ctx *logger.RequestContext, pk int64) bool {
+	lastWebhook, err := models.GetLastWebhook(ctx.Logging())
+	if err != nil {
+		ctx.Logging().Errorf("get last webhook failed. error:[%s]", err.Error())
+	}
+	if lastWebhook.Pk == pk {
+		return true
+	}
+	return false
+}
+
+func GetWebhookByID(ctx *logger.RequestContext, webhookID string) (models.Webhook, error) {
+	ctx.Logging().Debugf("begin get webhook by id. webhookID:%s", webhookID)
+	wh, err := models.GetWebhookByID(ctx.Logging(), webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.ErrorCode = common.WebhookNotFound
+			ctx.Logging().Errorln(err.Error())
+			return models.Webhook{}, common.NotFoundError(common.ResourceTypeWebhook, webhookID)
+		}
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("get webhook[%s] failed. error:%s", webhookID, err.Error())
+		return models.Webhook{}, err
+	}
+	if !common.IsRootUser(ctx.UserName) && ctx.UserName != wh.UserName {
+		err := common.NoAccessError(ctx.UserName, common.ResourceTypeWebhook, webhookID)
+		ctx.ErrorCode = common.AccessDenied
+		ctx.Logging().Errorln(err.Error())
+		return models.Webhook{}, err
+	}
+	return wh, nil
+}
+
+func DeleteWebhook(ctx *logger.RequestContext, webhookID string) error {
+	ctx.Logging().Debugf("begin delete webhook: %s", webhookID)
+	if _, err := GetWebhookByID(ctx, webhookID); err != nil {
+		ctx.Logging().Errorf("delete webhook[%s] failed when getting webhook. error: %v", webhookID, err)
+		return err
+	}
+	if err := models.DeleteWebhook(ctx.Logging(), webhookID); err != nil {
+		ctx.ErrorCode = common.InternalError
+		ctx.Logging().Errorf("models delete webhook[%s] failed. error:%s", webhookID, err.Error())
+		return err
+	}
+	return nil
+}
+
+func ListWebhookDelivery(ctx *logger.RequestContext, webhookID, marker string, maxKeys int) (ListWebhookDeliveryResponse, error) {
+	ctx.Logging().Debugf("begin list delivery of webhook[%s].", webhookID)
+	if _, err := GetWebhookByID(ctx, webhookID); err != nil {
+		ctx.Logging().Errorf("list delivery of webhook[%s] failed when getting webhook. error: %v", webhookID, err)
+		return ListWebhookDeliveryResponse{}, err
+	}
+	var pk int64
+	var err error
+	if marker != "" {
+		pk, err = common.DecryptPk(marker)
+		if err != nil {
+			ctx.Logging().Errorf("DecryptPk marker[%s] failed. err:[%s]",
+				marker, err.Error())
+			ctx.ErrorCode = common.InvalidMarker
+			return ListWebhookDeliveryResponse{}, err
+		}
+	}
+	deliveryList, err := models.ListWebhookDelivery(ctx.Logging(), webhookID, pk, maxKeys)
+	if err != nil {
+		ctx.Logging().Errorf("models list delivery of webhook[%s] failed. err:[%s]", webhookID, err.Error())
+		ctx.ErrorCode = common.InternalError
+		return ListWebhookDeliveryResponse{}, err
+	}
+	response := ListWebhookDeliveryResponse{DeliveryList: deliveryList}
+
+	// get next marker
+	response.IsTruncated = false
+	if len(deliveryList) > 0 {
+		delivery := deliveryList[len(deliveryList)-1]
+		lastDelivery, err := models.GetLastWebhookDelivery(ctx.Logging(), webhookID)
+		if err != nil {
+			ctx.Logging().Errorf("get last delivery of webhook[%s] failed. error:[%s]", webhookID, err.Error())
+		}
+		if lastDelivery.Pk != delivery.Pk {
+			nextMarker, err := common.EncryptPk(delivery.Pk)
+			if err != nil {
+				ctx.Logging().Errorf("EncryptPk error. pk:[%d] error:[%s]",
+					delivery.Pk, err.Error())
+				ctx.ErrorCode = common.InternalError
+				return ListWebhookDeliveryResponse{}, err
+			}
+			response.NextMarker = nextMarker
+			response.IsTruncated = true
+		}
+	}
+	response.MaxKeys = maxKeys
+	return response, nil
+}
@@ -0,0 +1,340 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/database/db_fake"
+	"paddleflow/pkg/common/logger"
+)
+
+const (
+	MockRootUser   = "root"
+	MockNormalUser = "user1"
+	MockOtherUser  = "user2"
+	MockFsID       = "fs-root-mock"
+)
+
+func createMockRun(t *testing.T, userName string) models.Run {
+	run := models.Run{
+		Name:     "mockRun",
+		UserName: userName,
+		FsID:     MockFsID,
+		Status:   common.StatusRunRunning,
+	}
+	_, err := models.CreateRun(logger.LoggerForRun(""), &run)
+	assert.Nil(t, err)
+	return run
+}
+
+// allowWebhookHosts 设置允许推送的内网地址，单测中的 webhook 接收方都监听在 127.0.0.1
+func allowWebhookHosts(hosts ...string) {
+	if config.GlobalServerConfig == nil {
+		config.GlobalServerConfig = &config.ServerConfig{}
+	}
+	config.GlobalServerConfig.ApiServer.WebhookAllowedHosts = hosts
+}
+
+// waitDelivery 推送是异步的，等待推送记录写入db
+func waitDelivery(t *testing.T, ctx *logger.RequestContext, webhookID string, count int) []models.WebhookDelivery {
+	var deliveryList []models.WebhookDelivery
+	for i := 0; i < 50; i++ {
+		resp, err := ListWebhookDelivery(ctx, webhookID, "", 50)
+		assert.Nil(t, err)
+		deliveryList = resp.DeliveryList
+		if len(deliveryList) >= count {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return deliveryList
+}
+
+func TestWebhookCRUD(t *testing.T) {
+	db_fake.InitFakeDB()
+	allowWebhookHosts("127.0.0.1")
+	run := createMockRun(t, MockNormalUser)
+	ctx := &logger.RequestContext{UserName: MockNormalUser}
+
+	// invalid url
+	req := &CreateWebhookRequest{ResourceType: common.ResourceTypeRun, ResourceID: run.ID, URL: "ftp://127.0.0.1/hook"}
+	_, err := CreateWebhook(ctx, req)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidHTTPRequest, ctx.ErrorCode)
+
+	// invalid resource type
+	ctx = &logger.RequestContext{UserName: MockNormalUser}
+	req = &CreateWebhookRequest{ResourceType: "queue", ResourceID: run.ID, URL: "http://127.0.0.1/hook"}
+	_, err = CreateWebhook(ctx, req)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.InvalidHTTPRequest, ctx.ErrorCode)
+
+	// run not found
+	ctx = &logger.RequestContext{UserName: MockNormalUser}
+	req = &CreateWebhookRequest{ResourceType: common.ResourceTypeRun, ResourceID: "run-notexist", URL: "http://127.0.0.1/hook"}
+	_, err = CreateWebhook(ctx, req)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.RunNotFound, ctx.ErrorCode)
+
+	// other user has no access to the run
+	ctx = &logger.RequestContext{UserName: MockOtherUser}
+	req = &CreateWebhookRequest{ResourceType: common.ResourceTypeRun, ResourceID: run.ID, URL: "http://127.0.0.1/hook"}
+	_, err = CreateWebhook(ctx, req)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, ctx.ErrorCode)
+
+	// create success
+	ctx = &logger.RequestContext{UserName: MockNormalUser}
+	resp, err := CreateWebhook(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, common.PrefixWebhook+"000001", resp.WebhookID)
+
+	wh, err := GetWebhookByID(ctx, resp.WebhookID)
+	assert.Nil(t, err)
+	assert.Equal(t, run.ID, wh.ResourceID)
+	assert.Equal(t, MockNormalUser, wh.UserName)
+
+	// list: normal user only sees its own webhooks, root sees all
+	listResp, err := ListWebhook(ctx, "", 50, common.ResourceTypeRun, run.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.WebhookList))
+	assert.False(t, listResp.IsTruncated)
+
+	otherCtx := &logger.RequestContext{UserName: MockOtherUser}
+	listResp, err = ListWebhook(otherCtx, "", 50, "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(listResp.WebhookList))
+
+	rootCtx := &logger.RequestContext{UserName: MockRootUser}
+	listResp, err = ListWebhook(rootCtx, "", 50, "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listResp.WebhookList))
+
+	// delete
+	err = DeleteWebhook(otherCtx, resp.WebhookID)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.AccessDenied, otherCtx.ErrorCode)
+
+	err = DeleteWebhook(ctx, resp.WebhookID)
+	assert.Nil(t, err)
+	_, err = GetWebhookByID(ctx, resp.WebhookID)
+	assert.NotNil(t, err)
+	assert.Equal(t, common.WebhookNotFound, ctx.ErrorCode)
+}
+
+func TestNotifyRunStatus(t *testing.T) {
+	db_fake.InitFakeDB()
+	allowWebhookHosts("127.0.0.1")
+	DeliveryRetryInterval = 10 * time.Millisecond
+
+	var received RunStatusPayload
+	var signature, event string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		signature = r.Header.Get(HeaderKeySignature)
+		event = r.Header.Get(HeaderKeyEvent)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	run := createMockRun(t, MockNormalUser)
+	ctx := &logger.RequestContext{UserName: MockNormalUser}
+	resp, err := CreateWebhook(ctx, &CreateWebhookRequest{
+		ResourceType: common.ResourceTypeRun,
+		ResourceID:   run.ID,
+		URL:          server.URL,
+		Secret:       "mockSecret",
+	})
+	assert.Nil(t, err)
+
+	run.Status = common.StatusRunSucceeded
+	NotifyRunStatus(run, common.StatusRunRunning)
+
+	deliveryList := waitDelivery(t, ctx, resp.WebhookID, 1)
+	assert.Equal(t, 1, len(deliveryList))
+	assert.True(t, deliveryList[0].Success)
+	assert.Equal(t, 1, deliveryList[0].Attempts)
+	assert.Equal(t, http.StatusOK, deliveryList[0].ResponseCode)
+	assert.Equal(t, common.StatusRunSucceeded, deliveryList[0].Status)
+
+	assert.Equal(t, EventRunStatus, event)
+	assert.Equal(t, run.ID, received.RunID)
+	assert.Equal(t, common.StatusRunSucceeded, received.Status)
+	assert.Equal(t, common.StatusRunRunning, received.PreStatus)
+	assert.Equal(t, Sign("mockSecret", []byte(deliveryList[0].Payload)), signature)
+}
+
+func TestNotifyRunStatusRetry(t *testing.T) {
+	db_fake.InitFakeDB()
+	allowWebhookHosts("127.0.0.1")
+	DeliveryRetryInterval = 10 * time.Millisecond
+
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(strings.Repeat("x", 4*maxDeliveryResponseSize)))
+	}))
+	defer server.Close()
+
+	run := createMockRun(t, MockNormalUser)
+	ctx := &logger.RequestContext{UserName: MockNormalUser}
+	resp, err := CreateWebhook(ctx, &CreateWebhookRequest{
+		ResourceType: common.ResourceTypeRun,
+		ResourceID:   run.ID,
+		URL:          server.URL,
+	})
+	assert.Nil(t, err)
+
+	run.Status = common.StatusRunFailed
+	NotifyRunStatus(run, common.StatusRunRunning)
+
+	deliveryList := waitDelivery(t, ctx, resp.WebhookID, 1)
+	assert.Equal(t, 1, len(deliveryList))
+	assert.False(t, deliveryList[0].Success)
+	assert.Equal(t, DeliveryMaxAttempts, deliveryList[0].Attempts)
+	assert.Equal(t, http.StatusInternalServerError, deliveryList[0].ResponseCode)
+	assert.Equal(t, int32(DeliveryMaxAttempts), atomic.LoadInt32(&count))
+	// 推送日志中只记录有限长度的响应内容
+	assert.True(t, len(deliveryList[0].Message) < 2*maxDeliveryResponseSize)
+}
+
+func TestNotifyRunStatusOrder(t *testing.T) {
+	db_fake.InitFakeDB()
+	allowWebhookHosts("127.0.0.1")
+
+	var mu sync.Mutex
+	var statuses []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload RunStatusPayload
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		// 第一次推送较慢，后续的通知也不能先于它到达
+		if payload.Status == common.StatusRunRunning {
+			time.Sleep(200 * time.Millisecond)
+		}
+		mu.Lock()
+		statuses = append(statuses, payload.Status)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	run := createMockRun(t, MockNormalUser)
+	ctx := &logger.RequestContext{UserName: MockNormalUser}
+	resp, err := CreateWebhook(ctx, &CreateWebhookRequest{
+		ResourceType: common.ResourceTypeRun,
+		ResourceID:   run.ID,
+		URL:          server.URL,
+	})
+	assert.Nil(t, err)
+
+	preStatus := common.StatusRunPending
+	for _, status := range []string{common.StatusRunRunning, common.StatusRunTerminating, common.StatusRunTerminated} {
+		run.Status = status
+		NotifyRunStatus(run, preStatus)
+		preStatus = status
+	}
+
+	deliveryList := waitDelivery(t, ctx, resp.WebhookID, 3)
+	assert.Equal(t, 3, len(deliveryList))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{common.StatusRunRunning, common.StatusRunTerminating, common.StatusRunTerminated}, statuses)
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	allowWebhookHosts()
+	lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "public.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("8.8.8.8")}}, nil
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("8.8.8.8")}, {IP: net.ParseIP("10.0.0.1")}}, nil
+		}
+		return nil, fmt.Errorf("no such host")
+	}
+	defer func() {
+		lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			return net.DefaultResolver.LookupIPAddr(ctx, host)
+		}
+	}()
+
+	testCases := []struct {
+		url    string
+		hasErr bool
+	}{
+		{"http://8.8.8.8/hook", false},
+		{"https://public.example.com:8443/hook", false},
+		{"ftp://8.8.8.8/hook", true},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://10.1.2.3/hook", true},
+		{"http://172.16.0.1/hook", true},
+		{"http://192.168.1.1/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://internal.example.com/hook", true},
+		{"http://notexist.example.com/hook", true},
+	}
+	for _, tc := range testCases {
+		err := validateWebhookURL(tc.url)
+		assert.Equal(t, tc.hasErr, err != nil, tc.url)
+	}
+
+	// 配置中允许的内网地址
+	allowWebhookHosts("10.0.0.0/8", "127.0.0.1", "internal.example.com")
+	assert.Nil(t, validateWebhookURL("http://10.1.2.3/hook"))
+	assert.Nil(t, validateWebhookURL("http://127.0.0.1:8080/hook"))
+	assert.Nil(t, validateWebhookURL("http://internal.example.com/hook"))
+	assert.NotNil(t, validateWebhookURL("http://192.168.1.1/hook"))
+}
+
+func TestDeliveryCheckAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// 注册后地址不再被允许（如域名被重新解析到内网地址），推送时建立连接会失败
+	allowWebhookHosts()
+	_, err := post(models.Webhook{ID: "webhook-000001", URL: server.URL}, []byte("{}"))
+	assert.NotNil(t, err)
+
+	allowWebhookHosts("127.0.0.1")
+	code, err := post(models.Webhook{ID: "webhook-000001", URL: server.URL}, []byte("{}"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -187,6 +193,62 @@ func (fh *FsHandler) ModTime(path string) (time.Time, error) {
 	return modTime, nil
 }
 
+// Sha256 计算文件内容的 sha256；如果 path 是目录，则根据目录下所有文件的相对路径及其内容计算
+func (fh *FsHandler) Sha256(path string) (string, error) {
+	fh.log.Debugf("begin to get the sha256 of file[%s] with fsId[%s]",
+		path, fh.fsID)
+
+	// 目录下文件相对路径到其内容 sha256 的映射，借助 json 序列化时 key 有序，保证结果与遍历顺序无关
+	fileDigests := map[string]string{}
+	root := filepath.Clean(path)
+	err := fh.fsClient.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		digest, err := fh.fileSha256(filePath)
+		if err != nil {
+			return err
+		}
+		relPath := strings.TrimPrefix(strings.TrimPrefix(filePath, root), "/")
+		fileDigests[relPath] = digest
+		return nil
+	})
+	if err != nil {
+		fh.log.Errorf("get the sha256 of file[%s] with fsId[%s] failed: %s",
+			path, fh.fsID, err.Error())
+		return "", err
+	}
+
+	// path 为普通文件时，直接返回文件内容的 sha256
+	if digest, ok := fileDigests[""]; ok && len(fileDigests) == 1 {
+		return digest, nil
+	}
+
+	digestsBytes, err := json.Marshal(fileDigests)
+	if err != nil {
+		return "", err
+	}
+	md := sha256.Sum256(digestsBytes)
+	return hex.EncodeToString(md[:]), nil
+}
+
+func (fh *FsHandler) fileSha256(path string) (string, error) {
+	reader, err := fh.fsClient.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func (fh *FsHandler) getFSClient() (fs.FSClient, error) {
 	fsService := service.GetFileSystemService()
 	fsModel, err := fsService.GetFileSystem(&request.GetFileSystemRequest{}, fh.fsID)
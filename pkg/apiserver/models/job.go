@@ -21,14 +21,17 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
-	"paddleflow/pkg/common/schema"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	batchv1 "k8s.io/api/batch/v1"
 	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
 
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
 	sparkoperatorv1beta2 "paddleflow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/schema"
 )
 
 type Conf struct {
@@ -43,6 +46,7 @@ type Job struct {
 	ID              string           `json:"jobID" gorm:"uniqueIndex"`
 	UserName        string           `json:"userName"`
 	Type            string           `json:"type"`
+	QueueName       string           `json:"queueName" gorm:"index"`
 	Config          Conf             `json:"config"`
 	RuntimeInfoJson string           `json:"-" gorm:"column:runtime_info;default:'{}'"`
 	RuntimeInfo     interface{}      `json:"runtimeInfo" gorm:"-"`
@@ -58,6 +62,42 @@ func (Job) TableName() string {
 	return "job"
 }
 
+func ListJob(logEntry *log.Entry, pk int64, maxKeys int, userFilter, queueFilter, statusFilter []string) ([]Job, error) {
+	logEntry.Debugf("begin list job. ")
+	tx := database.DB.Model(&Job{}).Where("pk > ?", pk)
+	if len(userFilter) > 0 {
+		tx = tx.Where("user_name IN (?)", userFilter)
+	}
+	if len(queueFilter) > 0 {
+		tx = tx.Where("queue_name IN (?)", queueFilter)
+	}
+	if len(statusFilter) > 0 {
+		tx = tx.Where("status IN (?)", statusFilter)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var jobList []Job
+	tx = tx.Find(&jobList)
+	if tx.Error != nil {
+		logEntry.Errorf("list job failed. Filters: user{%v}, queue{%v}, status{%v}. error:%s",
+			userFilter, queueFilter, statusFilter, tx.Error.Error())
+		return []Job{}, tx.Error
+	}
+	return jobList, nil
+}
+
+func GetLastJob(logEntry *log.Entry) (Job, error) {
+	logEntry.Debugf("get last job. ")
+	job := Job{}
+	tx := database.DB.Model(&Job{}).Last(&job)
+	if tx.Error != nil {
+		logEntry.Errorf("get last job failed. error:%s", tx.Error.Error())
+		return Job{}, tx.Error
+	}
+	return job, nil
+}
+
 func (job *Job) AfterFind(tx *gorm.DB) error {
 	switch job.Type {
 	case string(schema.TypeVcJob):
@@ -72,6 +112,18 @@ func (job *Job) AfterFind(tx *gorm.DB) error {
 			return err
 		}
 		job.RuntimeInfo = sparkApp
+	case string(schema.TypeBatchJob):
+		batchJob := batchv1.Job{}
+		if err := json.Unmarshal([]byte(job.RuntimeInfoJson), &batchJob); err != nil {
+			return err
+		}
+		job.RuntimeInfo = batchJob
+	case string(schema.TypePaddleJob):
+		paddleJob := paddlev1.PaddleJob{}
+		if err := json.Unmarshal([]byte(job.RuntimeInfoJson), &paddleJob); err != nil {
+			return err
+		}
+		job.RuntimeInfo = paddleJob
 	default:
 		log.Debugf("unknown job type %s, skip unmarshall runtime info for job %s", job.Type, job.ID)
 		return nil
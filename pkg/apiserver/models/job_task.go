@@ -0,0 +1,187 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/common/database"
+)
+
+// MaxTaskEvents only the recent events of task are persisted
+const MaxTaskEvents = 10
+
+// JobTask is the pod of job, which is synced from cluster when its status changed
+type JobTask struct {
+	Pk                 int64                 `json:"-" gorm:"primaryKey;autoIncrement"`
+	ID                 string                `json:"id" gorm:"type:varchar(64);uniqueIndex"` // uid of pod
+	JobID              string                `json:"jobID" gorm:"type:varchar(60);index"`
+	Namespace          string                `json:"namespace" gorm:"type:varchar(64)"`
+	Name               string                `json:"name" gorm:"type:varchar(255)"`      // name of pod
+	MemberRole         string                `json:"memberRole" gorm:"type:varchar(64)"` // task of job, e.g. ps, worker or driver
+	ReplicaIndex       int                   `json:"replicaIndex"`
+	Phase              string                `json:"phase" gorm:"type:varchar(32)"`
+	NodeName           string                `json:"nodeName" gorm:"type:varchar(255)"`
+	RestartCount       int32                 `json:"restartCount"`
+	ExitCode           *int32                `json:"exitCode,omitempty"`
+	Reason             string                `json:"reason" gorm:"type:varchar(255)"`
+	Message            string                `json:"message" gorm:"type:text"`
+	ContainerStatusRaw string                `json:"-" gorm:"column:container_status;type:text"`
+	ContainerStatuses  []TaskContainerStatus `json:"containerStatuses" gorm:"-"`
+	EventsRaw          string                `json:"-" gorm:"column:events;type:text"`
+	Events             []TaskEvent           `json:"events" gorm:"-"`
+	Deleted            bool                  `json:"deleted"` // pod is deleted from cluster
+	CreatedAt          time.Time             `json:"createTime"`
+	UpdatedAt          time.Time             `json:"updateTime"`
+}
+
+type TaskContainerStatus struct {
+	Name         string `json:"name"`
+	State        string `json:"state"` // waiting, running or terminated
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restartCount"`
+	ExitCode     *int32 `json:"exitCode,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// TaskEvent is the kubernetes event of pod
+type TaskEvent struct {
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Count          int32     `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+func (JobTask) TableName() string {
+	return "job_task"
+}
+
+func (task *JobTask) BeforeSave(tx *gorm.DB) error {
+	if task.ContainerStatuses != nil {
+		raw, err := json.Marshal(task.ContainerStatuses)
+		if err != nil {
+			return err
+		}
+		task.ContainerStatusRaw = string(raw)
+	}
+	if task.Events != nil {
+		raw, err := json.Marshal(task.Events)
+		if err != nil {
+			return err
+		}
+		task.EventsRaw = string(raw)
+	}
+	return nil
+}
+
+func (task *JobTask) AfterFind(tx *gorm.DB) error {
+	task.ContainerStatuses = []TaskContainerStatus{}
+	if task.ContainerStatusRaw != "" {
+		if err := json.Unmarshal([]byte(task.ContainerStatusRaw), &task.ContainerStatuses); err != nil {
+			log.Errorf("unmarshal container status of task[%s] failed. error:%s", task.ID, err.Error())
+			return err
+		}
+	}
+	task.Events = []TaskEvent{}
+	if task.EventsRaw != "" {
+		if err := json.Unmarshal([]byte(task.EventsRaw), &task.Events); err != nil {
+			log.Errorf("unmarshal events of task[%s] failed. error:%s", task.ID, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// AddEvent add or refresh event of task, and only the recent MaxTaskEvents events are kept
+func (task *JobTask) AddEvent(event TaskEvent) {
+	events := make([]TaskEvent, 0, len(task.Events)+1)
+	for _, e := range task.Events {
+		if e.Name != event.Name {
+			events = append(events, e)
+		}
+	}
+	events = append(events, event)
+	if len(events) > MaxTaskEvents {
+		events = events[len(events)-MaxTaskEvents:]
+	}
+	task.Events = events
+}
+
+func GetTaskByID(logEntry *log.Entry, taskID string) (JobTask, error) {
+	logEntry.Debugf("begin get task. taskID:%s", taskID)
+	task := JobTask{}
+	tx := database.DB.Model(&JobTask{}).Where("id = ?", taskID).First(&task)
+	if tx.Error != nil {
+		logEntry.Errorf("get task failed. taskID:%s error:%s", taskID, tx.Error.Error())
+		return JobTask{}, tx.Error
+	}
+	return task, nil
+}
+
+// UpdateTask create task if not exist, otherwise update status of task, events are kept if task.Events is nil
+func UpdateTask(logEntry *log.Entry, task *JobTask) error {
+	logEntry.Debugf("begin update task. taskID:%s jobID:%s", task.ID, task.JobID)
+	existed := JobTask{}
+	tx := database.DB.Model(&JobTask{}).Where("id = ?", task.ID).First(&existed)
+	if tx.Error != nil && tx.Error != gorm.ErrRecordNotFound {
+		logEntry.Errorf("get task failed. taskID:%s error:%s", task.ID, tx.Error.Error())
+		return tx.Error
+	}
+	if tx.Error == nil {
+		task.Pk = existed.Pk
+		task.CreatedAt = existed.CreatedAt
+		if task.Events == nil {
+			task.Events = existed.Events
+		}
+	}
+	tx = database.DB.Save(task)
+	if tx.Error != nil {
+		logEntry.Errorf("update task failed. taskID:%s error:%s", task.ID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func ListTaskByJobID(logEntry *log.Entry, jobID string) ([]JobTask, error) {
+	logEntry.Debugf("begin list task of job. jobID:%s", jobID)
+	var tasks []JobTask
+	tx := database.DB.Model(&JobTask{}).Where("job_id = ?", jobID).
+		Order("member_role, replica_index, name").Find(&tasks)
+	if tx.Error != nil {
+		logEntry.Errorf("list task of job failed. jobID:%s error:%s", jobID, tx.Error.Error())
+		return []JobTask{}, tx.Error
+	}
+	return tasks, nil
+}
+
+func DeleteTaskByJobID(logEntry *log.Entry, jobID string) error {
+	logEntry.Debugf("begin delete task of job. jobID:%s", jobID)
+	tx := database.DB.Where("job_id = ?", jobID).Delete(&JobTask{})
+	if tx.Error != nil {
+		logEntry.Errorf("delete task of job failed. jobID:%s error:%s", jobID, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
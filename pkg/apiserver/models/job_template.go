@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/logger"
+)
+
+// JobTemplate 任务模板，QueueName为空表示全局模板，否则仅对该队列中的任务生效
+type JobTemplate struct {
+	Pk          int64     `json:"-" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"type:varchar(60);uniqueIndex:idx_job_template_name_queue"`
+	QueueName   string    `json:"queueName,omitempty" gorm:"type:varchar(255);uniqueIndex:idx_job_template_name_queue"`
+	JobType     string    `json:"jobType"`
+	Description string    `json:"description,omitempty"`
+	Content     string    `json:"content" gorm:"type:text"`
+	UserName    string    `json:"userName"`
+	CreatedAt   time.Time `json:"createTime"`
+	UpdatedAt   time.Time `json:"updateTime,omitempty"`
+}
+
+func (JobTemplate) TableName() string {
+	return "job_template"
+}
+
+func CreateJobTemplate(ctx *logger.RequestContext, template *JobTemplate) error {
+	ctx.Logging().Debugf("begin create job template. name:%s queueName:%s", template.Name, template.QueueName)
+	tx := database.DB.Table("job_template").Create(template)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("create job template failed. name:%s, error:%s", template.Name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func GetJobTemplate(ctx *logger.RequestContext, name, queueName string) (JobTemplate, error) {
+	ctx.Logging().Debugf("begin get job template. name:%s queueName:%s", name, queueName)
+	var template JobTemplate
+	tx := database.DB.Table("job_template").Where("name = ? AND queue_name = ?", name, queueName).First(&template)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("get job template failed. name:%s queueName:%s, error:%s",
+			name, queueName, tx.Error.Error())
+		return JobTemplate{}, tx.Error
+	}
+	return template, nil
+}
+
+// ListJobTemplate 列出模板，queueFilter中的空字符串表示全局模板
+func ListJobTemplate(ctx *logger.RequestContext, pk int64, maxKeys int, queueFilter []string) ([]JobTemplate, error) {
+	ctx.Logging().Debugf("begin list job template. ")
+	tx := database.DB.Table("job_template").Where("pk > ?", pk)
+	if len(queueFilter) > 0 {
+		tx = tx.Where("queue_name IN (?)", queueFilter)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var templateList []JobTemplate
+	tx = tx.Find(&templateList)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("list job template failed. queueFilter:%v, error:%s", queueFilter, tx.Error.Error())
+		return []JobTemplate{}, tx.Error
+	}
+	return templateList, nil
+}
+
+func GetLastJobTemplate(ctx *logger.RequestContext) (JobTemplate, error) {
+	ctx.Logging().Debugf("get last job template. ")
+	template := JobTemplate{}
+	tx := database.DB.Table("job_template").Last(&template)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("get last job template failed. error:%s", tx.Error.Error())
+		return JobTemplate{}, tx.Error
+	}
+	return template, nil
+}
+
+func UpdateJobTemplate(ctx *logger.RequestContext, template *JobTemplate) error {
+	ctx.Logging().Debugf("begin update job template. name:%s queueName:%s", template.Name, template.QueueName)
+	tx := database.DB.Table("job_template").Where("pk = ?", template.Pk).Save(template)
+	if tx.Error != nil {
+		ctx.Logging().Errorf("update job template failed. name:%s, error:%s", template.Name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
+
+func DeleteJobTemplate(ctx *logger.RequestContext, name, queueName string) error {
+	ctx.Logging().Debugf("begin delete job template. name:%s queueName:%s", name, queueName)
+	tx := database.DB.Table("job_template").Where("name = ? AND queue_name = ?", name, queueName).Delete(&JobTemplate{})
+	if tx.Error != nil {
+		ctx.Logging().Errorf("delete job template failed. name:%s, error:%s", name, tx.Error.Error())
+		return tx.Error
+	}
+	return nil
+}
@@ -35,6 +35,7 @@ type Pipeline struct {
 	UserName     string         `json:"username"             gorm:"type:varchar(60);not null"`
 	PipelineYaml string         `json:"pipelineYaml"         gorm:"type:text;size:65535"`
 	PipelineMd5  string         `json:"pipelineMd5"          gorm:"type:varchar(32);not null;uniqueIndex:idx_fs_md5"`
+	Version      int            `json:"version"              gorm:"not null;default:1"` // latest version, PipelineYaml & PipelineMd5 belong to it
 	CreateTime   string         `json:"createTime"           gorm:"-"`
 	UpdateTime   string         `json:"updateTime,omitempty" gorm:"-"`
 	CreatedAt    time.Time      `json:"-"`
@@ -55,6 +56,9 @@ func (p *Pipeline) Decode() error {
 
 func CreatePipeline(logEntry *log.Entry, ppl *Pipeline) (string, error) {
 	logEntry.Debugf("begin create pipeline: %+v", ppl)
+	if ppl.Version == 0 {
+		ppl.Version = 1
+	}
 	err := withTransaction(database.DB, func(tx *gorm.DB) error {
 		result := tx.Model(&Pipeline{}).Create(ppl)
 		if result.Error != nil {
@@ -69,6 +73,13 @@ func CreatePipeline(logEntry *log.Entry, ppl *Pipeline) (string, error) {
 			logEntry.Errorf("back filling pplID failed. pk[%d], error:%v", ppl.Pk, result.Error)
 			return result.Error
 		}
+		// the first version of pipeline
+		pplVersion := newPipelineVersion(*ppl)
+		result = tx.Model(&PipelineVersion{}).Create(&pplVersion)
+		if result.Error != nil {
+			logEntry.Errorf("create version[%d] of ppl[%s] failed. error:%v", pplVersion.Version, ppl.ID, result.Error)
+			return result.Error
+		}
 		return nil
 	})
 	return ppl.ID, err
@@ -142,5 +153,11 @@ func GetLastPipeline(logEntry *log.Entry) (Pipeline, error) {
 
 func HardDeletePipeline(logEntry *log.Entry, id string) error {
 	logEntry.Debugf("delete ppl: %s", id)
-	return database.DB.Unscoped().Where("id = ?", id).Delete(&Pipeline{}).Error
+	return withTransaction(database.DB, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("pipeline_id = ?", id).Delete(&PipelineVersion{}).Error; err != nil {
+			logEntry.Errorf("delete versions of ppl[%s] failed. error:%v", id, err)
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", id).Delete(&Pipeline{}).Error
+	})
 }
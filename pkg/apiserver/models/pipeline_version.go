@@ -0,0 +1,129 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/common/database"
+)
+
+// PipelineVersion pipeline 的历史版本，每次以相同名称创建 pipeline 时新增一个版本
+type PipelineVersion struct {
+	Pk           int64     `json:"-"            gorm:"primaryKey;autoIncrement;not null"`
+	PipelineID   string    `json:"pipelineID"   gorm:"type:varchar(60);not null;uniqueIndex:idx_ppl_version"`
+	Version      int       `json:"version"      gorm:"not null;uniqueIndex:idx_ppl_version"`
+	FsID         string    `json:"-"            gorm:"type:varchar(60);not null"`
+	FsName       string    `json:"fsname"       gorm:"type:varchar(60);not null"`
+	UserName     string    `json:"username"     gorm:"type:varchar(60);not null"`
+	PipelineYaml string    `json:"pipelineYaml" gorm:"type:text;size:65535"`
+	PipelineMd5  string    `json:"pipelineMd5"  gorm:"type:varchar(32);not null"`
+	CreateTime   string    `json:"createTime"   gorm:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+func (PipelineVersion) TableName() string {
+	return "pipeline_version"
+}
+
+func (pv *PipelineVersion) Decode() {
+	pv.CreateTime = pv.CreatedAt.Format("2006-01-02 15:04:05")
+}
+
+func newPipelineVersion(ppl Pipeline) PipelineVersion {
+	return PipelineVersion{
+		PipelineID:   ppl.ID,
+		Version:      ppl.Version,
+		FsID:         ppl.FsID,
+		FsName:       ppl.FsName,
+		UserName:     ppl.UserName,
+		PipelineYaml: ppl.PipelineYaml,
+		PipelineMd5:  ppl.PipelineMd5,
+	}
+}
+
+// CreatePipelineVersion 为已存在的 pipeline 新增一个版本，并将 pipeline 的 yaml 更新为该版本
+func CreatePipelineVersion(logEntry *log.Entry, ppl *Pipeline, pipelineYaml, pipelineMd5 string) (int, error) {
+	logEntry.Debugf("begin create new version of pipeline[%s]", ppl.ID)
+	err := withTransaction(database.DB, func(tx *gorm.DB) error {
+		pplVersion := newPipelineVersion(*ppl)
+		pplVersion.Version = ppl.Version + 1
+		pplVersion.PipelineYaml = pipelineYaml
+		pplVersion.PipelineMd5 = pipelineMd5
+		result := tx.Model(&PipelineVersion{}).Create(&pplVersion)
+		if result.Error != nil {
+			logEntry.Errorf("create version[%d] of ppl[%s] failed. error:%v", pplVersion.Version, ppl.ID, result.Error)
+			return result.Error
+		}
+		result = tx.Model(&Pipeline{}).Where(&Pipeline{Pk: ppl.Pk}).Updates(map[string]interface{}{
+			"pipeline_yaml": pipelineYaml,
+			"pipeline_md5":  pipelineMd5,
+			"version":       pplVersion.Version,
+		})
+		if result.Error != nil {
+			logEntry.Errorf("update ppl[%s] to version[%d] failed. error:%v", ppl.ID, pplVersion.Version, result.Error)
+			return result.Error
+		}
+		ppl.PipelineYaml, ppl.PipelineMd5, ppl.Version = pipelineYaml, pipelineMd5, pplVersion.Version
+		return nil
+	})
+	return ppl.Version, err
+}
+
+func GetPipelineVersion(logEntry *log.Entry, pipelineID string, version int) (PipelineVersion, error) {
+	logEntry.Debugf("get version[%d] of ppl[%s]", version, pipelineID)
+	var pplVersion PipelineVersion
+	tx := database.DB.Model(&PipelineVersion{}).Where(&PipelineVersion{PipelineID: pipelineID, Version: version}).First(&pplVersion)
+	if tx.Error != nil {
+		logEntry.Errorf("get version[%d] of ppl[%s] failed. error:%v", version, pipelineID, tx.Error)
+		return PipelineVersion{}, tx.Error
+	}
+	pplVersion.Decode()
+	return pplVersion, nil
+}
+
+func ListPipelineVersion(logEntry *log.Entry, pipelineID string, pk int64, maxKeys int) ([]PipelineVersion, error) {
+	logEntry.Debugf("begin list versions of ppl[%s]", pipelineID)
+	tx := database.DB.Model(&PipelineVersion{}).Where("pipeline_id = ?", pipelineID).Where("pk > ?", pk)
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var pplVersionList []PipelineVersion
+	tx = tx.Order("pk").Find(&pplVersionList)
+	if tx.Error != nil {
+		logEntry.Errorf("list versions of ppl[%s] failed. error:%v", pipelineID, tx.Error)
+		return []PipelineVersion{}, tx.Error
+	}
+	for i := range pplVersionList {
+		pplVersionList[i].Decode()
+	}
+	return pplVersionList, nil
+}
+
+func GetLastPipelineVersion(logEntry *log.Entry, pipelineID string) (PipelineVersion, error) {
+	logEntry.Debugf("get last version of ppl[%s]", pipelineID)
+	pplVersion := PipelineVersion{}
+	tx := database.DB.Model(&PipelineVersion{}).Where("pipeline_id = ?", pipelineID).Last(&pplVersion)
+	if tx.Error != nil {
+		logEntry.Errorf("get last version of ppl[%s] failed. error:%v", pipelineID, tx.Error)
+		return PipelineVersion{}, tx.Error
+	}
+	return pplVersion, nil
+}
@@ -30,6 +30,7 @@ import (
 	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/common/database"
 	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
 )
 
 type ScalarResourcesType map[v1.ResourceName]string
@@ -41,6 +42,8 @@ type QueueInfo struct {
 	Cpu             string              `json:"cpu"`
 	Mem             string              `json:"mem"`
 	ScalarResources ScalarResourcesType `json:"scalarResources,omitempty" gorm:"type:text"`
+	// ReclaimPolicy 队列中任务的回收策略，未设置的字段使用全局配置
+	ReclaimPolicy *schema.ReclaimPolicy `json:"reclaimPolicy,omitempty" gorm:"type:text"`
 }
 
 type Queue struct {
@@ -195,7 +198,7 @@ func ListQueue(ctx *logger.RequestContext, pk int64, maxKeys int, queueName stri
 	if !common.IsRootUser(ctx.UserName) {
 		tx = database.DB.Table("queue").Select("queue.pk as pk, queue.name as name, "+
 			"queue.namespace as namespace, queue.cluster_name as cluster_name, queue.cpu as cpu, queue.mem as mem, "+
-			"queue.scalar_resources as scalar_resources, queue.status as status, "+
+			"queue.scalar_resources as scalar_resources, queue.reclaim_policy as reclaim_policy, queue.status as status, "+
 			"queue.created_at as created_at, queue.updated_at as updated_at, "+
 			"queue.deleted_at as deleted_at").Joins("join `grant` on `grant`.resource_id = queue.name").Where(
 			"`grant`.user_name = ?", ctx.UserName).Where("queue.pk > ?", pk)
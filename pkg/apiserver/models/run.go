@@ -32,31 +32,32 @@ import (
 )
 
 type Run struct {
-	Pk             int64                  `gorm:"primaryKey;autoIncrement;not null" json:"-"`
-	ID             string                 `gorm:"type:varchar(60);not null"         json:"runID"`
-	Name           string                 `gorm:"type:varchar(60);not null"         json:"name"`
-	Source         string                 `gorm:"type:varchar(256);not null"        json:"source"` // pipelineID or yamlPath
-	UserName       string                 `gorm:"type:varchar(60);not null"         json:"username"`
-	FsID           string                 `gorm:"type:varchar(60);not null"         json:"-"`
-	FsName         string                 `gorm:"type:varchar(60);not null"         json:"fsname"`
-	Description    string                 `gorm:"type:text;size:65535;not null"     json:"description"`
-	ParamRaw       string                 `gorm:"type:text;size:65535"              json:"-"`
-	Param          map[string]interface{} `gorm:"-"                                 json:"param"`
-	RunYaml        string                 `gorm:"type:text;size:65535"              json:"runYaml"`
-	WorkflowSource schema.WorkflowSource  `gorm:"-"                                 json:"-"` // RunYaml's dynamic struct
-	RuntimeRaw     string                 `gorm:"type:text;size:65535"              json:"-"`
-	Runtime        schema.RuntimeView     `gorm:"-"                                 json:"runtime"` // RuntimeRaw's struct
-	ImageUrl       string                 `gorm:"type:varchar(128)"                 json:"imageUrl"`
-	Entry          string                 `gorm:"type:varchar(256)"                 json:"entry"`
-	Message        string                 `gorm:"type:text;size:65535"              json:"runMsg"`
-	Status         string                 `gorm:"type:varchar(32)"                  json:"status"` // StatusRun%%%
-	CreateTime     string                 `gorm:"-"                                 json:"createTime"`
-	ActivateTime   string                 `gorm:"-"                                 json:"activateTime"`
-	UpdateTime     string                 `gorm:"-"                                 json:"updateTime,omitempty"`
-	CreatedAt      time.Time              `                                         json:"-"`
-	ActivatedAt    sql.NullTime           `                                         json:"-"`
-	UpdatedAt      time.Time              `                                         json:"-"`
-	DeletedAt      gorm.DeletedAt         `gorm:"index"                             json:"-"`
+	Pk              int64                  `gorm:"primaryKey;autoIncrement;not null" json:"-"`
+	ID              string                 `gorm:"type:varchar(60);not null"         json:"runID"`
+	Name            string                 `gorm:"type:varchar(60);not null"         json:"name"`
+	Source          string                 `gorm:"type:varchar(256);not null"        json:"source"` // pipelineID or yamlPath
+	PipelineVersion int                    `gorm:"default:0"                         json:"pipelineVersion,omitempty"`
+	UserName        string                 `gorm:"type:varchar(60);not null"         json:"username"`
+	FsID            string                 `gorm:"type:varchar(60);not null"         json:"-"`
+	FsName          string                 `gorm:"type:varchar(60);not null"         json:"fsname"`
+	Description     string                 `gorm:"type:text;size:65535;not null"     json:"description"`
+	ParamRaw        string                 `gorm:"type:text;size:65535"              json:"-"`
+	Param           map[string]interface{} `gorm:"-"                                 json:"param"`
+	RunYaml         string                 `gorm:"type:text;size:65535"              json:"runYaml"`
+	WorkflowSource  schema.WorkflowSource  `gorm:"-"                                 json:"-"` // RunYaml's dynamic struct
+	RuntimeRaw      string                 `gorm:"type:text;size:65535"              json:"-"`
+	Runtime         schema.RuntimeView     `gorm:"-"                                 json:"runtime"` // RuntimeRaw's struct
+	ImageUrl        string                 `gorm:"type:varchar(128)"                 json:"imageUrl"`
+	Entry           string                 `gorm:"type:varchar(256)"                 json:"entry"`
+	Message         string                 `gorm:"type:text;size:65535"              json:"runMsg"`
+	Status          string                 `gorm:"type:varchar(32)"                  json:"status"` // StatusRun%%%
+	CreateTime      string                 `gorm:"-"                                 json:"createTime"`
+	ActivateTime    string                 `gorm:"-"                                 json:"activateTime"`
+	UpdateTime      string                 `gorm:"-"                                 json:"updateTime,omitempty"`
+	CreatedAt       time.Time              `                                         json:"-"`
+	ActivatedAt     sql.NullTime           `                                         json:"-"`
+	UpdatedAt       time.Time              `                                         json:"-"`
+	DeletedAt       gorm.DeletedAt         `gorm:"index"                             json:"-"`
 }
 
 func (Run) TableName() string {
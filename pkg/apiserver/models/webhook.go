@@ -0,0 +1,207 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/common/database"
+)
+
+// Webhook run 状态变化时，通过 http POST 推送通知的地址，可以注册在 run 或者 pipeline 上
+type Webhook struct {
+	Pk           int64          `json:"-"                    gorm:"primaryKey;autoIncrement;not null"`
+	ID           string         `json:"webhookID"            gorm:"type:varchar(60);not null;uniqueIndex"`
+	ResourceType string         `json:"resourceType"         gorm:"type:varchar(36);not null;index:idx_resource"` // run or pipeline
+	ResourceID   string         `json:"resourceID"           gorm:"type:varchar(60);not null;index:idx_resource"`
+	URL          string         `json:"url"                  gorm:"type:varchar(512);not null"`
+	Secret       string         `json:"-"                    gorm:"type:varchar(256)"` // 用于对推送内容签名
+	UserName     string         `json:"username"             gorm:"type:varchar(60);not null"`
+	CreateTime   string         `json:"createTime"           gorm:"-"`
+	UpdateTime   string         `json:"updateTime,omitempty" gorm:"-"`
+	CreatedAt    time.Time      `json:"-"`
+	UpdatedAt    time.Time      `json:"-"`
+	DeletedAt    gorm.DeletedAt `json:"-"                    gorm:"index"`
+}
+
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+func (wh *Webhook) Decode() {
+	// format time
+	wh.CreateTime = wh.CreatedAt.Format("2006-01-02 15:04:05")
+	wh.UpdateTime = wh.UpdatedAt.Format("2006-01-02 15:04:05")
+}
+
+// WebhookDelivery webhook 的推送记录，每次 run 状态变化对应一条记录
+type WebhookDelivery struct {
+	Pk           int64     `json:"-"            gorm:"primaryKey;autoIncrement;not null"`
+	WebhookID    string    `json:"webhookID"    gorm:"type:varchar(60);not null;index"`
+	RunID        string    `json:"runID"        gorm:"type:varchar(60);not null"`
+	Status       string    `json:"status"       gorm:"type:varchar(32)"` // 触发推送的 run 状态
+	Payload      string    `json:"payload"      gorm:"type:text;size:65535"`
+	ResponseCode int       `json:"responseCode"`
+	Attempts     int       `json:"attempts"`
+	Success      bool      `json:"success"`
+	Message      string    `json:"message"      gorm:"type:text;size:65535"`
+	CreateTime   string    `json:"createTime"   gorm:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+func (d *WebhookDelivery) Decode() {
+	d.CreateTime = d.CreatedAt.Format("2006-01-02 15:04:05")
+}
+
+func CreateWebhook(logEntry *log.Entry, wh *Webhook) (string, error) {
+	logEntry.Debugf("begin create webhook: %+v", wh)
+	err := withTransaction(database.DB, func(tx *gorm.DB) error {
+		result := tx.Model(&Webhook{}).Create(wh)
+		if result.Error != nil {
+			logEntry.Errorf("create webhook failed. webhook:%+v, error:%v", wh, result.Error)
+			return result.Error
+		}
+		wh.ID = common.PrefixWebhook + fmt.Sprintf("%06d", wh.Pk)
+		logEntry.Debugf("created webhook with pk[%d], webhookID[%s]", wh.Pk, wh.ID)
+		// update ID by pk
+		result = tx.Model(&Webhook{}).Where("pk = ?", wh.Pk).Update("id", wh.ID)
+		if result.Error != nil {
+			logEntry.Errorf("back filling webhookID failed. pk[%d], error:%v", wh.Pk, result.Error)
+			return result.Error
+		}
+		return nil
+	})
+	return wh.ID, err
+}
+
+func GetWebhookByID(logEntry *log.Entry, id string) (Webhook, error) {
+	logEntry.Debugf("begin get webhook. webhookID:%s", id)
+	var wh Webhook
+	tx := database.DB.Model(&Webhook{}).Where("id = ?", id).First(&wh)
+	if tx.Error != nil {
+		logEntry.Errorf("get webhook failed. webhookID:%s, error:%v", id, tx.Error)
+		return Webhook{}, tx.Error
+	}
+	wh.Decode()
+	return wh, nil
+}
+
+func ListWebhook(logEntry *log.Entry, pk int64, maxKeys int, userFilter []string, resourceType, resourceID string) ([]Webhook, error) {
+	logEntry.Debugf("begin list webhook. ")
+	tx := database.DB.Model(&Webhook{}).Where("pk > ?", pk)
+	if len(userFilter) > 0 {
+		tx = tx.Where("user_name IN (?)", userFilter)
+	}
+	if resourceType != "" {
+		tx = tx.Where("resource_type = ?", resourceType)
+	}
+	if resourceID != "" {
+		tx = tx.Where("resource_id = ?", resourceID)
+	}
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var whList []Webhook
+	tx = tx.Find(&whList)
+	if tx.Error != nil {
+		logEntry.Errorf("list webhook failed. Filters: user{%v}, resourceType{%s}, resourceID{%s}. error:%s",
+			userFilter, resourceType, resourceID, tx.Error.Error())
+		return []Webhook{}, tx.Error
+	}
+	for i := range whList {
+		whList[i].Decode()
+	}
+	return whList, nil
+}
+
+// ListWebhookForRun 获取 run 及其所属 pipeline 上注册的 webhook
+func ListWebhookForRun(logEntry *log.Entry, runID, pipelineID string) ([]Webhook, error) {
+	var whList []Webhook
+	tx := database.DB.Model(&Webhook{}).
+		Where("resource_type = ? AND resource_id = ?", common.ResourceTypeRun, runID).
+		Or("resource_type = ? AND resource_id = ?", common.ResourceTypePipeline, pipelineID).
+		Find(&whList)
+	if tx.Error != nil {
+		logEntry.Errorf("list webhook for run[%s] pipeline[%s] failed. error:%v", runID, pipelineID, tx.Error)
+		return nil, tx.Error
+	}
+	return whList, nil
+}
+
+func GetLastWebhook(logEntry *log.Entry) (Webhook, error) {
+	logEntry.Debugf("get last webhook. ")
+	wh := Webhook{}
+	tx := database.DB.Model(&Webhook{}).Last(&wh)
+	if tx.Error != nil {
+		logEntry.Errorf("get last webhook failed. error:%s", tx.Error.Error())
+		return Webhook{}, tx.Error
+	}
+	return wh, nil
+}
+
+func DeleteWebhook(logEntry *log.Entry, id string) error {
+	logEntry.Debugf("delete webhook: %s", id)
+	return database.DB.Where("id = ?", id).Delete(&Webhook{}).Error
+}
+
+func CreateWebhookDelivery(logEntry *log.Entry, delivery *WebhookDelivery) error {
+	logEntry.Debugf("begin create webhook delivery: %+v", delivery)
+	tx := database.DB.Model(&WebhookDelivery{}).Create(delivery)
+	if tx.Error != nil {
+		logEntry.Errorf("create webhook delivery failed. delivery:%+v, error:%v", delivery, tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+func ListWebhookDelivery(logEntry *log.Entry, webhookID string, pk int64, maxKeys int) ([]WebhookDelivery, error) {
+	logEntry.Debugf("begin list delivery of webhook[%s]. ", webhookID)
+	tx := database.DB.Model(&WebhookDelivery{}).Where("pk > ?", pk).Where("webhook_id = ?", webhookID)
+	if maxKeys > 0 {
+		tx = tx.Limit(maxKeys)
+	}
+	var deliveryList []WebhookDelivery
+	tx = tx.Find(&deliveryList)
+	if tx.Error != nil {
+		logEntry.Errorf("list delivery of webhook[%s] failed. error:%s", webhookID, tx.Error.Error())
+		return []WebhookDelivery{}, tx.Error
+	}
+	for i := range deliveryList {
+		deliveryList[i].Decode()
+	}
+	return deliveryList, nil
+}
+
+func GetLastWebhookDelivery(logEntry *log.Entry, webhookID string) (WebhookDelivery, error) {
+	logEntry.Debugf("get last delivery of webhook[%s]. ", webhookID)
+	delivery := WebhookDelivery{}
+	tx := database.DB.Model(&WebhookDelivery{}).Where("webhook_id = ?", webhookID).Last(&delivery)
+	if tx.Error != nil {
+		logEntry.Errorf("get last delivery of webhook[%s] failed. error:%s", webhookID, tx.Error.Error())
+		return WebhookDelivery{}, tx.Error
+	}
+	return delivery, nil
+}
@@ -32,30 +32,50 @@ const (
 	DefaultMaxKeys = 50
 	ListPageMax    = 1000
 
-	ParamKeyQueueName  = "queueName"
-	ParamKeyRunID      = "runID"
-	ParamKeyRunCacheID = "runCacheID"
-	ParamKeyPipelineID = "pipelineID"
-
-	QueryKeyAction   = "action"
-	QueryActionStop  = "stop"
-	QueryActionRetry = "retry"
-	QueryActionClose = "close"
+	ParamKeyQueueName       = "queueName"
+	ParamKeyRunID           = "runID"
+	ParamKeyRunCacheID      = "runCacheID"
+	ParamKeyPipelineID      = "pipelineID"
+	ParamKeyPipelineVersion = "version"
+	ParamKeyJobID           = "jobID"
+	ParamKeyWebhookID       = "webhookID"
+	ParamKeyStepName        = "stepName"
+	ParamKeyTemplateName    = "templateName"
+
+	QueryKeyAction      = "action"
+	QueryActionStop     = "stop"
+	QueryActionRetry    = "retry"
+	QueryActionClose    = "close"
+	QueryActionPriority = "priority"
 
 	QueryKeyMarker  = "marker"
 	QueryKeyMaxKeys = "maxKeys"
 
-	QueryKeyUserFilter = "userFilter"
-	QueryKeyFsFilter   = "fsFilter"
-	QueryKeyNameFilter = "nameFilter"
-	QueryKeyRunFilter  = "runFilter"
-	QueryKeyTypeFilter = "typeFilter"
-	QueryKeyPathFilter = "pathFilter"
-	QueryKeyUser       = "user"
-	QueryKeyName       = "name"
-	QueryKeyUserName   = "username"
-	QueryResourceType  = "resourceType"
-	QueryResourceID    = "resourceID"
+	QueryKeyFromVersion = "fromVersion"
+	QueryKeyToVersion   = "toVersion"
+
+	QueryKeyTaskName      = "taskName"
+	QueryKeyContainerName = "containerName"
+	QueryKeyTailLines     = "tailLines"
+	QueryKeyLineLimit     = "lineLimit"
+	QueryKeyPageNo        = "pageNo"
+	QueryKeyFollow        = "follow"
+	QueryKeyLoopIndex     = "loopIndex"
+
+	QueryKeyUserFilter   = "userFilter"
+	QueryKeyFsFilter     = "fsFilter"
+	QueryKeyNameFilter   = "nameFilter"
+	QueryKeyRunFilter    = "runFilter"
+	QueryKeyTypeFilter   = "typeFilter"
+	QueryKeyPathFilter   = "pathFilter"
+	QueryKeyQueueFilter  = "queueFilter"
+	QueryKeyStatusFilter = "statusFilter"
+	QueryKeyUser         = "user"
+	QueryKeyQueue        = "queue"
+	QueryKeyName         = "name"
+	QueryKeyUserName     = "username"
+	QueryResourceType    = "resourceType"
+	QueryResourceID      = "resourceID"
 
 	ParamKeyClusterName   = "clusterName"
 	ParamKeyClusterNames  = "clusterNames"
@@ -0,0 +1,375 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/job"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/apiserver/router/util"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/fs/server/utils/fs"
+)
+
+type JobRouter struct{}
+
+func (jr *JobRouter) Name() string {
+	return "JobRouter"
+}
+
+func (jr *JobRouter) AddRouter(r chi.Router) {
+	log.Info("add job router")
+	r.Post("/job", jr.createJob)
+	r.Get("/job", jr.listJob)
+	r.Get("/job/{jobID}", jr.getJobByID)
+	r.Put("/job/{jobID}", jr.updateJob)
+	r.Delete("/job/{jobID}", jr.deleteJob)
+	r.Get("/job/{jobID}/log", jr.getJobLog)
+	r.Get("/job/{jobID}/task", jr.listJobTask)
+}
+
+// createJob
+// @Summary 创建任务
+// @Description 创建任务
+// @Id createJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param request body job.CreateJobRequest true "创建任务请求"
+// @Success 201 {object} job.CreateJobResponse "创建任务响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job [POST]
+func (jr *JobRouter) createJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var createJobInfo job.CreateJobRequest
+	if err := common.BindJSON(r, &createJobInfo); err != nil {
+		logger.LoggerForRequest(&ctx).Errorf(
+			"create job failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if createJobInfo.FsName == "" {
+		ctx.ErrorCode = common.InappropriateJSON
+		logger.LoggerForRequest(&ctx).Errorf(
+			"create job failed. fsname shall not be empty")
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, "create job failed. fsname in request body shall not be empty")
+		return
+	}
+	// check grant
+	if !common.IsRootUser(ctx.UserName) {
+		fsID := fs.ID(ctx.UserName, createJobInfo.FsName)
+		if !models.HasAccessToResource(&ctx, common.ResourceTypeFs, fsID) {
+			ctx.ErrorCode = common.AccessDenied
+			err := common.NoAccessError(ctx.UserName, common.ResourceTypeFs, fsID)
+			ctx.Logging().Errorf("create job failed. error: %v", err)
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+	response, err := job.CreateJob(&ctx, &createJobInfo)
+	if err != nil {
+		logger.LoggerForRequest(&ctx).Errorf(
+			"create job failed. createJobInfo:%v error:%s", createJobInfo, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listJob
+// @Summary 获取任务列表
+// @Description 获取任务列表
+// @Id listJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param userFilter query string false "(root用户)用户过滤"
+// @Param queueFilter query string false "队列过滤"
+// @Param statusFilter query string false "状态过滤"
+// @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
+// @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
+// @Success 200 {object} job.ListJobResponse "获取任务列表的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job [GET]
+func (jr *JobRouter) listJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys := util.DefaultMaxKeys
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	limitCustom := r.URL.Query().Get(util.QueryKeyMaxKeys)
+	if limitCustom != "" {
+		var err error
+		maxKeys, err = strconv.Atoi(limitCustom)
+		if err != nil || maxKeys <= 0 || maxKeys > util.ListPageMax {
+			err := fmt.Errorf("invalid query pageLimit[%s]. should be an integer between 1~1000",
+				limitCustom)
+			ctx.ErrorCode = common.InvalidURI
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+	userNames, queueNames := r.URL.Query().Get(util.QueryKeyUserFilter), r.URL.Query().Get(util.QueryKeyQueueFilter)
+	statuses := r.URL.Query().Get(util.QueryKeyStatusFilter)
+	userFilter, queueFilter, statusFilter := make([]string, 0), make([]string, 0), make([]string, 0)
+	if userNames != "" {
+		userFilter = strings.Split(userNames, common.SeparatorComma)
+	}
+	if queueNames != "" {
+		queueFilter = strings.Split(queueNames, common.SeparatorComma)
+	}
+	if statuses != "" {
+		statusFilter = strings.Split(statuses, common.SeparatorComma)
+	}
+	logger.LoggerForRequest(&ctx).Debugf(
+		"user[%s] ListJob marker:[%s] maxKeys:[%d] userFilter:%v queueFilter:%v statusFilter:%v",
+		ctx.UserName, marker, maxKeys, userFilter, queueFilter, statusFilter)
+	listJobResponse, err := job.ListJob(&ctx, marker, maxKeys, userFilter, queueFilter, statusFilter)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, listJobResponse)
+}
+
+// getJobByID
+// @Summary 获取任务
+// @Description 获取任务
+// @Id getJobByID
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "任务ID"
+// @Success 200 {object} models.Job "任务详情"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID} [GET]
+func (jr *JobRouter) getJobByID(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	jobInfo, err := job.GetJobByID(&ctx, jobID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, jobInfo)
+}
+
+// updateJob
+// @Summary 修改任务
+// @Description 修改任务
+// @Id updateJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "任务ID"
+// @Param action query string true "修改动作，stop为停止任务，priority为修改排队中任务的优先级"
+// @Param request body job.UpdateJobPriorityRequest false "修改任务优先级请求，action为priority时必填"
+// @Success 200 "修改任务成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID} [PUT]
+func (jr *JobRouter) updateJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	action := r.URL.Query().Get(util.QueryKeyAction)
+	var err error
+	switch action {
+	case util.QueryActionStop:
+		err = job.StopJob(&ctx, jobID)
+	case util.QueryActionPriority:
+		var request job.UpdateJobPriorityRequest
+		if err = common.BindJSON(r, &request); err != nil {
+			ctx.ErrorCode = common.MalformedJSON
+			ctx.Logging().Errorf("update priority of job[%s] failed parsing request body. error:%s", jobID, err.Error())
+			break
+		}
+		err = job.UpdateJobPriority(&ctx, jobID, &request)
+	default:
+		ctx.ErrorCode = common.InvalidURI
+		err = fmt.Errorf("invalid action[%s] for UpdateJob", action)
+	}
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteJob
+// @Summary 删除任务
+// @Description 删除任务
+// @Id deleteJob
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "任务ID"
+// @Success 200 {string} string "删除任务的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID} [DELETE]
+func (jr *JobRouter) deleteJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	if err := job.DeleteJob(&ctx, jobID); err != nil {
+		ctx.Logging().Errorf("delete job: %s failed. error:%s", jobID, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// getJobLog
+// @Summary 获取任务日志
+// @Description 获取任务各个task、各个container的分页日志，follow为true时持续输出某个container的日志
+// @Id getJobLog
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "任务ID"
+// @Param taskName query string false "task名称，即pod名称"
+// @Param containerName query string false "container名称"
+// @Param tailLines query int false "只获取最后tailLines行日志"
+// @Param lineLimit query int false "每页日志行数，缺省值为1000"
+// @Param pageNo query int false "日志页码，从1开始"
+// @Param follow query bool false "是否持续输出日志"
+// @Success 200 {object} schema.JobLogInfo "任务日志"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID}/log [GET]
+func (jr *JobRouter) getJobLog(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	request, err := parseJobLogRequest(r)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidURI
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if request.Follow {
+		stream, err := job.GetJobLogStream(&ctx, r.Context(), jobID, request)
+		if err != nil {
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+		renderLogStream(&ctx, w, stream)
+		return
+	}
+	jobLogInfo, err := job.GetJobLog(&ctx, jobID, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, jobLogInfo)
+}
+
+// listJobTask
+// @Summary 获取任务的task列表
+// @Description 获取任务各个pod的状态，包括phase、节点、重启次数、退出码、等待/终止原因及最近的事件
+// @Id listJobTask
+// @tags Job
+// @Accept  json
+// @Produce json
+// @Param jobID path string true "任务ID"
+// @Success 200 {object} job.ListJobTaskResponse "任务的task列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /job/{jobID}/task [GET]
+func (jr *JobRouter) listJobTask(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	jobID := chi.URLParam(r, util.ParamKeyJobID)
+	response, err := job.ListJobTask(&ctx, jobID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+func parseJobLogRequest(r *http.Request) (schema.JobLogRequest, error) {
+	query := r.URL.Query()
+	request := schema.JobLogRequest{
+		TaskName:      query.Get(util.QueryKeyTaskName),
+		ContainerName: query.Get(util.QueryKeyContainerName),
+		LineLimit:     schema.DefaultLogLineLimit,
+		PageNo:        schema.DefaultLogPageNo,
+	}
+	var err error
+	if tailLines := query.Get(util.QueryKeyTailLines); tailLines != "" {
+		request.TailLines, err = strconv.ParseInt(tailLines, 10, 64)
+		if err != nil || request.TailLines <= 0 {
+			return request, fmt.Errorf("invalid query tailLines[%s]. should be a positive integer", tailLines)
+		}
+	}
+	if lineLimit := query.Get(util.QueryKeyLineLimit); lineLimit != "" {
+		request.LineLimit, err = strconv.Atoi(lineLimit)
+		if err != nil || request.LineLimit <= 0 || request.LineLimit > schema.MaxLogLineLimit {
+			return request, fmt.Errorf("invalid query lineLimit[%s]. should be an integer between 1~%d",
+				lineLimit, schema.MaxLogLineLimit)
+		}
+	}
+	if pageNo := query.Get(util.QueryKeyPageNo); pageNo != "" {
+		request.PageNo, err = strconv.Atoi(pageNo)
+		if err != nil || request.PageNo <= 0 {
+			return request, fmt.Errorf("invalid query pageNo[%s]. should be a positive integer", pageNo)
+		}
+	}
+	if follow := query.Get(util.QueryKeyFollow); follow != "" {
+		request.Follow, err = strconv.ParseBool(follow)
+		if err != nil {
+			return request, fmt.Errorf("invalid query follow[%s]. should be true or false", follow)
+		}
+	}
+	return request, nil
+}
+
+// renderLogStream write log to response as soon as it is read, until log stream or request is closed
+func renderLogStream(ctx *logger.RequestContext, w http.ResponseWriter, stream io.ReadCloser) {
+	defer stream.Close()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				ctx.Logging().Warnf("write log stream failed. error:%s", writeErr.Error())
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				ctx.Logging().Warnf("read log stream failed. error:%s", err.Error())
+			}
+			return
+		}
+	}
+}
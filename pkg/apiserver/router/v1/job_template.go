@@ -0,0 +1,184 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/job"
+	"paddleflow/pkg/apiserver/router/util"
+)
+
+type JobTemplateRouter struct{}
+
+func (tr *JobTemplateRouter) Name() string {
+	return "JobTemplateRouter"
+}
+
+func (tr *JobTemplateRouter) AddRouter(r chi.Router) {
+	log.Info("add job template router")
+	r.Post("/jobtemplate", tr.createJobTemplate)
+	r.Get("/jobtemplate", tr.listJobTemplate)
+	r.Get("/jobtemplate/{templateName}", tr.getJobTemplate)
+	r.Put("/jobtemplate/{templateName}", tr.updateJobTemplate)
+	r.Delete("/jobtemplate/{templateName}", tr.deleteJobTemplate)
+}
+
+// createJobTemplate
+// @Summary 创建任务模板
+// @Description 创建任务模板，任务通过环境变量PF_JOB_TEMPLATE引用模板，模板内容在创建时校验
+// @Id createJobTemplate
+// @tags JobTemplate
+// @Accept  json
+// @Produce json
+// @Param request body job.CreateJobTemplateRequest true "创建任务模板请求"
+// @Success 201 {object} job.CreateJobTemplateResponse "创建任务模板响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /jobtemplate [POST]
+func (tr *JobTemplateRouter) createJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var request job.CreateJobTemplateRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		ctx.Logging().Errorf("create job template failed parsing request body. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := job.CreateJobTemplate(&ctx, &request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listJobTemplate
+// @Summary 获取任务模板列表
+// @Description 获取任务模板列表，queue为空时返回全局模板及有权限队列的模板
+// @Id listJobTemplate
+// @tags JobTemplate
+// @Accept  json
+// @Produce json
+// @Param queue query string false "队列名称"
+// @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
+// @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
+// @Success 200 {object} job.ListJobTemplateResponse "任务模板列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /jobtemplate [GET]
+func (tr *JobTemplateRouter) listJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, common.InvalidURI, err.Error())
+		return
+	}
+	queueName := r.URL.Query().Get(util.QueryKeyQueue)
+	response, err := job.ListJobTemplate(&ctx, marker, maxKeys, queueName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getJobTemplate
+// @Summary 获取任务模板
+// @Description 获取任务模板，queue为空表示全局模板
+// @Id getJobTemplate
+// @tags JobTemplate
+// @Accept  json
+// @Produce json
+// @Param templateName path string true "模板名称"
+// @Param queue query string false "队列名称"
+// @Success 200 {object} models.JobTemplate "任务模板"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /jobtemplate/{templateName} [GET]
+func (tr *JobTemplateRouter) getJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyTemplateName)
+	queueName := r.URL.Query().Get(util.QueryKeyQueue)
+	template, err := job.GetJobTemplate(&ctx, name, queueName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, template)
+}
+
+// updateJobTemplate
+// @Summary 修改任务模板
+// @Description 修改任务模板，已提交的任务不受影响
+// @Id updateJobTemplate
+// @tags JobTemplate
+// @Accept  json
+// @Produce json
+// @Param templateName path string true "模板名称"
+// @Param queue query string false "队列名称"
+// @Param request body job.UpdateJobTemplateRequest true "修改任务模板请求"
+// @Success 200 "修改任务模板成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /jobtemplate/{templateName} [PUT]
+func (tr *JobTemplateRouter) updateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyTemplateName)
+	queueName := r.URL.Query().Get(util.QueryKeyQueue)
+	var request job.UpdateJobTemplateRequest
+	if err := common.BindJSON(r, &request); err != nil {
+		ctx.ErrorCode = common.MalformedJSON
+		ctx.Logging().Errorf("update job template failed parsing request body. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if err := job.UpdateJobTemplate(&ctx, name, queueName, &request); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// deleteJobTemplate
+// @Summary 删除任务模板
+// @Description 删除任务模板
+// @Id deleteJobTemplate
+// @tags JobTemplate
+// @Accept  json
+// @Produce json
+// @Param templateName path string true "模板名称"
+// @Param queue query string false "队列名称"
+// @Success 200 "删除任务模板成功"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /jobtemplate/{templateName} [DELETE]
+func (tr *JobTemplateRouter) deleteJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	name := chi.URLParam(r, util.ParamKeyTemplateName)
+	queueName := r.URL.Query().Get(util.QueryKeyQueue)
+	if err := job.DeleteJobTemplate(&ctx, name, queueName); err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
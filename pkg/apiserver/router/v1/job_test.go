@@ -0,0 +1,206 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/controller/job"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/apiserver/router/util"
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+const (
+	MockJobID1     = "job-id-000001"
+	MockJobID2     = "job-id-000002"
+	MockJobID3     = "job-id-000003"
+	MockQueueName1 = "queue-1"
+	MockQueueName2 = "queue-2"
+)
+
+func createMockJobs(t *testing.T) {
+	jobs := []models.Job{
+		{
+			ID:        MockJobID1,
+			UserName:  MockRootUser,
+			Type:      string(schema.TypeVcJob),
+			QueueName: MockQueueName1,
+			Status:    schema.StatusJobRunning,
+		},
+		{
+			ID:        MockJobID2,
+			UserName:  MockRootUser,
+			Type:      string(schema.TypeVcJob),
+			QueueName: MockQueueName2,
+			Status:    schema.StatusJobSucceeded,
+		},
+		{
+			ID:        MockJobID3,
+			UserName:  MockNormalUser,
+			Type:      string(schema.TypeSparkJob),
+			QueueName: MockQueueName1,
+			Status:    schema.StatusJobFailed,
+		},
+	}
+	for i := range jobs {
+		tx := database.DB.Create(&jobs[i])
+		assert.Nil(t, tx.Error)
+	}
+}
+
+func TestGetJobRouter(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	createMockJobs(t)
+
+	result, err := PerformGetRequest(router, baseUrl+"/job/"+MockJobID1)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	jobRsp := models.Job{}
+	err = ParseBody(result.Body, &jobRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, MockJobID1, jobRsp.ID)
+	assert.Equal(t, MockQueueName1, jobRsp.QueueName)
+
+	result, err = PerformGetRequest(router, baseUrl+"/job/job-not-exist")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+}
+
+func TestListJobRouter(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	createMockJobs(t)
+	jobUrl := baseUrl + "/job"
+
+	result, err := PerformGetRequest(router, jobUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	jobRsp := job.ListJobResponse{}
+	err = ParseBody(result.Body, &jobRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(jobRsp.JobList))
+
+	// with filters
+	filters := "?" + util.QueryKeyQueueFilter + "=" + MockQueueName1 + "&" + util.QueryKeyStatusFilter + "=" + string(schema.StatusJobFailed)
+	result, err = PerformGetRequest(router, jobUrl+filters)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	jobRsp = job.ListJobResponse{}
+	err = ParseBody(result.Body, &jobRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobRsp.JobList))
+	assert.Equal(t, MockJobID3, jobRsp.JobList[0].ID)
+}
+
+func TestUpdateAndDeleteJobRouter(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	createMockJobs(t)
+
+	// invalid action
+	result, err := PerformPutRequest(router, baseUrl+"/job/"+MockJobID1+"?action=retry", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+	// job in final status cannot be stopped
+	result, err = PerformPutRequest(router, baseUrl+"/job/"+MockJobID2+"?action=stop", nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, http.StatusOK, result.Code)
+
+	// running job cannot be deleted
+	result, err = PerformDeleteRequest(router, baseUrl+"/job/"+MockJobID1)
+	assert.Nil(t, err)
+	assert.NotEqual(t, http.StatusOK, result.Code)
+	// job in final status can be deleted
+	result, err = PerformDeleteRequest(router, baseUrl+"/job/"+MockJobID2)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	result, err = PerformGetRequest(router, baseUrl+"/job/"+MockJobID2)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+}
+
+func TestParseJobLogRequest(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet,
+		"/job/job-1/log?taskName=t1&containerName=c1&tailLines=100&lineLimit=20&pageNo=2&follow=true", nil)
+	assert.NoError(t, err)
+	request, err := parseJobLogRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "t1", request.TaskName)
+	assert.Equal(t, "c1", request.ContainerName)
+	assert.Equal(t, int64(100), request.TailLines)
+	assert.Equal(t, 20, request.LineLimit)
+	assert.Equal(t, 2, request.PageNo)
+	assert.True(t, request.Follow)
+
+	// default values
+	r, err = http.NewRequest(http.MethodGet, "/job/job-1/log", nil)
+	assert.NoError(t, err)
+	request, err = parseJobLogRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.DefaultLogLineLimit, request.LineLimit)
+	assert.Equal(t, schema.DefaultLogPageNo, request.PageNo)
+	assert.False(t, request.Follow)
+
+	// invalid values
+	for _, query := range []string{"tailLines=-1", "lineLimit=0", "lineLimit=10001", "pageNo=a", "follow=yes"} {
+		r, err = http.NewRequest(http.MethodGet, "/job/job-1/log?"+query, nil)
+		assert.NoError(t, err)
+		_, err = parseJobLogRequest(r)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestListJobTaskRouter(t *testing.T) {
+	router, baseUrl := prepareDBAndAPI(t)
+	createMockJobs(t)
+
+	exitCode := int32(1)
+	tasks := []models.JobTask{
+		{ID: "uid-2", JobID: MockJobID1, Name: "job-worker-1", MemberRole: "worker", ReplicaIndex: 1,
+			Phase: "Failed", ExitCode: &exitCode, Reason: "Error"},
+		{ID: "uid-1", JobID: MockJobID1, Name: "job-worker-0", MemberRole: "worker", ReplicaIndex: 0,
+			Phase: "Running", Events: []models.TaskEvent{{Name: "e1", Reason: "Scheduled"}}},
+		{ID: "uid-3", JobID: MockJobID2, Name: "job2-worker-0", MemberRole: "worker", Phase: "Succeeded"},
+	}
+	for i := range tasks {
+		assert.Nil(t, models.UpdateTask(logger.LoggerForJob(tasks[i].JobID), &tasks[i]))
+	}
+	// status update keeps events of task
+	tasks[1].Phase = "Succeeded"
+	tasks[1].Events = nil
+	assert.Nil(t, models.UpdateTask(logger.LoggerForJob(MockJobID1), &tasks[1]))
+
+	result, err := PerformGetRequest(router, baseUrl+"/job/"+MockJobID1+"/task")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.Code)
+	taskRsp := job.ListJobTaskResponse{}
+	err = ParseBody(result.Body, &taskRsp)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(taskRsp.TaskList))
+	assert.Equal(t, "job-worker-0", taskRsp.TaskList[0].Name)
+	assert.Equal(t, "Succeeded", taskRsp.TaskList[0].Phase)
+	assert.Equal(t, 1, len(taskRsp.TaskList[0].Events))
+	assert.Equal(t, exitCode, *taskRsp.TaskList[1].ExitCode)
+
+	result, err = PerformGetRequest(router, baseUrl+"/job/job-not-exist/task")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Code)
+}
@@ -45,6 +45,9 @@ func (pr *PipelineRouter) AddRouter(r chi.Router) {
 	r.Get("/pipeline", pr.listPipeline)
 	r.Get("/pipeline/{pipelineID}", pr.getPipeline)
 	r.Delete("/pipeline/{pipelineID}", pr.deletePipeline)
+	r.Get("/pipeline/{pipelineID}/version", pr.listPipelineVersion)
+	r.Get("/pipeline/{pipelineID}/version/{version}", pr.getPipelineVersion)
+	r.Get("/pipeline/{pipelineID}/diff", pr.diffPipelineVersion)
 }
 
 // createPipeline
@@ -201,3 +204,111 @@ func (pr *PipelineRouter) deletePipeline(w http.ResponseWriter, r *http.Request)
 	}
 	common.RenderStatus(w, http.StatusOK)
 }
+
+// listPipelineVersion
+// @Summary 获取工作流的版本列表
+// @Description 获取工作流的版本列表
+// @Id listPipelineVersion
+// @tags Pipeline
+// @Accept  json
+// @Produce json
+// @Param pipelineID path string true "工作流ID"
+// @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
+// @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
+// @Success 200 {object} pipeline.ListPipelineVersionResponse "获取工作流版本列表的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /pipeline/{pipelineID}/version [GET]
+func (pr *PipelineRouter) listPipelineVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	pipelineID := chi.URLParam(r, util.ParamKeyPipelineID)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	response, err := pipeline.ListPipelineVersion(&ctx, pipelineID, marker, maxKeys)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getPipelineVersion
+// @Summary 获取工作流的指定版本
+// @Description 获取工作流的指定版本
+// @Id getPipelineVersion
+// @tags Pipeline
+// @Accept  json
+// @Produce json
+// @Param pipelineID path string true "工作流ID"
+// @Param version path int true "工作流版本"
+// @Success 200 {object} models.PipelineVersion "工作流版本结构体"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /pipeline/{pipelineID}/version/{version} [GET]
+func (pr *PipelineRouter) getPipelineVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	pipelineID := chi.URLParam(r, util.ParamKeyPipelineID)
+	version, err := parsePipelineVersion(&ctx, chi.URLParam(r, util.ParamKeyPipelineVersion))
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	pplVersion, err := pipeline.GetPipelineVersion(&ctx, pipelineID, version)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, pplVersion)
+}
+
+// diffPipelineVersion
+// @Summary 比较工作流的两个版本
+// @Description 逐行比较工作流两个版本的yaml
+// @Id diffPipelineVersion
+// @tags Pipeline
+// @Accept  json
+// @Produce json
+// @Param pipelineID path string true "工作流ID"
+// @Param fromVersion query int true "旧版本"
+// @Param toVersion query int true "新版本"
+// @Success 200 {object} pipeline.DiffPipelineVersionResponse "工作流版本比较的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /pipeline/{pipelineID}/diff [GET]
+func (pr *PipelineRouter) diffPipelineVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	pipelineID := chi.URLParam(r, util.ParamKeyPipelineID)
+	fromVersion, err := parsePipelineVersion(&ctx, r.URL.Query().Get(util.QueryKeyFromVersion))
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	toVersion, err := parsePipelineVersion(&ctx, r.URL.Query().Get(util.QueryKeyToVersion))
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := pipeline.DiffPipelineVersion(&ctx, pipelineID, fromVersion, toVersion)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+func parsePipelineVersion(ctx *logger.RequestContext, version string) (int, error) {
+	v, err := strconv.Atoi(version)
+	if err != nil || v <= 0 {
+		ctx.ErrorCode = common.InvalidURI
+		err := fmt.Errorf("invalid pipeline version[%s]. should be a positive integer", version)
+		ctx.Logging().Errorln(err.Error())
+		return 0, err
+	}
+	return v, nil
+}
@@ -24,6 +24,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/job"
 	"paddleflow/pkg/apiserver/controller/queue"
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/apiserver/router/util"
@@ -43,6 +44,7 @@ func (qr *QueueRouter) AddRouter(r chi.Router) {
 	r.Get("/queue/{queueName}", qr.getQueueByName)
 	r.Put("/queue/{queueName}", qr.closeQueue)
 	r.Delete("/queue/{queueName}", qr.deleteQueue)
+	r.Get("/queue/{queueName}/pendingjob", qr.listPendingJob)
 }
 
 // createQueue
@@ -200,3 +202,26 @@ func (qr *QueueRouter) deleteQueue(w http.ResponseWriter, r *http.Request) {
 	}
 	common.RenderStatus(w, http.StatusOK)
 }
+
+// listPendingJob
+// @Summary 获取队列中排队的任务
+// @Description 按调度顺序获取队列中排队的任务，优先级高的任务在前，相同优先级按创建时间排序
+// @Id listPendingJob
+// @tags Queue
+// @Accept  json
+// @Produce json
+// @Param queueName path string true "队列名称"
+// @Success 200 {object} job.ListPendingJobResponse "排队任务列表"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /queue/{queueName}/pendingjob [GET]
+func (qr *QueueRouter) listPendingJob(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	queueName := chi.URLParam(r, util.ParamKeyQueueName)
+	response, err := job.ListPendingJob(&ctx, queueName)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
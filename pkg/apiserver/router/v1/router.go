@@ -60,9 +60,13 @@ func RegisterRouters(r *chi.Mux, debugMode bool) {
 		AddRouter(apiV1Router, &QueueRouter{})
 		AddRouter(apiV1Router, &FlavourRouter{})
 		AddRouter(apiV1Router, &RunRouter{})
+		AddRouter(apiV1Router, &JobRouter{})
+		AddRouter(apiV1Router, &JobTemplateRouter{})
 		AddRouter(apiV1Router, &PipelineRouter{})
+		AddRouter(apiV1Router, &WebhookRouter{})
 		AddRouter(apiV1Router, &UserRouter{})
 		AddRouter(apiV1Router, &fs.LinkRouter{})
+		AddRouter(apiV1Router, &fs.FileLockRouter{})
 		AddRouter(apiV1Router, &fs.PFSRouter{})
 		AddRouter(apiV1Router, &ClusterRouter{})
 		AddRouter(apiV1Router, &TrackRouter{})
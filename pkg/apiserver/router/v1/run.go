@@ -26,6 +26,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/job"
 	"paddleflow/pkg/apiserver/controller/run"
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/apiserver/router/util"
@@ -46,6 +47,7 @@ func (rr *RunRouter) AddRouter(r chi.Router) {
 	r.Get("/run/{runID}", rr.getRunByID)
 	r.Put("/run/{runID}", rr.updateRun)
 	r.Delete("/run/{runID}", rr.deleteRun)
+	r.Get("/run/{runID}/step/{stepName}/log", rr.getRunStepLog)
 }
 
 // createRun
@@ -239,3 +241,66 @@ func (rr *RunRouter) deleteRun(w http.ResponseWriter, r *http.Request) {
 	}
 	common.RenderStatus(w, http.StatusOK)
 }
+
+// getRunStepLog
+// @Summary 获取运行中某个步骤的日志
+// @Description 获取运行中某个步骤对应任务的日志，参数与获取任务日志一致
+// @Id getRunStepLog
+// @tags Run
+// @Accept  json
+// @Produce json
+// @Param runID path string true "运行ID"
+// @Param stepName path string true "步骤名称"
+// @Param loopIndex query int false "loop 步骤的迭代序号，从0开始，loop 步骤必须指定"
+// @Param taskName query string false "task名称，即pod名称"
+// @Param containerName query string false "container名称"
+// @Param tailLines query int false "只获取最后tailLines行日志"
+// @Param lineLimit query int false "每页日志行数，缺省值为1000"
+// @Param pageNo query int false "日志页码，从1开始"
+// @Param follow query bool false "是否持续输出日志"
+// @Success 200 {object} schema.JobLogInfo "步骤日志"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /run/{runID}/step/{stepName}/log [GET]
+func (rr *RunRouter) getRunStepLog(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	runID := chi.URLParam(r, util.ParamKeyRunID)
+	stepName := chi.URLParam(r, util.ParamKeyStepName)
+	request, err := parseJobLogRequest(r)
+	if err != nil {
+		ctx.ErrorCode = common.InvalidURI
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	loopIndex := -1
+	if index := r.URL.Query().Get(util.QueryKeyLoopIndex); index != "" {
+		loopIndex, err = strconv.Atoi(index)
+		if err != nil || loopIndex < 0 {
+			ctx.ErrorCode = common.InvalidURI
+			err = fmt.Errorf("invalid query loopIndex[%s]. should be a non-negative integer", index)
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+	jobID, err := run.GetRunStepJobID(&ctx, runID, stepName, loopIndex)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if request.Follow {
+		stream, err := job.GetJobLogStream(&ctx, r.Context(), jobID, request)
+		if err != nil {
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+		renderLogStream(&ctx, w, stream)
+		return
+	}
+	jobLogInfo, err := job.GetJobLog(&ctx, jobID, request)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, jobLogInfo)
+}
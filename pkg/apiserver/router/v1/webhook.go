@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/controller/webhook"
+	"paddleflow/pkg/apiserver/router/util"
+	"paddleflow/pkg/common/logger"
+)
+
+type WebhookRouter struct{}
+
+func (wr *WebhookRouter) Name() string {
+	return "WebhookRouter"
+}
+
+func (wr *WebhookRouter) AddRouter(r chi.Router) {
+	log.Info("add webhook router")
+	r.Post("/webhook", wr.createWebhook)
+	r.Get("/webhook", wr.listWebhook)
+	r.Get("/webhook/{webhookID}", wr.getWebhook)
+	r.Delete("/webhook/{webhookID}", wr.deleteWebhook)
+	r.Get("/webhook/{webhookID}/delivery", wr.listWebhookDelivery)
+}
+
+// createWebhook
+// @Summary 创建webhook
+// @Description 在run或者pipeline上注册webhook，run状态变化时推送通知
+// @Id createWebhook
+// @tags Webhook
+// @Accept  json
+// @Produce json
+// @Param request body webhook.CreateWebhookRequest true "创建webhook请求"
+// @Success 201 {object} webhook.CreateWebhookResponse "创建webhook响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /webhook [POST]
+func (wr *WebhookRouter) createWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	var createWebhookReq webhook.CreateWebhookRequest
+	if err := common.BindJSON(r, &createWebhookReq); err != nil {
+		logger.LoggerForRequest(&ctx).Errorf(
+			"create webhook failed parsing request body:%+v. error:%s", r.Body, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	response, err := webhook.CreateWebhook(&ctx, &createWebhookReq)
+	if err != nil {
+		logger.LoggerForRequest(&ctx).Errorf(
+			"create webhook failed. request:%+v error:%s", createWebhookReq, err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusCreated, response)
+}
+
+// listWebhook
+// @Summary 获取webhook列表
+// @Description 获取webhook列表
+// @Id listWebhook
+// @tags Webhook
+// @Accept  json
+// @Produce json
+// @Param resourceType query string false "资源类型过滤，run或者pipeline"
+// @Param resourceID query string false "资源ID过滤"
+// @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
+// @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
+// @Success 200 {object} webhook.ListWebhookResponse "获取webhook列表的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /webhook [GET]
+func (wr *WebhookRouter) listWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	resourceType := r.URL.Query().Get(util.QueryResourceType)
+	resourceID := r.URL.Query().Get(util.QueryResourceID)
+	logger.LoggerForRequest(&ctx).Debugf(
+		"user[%s] ListWebhook marker:[%s] maxKeys:[%d] resourceType:[%s] resourceID:[%s]",
+		ctx.UserName, marker, maxKeys, resourceType, resourceID)
+	response, err := webhook.ListWebhook(&ctx, marker, maxKeys, resourceType, resourceID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
+
+// getWebhook
+// @Summary 通过ID获取webhook
+// @Description 通过ID获取webhook
+// @Id getWebhook
+// @tags Webhook
+// @Accept  json
+// @Produce json
+// @Param webhookID path string true "webhook ID"
+// @Success 200 {object} models.Webhook "webhook结构体"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /webhook/{webhookID} [GET]
+func (wr *WebhookRouter) getWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	webhookID := chi.URLParam(r, util.ParamKeyWebhookID)
+	wh, err := webhook.GetWebhookByID(&ctx, webhookID)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, wh)
+}
+
+// deleteWebhook
+// @Summary 删除webhook
+// @Description 删除webhook
+// @Id deleteWebhook
+// @tags Webhook
+// @Accept  json
+// @Produce json
+// @Param webhookID path string true "webhook ID"
+// @Success 200 {string} string "删除webhook的响应码"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /webhook/{webhookID} [DELETE]
+func (wr *WebhookRouter) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	webhookID := chi.URLParam(r, util.ParamKeyWebhookID)
+	if err := webhook.DeleteWebhook(&ctx, webhookID); err != nil {
+		ctx.Logging().Errorf("delete webhook failed. error:%s", err.Error())
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.RenderStatus(w, http.StatusOK)
+}
+
+// listWebhookDelivery
+// @Summary 获取webhook推送记录
+// @Description 获取webhook推送记录，包括推送内容、响应码、尝试次数及是否成功
+// @Id listWebhookDelivery
+// @tags Webhook
+// @Accept  json
+// @Produce json
+// @Param webhookID path string true "webhook ID"
+// @Param maxKeys query int false "每页包含的最大数量，缺省值为50"
+// @Param marker query string false "批量获取列表的查询的起始位置，是一个由系统生成的字符串"
+// @Success 200 {object} webhook.ListWebhookDeliveryResponse "获取webhook推送记录的响应"
+// @Failure 400 {object} common.ErrorResponse "400"
+// @Failure 404 {object} common.ErrorResponse "404"
+// @Failure 500 {object} common.ErrorResponse "500"
+// @Router /webhook/{webhookID}/delivery [GET]
+func (wr *WebhookRouter) listWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	webhookID := chi.URLParam(r, util.ParamKeyWebhookID)
+	maxKeys, err := util.GetQueryMaxKeys(&ctx, r)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	marker := r.URL.Query().Get(util.QueryKeyMarker)
+	response, err := webhook.ListWebhookDelivery(&ctx, webhookID, marker, maxKeys)
+	if err != nil {
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, response)
+}
@@ -58,13 +58,21 @@ var defaultFuseConfig = FuseConfig{
 		LinkUpdateInterval:   15,
 		LinkMetaDirPrefix:    "",
 		SkipCheckLinks:       false,
+		EnableLocks:          true,
+		SharedLocks:          false,
 		Cache: Cache{
 			MemoryExpire:  100 * time.Second,
 			MemorySize:    0, // memorySize * BlockSize才是实际的内存cache大小
 			BlockSize:     0, // BlockSize == 0 表示关闭cache
+			ReadAhead:     4, // 顺序读时预读的block数量，0表示关闭预读
 			DiskCachePath: "./cache_dir",
 			DiskExpire:    15 * 60 * time.Second,
 		},
+		Meta: Meta{
+			Driver:      "mem",
+			CachePath:   "./meta_dir",
+			CacheExpire: 10 * 60 * time.Second,
+		},
 	},
 }
 
@@ -98,7 +106,10 @@ type Fuse struct {
 	LinkUpdateInterval   int    `yaml:"linkUpdateInterval"`
 	LinkMetaDirPrefix    string `yaml:"linkMetaDirPrefix"`
 	SkipCheckLinks       bool   `yaml:"skipCheckLinks"`
+	EnableLocks          bool   `yaml:"enableLocks"` // 支持flock和posix锁
+	SharedLocks          bool   `yaml:"sharedLocks"` // 锁通过pfs server在多个挂载点之间共享，锁只保存在单个pfs server进程内存中
 	Cache                `yaml:"cache"`
+	Meta                 Meta   `yaml:"meta"`
 	Password             string `yaml:"password"`
 }
 
@@ -108,6 +119,17 @@ type Cache struct {
 	MemoryExpire  time.Duration
 	DiskExpire    time.Duration
 	DiskCachePath string
+	ReadAhead     int
+}
+
+// Meta 为mem时inode仅保存在内存中，为bolt时inode、属性和目录项持久化到本地kv存储，重新挂载后可复用
+// LoadFile不为空时挂载前从文件加载meta，DumpFile不为空时卸载后将meta导出到文件
+type Meta struct {
+	Driver      string
+	CachePath   string
+	CacheExpire time.Duration
+	LoadFile    string
+	DumpFile    string
 }
 
 var (
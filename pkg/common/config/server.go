@@ -41,6 +41,10 @@ type ApiServerConfig struct {
 	Port                int    `yaml:"port"`
 	PrintVersionAndExit bool   `yaml:"printVersionAndExit"`
 	TokenExpirationHour int    `yaml:"tokenExpirationHour"`
+	// EnableLocalExecutor allows root user to run pipeline steps as processes on the apiserver host, it is off by default
+	EnableLocalExecutor bool `yaml:"enableLocalExecutor"`
+	// WebhookAllowedHosts are hosts, ips or cidrs in private network that webhooks are allowed to notify
+	WebhookAllowedHosts []string `yaml:"webhookAllowedHosts"`
 }
 
 type JobConfig struct {
@@ -61,10 +65,17 @@ type FsServerConf struct {
 }
 
 type ReclaimConfig struct {
-	CleanJob             bool `yaml:"isCleanJob"`
-	SkipCleanFailedJob   bool `yaml:"isSkipCleanFailedJob"`
-	JobTTLSeconds        int  `yaml:"jobTTLSeconds"`
-	JobPendingTTLSeconds int  `yaml:"JobPendingTTLSeconds,omitempty"`
+	CleanJob           bool `yaml:"isCleanJob"`
+	SkipCleanFailedJob bool `yaml:"isSkipCleanFailedJob"`
+	JobTTLSeconds      int  `yaml:"jobTTLSeconds"`
+	// JobPendingTTLSeconds only applies to jobs whose pods cannot be started, such as image pulling failed.
+	// jobs pending for resources of queue are only killed when pendingTTLSeconds is set by queue or job
+	JobPendingTTLSeconds int `yaml:"JobPendingTTLSeconds,omitempty"`
+	// SucceededJobTTLSeconds, FailedJobTTLSeconds and TerminatedJobTTLSeconds override JobTTLSeconds for jobs in each status,
+	// and can be overridden by reclaim policy of queue or job
+	SucceededJobTTLSeconds  *int `yaml:"succeededJobTTLSeconds,omitempty"`
+	FailedJobTTLSeconds     *int `yaml:"failedJobTTLSeconds,omitempty"`
+	TerminatedJobTTLSeconds *int `yaml:"terminatedJobTTLSeconds,omitempty"`
 }
 
 type ImageConfig struct {
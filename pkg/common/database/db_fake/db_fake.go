@@ -33,6 +33,7 @@ func InitFakeDB() {
 	// Create tables
 	db.AutoMigrate(
 		&models.Pipeline{},
+		&models.PipelineVersion{},
 		&models.RunCache{},
 		&models.ArtifactEvent{},
 		&models.User{},
@@ -40,6 +41,11 @@ func InitFakeDB() {
 		&models.Queue{},
 		&models.Grant{},
 		&models.Job{},
+		&models.JobTask{},
+		&models.JobTemplate{},
+		&models.ClusterInfo{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	)
 	database.DB = db
 }
@@ -105,6 +105,7 @@ func initSQLiteDB(dbConf *config.DatabaseConfig, gormConf *gorm.Config) *gorm.DB
 	// Create tables
 	db.AutoMigrate(
 		&models.Pipeline{},
+		&models.PipelineVersion{},
 		&models.RunCache{},
 		&models.ArtifactEvent{},
 		&models.User{},
@@ -112,10 +113,14 @@ func initSQLiteDB(dbConf *config.DatabaseConfig, gormConf *gorm.Config) *gorm.DB
 		&models.Queue{},
 		&models.Grant{},
 		&models.Job{},
+		&models.JobTask{},
+		&models.JobTemplate{},
 		&models.ClusterInfo{},
 		&models.Image{},
 		&models.FileSystem{},
 		&models.Link{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	)
 	// init root user to db, can not be modified by config file currently
 	rootUser := models.User{
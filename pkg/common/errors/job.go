@@ -19,10 +19,12 @@ package errors
 import "fmt"
 
 const (
-	CpuNotFound           = "CpuNotFound"
-	MemoryNotFound        = "MemoryNotFound"
-	QueueResourceNotMatch = "QueueResourceNotMatch"
-	InvalidScaleResource  = "InvalidScaleResource" // 扩展资源类型不支持
+	CpuNotFound            = "CpuNotFound"
+	MemoryNotFound         = "MemoryNotFound"
+	QueueResourceNotMatch  = "QueueResourceNotMatch"
+	InvalidScaleResource   = "InvalidScaleResource"   // 扩展资源类型不支持
+	QueueResourceNotEnough = "QueueResourceNotEnough" // 作业资源需求超出队列容量
+	InvalidJobConf         = "InvalidJobConf"         // 作业配置校验失败
 )
 
 type PFError struct {
@@ -63,6 +65,21 @@ func InvalidScaleResourceError(resourceName string) error {
 	}
 }
 
+func QueueResourceNotEnoughError(queueName, resourceName, request, capacity string) error {
+	return &PFError{
+		Code: QueueResourceNotEnough,
+		Message: fmt.Sprintf("job request %s[%s] exceeds capacity[%s] of queue[%s]",
+			resourceName, request, capacity, queueName),
+	}
+}
+
+func InvalidJobConfError(err error) error {
+	return &PFError{
+		Code:    InvalidJobConf,
+		Message: err.Error(),
+	}
+}
+
 func EmptyUserNameError() error {
 	return fmt.Errorf("empty user name")
 }
@@ -123,6 +140,22 @@ func EmptySparkMainFileError() error {
 	return fmt.Errorf("empty spark main file path")
 }
 
+func EmptySparkMainClassError(appType string) error {
+	return fmt.Errorf("empty spark main class, which is required by %s application", appType)
+}
+
+func InvalidSparkAppTypeError(appType string) error {
+	return fmt.Errorf("invalid spark application type %s, should be in [Java, Scala, Python, R]", appType)
+}
+
+func InvalidSparkPythonVersionError(version string) error {
+	return fmt.Errorf("invalid spark python version %s, should be in [2, 3]", version)
+}
+
+func InvalidSparkExecutorsError(minExecutors, maxExecutors int) error {
+	return fmt.Errorf("invalid spark dynamic allocation, min executors %d and max executors %d", minExecutors, maxExecutors)
+}
+
 func InvalidJobPriorityError(priority string) error {
 	return fmt.Errorf("invalid job priority %s, should be in [LOW, NORMAL, HIGH]", priority)
 }
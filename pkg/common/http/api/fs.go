@@ -17,12 +17,16 @@ limitations under the License.
 package api
 
 import (
+	"strconv"
+
 	log "github.com/sirupsen/logrus"
 
 	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/apiserver/router/util"
 	"paddleflow/pkg/common/http/core"
 	"paddleflow/pkg/common/http/util/http"
+	fscommon "paddleflow/pkg/fs/server/api/common"
+	"paddleflow/pkg/fs/server/api/request"
 	"paddleflow/pkg/fs/server/api/response"
 )
 
@@ -31,6 +35,8 @@ const (
 	LoginApi     = Prefix + "/login"
 	GetFsApi     = Prefix + "/fs"
 	GetLinksApis = Prefix + "/link"
+	FileLockApi  = Prefix + "/fslock"
+	LockLeaseApi = FileLockApi + "/lease"
 )
 
 type LoginParams struct {
@@ -56,10 +62,17 @@ type LinksParams struct {
 	Token    string
 }
 
+type FileLockParams struct {
+	request.FileLockRequest
+	Token string `json:"-"`
+}
+
 type FsResponse response.FileSystemResponse
 
 type LinksResponse response.GetLinkResponse
 
+type FileLockResponse response.GetFileLockResponse
+
 func LoginRequest(params LoginParams, c *core.PFClient) (*LoginResponse, error) {
 	var err error
 	resp := &LoginResponse{}
@@ -103,3 +116,61 @@ func LinksRequest(params LinksParams, c *core.PFClient) (*LinksResponse, error)
 	}
 	return resp, nil
 }
+
+func SetFileLockRequest(params FileLockParams, c *core.PFClient) error {
+	return core.NewRequestBuilder(c).
+		WithHeader(common.HeaderKeyAuthorization, params.Token).
+		WithURL(FileLockApi).
+		WithMethod(http.POST).
+		WithBody(params.FileLockRequest).
+		Do()
+}
+
+func GetFileLockRequest(params FileLockParams, c *core.PFClient) (*FileLockResponse, error) {
+	resp := &FileLockResponse{}
+	err := core.NewRequestBuilder(c).
+		WithHeader(common.HeaderKeyAuthorization, params.Token).
+		WithURL(FileLockApi).
+		WithMethod(http.GET).
+		WithQueryParam("fsName", params.FsName).
+		WithQueryParam("path", params.Path).
+		WithQueryParam("clientID", params.ClientID).
+		WithQueryParam("owner", strconv.FormatUint(params.Owner, 10)).
+		WithQueryParam("type", strconv.FormatUint(uint64(params.Type), 10)).
+		WithQueryParam("start", strconv.FormatUint(params.Start, 10)).
+		WithQueryParam("end", strconv.FormatUint(params.End, 10)).
+		WithResult(resp).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func RenewLockLeaseRequest(clientID, token string, c *core.PFClient) error {
+	return core.NewRequestBuilder(c).
+		WithHeader(common.HeaderKeyAuthorization, token).
+		WithURL(LockLeaseApi + "/" + clientID).
+		WithMethod(http.PUT).
+		Do()
+}
+
+func ReleaseLockLeaseRequest(clientID, token string, c *core.PFClient) error {
+	return core.NewRequestBuilder(c).
+		WithHeader(common.HeaderKeyAuthorization, token).
+		WithURL(LockLeaseApi + "/" + clientID).
+		WithMethod(http.DELETE).
+		Do()
+}
+
+// IsFileLockConflict returns true if lock request failed because the file is locked by others
+func IsFileLockConflict(err error) bool {
+	serviceErr, ok := err.(*core.PFServiceError)
+	return ok && serviceErr.Code == fscommon.FileLockConflict
+}
+
+// IsFileLockLeaseNotFound returns true if lease of the client has expired in pfs server
+func IsFileLockLeaseNotFound(err error) bool {
+	serviceErr, ok := err.(*core.PFServiceError)
+	return ok && serviceErr.Code == fscommon.FileLockLeaseNotFound
+}
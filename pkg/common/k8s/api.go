@@ -30,9 +30,13 @@ import (
 )
 
 var (
-	PodGVK      = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}
-	VCJobGVK    = schema.GroupVersionKind{Group: "batch.volcano.sh", Version: "v1alpha1", Kind: "Job"}
-	SparkAppGVK = schema.GroupVersionKind{Group: "sparkoperator.k8s.io", Version: "v1beta2", Kind: "SparkApplication"}
+	PodGVK       = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}
+	EventGVK     = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Event"}
+	VCJobGVK     = schema.GroupVersionKind{Group: "batch.volcano.sh", Version: "v1alpha1", Kind: "Job"}
+	SparkAppGVK  = schema.GroupVersionKind{Group: "sparkoperator.k8s.io", Version: "v1beta2", Kind: "SparkApplication"}
+	BatchJobGVK  = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+	PaddleJobGVK = schema.GroupVersionKind{Group: "batch.paddlepaddle.org", Version: "v1", Kind: "PaddleJob"}
+	PodGroupGVK  = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "PodGroup"}
 
 	GVKToGVR sync.Map
 )
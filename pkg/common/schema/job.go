@@ -18,8 +18,12 @@ package schema
 
 import (
 	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
 
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
 	sparkoperatorv1beta2 "paddleflow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
 )
 
@@ -38,6 +42,8 @@ const (
 	EnvJobMode      = "PF_JOB_MODE"
 	// EnvJobYamlPath Additional configuration for a specific job
 	EnvJobYamlPath = "PF_JOB_YAML_PATH"
+	// EnvJobTemplate name of job template registered by api, template of queue is preferred to global template
+	EnvJobTemplate = "PF_JOB_TEMPLATE"
 
 	// EnvJobModePS env
 	EnvJobModePS          = "PS"
@@ -64,9 +70,23 @@ const (
 	EnvJobDriverFlavour    = "PF_JOB_DRIVER_FLAVOUR"
 	EnvJobExecutorReplicas = "PF_JOB_EXECUTOR_REPLICAS"
 	EnvJobExecutorFlavour  = "PF_JOB_EXECUTOR_FLAVOUR"
-
-	TypeVcJob    JobType = "vcjob"
-	TypeSparkJob JobType = "spark"
+	// EnvJobSparkAppType type of spark application, should be in [Java, Scala, Python, R]
+	EnvJobSparkAppType       = "PF_JOB_SPARK_APP_TYPE"
+	EnvJobSparkPythonVersion = "PF_JOB_SPARK_PYTHON_VERSION"
+	// EnvJobSparkJars, EnvJobSparkPyFiles and EnvJobSparkFiles are dependencies separated by comma
+	EnvJobSparkJars    = "PF_JOB_SPARK_JARS"
+	EnvJobSparkPyFiles = "PF_JOB_SPARK_PY_FILES"
+	EnvJobSparkFiles   = "PF_JOB_SPARK_FILES"
+	// EnvJobSparkConf spark conf in json format, e.g. {"spark.executor.memoryOverhead": "1g"}
+	EnvJobSparkConf = "PF_JOB_SPARK_CONF"
+	// EnvJobSparkMinExecutors and EnvJobSparkMaxExecutors enable dynamic allocation of executors
+	EnvJobSparkMinExecutors = "PF_JOB_SPARK_MIN_EXECUTORS"
+	EnvJobSparkMaxExecutors = "PF_JOB_SPARK_MAX_EXECUTORS"
+
+	TypeVcJob     JobType = "vcjob"
+	TypeSparkJob  JobType = "spark"
+	TypeBatchJob  JobType = "batchjob"  // kubernetes batch/v1 Job, for clusters without volcano
+	TypePaddleJob JobType = "paddlejob" // PaddleJob of paddle-operator
 
 	StatusJobPending     JobStatus = "pending"
 	StatusJobRunning     JobStatus = "running"
@@ -75,7 +95,9 @@ const (
 	StatusJobTerminating JobStatus = "terminating"
 	StatusJobTerminated  JobStatus = "terminated"
 	StatusJobCancelled   JobStatus = "cancelled"
-	StatusJobCached      JobStatus = "cached" // 表示这个步骤使用cache，跳过运行
+	StatusJobCached      JobStatus = "cached"  // 表示这个步骤使用cache，跳过运行
+	StatusJobSkipped     JobStatus = "skipped" // 表示这个步骤的condition不满足，跳过运行
+	StatusJobTimeout     JobStatus = "timeout" // 表示这个步骤运行超时，被停止
 
 	// job priority
 	EnvJobVeryLowPriority  = "VERY_LOW"
@@ -150,3 +172,45 @@ func GetVCJobStatus(phase batchv1alpha1.JobPhase) (JobStatus, error) {
 	}
 	return status, nil
 }
+
+func GetPaddleJobStatus(phase paddlev1.PaddleJobPhase) (JobStatus, error) {
+	status := JobStatus("")
+	switch phase {
+	case paddlev1.Pending, paddlev1.Starting:
+		status = StatusJobPending
+	case paddlev1.Running, paddlev1.Restarting, paddlev1.Completing, paddlev1.Scaling:
+		status = StatusJobRunning
+	case paddlev1.Terminating, paddlev1.Aborting:
+		status = StatusJobTerminating
+	case paddlev1.Completed, paddlev1.Succeed:
+		status = StatusJobSucceeded
+	case paddlev1.Aborted:
+		status = StatusJobTerminated
+	case paddlev1.Failed, paddlev1.Terminated, paddlev1.Unknown:
+		status = StatusJobFailed
+	}
+
+	if status == "" {
+		return status, fmt.Errorf("unexpected paddlejob status [%s]\n", phase)
+	}
+	return status, nil
+}
+
+// GetBatchJobStatus batch/v1 Job has no phase, status is inferred from its conditions and active pods
+func GetBatchJobStatus(jobStatus batchv1.JobStatus) (JobStatus, string) {
+	for _, condition := range jobStatus.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return StatusJobSucceeded, condition.Message
+		case batchv1.JobFailed:
+			return StatusJobFailed, fmt.Sprintf("%s:%s", condition.Reason, condition.Message)
+		}
+	}
+	if jobStatus.Active > 0 || jobStatus.Succeeded > 0 || jobStatus.Failed > 0 {
+		return StatusJobRunning, ""
+	}
+	return StatusJobPending, ""
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+const (
+	DefaultLogLineLimit = 1000
+	MaxLogLineLimit     = 10000
+	DefaultLogPageNo    = 1
+	// MaxLogBytes is the max bytes of log fetched from a container, use tailLines to get the latest logs of a large log
+	MaxLogBytes int64 = 32 * 1024 * 1024
+)
+
+// JobLogRequest can be used to get logs of job in all tasks and containers, or logs of specified task and container
+type JobLogRequest struct {
+	JobID         string `json:"jobID"`
+	JobType       string `json:"jobType"`
+	Namespace     string `json:"namespace"`
+	TaskName      string `json:"taskName,omitempty"`      // pod name of job, optional
+	ContainerName string `json:"containerName,omitempty"` // container name of pod, optional
+	TailLines     int64  `json:"tailLines,omitempty"`     // only the last tailLines lines of log are returned if set
+	LineLimit     int    `json:"lineLimit"`               // lines per page
+	PageNo        int    `json:"pageNo"`                  // page of log lines, begin with 1
+	Follow        bool   `json:"follow,omitempty"`        // stream the log of one container
+}
+
+// JobLogInfo logs of job grouped by task and container
+type JobLogInfo struct {
+	JobID    string        `json:"jobID"`
+	TaskList []TaskLogInfo `json:"taskList"`
+}
+
+type TaskLogInfo struct {
+	TaskID        string  `json:"taskID"`
+	ContainerName string  `json:"containerName"`
+	Info          LogInfo `json:"logInfo"`
+}
+
+type LogInfo struct {
+	LogContent  string `json:"logContent"`
+	HasNextPage bool   `json:"hasNextPage"`
+	LineCount   int    `json:"lineCount"` // total lines of fetched log
+	PageNo      int    `json:"pageNo"`
+	LineLimit   int    `json:"lineLimit"`
+	Message     string `json:"message,omitempty"` // reason if log is unavailable, e.g. container is creating
+}
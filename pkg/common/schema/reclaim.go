@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// NeverReclaimTTLSeconds indicate jobs are never cleaned or killed
+	NeverReclaimTTLSeconds = -1
+
+	// EnvJobSucceededTTLSeconds and other ttl envs override reclaim policy of queue for single job
+	EnvJobSucceededTTLSeconds  = "PF_JOB_SUCCEEDED_TTL_SECONDS"
+	EnvJobFailedTTLSeconds     = "PF_JOB_FAILED_TTL_SECONDS"
+	EnvJobTerminatedTTLSeconds = "PF_JOB_TERMINATED_TTL_SECONDS"
+	EnvJobPendingTTLSeconds    = "PF_JOB_PENDING_TTL_SECONDS"
+)
+
+// ReclaimPolicy indicate how long finished jobs are retained in cluster, and how long jobs can be pending before killed.
+// nil field is inherited from upper level, the order is job > queue > server config,
+// and NeverReclaimTTLSeconds disable reclaiming of jobs in that status.
+type ReclaimPolicy struct {
+	SucceededTTLSeconds  *int `json:"succeededTTLSeconds,omitempty"`
+	FailedTTLSeconds     *int `json:"failedTTLSeconds,omitempty"`
+	TerminatedTTLSeconds *int `json:"terminatedTTLSeconds,omitempty"`
+	PendingTTLSeconds    *int `json:"pendingTTLSeconds,omitempty"`
+}
+
+// Merge override fields of policy with fields which are set in override
+func (p *ReclaimPolicy) Merge(override *ReclaimPolicy) {
+	if override == nil {
+		return
+	}
+	if override.SucceededTTLSeconds != nil {
+		p.SucceededTTLSeconds = override.SucceededTTLSeconds
+	}
+	if override.FailedTTLSeconds != nil {
+		p.FailedTTLSeconds = override.FailedTTLSeconds
+	}
+	if override.TerminatedTTLSeconds != nil {
+		p.TerminatedTTLSeconds = override.TerminatedTTLSeconds
+	}
+	if override.PendingTTLSeconds != nil {
+		p.PendingTTLSeconds = override.PendingTTLSeconds
+	}
+}
+
+// GetTTLSeconds get ttl of job in status, the second return value is false if job in status should not be reclaimed
+func (p *ReclaimPolicy) GetTTLSeconds(status JobStatus) (int, bool) {
+	var ttl *int
+	switch status {
+	case StatusJobSucceeded:
+		ttl = p.SucceededTTLSeconds
+	case StatusJobFailed:
+		ttl = p.FailedTTLSeconds
+	case StatusJobTerminated:
+		ttl = p.TerminatedTTLSeconds
+	case StatusJobPending:
+		ttl = p.PendingTTLSeconds
+	}
+	if ttl == nil || *ttl < 0 {
+		return 0, false
+	}
+	return *ttl, true
+}
+
+func (p *ReclaimPolicy) Validate() error {
+	for name, ttl := range map[string]*int{
+		"succeededTTLSeconds":  p.SucceededTTLSeconds,
+		"failedTTLSeconds":     p.FailedTTLSeconds,
+		"terminatedTTLSeconds": p.TerminatedTTLSeconds,
+		"pendingTTLSeconds":    p.PendingTTLSeconds,
+	} {
+		if ttl != nil && *ttl < NeverReclaimTTLSeconds {
+			return fmt.Errorf("invalid reclaim policy %s[%d], should be -1 or not less than 0", name, *ttl)
+		}
+	}
+	return nil
+}
+
+func (p *ReclaimPolicy) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("ReclaimPolicy scan failed, value type %T is invalid", value)
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, p)
+}
+
+func (p ReclaimPolicy) Value() (driver.Value, error) {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
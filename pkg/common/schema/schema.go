@@ -30,6 +30,8 @@ type JobView struct {
 	Image      string            `json:"image"`
 	Artifacts  Artifacts         `json:"artifacts"`
 	JobMessage string            `json:"jobMessage"`
+	LoopJobs   []JobView         `json:"loopJobs,omitempty"` // loop step 每次迭代对应的 job
+	Attempts   []JobView         `json:"attempts,omitempty"` // 重试之前的历次运行
 }
 
 // RuntimeView is view of run responded to user, while workflowRuntime is for pipeline engine to process
@@ -26,12 +26,23 @@ const (
 )
 
 type WorkflowSourceStep struct {
-	Parameters map[string]interface{} `yaml:"parameters"`
-	Command    string                 `yaml:"command"`
-	Deps       string                 `yaml:"deps"`
-	Artifacts  Artifacts              `yaml:"artifacts"`
-	Env        map[string]string      `yaml:"env"`
-	Image      string                 `yaml:"image"` // 这个字段暂时不对用户暴露
+	Parameters   map[string]interface{} `yaml:"parameters"`
+	Command      string                 `yaml:"command"`
+	Deps         string                 `yaml:"deps"`
+	Artifacts    Artifacts              `yaml:"artifacts"`
+	Env          map[string]string      `yaml:"env"`
+	Condition    string                 `yaml:"condition"`
+	LoopArgument interface{}            `yaml:"loop_argument"` // 列表，或对上游 output artifact（内容为 json 列表）的引用
+	Retry        *Retry                 `yaml:"retry"`         // 失败重试策略
+	Timeout      string                 `yaml:"timeout"`       // 单次运行的超时时间，如 2h，超时后 job 会被停止
+	Image        string                 `yaml:"image"`         // 这个字段暂时不对用户暴露
+}
+
+type Retry struct {
+	MaxAttempts   int      `yaml:"max_attempts"`   // 最大运行次数，包含首次运行
+	Backoff       string   `yaml:"backoff"`        // 首次重试前的等待时间，如 30s，默认不等待
+	BackoffFactor float64  `yaml:"backoff_factor"` // 每次重试等待时间的增长倍数，默认为 1
+	RetryOn       []string `yaml:"retry_on"`       // 触发重试的 job 状态，或者失败信息中的关键字，默认只在 failed 时重试
 }
 
 type Artifacts struct {
@@ -55,12 +66,16 @@ type Cache struct {
 	Enable         bool   `yaml:"enable"`
 	MaxExpiredTime string `yaml:"max_expired_time"` // seconds
 	FsScope        string `yaml:"fs_scope"`         // seperated by ","
+	Strategy       string `yaml:"strategy"`         // conservative or aggressive
 }
 
 type WorkflowSource struct {
 	Name        string                         `yaml:"name"`
 	DockerEnv   string                         `yaml:"docker_env"`
 	EntryPoints map[string]*WorkflowSourceStep `yaml:"entry_points"`
+	PostProcess map[string]*WorkflowSourceStep `yaml:"post_process"` // 主 DAG 结束后，无论成功与否都会运行的 step
 	Cache       Cache                          `yaml:"cache"`
 	Parallelism int                            `yaml:"parallelism"`
+	Executor    string                         `yaml:"executor"` // paddleflow or local, default paddleflow
+	Timeout     string                         `yaml:"timeout"`  // run 整体的超时时间，如 24h，超时后所有运行中的 job 会被停止
 }
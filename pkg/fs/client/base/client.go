@@ -22,6 +22,7 @@ import (
 
 	"paddleflow/pkg/common/http/api"
 	"paddleflow/pkg/common/http/core"
+	"paddleflow/pkg/fs/server/api/request"
 )
 
 const (
@@ -105,3 +106,35 @@ func (c *_Client) GetLinks() (map[string]FSMeta, error) {
 	}
 	return result, nil
 }
+
+// SetFileLock puts or releases a lock shared with other clients of the file system
+func (c *_Client) SetFileLock(req request.FileLockRequest) error {
+	req.FsName = c.FsID
+	req.ClientID = c.Uuid
+	params := api.FileLockParams{
+		FileLockRequest: req,
+		Token:           c.Token,
+	}
+	return api.SetFileLockRequest(params, c.httpClient)
+}
+
+// GetFileLock returns the lock conflicting with req
+func (c *_Client) GetFileLock(req request.FileLockRequest) (*api.FileLockResponse, error) {
+	req.FsName = c.FsID
+	req.ClientID = c.Uuid
+	params := api.FileLockParams{
+		FileLockRequest: req,
+		Token:           c.Token,
+	}
+	return api.GetFileLockRequest(params, c.httpClient)
+}
+
+// RenewLockLease keeps locks of the client alive in pfs server
+func (c *_Client) RenewLockLease() error {
+	return api.RenewLockLeaseRequest(c.Uuid, c.Token, c.httpClient)
+}
+
+// ReleaseLocks releases all locks of the client in pfs server
+func (c *_Client) ReleaseLocks() error {
+	return api.ReleaseLockLeaseRequest(c.Uuid, c.Token, c.httpClient)
+}
@@ -31,6 +31,8 @@ import (
 
 const (
 	readAheadNum = 2
+	// maxPrefetchNum limits blocks being prefetched from ufs at the same time
+	maxPrefetchNum = 16
 )
 
 type store struct {
@@ -39,12 +41,18 @@ type store struct {
 	conf Config
 	sync.RWMutex
 	meta map[string]string
+
+	prefetchLock sync.Mutex
+	// blocks being prefetched, the channel is closed after the block is loaded
+	prefetching map[string]chan struct{}
 }
 
 type Config struct {
 	Mem       *MemConfig
 	Disk      *DiskConfig
 	BlockSize int
+	// ReadAhead is the number of blocks prefetched on sequential read, 0 means no prefetch
+	ReadAhead int
 }
 
 type rCache struct {
@@ -58,8 +66,9 @@ func NewCacheStore(config *Config) Store {
 		return nil
 	}
 	cacheStore := &store{
-		conf: *config,
-		meta: make(map[string]string, 100),
+		conf:        *config,
+		meta:        make(map[string]string, 100),
+		prefetching: make(map[string]chan struct{}),
 	}
 	if config.Mem != nil {
 		cacheStore.mem = NewMemCache(config.Mem)
@@ -123,7 +132,8 @@ func (r *rCache) ReadAt(buf []byte, off int64) (int, error) {
 	var key string
 
 	index = r.index(int(off))
-	key = r.key(index)
+	keyID := r.keyID()
+	key = r.store.key(keyID, index)
 	blockOff := r.off(int(off))
 	blockSize := r.store.conf.BlockSize
 	bufSize := len(buf)
@@ -133,6 +143,13 @@ func (r *rCache) ReadAt(buf []byte, off int64) (int, error) {
 		// 最后一个block大小未填满
 		return n, nil
 	}
+	// the block is being prefetched, wait for it instead of reading it from ufs again
+	if done := r.store.prefetchDone(key); done != nil {
+		<-done
+		if n, ok = r.readCache(buf, key, blockOff); ok {
+			return n, nil
+		}
+	}
 
 	// todo:: readAheadNum改成可配的
 	ufsBuf := make([]byte, readAheadNum*blockSize)
@@ -141,7 +158,7 @@ func (r *rCache) ReadAt(buf []byte, off int64) (int, error) {
 		return 0, err
 	}
 
-	go r.setCache(int(off), ufsBuf, n)
+	go r.setCache(keyID, int(off), ufsBuf, n)
 
 	/**
 	1. 当buf小于等于blockSize，会填充前len(buf)大小的block数据，多余部分舍弃
@@ -162,16 +179,31 @@ func (r *rCache) off(off int) int {
 }
 
 func (r *rCache) key(index int) string {
+	return r.store.key(r.keyID(), index)
+}
+
+// keyID returns the id of blocks of the file, which is changed after the cache is invalidated
+func (r *rCache) keyID() string {
 	r.store.RLock()
-	keyID := r.store.meta[r.id]
+	keyID, ok := r.store.meta[r.id]
 	r.store.RUnlock()
-	if keyID == "" {
+	if !ok {
 		r.store.Lock()
-		r.store.meta[r.id] = uuid.NewString()
+		// prefetch may generate keyID of the same file concurrently
+		if keyID, ok = r.store.meta[r.id]; !ok {
+			keyID = uuid.NewString()
+			r.store.meta[r.id] = keyID
+		}
 		r.store.Unlock()
 	}
-	hash := utils.KeyHash(keyID)
-	return path.Clean(fmt.Sprintf("blocks/%d/%v_%v", hash%256, keyID, index))
+	return keyID
+}
+
+// invalidated returns true if cache of the file has been invalidated since keyID was got
+func (r *rCache) invalidated(keyID string) bool {
+	r.store.RLock()
+	defer r.store.RUnlock()
+	return r.store.meta[r.id] != keyID
 }
 
 func (r *rCache) readCache(buf []byte, key string, off int) (int, bool) {
@@ -205,7 +237,13 @@ func (r *rCache) readCache(buf []byte, key string, off int) (int, bool) {
 	return 0, false
 }
 
-func (r *rCache) setCache(off int, p []byte, n int) {
+// setCache saves data read from ufs with keyID got before reading, data is dropped if
+// the cache is invalidated during reading, as it may be older than the file.
+func (r *rCache) setCache(keyID string, off int, p []byte, n int) {
+	if r.invalidated(keyID) {
+		log.Debugf("cache of %s is invalidated, skip setting cache", r.id)
+		return
+	}
 	blockNum := 1
 	if n > 0 {
 		blockNum = (n-1)/r.store.conf.BlockSize + 1
@@ -218,7 +256,7 @@ func (r *rCache) setCache(off int, p []byte, n int) {
 	index = r.index(off)
 
 	for i := 0; i < int(blockNum); i++ {
-		key = r.key(index + i)
+		key = r.store.key(keyID, index+i)
 		log.Debugf("cache set key is %s name %s", key, r.id)
 		right := left + r.store.conf.BlockSize
 		if right > n {
@@ -233,3 +271,85 @@ func (r *rCache) setCache(off int, p []byte, n int) {
 		left += right - left
 	}
 }
+
+// Prefetch loads the block of index from ufs into cache in background, it is skipped
+// if the block is cached or being loaded. It returns false if the block is not scheduled
+// as too many blocks are being prefetched, and it should be prefetched again later.
+// wg is done after the scheduled block is loaded, so that ufs file handle is released after it.
+func (r *rCache) Prefetch(index int, wg *sync.WaitGroup) bool {
+	if r.store.mem == nil && (r.store.disk == nil || r.store.disk.dir == "") {
+		return true
+	}
+	keyID := r.keyID()
+	key := r.store.key(keyID, index)
+	if r.store.cached(key) {
+		return true
+	}
+	if !r.store.startPrefetch(key) {
+		return r.store.prefetchDone(key) != nil
+	}
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		defer func() {
+			r.store.finishPrefetch(key)
+			if wg != nil {
+				wg.Done()
+			}
+		}()
+		blockSize := r.store.conf.BlockSize
+		buf := make([]byte, blockSize)
+		n, err := r.ufsFh.ReadAt(buf, int64(index*blockSize))
+		if err != nil {
+			log.Debugf("prefetch block[%d] of %s failed: %v", index, r.id, err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+		r.setCache(keyID, index*blockSize, buf, n)
+	}()
+	return true
+}
+
+func (store *store) cached(key string) bool {
+	if store.mem != nil {
+		if _, err := store.mem.m.Get(key); err == nil {
+			return true
+		}
+	}
+	if store.disk != nil && store.disk.dir != "" {
+		return store.disk.exist(key)
+	}
+	return false
+}
+
+func (store *store) startPrefetch(key string) bool {
+	store.prefetchLock.Lock()
+	defer store.prefetchLock.Unlock()
+	if _, ok := store.prefetching[key]; ok || len(store.prefetching) >= maxPrefetchNum {
+		return false
+	}
+	store.prefetching[key] = make(chan struct{})
+	return true
+}
+
+func (store *store) finishPrefetch(key string) {
+	store.prefetchLock.Lock()
+	if done, ok := store.prefetching[key]; ok {
+		close(done)
+		delete(store.prefetching, key)
+	}
+	store.prefetchLock.Unlock()
+}
+
+// prefetchDone returns a channel closed after the block is loaded, or nil if the block is not being prefetched
+func (store *store) prefetchDone(key string) <-chan struct{} {
+	store.prefetchLock.Lock()
+	defer store.prefetchLock.Unlock()
+	if done, ok := store.prefetching[key]; ok {
+		return done
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/fs/client/base"
+	"paddleflow/pkg/fs/client/ufs"
+)
+
+func TestPrefetch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("0123456789"), 0644))
+
+	local, err := ufs.NewLocalFileSystem(map[string]interface{}{base.SubPath: dir})
+	assert.NoError(t, err)
+	fd, err := local.Open("file", syscall.O_RDONLY)
+	assert.NoError(t, err)
+	defer fd.Release()
+
+	store := NewCacheStore(&Config{
+		Mem:       &MemConfig{CacheSize: 10},
+		BlockSize: 4,
+		ReadAhead: 2,
+	}).(*store)
+	reader := store.NewReader("/file", ufs.NewFileHandle(fd)).(*rCache)
+	// key of a file is stable
+	assert.Equal(t, reader.key(1), reader.key(1))
+	assert.NotEqual(t, reader.key(1), reader.key(2))
+
+	var wg sync.WaitGroup
+	assert.True(t, reader.Prefetch(1, &wg))
+	assert.True(t, reader.Prefetch(2, &wg))
+	wg.Wait()
+	assert.True(t, store.cached(reader.key(1)))
+	assert.True(t, store.cached(reader.key(2)))
+	assert.False(t, store.cached(reader.key(0)))
+	// cached blocks are not prefetched again
+	assert.True(t, reader.Prefetch(1, &wg))
+
+	buf := make([]byte, 4)
+	n, ok := reader.readCache(buf, reader.key(1), 0)
+	assert.True(t, ok)
+	assert.Equal(t, "4567", string(buf[:n]))
+	n, ok = reader.readCache(buf, reader.key(2), 0)
+	assert.True(t, ok)
+	assert.Equal(t, "89", string(buf[:n]))
+
+	// blocks being prefetched are bounded
+	for i := 0; i < maxPrefetchNum; i++ {
+		assert.True(t, store.startPrefetch(reader.key(10+i)))
+	}
+	assert.False(t, store.startPrefetch(reader.key(100)))
+	assert.False(t, store.startPrefetch(reader.key(10)))
+	// blocks being prefetched by others are taken as scheduled, others are refused
+	assert.True(t, reader.Prefetch(10, &wg))
+	assert.False(t, reader.Prefetch(3, &wg))
+}
+
+// blockingFile blocks reading until release is closed
+type blockingFile struct {
+	base.FileHandle
+	release chan struct{}
+}
+
+func (f *blockingFile) Read(dest []byte, off int64) (fuse.ReadResult, fuse.Status) {
+	<-f.release
+	return f.FileHandle.Read(dest, off)
+}
+
+func TestPrefetchInvalidated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("0123456789"), 0644))
+
+	local, err := ufs.NewLocalFileSystem(map[string]interface{}{base.SubPath: dir})
+	assert.NoError(t, err)
+	fd, err := local.Open("file", syscall.O_RDONLY)
+	assert.NoError(t, err)
+	defer fd.Release()
+
+	store := NewCacheStore(&Config{
+		Mem:       &MemConfig{CacheSize: 10},
+		BlockSize: 4,
+		ReadAhead: 2,
+	}).(*store)
+	file := &blockingFile{FileHandle: fd, release: make(chan struct{})}
+	reader := store.NewReader("/file", ufs.NewFileHandle(file)).(*rCache)
+	oldKey := reader.key(1)
+
+	// the file is changed while the block is being prefetched
+	var wg sync.WaitGroup
+	assert.True(t, reader.Prefetch(1, &wg))
+	assert.NoError(t, store.InvalidateCache("/file", 10))
+	close(file.release)
+	wg.Wait()
+	assert.False(t, store.cached(oldKey))
+	assert.False(t, store.cached(reader.key(1)))
+}
+
+// countingFile counts reads of the file
+type countingFile struct {
+	blockingFile
+	reads int32
+}
+
+func (f *countingFile) Read(dest []byte, off int64) (fuse.ReadResult, fuse.Status) {
+	atomic.AddInt32(&f.reads, 1)
+	return f.blockingFile.Read(dest, off)
+}
+
+func TestReadAtWaitPrefetch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("0123456789"), 0644))
+
+	local, err := ufs.NewLocalFileSystem(map[string]interface{}{base.SubPath: dir})
+	assert.NoError(t, err)
+	fd, err := local.Open("file", syscall.O_RDONLY)
+	assert.NoError(t, err)
+	defer fd.Release()
+
+	store := NewCacheStore(&Config{
+		Mem:       &MemConfig{CacheSize: 10},
+		BlockSize: 4,
+		ReadAhead: 2,
+	}).(*store)
+	file := &countingFile{blockingFile: blockingFile{FileHandle: fd, release: make(chan struct{})}}
+	reader := store.NewReader("/file", ufs.NewFileHandle(file)).(*rCache)
+
+	// reading a block being prefetched waits for the prefetch instead of reading ufs again
+	var wg sync.WaitGroup
+	assert.True(t, reader.Prefetch(1, &wg))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(file.release)
+	}()
+	buf := make([]byte, 4)
+	n, err := reader.ReadAt(buf, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "4567", string(buf[:n]))
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&file.reads))
+}
@@ -18,12 +18,16 @@ package cache
 
 import (
 	"io"
+	"sync"
 
 	"paddleflow/pkg/fs/client/ufs"
 )
 
 type Reader interface {
 	io.ReaderAt
+	// Prefetch loads the block of index into cache asynchronously, it returns false if the block
+	// is not scheduled to be loaded, and wg is done after the scheduled block is loaded
+	Prefetch(index int, wg *sync.WaitGroup) bool
 }
 
 type Writer interface {
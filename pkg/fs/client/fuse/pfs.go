@@ -135,15 +135,32 @@ func (fs *PFS) Rename(cancel <-chan struct{}, input *fuse.RenameIn, oldName stri
 }
 
 func (fs *PFS) Link(cancel <-chan struct{}, input *fuse.LinkIn, filename string, out *fuse.EntryOut) fuse.Status {
-	return fuse.ENOSYS
+	log.Debugf("Link: input[%+v] filename[%s]", *input, filename)
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	entry, code := vfs.GetVFS().Link(ctx, vfs.Ino(input.Oldnodeid), vfs.Ino(input.NodeId), filename)
+	if code != 0 {
+		return fuse.Status(code)
+	}
+	fs.replyEntry(entry, out)
+	return fuse.OK
 }
 
 func (fs *PFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, pointedTo string, linkName string, out *fuse.EntryOut) fuse.Status {
-	return fuse.ENOSYS
+	log.Debugf("Symlink: header[%+v] pointedTo[%s] linkName[%s]", *header, pointedTo, linkName)
+	ctx := meta.NewContext(cancel, header.Uid, header.Pid, header.Gid)
+	entry, code := vfs.GetVFS().Symlink(ctx, pointedTo, vfs.Ino(header.NodeId), linkName)
+	if code != 0 {
+		return fuse.Status(code)
+	}
+	fs.replyEntry(entry, out)
+	return fuse.OK
 }
 
 func (fs *PFS) Readlink(cancel <-chan struct{}, header *fuse.InHeader) (out []byte, code fuse.Status) {
-	return out, fuse.ENOSYS
+	log.Debugf("Readlink: header[%+v]", *header)
+	ctx := meta.NewContext(cancel, header.Uid, header.Pid, header.Gid)
+	out, errno := vfs.GetVFS().Readlink(ctx, vfs.Ino(header.NodeId))
+	return out, fuse.Status(errno)
 }
 
 func (fs *PFS) Access(cancel <-chan struct{}, input *fuse.AccessIn) fuse.Status {
@@ -237,15 +254,28 @@ func (fs *PFS) Read(cancel <-chan struct{}, input *fuse.ReadIn, buf []byte) (fus
 
 // File locking
 func (fs *PFS) GetLk(cancel <-chan struct{}, input *fuse.LkIn, out *fuse.LkOut) (code fuse.Status) {
-	return fuse.ENOSYS
+	log.Debugf("GetLk: input[%+v]", *input)
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	out.Lk = input.Lk
+	lk := &out.Lk
+	err := vfs.GetVFS().GetLk(ctx, vfs.Ino(input.NodeId), input.Fh, input.Owner, &lk.Start, &lk.End, &lk.Typ, &lk.Pid)
+	return fuse.Status(err)
 }
 
 func (fs *PFS) SetLk(cancel <-chan struct{}, input *fuse.LkIn) (code fuse.Status) {
-	return fuse.ENOSYS
+	log.Debugf("SetLk: input[%+v]", *input)
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	err := vfs.GetVFS().SetLk(ctx, vfs.Ino(input.NodeId), input.Fh, input.Owner, input.Lk.Start, input.Lk.End,
+		input.Lk.Typ, input.Lk.Pid, input.LkFlags&fuse.FUSE_LK_FLOCK != 0)
+	return fuse.Status(err)
 }
 
 func (fs *PFS) SetLkw(cancel <-chan struct{}, input *fuse.LkIn) (code fuse.Status) {
-	return fuse.ENOSYS
+	log.Debugf("SetLkw: input[%+v]", *input)
+	ctx := meta.NewContext(cancel, input.Uid, input.Pid, input.Gid)
+	err := vfs.GetVFS().SetLkw(ctx, vfs.Ino(input.NodeId), input.Fh, input.Owner, input.Lk.Start, input.Lk.End,
+		input.Lk.Typ, input.Lk.Pid, input.LkFlags&fuse.FUSE_LK_FLOCK != 0)
+	return fuse.Status(err)
 }
 
 func (fs *PFS) Write(cancel <-chan struct{}, input *fuse.WriteIn, data []byte) (uint32, fuse.Status) {
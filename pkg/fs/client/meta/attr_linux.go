@@ -26,6 +26,8 @@ func (a *Attr) FromFileInfo(info *base.FileInfo) {
 	st := info.Sys.(syscall.Stat_t)
 	if info.IsDir {
 		a.Type = TypeDirectory
+	} else if st.Mode&syscall.S_IFMT == syscall.S_IFLNK {
+		a.Type = TypeSymlink
 	} else {
 		a.Type = TypeFile
 	}
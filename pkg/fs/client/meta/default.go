@@ -33,6 +33,7 @@ import (
 	"paddleflow/pkg/fs/client/base"
 	ufslib "paddleflow/pkg/fs/client/ufs"
 	"paddleflow/pkg/fs/client/utils"
+	"paddleflow/pkg/fs/utils/lock"
 )
 
 const DefaultName = "Mem"
@@ -46,12 +47,18 @@ type DefaultMeta struct {
 	ufsMapLock  sync.RWMutex
 	ufsMapUT    int64
 	inodeHandle *InodeHandle
+	locker      locker
 }
 
 func NewDefaultMeta(fsMeta base.FSMeta, links map[string]base.FSMeta, inodeHandle *InodeHandle) (Meta, error) {
+	return newDefaultMeta(fsMeta, links, inodeHandle)
+}
+
+func newDefaultMeta(fsMeta base.FSMeta, links map[string]base.FSMeta, inodeHandle *InodeHandle) (*DefaultMeta, error) {
 	meta := &DefaultMeta{
 		name:        DefaultName,
 		inodeHandle: inodeHandle,
+		locker:      newLocker(nil),
 	}
 	ufs, err := newUFS(fsMeta)
 	if err != nil {
@@ -261,12 +268,32 @@ func (m *DefaultMeta) Fallocate(ctx *Context, inode Ino, mode uint8, off uint64,
 
 // ReadLink returns the target of a symlink.
 func (m *DefaultMeta) ReadLink(ctx *Context, inode Ino, path *[]byte) syscall.Errno {
-	return syscall.ENOSYS
+	name := m.inodeHandle.InoToPath(inode)
+	ufs, _, _, linkPath := m.GetUFS(name)
+	target, err := ufs.Readlink(linkPath)
+	if err != nil {
+		log.Debugf("[vfs] Readlink failed: %v with path[%s]", err, linkPath)
+		return utils.ToSyscallErrno(err)
+	}
+	*path = []byte(target)
+	return syscall.F_OK
 }
 
 // Symlink creates a symlink in a directory with given name.
 func (m *DefaultMeta) Symlink(ctx *Context, parent Ino, name string, path string, inode *Ino, attr *Attr) syscall.Errno {
-	return syscall.ENOSYS
+	pnode := m.inodeHandle.toInode(parent)
+	linkPath := m.inodeHandle.ParentInodeToPath(pnode, name)
+	ufs, _, _, newPath := m.GetUFS(linkPath)
+	if err := ufs.Symlink(path, newPath); err != nil {
+		log.Errorf("Symlink: name[%s], target[%s] failed: [%v]", name, path, err)
+		return utils.ToSyscallErrno(err)
+	}
+	node := pnode.NewChild(name, false)
+	*inode = node.inode
+	if err := m.getAttr(linkPath, attr); utils.IsError(err) {
+		return err
+	}
+	return syscall.F_OK
 }
 
 // Mknod creates a node in a directory with given name, type and permissions.
@@ -363,8 +390,26 @@ func (m *DefaultMeta) Rename(ctx *Context, parentSrc Ino, nameSrc string, parent
 }
 
 // Link creates an entry for node.
+// Inodes are bound to paths, so the new entry gets its own inode when it is looked up,
+// and attr of the source node with increased nlink is returned.
 func (m *DefaultMeta) Link(ctx *Context, inodeSrc, parent Ino, name string, attr *Attr) syscall.Errno {
-	return syscall.ENOSYS
+	pathSrc := m.inodeHandle.InoToPath(inodeSrc)
+	ufsSrc, _, _, newPathSrc := m.GetUFS(pathSrc)
+
+	pathDst := m.inodeHandle.ParentInodeToPath(m.inodeHandle.toInode(parent), name)
+	ufsDst, _, _, newPathDst := m.GetUFS(pathDst)
+	if ufsSrc != ufsDst {
+		log.Errorf("Link between two ufs is not supported")
+		return syscall.EXDEV
+	}
+	if err := ufsSrc.Link(newPathSrc, newPathDst); err != nil {
+		log.Errorf("Link: src[%s], dst[%s] failed: [%v]", pathSrc, pathDst, err)
+		return utils.ToSyscallErrno(err)
+	}
+	if err := m.getAttr(pathSrc, attr); utils.IsError(err) {
+		return err
+	}
+	return syscall.F_OK
 }
 
 // Readdir returns all entries for given directory, which include attributes if plus is true.
@@ -520,17 +565,39 @@ func (m *DefaultMeta) RemoveXattr(ctx *Context, inode Ino, attribute string) sys
 
 // Flock tries to put a lock on given file.
 func (m *DefaultMeta) Flock(ctx *Context, inode Ino, owner uint64, ltype uint32, block bool) syscall.Errno {
-	return syscall.ENOSYS
+	path := m.inodeHandle.InoToPath(inode)
+	return waitLock(ctx, m.locker, inode, path, block, func() syscall.Errno {
+		return m.locker.flock(inode, path, owner, ltype)
+	})
 }
 
 // Getlk returns the current lock owner for a range on a file.
 func (m *DefaultMeta) Getlk(ctx *Context, inode Ino, owner uint64, ltype *uint32, start, end *uint64, pid *uint32) syscall.Errno {
-	return syscall.ENOSYS
+	if *ltype == lock.Unlock {
+		return syscall.F_OK
+	}
+	path := m.inodeHandle.InoToPath(inode)
+	conflict, ok, err := m.locker.getlk(inode, path, owner, *ltype, *start, *end)
+	if err != syscall.F_OK {
+		return err
+	}
+	if !ok {
+		*ltype = lock.Unlock
+		return syscall.F_OK
+	}
+	*ltype = conflict.Type
+	*start = conflict.Start
+	*end = conflict.End
+	*pid = conflict.Pid
+	return syscall.F_OK
 }
 
 // Setlk sets a file range lock on given file.
 func (m *DefaultMeta) Setlk(ctx *Context, inode Ino, owner uint64, block bool, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
-	return syscall.ENOSYS
+	path := m.inodeHandle.InoToPath(inode)
+	return waitLock(ctx, m.locker, inode, path, block, func() syscall.Errno {
+		return m.locker.setlk(inode, path, owner, ltype, start, end, pid)
+	})
 }
 
 func (m *DefaultMeta) DumpMeta(w io.Writer) error {
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/fs/client/base"
+	"paddleflow/pkg/fs/utils/lock"
+)
+
+func TestDefaultMetaSymlinkAndLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "default_meta")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fsMeta := base.FSMeta{
+		UfsType: base.LocalType,
+		SubPath: dir,
+	}
+	inodeHandle := NewInodeHandle()
+	inodeHandle.InitRootNode()
+	m, err := NewDefaultMeta(fsMeta, nil, inodeHandle)
+	assert.NoError(t, err)
+	ctx := NewEmptyContext()
+
+	var fileIno, linkIno Ino
+	attr := &Attr{}
+	_, _, errno := m.Create(ctx, rootInodeID, "file", 0644, 0, uint32(os.O_WRONLY|os.O_CREATE), &fileIno, attr)
+	assert.Equal(t, syscall.F_OK, errno)
+
+	// symlink
+	assert.Equal(t, syscall.F_OK, m.Symlink(ctx, rootInodeID, "symlink", "file", &linkIno, attr))
+	assert.Equal(t, uint8(TypeSymlink), attr.Type)
+	var target []byte
+	assert.Equal(t, syscall.F_OK, m.ReadLink(ctx, linkIno, &target))
+	assert.Equal(t, "file", string(target))
+	realTarget, err := os.Readlink(filepath.Join(dir, "symlink"))
+	assert.NoError(t, err)
+	assert.Equal(t, "file", realTarget)
+	assert.Equal(t, syscall.EEXIST, m.Symlink(ctx, rootInodeID, "symlink", "file", &linkIno, attr))
+
+	// readlink of regular file
+	assert.Equal(t, syscall.EINVAL, m.ReadLink(ctx, fileIno, &target))
+
+	// hardlink
+	attr = &Attr{}
+	assert.Equal(t, syscall.F_OK, m.Link(ctx, fileIno, rootInodeID, "hardlink", attr))
+	assert.Equal(t, uint64(2), attr.Nlink)
+	_, linkAttr, errno := m.Lookup(ctx, rootInodeID, "hardlink")
+	assert.Equal(t, syscall.F_OK, errno)
+	assert.Equal(t, uint8(TypeFile), linkAttr.Type)
+	assert.Equal(t, uint64(2), linkAttr.Nlink)
+}
+
+func TestDefaultMetaLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "default_meta")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fsMeta := base.FSMeta{
+		UfsType: base.LocalType,
+		SubPath: dir,
+	}
+	inodeHandle := NewInodeHandle()
+	inodeHandle.InitRootNode()
+	m, err := NewDefaultMeta(fsMeta, nil, inodeHandle)
+	assert.NoError(t, err)
+	ctx := NewEmptyContext()
+
+	var ino Ino
+	attr := &Attr{}
+	_, _, errno := m.Create(ctx, rootInodeID, "file", 0644, 0, uint32(os.O_WRONLY|os.O_CREATE), &ino, attr)
+	assert.Equal(t, syscall.F_OK, errno)
+
+	// flock
+	assert.Equal(t, syscall.F_OK, m.Flock(ctx, ino, 1, lock.WriteLock, false))
+	assert.Equal(t, syscall.EAGAIN, m.Flock(ctx, ino, 2, lock.ReadLock, false))
+	done := make(chan syscall.Errno)
+	go func() {
+		done <- m.Flock(ctx, ino, 2, lock.ReadLock, true)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, syscall.F_OK, m.Flock(ctx, ino, 1, lock.Unlock, false))
+	assert.Equal(t, syscall.F_OK, <-done)
+
+	// posix lock
+	assert.Equal(t, syscall.F_OK, m.Setlk(ctx, ino, 1, false, lock.WriteLock, 0, 99, 10))
+	ltype, start, end, pid := lock.ReadLock, uint64(50), lock.MaxOffset, uint32(20)
+	assert.Equal(t, syscall.F_OK, m.Getlk(ctx, ino, 2, &ltype, &start, &end, &pid))
+	assert.Equal(t, lock.WriteLock, ltype)
+	assert.Equal(t, uint64(0), start)
+	assert.Equal(t, uint64(99), end)
+	assert.Equal(t, uint32(10), pid)
+
+	// blocking lock is interrupted by cancel
+	cancel := make(chan struct{})
+	go func() {
+		done <- m.Setlk(NewContext(cancel, 0, 20, 0), ino, 2, true, lock.ReadLock, 50, 60, 20)
+	}()
+	close(cancel)
+	assert.Equal(t, syscall.EINTR, <-done)
+
+	assert.Equal(t, syscall.F_OK, m.Setlk(ctx, ino, 1, false, lock.Unlock, 0, lock.MaxOffset, 10))
+	ltype = lock.WriteLock
+	assert.Equal(t, syscall.F_OK, m.Getlk(ctx, ino, 2, &ltype, &start, &end, &pid))
+	assert.Equal(t, lock.Unlock, ltype)
+}
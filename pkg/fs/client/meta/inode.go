@@ -39,6 +39,22 @@ type InodeHandle struct {
 	// Map of Go objects indexed by NodeId
 	handles    map[Ino]*Inode
 	nextNodeID uint64
+	// store persists inodes if not nil
+	store inodeStore
+}
+
+// inodeStore is notified when inode is added to or removed from the tree
+type inodeStore interface {
+	saveInode(node *Inode)
+	removeInode(inode Ino)
+}
+
+// inodeRecord is the persistent form of inode
+type inodeRecord struct {
+	Ino    Ino    `json:"ino"`
+	Parent Ino    `json:"parent"`
+	Name   string `json:"name"`
+	IsDir  bool   `json:"isDir"`
 }
 
 func NewInodeHandle() *InodeHandle {
@@ -151,6 +167,9 @@ func (n *Inode) AddChild(name string, child *Inode) {
 	n.child[name] = child.inode
 	child.parent = n
 	n.Unlock()
+	if n.inodeHandle.store != nil {
+		n.inodeHandle.store.saveInode(child)
+	}
 }
 
 func (n *Inode) RmChild(name string) (child Ino) {
@@ -161,6 +180,9 @@ func (n *Inode) RmChild(name string) (child Ino) {
 		inode := n.inodeHandle.toInode(ino)
 		delete(n.child, name)
 		inode.parent = nil
+		if n.inodeHandle.store != nil {
+			n.inodeHandle.store.removeInode(ino)
+		}
 	}
 	return ino
 }
@@ -176,3 +198,67 @@ func (n *Inode) GetChild(name string) (child Ino) {
 func (n *Inode) IsDir() bool {
 	return n.child != nil
 }
+
+func (n *Inode) record() *inodeRecord {
+	record := &inodeRecord{
+		Ino:   n.inode,
+		Name:  n.name,
+		IsDir: n.IsDir(),
+	}
+	if n.parent != nil {
+		record.Parent = n.parent.inode
+	}
+	return record
+}
+
+// restore rebuilds the tree from records, inodes whose parent is missing are returned as orphans.
+// If two inodes have the same parent and name, the newer one with larger ino is kept.
+func (m *InodeHandle) restore(records []*inodeRecord) (orphans []Ino) {
+	m.Lock()
+	defer m.Unlock()
+	m.handles = make(map[Ino]*Inode, len(records)+1)
+	m.nextNodeID = uint64(rootInodeID)
+	m.handles[rootInodeID] = &Inode{
+		inode:       rootInodeID,
+		child:       make(map[string]Ino, initDirSize),
+		inodeHandle: m,
+	}
+	m.nextNodeID++
+	for _, record := range records {
+		if record.Ino == rootInodeID {
+			continue
+		}
+		node := &Inode{inode: record.Ino, name: record.Name, inodeHandle: m}
+		if record.IsDir {
+			node.child = make(map[string]Ino, initDirSize)
+		}
+		m.handles[record.Ino] = node
+		if uint64(record.Ino) >= m.nextNodeID {
+			m.nextNodeID = uint64(record.Ino) + 1
+		}
+	}
+	for _, record := range records {
+		node := m.handles[record.Ino]
+		parent := m.handles[record.Parent]
+		if node == nil || record.Ino == rootInodeID {
+			continue
+		}
+		if parent == nil || !parent.IsDir() {
+			delete(m.handles, record.Ino)
+			orphans = append(orphans, record.Ino)
+			continue
+		}
+		if existing, found := parent.child[record.Name]; found {
+			if existing > record.Ino {
+				delete(m.handles, record.Ino)
+				orphans = append(orphans, record.Ino)
+				continue
+			}
+			delete(m.handles, existing)
+			orphans = append(orphans, existing)
+		}
+		parent.child[record.Name] = record.Ino
+		node.parent = parent
+	}
+	return orphans
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package meta
 
 import (
+	"fmt"
+	"hash/fnv"
 	"io"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"paddleflow/pkg/fs/client/base"
 	ufslib "paddleflow/pkg/fs/client/ufs"
@@ -162,6 +167,52 @@ type Meta interface {
 	LinksMetaUpdateHandler(stopChan chan struct{}, interval int, linkMetaDirPrefix string) error
 }
 
+// Config of meta, inodes are only kept in memory by DefaultName driver,
+// other drivers persist inodes, attributes and entries in kv store under CachePath.
+// Each mount point of the same fs has its own kv store, so fs can be mounted more than once on a host.
+// The store is tied to the mount point: after fs is remounted at a different path, persistent meta
+// starts empty, and can be carried over by dumping it before umount and loading it after mount.
+// Locks are kept in-process unless SharedLocks is set, in which case they are
+// coordinated with other mounts by pfs server.
+type Config struct {
+	Driver      string
+	CachePath   string
+	CacheExpire time.Duration
+	MountPoint  string
+	SharedLocks bool
+}
+
+// path of kv store, which is named by fs id and hash of absolute mount point
+func (c *Config) path(fsMeta base.FSMeta) string {
+	name := fsMeta.ID
+	if name == "" {
+		name = fsMeta.Name
+	}
+	if c.MountPoint != "" {
+		mountPoint, err := filepath.Abs(c.MountPoint)
+		if err != nil {
+			mountPoint = filepath.Clean(c.MountPoint)
+		}
+		h := fnv.New32a()
+		h.Write([]byte(mountPoint))
+		name = fmt.Sprintf("%s.%08x", name, h.Sum32())
+	}
+	return filepath.Join(c.CachePath, fmt.Sprintf("%s.%s.db", name, c.Driver))
+}
+
+// NewMeta creates meta with driver of config, DefaultMeta is used if config is nil
+func NewMeta(fsMeta base.FSMeta, links map[string]base.FSMeta, inodeHandle *InodeHandle, config *Config) (Meta, error) {
+	if config == nil || config.Driver == "" || strings.EqualFold(config.Driver, DefaultName) {
+		m, err := newDefaultMeta(fsMeta, links, inodeHandle)
+		if err != nil {
+			return nil, err
+		}
+		m.locker = newLocker(config)
+		return m, nil
+	}
+	return NewKVMeta(fsMeta, links, inodeHandle, config)
+}
+
 func (a *Attr) IsDir() bool {
 	return utils.StatModeToFileMode(int(a.Mode)).IsDir()
 }
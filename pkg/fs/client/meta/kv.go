@@ -0,0 +1,140 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	BoltDriver = "bolt"
+
+	boltBucket = "meta"
+)
+
+// kvTxn is a transaction of kv store, keys and values are only valid in the transaction
+type kvTxn interface {
+	get(key []byte) []byte
+	set(key, value []byte) error
+	delete(key []byte) error
+	// scan calls handler for each key with the prefix in order
+	scan(prefix []byte, handler func(key, value []byte) error) error
+}
+
+// kvClient is the embedded kv store used by KVMeta
+type kvClient interface {
+	name() string
+	view(f func(tx kvTxn) error) error
+	update(f func(tx kvTxn) error) error
+	close() error
+}
+
+func newKVClient(driver, path string) (kvClient, error) {
+	switch driver {
+	case BoltDriver:
+		return newBoltClient(path)
+	default:
+		return nil, fmt.Errorf("unsupported meta driver[%s]", driver)
+	}
+}
+
+type boltClient struct {
+	db *bolt.DB
+}
+
+func newBoltClient(path string) (kvClient, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// the db is only a cache of ufs meta, so writes are not fsynced, a db broken by
+	// power failure is removed and rebuilt
+	options := &bolt.Options{Timeout: defaultKVOpenTimeout, NoSync: true}
+	db, err := bolt.Open(path, 0600, options)
+	if err != nil && err != bolt.ErrTimeout {
+		log.Warnf("open bolt db[%s] failed: %v, rebuild it", path, err)
+		if err = os.Remove(path); err == nil {
+			db, err = bolt.Open(path, 0600, options)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open bolt db[%s] failed: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltClient{db: db}, nil
+}
+
+func (c *boltClient) name() string {
+	return BoltDriver
+}
+
+func (c *boltClient) view(f func(tx kvTxn) error) error {
+	return c.db.View(func(tx *bolt.Tx) error {
+		return f(&boltTxn{bucket: tx.Bucket([]byte(boltBucket))})
+	})
+}
+
+func (c *boltClient) update(f func(tx kvTxn) error) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return f(&boltTxn{bucket: tx.Bucket([]byte(boltBucket))})
+	})
+}
+
+func (c *boltClient) close() error {
+	if err := c.db.Sync(); err != nil {
+		log.Errorf("sync bolt db failed: %v", err)
+	}
+	return c.db.Close()
+}
+
+type boltTxn struct {
+	bucket *bolt.Bucket
+}
+
+func (tx *boltTxn) get(key []byte) []byte {
+	return tx.bucket.Get(key)
+}
+
+func (tx *boltTxn) set(key, value []byte) error {
+	return tx.bucket.Put(key, value)
+}
+
+func (tx *boltTxn) delete(key []byte) error {
+	return tx.bucket.Delete(key)
+}
+
+func (tx *boltTxn) scan(prefix []byte, handler func(key, value []byte) error) error {
+	cursor := tx.bucket.Cursor()
+	for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+		if err := handler(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
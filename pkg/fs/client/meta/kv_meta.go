@@ -0,0 +1,439 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/fs/client/base"
+	ufslib "paddleflow/pkg/fs/client/ufs"
+	"paddleflow/pkg/fs/client/utils"
+)
+
+const defaultKVOpenTimeout = 3 * time.Second
+
+// prefixes of keys in kv store, followed by ino in big endian
+const (
+	inodeKeyPrefix   = 'I'
+	attrKeyPrefix    = 'A'
+	entriesKeyPrefix = 'D'
+)
+
+type attrRecord struct {
+	Attr   *Attr `json:"attr"`
+	Expire int64 `json:"expire"`
+}
+
+type entriesRecord struct {
+	Entries []*Entry `json:"entries"`
+	Expire  int64    `json:"expire"`
+}
+
+// metaDump is the format of DumpMeta and LoadMeta
+type metaDump struct {
+	Inodes  []*inodeRecord         `json:"inodes"`
+	Attrs   map[Ino]*attrRecord    `json:"attrs"`
+	Entries map[Ino]*entriesRecord `json:"entries"`
+}
+
+// KVMeta persists inodes, attributes and directory entries in embedded kv store,
+// so that inodes keep the same and the ufs does not need to be listed again after remount.
+// Cached attributes and entries expire after expire duration, data changed by others are visible then.
+type KVMeta struct {
+	*DefaultMeta
+	client kvClient
+	expire time.Duration
+}
+
+func NewKVMeta(fsMeta base.FSMeta, links map[string]base.FSMeta, inodeHandle *InodeHandle, config *Config) (Meta, error) {
+	defaultMeta, err := newDefaultMeta(fsMeta, links, inodeHandle)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newKVClient(config.Driver, config.path(fsMeta))
+	if err != nil {
+		log.Errorf("new kv client of driver[%s] failed: %v", config.Driver, err)
+		return nil, err
+	}
+	defaultMeta.name = client.name()
+	defaultMeta.locker = newLocker(config)
+	m := &KVMeta{
+		DefaultMeta: defaultMeta,
+		client:      client,
+		expire:      config.CacheExpire,
+	}
+	if err = m.loadInodes(); err != nil {
+		log.Errorf("load inodes from kv store failed: %v", err)
+		client.close()
+		return nil, err
+	}
+	inodeHandle.store = m
+	return m, nil
+}
+
+func kvKey(prefix byte, inode Ino) []byte {
+	key := make([]byte, 9)
+	key[0] = prefix
+	binary.BigEndian.PutUint64(key[1:], uint64(inode))
+	return key
+}
+
+func keyToIno(key []byte) Ino {
+	return Ino(binary.BigEndian.Uint64(key[1:]))
+}
+
+func (m *KVMeta) get(prefix byte, inode Ino, value interface{}) bool {
+	var data []byte
+	m.client.view(func(tx kvTxn) error {
+		if v := tx.get(kvKey(prefix, inode)); v != nil {
+			data = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if data == nil {
+		return false
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		log.Errorf("unmarshal meta of inode[%d] failed: %v", inode, err)
+		return false
+	}
+	return true
+}
+
+func (m *KVMeta) set(prefix byte, inode Ino, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Errorf("marshal meta of inode[%d] failed: %v", inode, err)
+		return
+	}
+	err = m.client.update(func(tx kvTxn) error {
+		return tx.set(kvKey(prefix, inode), data)
+	})
+	if err != nil {
+		log.Errorf("save meta of inode[%d] failed: %v", inode, err)
+	}
+}
+
+func (m *KVMeta) delete(inode Ino, prefixes ...byte) {
+	err := m.client.update(func(tx kvTxn) error {
+		for _, prefix := range prefixes {
+			if err := tx.delete(kvKey(prefix, inode)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("delete meta of inode[%d] failed: %v", inode, err)
+	}
+}
+
+func (m *KVMeta) saveInode(node *Inode) {
+	m.set(inodeKeyPrefix, node.inode, node.record())
+}
+
+// removeInode removes inode with its cached attributes and entries
+func (m *KVMeta) removeInode(inode Ino) {
+	m.delete(inode, inodeKeyPrefix, attrKeyPrefix, entriesKeyPrefix)
+}
+
+func (m *KVMeta) loadInodes() error {
+	var records []*inodeRecord
+	err := m.client.view(func(tx kvTxn) error {
+		return tx.scan([]byte{inodeKeyPrefix}, func(key, value []byte) error {
+			record := &inodeRecord{}
+			if err := json.Unmarshal(value, record); err != nil {
+				return err
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	orphans := m.inodeHandle.restore(records)
+	for _, inode := range orphans {
+		m.removeInode(inode)
+	}
+	log.Infof("load %d inodes from kv store, %d orphans are removed", len(records), len(orphans))
+	return nil
+}
+
+func (m *KVMeta) getCachedAttr(inode Ino, attr *Attr) bool {
+	record := &attrRecord{}
+	if !m.get(attrKeyPrefix, inode, record) || record.Attr == nil || time.Now().UnixNano() > record.Expire {
+		return false
+	}
+	*attr = *record.Attr
+	return true
+}
+
+func (m *KVMeta) cacheAttr(inode Ino, attr *Attr) {
+	if m.expire <= 0 {
+		return
+	}
+	m.set(attrKeyPrefix, inode, &attrRecord{Attr: attr, Expire: time.Now().Add(m.expire).UnixNano()})
+}
+
+func (m *KVMeta) invalidateAttr(inode Ino) {
+	m.delete(inode, attrKeyPrefix)
+}
+
+func (m *KVMeta) invalidateEntries(inode Ino) {
+	m.delete(inode, entriesKeyPrefix)
+}
+
+// Lookup returns the inode and attributes for the given entry in a directory.
+func (m *KVMeta) Lookup(ctx *Context, parent Ino, name string) (Ino, *Attr, syscall.Errno) {
+	if parentInode := m.inodeHandle.toInode(parent); parentInode != nil && parentInode.IsDir() {
+		if inode := parentInode.GetChild(name); inode != 0 {
+			attr := &Attr{}
+			if m.getCachedAttr(inode, attr) {
+				return inode, attr, syscall.F_OK
+			}
+		}
+	}
+	inode, attr, err := m.DefaultMeta.Lookup(ctx, parent, name)
+	if err == syscall.F_OK {
+		m.cacheAttr(inode, attr)
+	}
+	return inode, attr, err
+}
+
+// GetAttr returns the attributes for given node.
+func (m *KVMeta) GetAttr(ctx *Context, inode Ino, attr *Attr) syscall.Errno {
+	if m.getCachedAttr(inode, attr) {
+		return syscall.F_OK
+	}
+	err := m.DefaultMeta.GetAttr(ctx, inode, attr)
+	if err == syscall.F_OK {
+		m.cacheAttr(inode, attr)
+	}
+	return err
+}
+
+// SetAttr updates the attributes for given node.
+func (m *KVMeta) SetAttr(ctx *Context, inode Ino, set uint32, attr *Attr) syscall.Errno {
+	err := m.DefaultMeta.SetAttr(ctx, inode, set, attr)
+	if err == syscall.F_OK {
+		m.cacheAttr(inode, attr)
+	} else {
+		m.invalidateAttr(inode)
+	}
+	return err
+}
+
+// Truncate changes the length for given file.
+func (m *KVMeta) Truncate(ctx *Context, inode Ino, size uint64) syscall.Errno {
+	defer m.invalidateAttr(inode)
+	return m.DefaultMeta.Truncate(ctx, inode, size)
+}
+
+// Mknod creates a node in a directory with given name, type and permissions.
+func (m *KVMeta) Mknod(ctx *Context, parent Ino, name string, mode uint32, rdev uint32, inode *Ino, attr *Attr) syscall.Errno {
+	err := m.DefaultMeta.Mknod(ctx, parent, name, mode, rdev, inode, attr)
+	m.afterCreate(parent, *inode, attr, err)
+	return err
+}
+
+// Mkdir creates a sub-directory with given name and mode.
+func (m *KVMeta) Mkdir(ctx *Context, parent Ino, name string, mode uint32, inode *Ino, attr *Attr) syscall.Errno {
+	err := m.DefaultMeta.Mkdir(ctx, parent, name, mode, inode, attr)
+	m.afterCreate(parent, *inode, attr, err)
+	return err
+}
+
+// Create creates a file in a directory with given name.
+func (m *KVMeta) Create(ctx *Context, parent Ino, name string, mode uint32, cumask uint16,
+	flags uint32, inode *Ino, attr *Attr) (ufslib.UnderFileStorage, string, syscall.Errno) {
+	ufs, path, err := m.DefaultMeta.Create(ctx, parent, name, mode, cumask, flags, inode, attr)
+	m.afterCreate(parent, *inode, attr, err)
+	return ufs, path, err
+}
+
+func (m *KVMeta) afterCreate(parent, inode Ino, attr *Attr, err syscall.Errno) {
+	m.invalidateEntries(parent)
+	if err == syscall.F_OK {
+		m.cacheAttr(inode, attr)
+	}
+}
+
+// Symlink creates a symlink in a directory with given name.
+func (m *KVMeta) Symlink(ctx *Context, parent Ino, name string, path string, inode *Ino, attr *Attr) syscall.Errno {
+	err := m.DefaultMeta.Symlink(ctx, parent, name, path, inode, attr)
+	m.afterCreate(parent, *inode, attr, err)
+	return err
+}
+
+// Link creates an entry for node.
+func (m *KVMeta) Link(ctx *Context, inodeSrc, parent Ino, name string, attr *Attr) syscall.Errno {
+	err := m.DefaultMeta.Link(ctx, inodeSrc, parent, name, attr)
+	m.afterCreate(parent, inodeSrc, attr, err)
+	return err
+}
+
+// Unlink removes a file entry from a directory.
+func (m *KVMeta) Unlink(ctx *Context, parent Ino, name string) syscall.Errno {
+	defer m.invalidateEntries(parent)
+	return m.DefaultMeta.Unlink(ctx, parent, name)
+}
+
+// Rmdir removes an empty sub-directory.
+func (m *KVMeta) Rmdir(ctx *Context, parent Ino, name string) syscall.Errno {
+	defer m.invalidateEntries(parent)
+	return m.DefaultMeta.Rmdir(ctx, parent, name)
+}
+
+// Rename move an entry from a source directory to another with given name.
+func (m *KVMeta) Rename(ctx *Context, parentSrc Ino, nameSrc string, parentDst Ino,
+	nameDst string, flags uint32, inode *Ino, attr *Attr) syscall.Errno {
+	err := m.DefaultMeta.Rename(ctx, parentSrc, nameSrc, parentDst, nameDst, flags, inode, attr)
+	m.invalidateEntries(parentSrc)
+	m.afterCreate(parentDst, *inode, attr, err)
+	return err
+}
+
+// Readdir returns all entries for given directory.
+func (m *KVMeta) Readdir(ctx *Context, inode Ino, entries *[]*Entry) syscall.Errno {
+	record := &entriesRecord{}
+	if m.get(entriesKeyPrefix, inode, record) && time.Now().UnixNano() <= record.Expire {
+		*entries = append(*entries, record.Entries...)
+		return syscall.F_OK
+	}
+	var children []*Entry
+	if err := m.DefaultMeta.Readdir(ctx, inode, &children); utils.IsError(err) {
+		return err
+	}
+	if m.expire > 0 {
+		m.set(entriesKeyPrefix, inode, &entriesRecord{Entries: children, Expire: time.Now().Add(m.expire).UnixNano()})
+	}
+	*entries = append(*entries, children...)
+	return syscall.F_OK
+}
+
+// Open checks permission on a node and track it as open.
+func (m *KVMeta) Open(ctx *Context, inode Ino, flags uint32, attr *Attr) (ufslib.UnderFileStorage, string, syscall.Errno) {
+	// attributes of file opened for writing would be changed
+	if flags&syscall.O_ACCMODE != syscall.O_RDONLY {
+		m.invalidateAttr(inode)
+	}
+	return m.DefaultMeta.Open(ctx, inode, flags, attr)
+}
+
+// Close a file.
+func (m *KVMeta) Close(ctx *Context, inode Ino) syscall.Errno {
+	m.invalidateAttr(inode)
+	return syscall.F_OK
+}
+
+// DumpMeta writes all inodes and cached attributes and entries in json format
+func (m *KVMeta) DumpMeta(w io.Writer) error {
+	dump := &metaDump{
+		Attrs:   make(map[Ino]*attrRecord),
+		Entries: make(map[Ino]*entriesRecord),
+	}
+	err := m.client.view(func(tx kvTxn) error {
+		err := tx.scan([]byte{inodeKeyPrefix}, func(key, value []byte) error {
+			record := &inodeRecord{}
+			dump.Inodes = append(dump.Inodes, record)
+			return json.Unmarshal(value, record)
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.scan([]byte{attrKeyPrefix}, func(key, value []byte) error {
+			record := &attrRecord{}
+			dump.Attrs[keyToIno(key)] = record
+			return json.Unmarshal(value, record)
+		})
+		if err != nil {
+			return err
+		}
+		return tx.scan([]byte{entriesKeyPrefix}, func(key, value []byte) error {
+			record := &entriesRecord{}
+			dump.Entries[keyToIno(key)] = record
+			return json.Unmarshal(value, record)
+		})
+	})
+	if err != nil {
+		log.Errorf("dump meta failed: %v", err)
+		return err
+	}
+	return json.NewEncoder(w).Encode(dump)
+}
+
+// LoadMeta replaces all meta with meta dumped by DumpMeta, it should be called before fs is served
+func (m *KVMeta) LoadMeta(r io.Reader) error {
+	dump := &metaDump{}
+	if err := json.NewDecoder(r).Decode(dump); err != nil {
+		log.Errorf("decode meta failed: %v", err)
+		return err
+	}
+	err := m.client.update(func(tx kvTxn) error {
+		var keys [][]byte
+		for _, prefix := range []byte{inodeKeyPrefix, attrKeyPrefix, entriesKeyPrefix} {
+			err := tx.scan([]byte{prefix}, func(key, value []byte) error {
+				keys = append(keys, append([]byte{}, key...))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			if err := tx.delete(key); err != nil {
+				return err
+			}
+		}
+		for _, record := range dump.Inodes {
+			if err := setJSON(tx, kvKey(inodeKeyPrefix, record.Ino), record); err != nil {
+				return err
+			}
+		}
+		for inode, record := range dump.Attrs {
+			if err := setJSON(tx, kvKey(attrKeyPrefix, inode), record); err != nil {
+				return err
+			}
+		}
+		for inode, record := range dump.Entries {
+			if err := setJSON(tx, kvKey(entriesKeyPrefix, inode), record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("load meta failed: %v", err)
+		return err
+	}
+	return m.loadInodes()
+}
+
+func setJSON(tx kvTxn, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return tx.set(key, data)
+}
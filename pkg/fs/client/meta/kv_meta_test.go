@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/fs/client/base"
+)
+
+func newTestKVMeta(t *testing.T, fsMeta base.FSMeta, cachePath string) *KVMeta {
+	inodeHandle := NewInodeHandle()
+	inodeHandle.InitRootNode()
+	config := &Config{Driver: BoltDriver, CachePath: cachePath, CacheExpire: time.Minute}
+	m, err := NewMeta(fsMeta, nil, inodeHandle, config)
+	assert.NoError(t, err)
+	return m.(*KVMeta)
+}
+
+func TestKVMeta(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kv_meta")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fsMeta := base.FSMeta{
+		ID:      "fs-root-kv",
+		UfsType: base.LocalType,
+		SubPath: filepath.Join(dir, "data"),
+	}
+	ctx := NewEmptyContext()
+
+	m := newTestKVMeta(t, fsMeta, filepath.Join(dir, "meta"))
+	var dirIno, fileIno Ino
+	attr := &Attr{}
+	assert.Equal(t, syscall.F_OK, m.Mkdir(ctx, rootInodeID, "a", 0755, &dirIno, attr))
+	_, _, errno := m.Create(ctx, dirIno, "b", 0644, 0, uint32(os.O_WRONLY|os.O_CREATE), &fileIno, attr)
+	assert.Equal(t, syscall.F_OK, errno)
+	var entries []*Entry
+	assert.Equal(t, syscall.F_OK, m.Readdir(ctx, dirIno, &entries))
+	assert.Equal(t, 1, len(entries))
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.DumpMeta(&buf))
+	assert.NoError(t, m.client.close())
+
+	// inodes are the same after remount, and entries are served from cache
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "data", "a", "c"), []byte("c"), 0644))
+	m = newTestKVMeta(t, fsMeta, filepath.Join(dir, "meta"))
+	assert.Equal(t, fileIno, m.PathToIno("/a/b"))
+	entries = nil
+	assert.Equal(t, syscall.F_OK, m.Readdir(ctx, dirIno, &entries))
+	assert.Equal(t, 1, len(entries))
+	ino, _, errno := m.Lookup(ctx, dirIno, "b")
+	assert.Equal(t, syscall.F_OK, errno)
+	assert.Equal(t, fileIno, ino)
+
+	// unlink removes the inode and refreshes entries of parent
+	assert.Equal(t, syscall.F_OK, m.Unlink(ctx, dirIno, "b"))
+	entries = nil
+	assert.Equal(t, syscall.F_OK, m.Readdir(ctx, dirIno, &entries))
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "c", entries[0].Name)
+	assert.NoError(t, m.client.close())
+
+	// meta dumped before unlink can be loaded into another store
+	m = newTestKVMeta(t, fsMeta, filepath.Join(dir, "meta_loaded"))
+	assert.NoError(t, m.LoadMeta(&buf))
+	assert.Equal(t, fileIno, m.PathToIno("/a/b"))
+	assert.Equal(t, "/a/b", m.InoToPath(fileIno))
+	assert.NoError(t, m.client.close())
+}
+
+func TestInodeHandleRestore(t *testing.T) {
+	inodeHandle := NewInodeHandle()
+	orphans := inodeHandle.restore([]*inodeRecord{
+		{Ino: 2, Parent: 1, Name: "a", IsDir: true},
+		{Ino: 3, Parent: 2, Name: "b"},
+		{Ino: 5, Parent: 2, Name: "b"},
+		{Ino: 6, Parent: 4, Name: "c"},
+	})
+	assert.ElementsMatch(t, []Ino{3, 6}, orphans)
+	assert.Equal(t, "/a/b", inodeHandle.InoToPath(5))
+	assert.Equal(t, uint64(7), inodeHandle.nextNodeID)
+}
+
+func TestKVMetaMountPoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kv_meta")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fsMeta := base.FSMeta{
+		ID:      "fs-root-kv",
+		UfsType: base.LocalType,
+		SubPath: filepath.Join(dir, "data"),
+	}
+	newMeta := func(mountPoint string) *KVMeta {
+		inodeHandle := NewInodeHandle()
+		inodeHandle.InitRootNode()
+		config := &Config{Driver: BoltDriver, CachePath: filepath.Join(dir, "meta"),
+			CacheExpire: time.Minute, MountPoint: mountPoint}
+		m, err := NewMeta(fsMeta, nil, inodeHandle, config)
+		assert.NoError(t, err)
+		return m.(*KVMeta)
+	}
+
+	// the same fs mounted twice does not wait for the lock of the other store
+	m1 := newMeta(filepath.Join(dir, "mnt1"))
+	m2 := newMeta(filepath.Join(dir, "mnt2"))
+	assert.NoError(t, m1.client.close())
+	assert.NoError(t, m2.client.close())
+	files, err := ioutil.ReadDir(filepath.Join(dir, "meta"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/common/http/api"
+	"paddleflow/pkg/fs/client/base"
+	"paddleflow/pkg/fs/server/api/request"
+	"paddleflow/pkg/fs/utils/lock"
+)
+
+const (
+	// lockRetryInterval is the interval of retrying a blocking lock which could not be waited on
+	lockRetryInterval = 200 * time.Millisecond
+	// lockLeaseInterval should be much shorter than lease timeout of pfs server
+	lockLeaseInterval = 20 * time.Second
+)
+
+// locker keeps advisory locks of files, locks are identified by inode in a single
+// mount and by path when they are shared with other mounts.
+type locker interface {
+	flock(inode Ino, path string, owner uint64, ltype uint32) syscall.Errno
+	getlk(inode Ino, path string, owner uint64, ltype uint32, start, end uint64) (lock.Range, bool, syscall.Errno)
+	setlk(inode Ino, path string, owner uint64, ltype uint32, start, end uint64, pid uint32) syscall.Errno
+	// wait returns a channel closed when a lock of the file is released, nil means polling
+	wait(inode Ino, path string) <-chan struct{}
+}
+
+func newLocker(config *Config) locker {
+	if config != nil && config.SharedLocks && base.Client != nil {
+		return newRemoteLocker()
+	}
+	return &localLocker{table: lock.NewTable()}
+}
+
+type localLocker struct {
+	table *lock.Table
+}
+
+func lockKey(inode Ino) string {
+	return strconv.FormatUint(uint64(inode), 10)
+}
+
+func (l *localLocker) flock(inode Ino, path string, owner uint64, ltype uint32) syscall.Errno {
+	return l.table.Flock(lockKey(inode), lock.Owner{ID: owner}, ltype)
+}
+
+func (l *localLocker) getlk(inode Ino, path string, owner uint64, ltype uint32, start, end uint64) (lock.Range, bool, syscall.Errno) {
+	conflict, ok := l.table.Getlk(lockKey(inode), lock.Owner{ID: owner}, ltype, start, end)
+	return conflict, ok, syscall.F_OK
+}
+
+func (l *localLocker) setlk(inode Ino, path string, owner uint64, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
+	return l.table.Setlk(lockKey(inode), lock.Owner{ID: owner}, ltype, start, end, pid)
+}
+
+func (l *localLocker) wait(inode Ino, path string) <-chan struct{} {
+	return l.table.Wait(lockKey(inode))
+}
+
+// remoteLocker shares locks with other mounts of the file system by pfs server,
+// locks are kept alive by renewing lease of the client. Once the lease is lost,
+// locks held by the client have been released by pfs server, so all following
+// lock operations fail with ENOLCK instead of acting as if they were still held.
+type remoteLocker struct {
+	leaseOnce sync.Once
+	leaseLost int32
+}
+
+func newRemoteLocker() *remoteLocker {
+	return &remoteLocker{}
+}
+
+func (l *remoteLocker) renewLease() {
+	for {
+		time.Sleep(lockLeaseInterval)
+		err := base.Client.RenewLockLease()
+		if api.IsFileLockLeaseNotFound(err) {
+			l.loseLease(err)
+			return
+		}
+		if err != nil {
+			log.Errorf("renew lock lease of client[%s] failed: %v", base.Client.Uuid, err)
+		}
+	}
+}
+
+func (l *remoteLocker) loseLease(err error) {
+	log.Errorf("lock lease of client[%s] is lost, locks of the mount are released: %v", base.Client.Uuid, err)
+	atomic.StoreInt32(&l.leaseLost, 1)
+}
+
+func (l *remoteLocker) isLeaseLost() bool {
+	return atomic.LoadInt32(&l.leaseLost) == 1
+}
+
+func (l *remoteLocker) set(req request.FileLockRequest) syscall.Errno {
+	if l.isLeaseLost() {
+		return syscall.ENOLCK
+	}
+	err := base.Client.SetFileLock(req)
+	if err == nil || api.IsFileLockConflict(err) {
+		// lease is created by the first lock request handled by pfs server
+		l.leaseOnce.Do(func() {
+			go l.renewLease()
+		})
+	}
+	if err == nil {
+		return syscall.F_OK
+	}
+	if api.IsFileLockConflict(err) {
+		return syscall.EAGAIN
+	}
+	if api.IsFileLockLeaseNotFound(err) {
+		l.loseLease(err)
+		return syscall.ENOLCK
+	}
+	log.Errorf("set lock of file[%s] by pfs server failed: %v", req.Path, err)
+	return syscall.EIO
+}
+
+func (l *remoteLocker) flock(inode Ino, path string, owner uint64, ltype uint32) syscall.Errno {
+	return l.set(request.FileLockRequest{
+		Path:  path,
+		Owner: owner,
+		Flock: true,
+		Type:  ltype,
+	})
+}
+
+func (l *remoteLocker) getlk(inode Ino, path string, owner uint64, ltype uint32, start, end uint64) (lock.Range, bool, syscall.Errno) {
+	if l.isLeaseLost() {
+		return lock.Range{}, false, syscall.ENOLCK
+	}
+	resp, err := base.Client.GetFileLock(request.FileLockRequest{
+		Path:  path,
+		Owner: owner,
+		Type:  ltype,
+		Start: start,
+		End:   end,
+	})
+	if err != nil {
+		log.Errorf("get lock of file[%s] by pfs server failed: %v", path, err)
+		return lock.Range{}, false, syscall.EIO
+	}
+	if resp.Type == lock.Unlock {
+		return lock.Range{}, false, syscall.F_OK
+	}
+	return lock.Range{Type: resp.Type, Start: resp.Start, End: resp.End, Pid: resp.Pid}, true, syscall.F_OK
+}
+
+func (l *remoteLocker) setlk(inode Ino, path string, owner uint64, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
+	return l.set(request.FileLockRequest{
+		Path:  path,
+		Owner: owner,
+		Type:  ltype,
+		Start: start,
+		End:   end,
+		Pid:   pid,
+	})
+}
+
+func (l *remoteLocker) wait(inode Ino, path string) <-chan struct{} {
+	return nil
+}
+
+// waitLock calls try until it does not conflict with others or ctx is canceled if block is true.
+func waitLock(ctx *Context, l locker, inode Ino, path string, block bool, try func() syscall.Errno) syscall.Errno {
+	for {
+		waiter := l.wait(inode, path)
+		err := try()
+		if err != syscall.EAGAIN || !block {
+			return err
+		}
+		timer := time.NewTimer(lockRetryInterval)
+		select {
+		case <-ctx.cancel:
+			timer.Stop()
+			return syscall.EINTR
+		case <-waiter:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
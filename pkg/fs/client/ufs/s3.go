@@ -48,6 +48,14 @@ const (
 	MaxKeys          = 1000
 	AwsDefaultRegion = "us-east-1"
 	TmpPath          = "./tmp/pfs/"
+	// SymlinkMetaKey is the user metadata of marker object which stores target of symlink
+	SymlinkMetaKey = "Symlink-Target"
+	// s3 limits user metadata to 2KB
+	maxSymlinkTargetLen = 2000
+	// max number of objects headed at the same time when listing directory
+	symlinkCheckConcurrency = 16
+	// results of symlink check are dropped when the cache is full
+	maxSymlinkCacheSize = 100000
 )
 
 var Owner string
@@ -60,6 +68,16 @@ type s3FileSystem struct {
 	s3          *s3.S3
 	defaultTime time.Time
 	sync.Mutex
+	// whether empty objects are markers of symlinks, keyed by object path
+	symlinks   map[string]symlinkCheck
+	symlinksMu sync.Mutex
+}
+
+// symlinkCheck result of checking whether the object is a marker of symlink,
+// which is valid until the object is modified
+type symlinkCheck struct {
+	mtime  uint64
+	isLink bool
 }
 
 var _ UnderFileStorage = &s3FileSystem{}
@@ -302,6 +320,9 @@ func (fs *s3FileSystem) GetAttr(name string) (*base.FileInfo, error) {
 	if isDir {
 		size = 4096
 		mode = syscall.S_IFDIR | 0777
+	} else if target, ok := getSymlinkTarget(response.Metadata); ok {
+		size = int64(len(target))
+		mode = syscall.S_IFLNK | 0777
 	}
 
 	uid := uint32(utils.LookupUser(Owner))
@@ -512,6 +533,11 @@ func (fs *s3FileSystem) Open(name string, flags uint32) (fd base.FileHandle, err
 	}
 
 	if flags&syscall.O_ACCMODE == syscall.O_RDWR || flags&syscall.O_ACCMODE == syscall.O_WRONLY {
+		// empty file is written by streaming, otherwise existing content should be kept in temp file
+		if fh.size == 0 {
+			fs.openForStreamWrite(fh)
+			return fh, nil
+		}
 		err := fs.openForWrite(fh)
 		if err != nil {
 			return nil, err
@@ -559,10 +585,7 @@ func (fs *s3FileSystem) Create(name string, flags uint32, mode uint32) (fd base.
 			flags:  flags,
 			size:   0,
 		}
-		err = fs.openForWrite(fh)
-		if err != nil {
-			return nil, err
-		}
+		fs.openForStreamWrite(fh)
 		return fh, nil
 	}
 	return nil, syscall.ENOSYS
@@ -609,6 +632,12 @@ func (fs *s3FileSystem) openForWrite(fh *s3FileHandle) error {
 	return nil
 }
 
+// openForStreamWrite uploads sequential writes as parts of multipart upload without temp file
+func (fs *s3FileSystem) openForStreamWrite(fh *s3FileHandle) {
+	log.Debugf("S3 OpenForStreamWrite: fh.name[%s]", fh.name)
+	fh.writer = newS3MultipartWriter(fs.s3, fh.bucket, fs.getFullPath(fh.name), S3PartSize, S3UploadConcurrency)
+}
+
 // Directory handling
 func (fs *s3FileSystem) ReadDir(name string) (stream []base.DirEntry, err error) {
 	path := fs.getFullPath(name)
@@ -620,11 +649,18 @@ func (fs *s3FileSystem) ReadDir(name string) (stream []base.DirEntry, err error)
 	if err != nil {
 		return nil, err
 	}
-
+	var finfos []base.FileInfo
 	for finfo := range ch {
+		finfos = append(finfos, finfo)
+	}
+	links := fs.checkSymlinks(finfos)
+
+	for _, finfo := range finfos {
 		mode := syscall.S_IFREG | 0666
 		if finfo.IsDir {
 			mode = int(utils.StatModeToFileMode(syscall.S_IFDIR | 0777))
+		} else if links[finfo.Path] {
+			mode = syscall.S_IFLNK | 0777
 		}
 		subName := strings.TrimSuffix(finfo.Name, Delimiter)
 		if subName == "" {
@@ -640,12 +676,120 @@ func (fs *s3FileSystem) ReadDir(name string) (stream []base.DirEntry, err error)
 }
 
 // Symlinks.
+// s3 does not support symlink, which is emulated by an empty marker object with target in its metadata
 func (fs *s3FileSystem) Symlink(value string, linkName string) error {
-	return syscall.ENOSYS
+	if len(value) > maxSymlinkTargetLen {
+		return syscall.ENAMETOOLONG
+	}
+	fs.Lock()
+	defer fs.Unlock()
+	exist, err := fs.exists(linkName)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return syscall.EEXIST
+	}
+	path := fs.getFullPath(linkName)
+	request := &s3.PutObjectInput{
+		Bucket:   &fs.bucket,
+		Key:      &path,
+		Metadata: map[string]*string{SymlinkMetaKey: aws.String(value)},
+	}
+	_, err = fs.s3.PutObject(request)
+	fs.symlinksMu.Lock()
+	delete(fs.symlinks, path)
+	fs.symlinksMu.Unlock()
+	return err
 }
 
 func (fs *s3FileSystem) Readlink(name string) (string, error) {
-	return "", syscall.ENOSYS
+	path := fs.getFullPath(name)
+	request := &s3.HeadObjectInput{
+		Bucket: &fs.bucket,
+		Key:    &path,
+	}
+	response, err := fs.s3.HeadObject(request)
+	if err != nil {
+		if isNotExistErr(err) {
+			return "", syscall.ENOENT
+		}
+		return "", err
+	}
+	target, ok := getSymlinkTarget(response.Metadata)
+	if !ok {
+		return "", syscall.EINVAL
+	}
+	return target, nil
+}
+
+// isSymlink check whether the object is a marker of symlink by its metadata
+func (fs *s3FileSystem) isSymlink(key string) (bool, error) {
+	response, err := fs.s3.HeadObject(&s3.HeadObjectInput{
+		Bucket: &fs.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return false, err
+	}
+	_, ok := getSymlinkTarget(response.Metadata)
+	return ok, nil
+}
+
+// checkSymlinks find markers of symlinks in listed objects. listing does not return metadata,
+// so empty objects, which may be markers, are headed concurrently, and results are cached until objects are modified
+func (fs *s3FileSystem) checkSymlinks(finfos []base.FileInfo) map[string]bool {
+	links := make(map[string]bool)
+	var linksMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, symlinkCheckConcurrency)
+	for _, finfo := range finfos {
+		if finfo.IsDir || finfo.Size != 0 || strings.HasSuffix(finfo.Path, Delimiter) {
+			continue
+		}
+		fs.symlinksMu.Lock()
+		check, found := fs.symlinks[finfo.Path]
+		fs.symlinksMu.Unlock()
+		if found && check.mtime == finfo.Mtime {
+			links[finfo.Path] = check.isLink
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(path string, mtime uint64) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			isLink, err := fs.isSymlink(path)
+			if err != nil {
+				log.Debugf("head object[%s] failed: %v", path, err)
+				return
+			}
+			fs.symlinksMu.Lock()
+			if fs.symlinks == nil || len(fs.symlinks) >= maxSymlinkCacheSize {
+				fs.symlinks = make(map[string]symlinkCheck)
+			}
+			fs.symlinks[path] = symlinkCheck{mtime: mtime, isLink: isLink}
+			fs.symlinksMu.Unlock()
+			linksMu.Lock()
+			links[path] = isLink
+			linksMu.Unlock()
+		}(finfo.Path, finfo.Mtime)
+	}
+	wg.Wait()
+	return links
+}
+
+// getSymlinkTarget get target of symlink from metadata, the case of metadata key may be changed by s3 server
+func getSymlinkTarget(metadata map[string]*string) (string, bool) {
+	for key, value := range metadata {
+		if strings.EqualFold(key, SymlinkMetaKey) && value != nil {
+			return *value, true
+		}
+	}
+	return "", false
 }
 
 func (fs *s3FileSystem) StatFs(name string) *base.StatfsOut {
@@ -668,7 +812,10 @@ type s3FileHandle struct {
 	writeTmpfile   *os.File
 	canWrite       chan struct{}
 	writeSrcReader io.ReadCloser
+	writer         *s3MultipartWriter
 	fs             *s3FileSystem
+	// protect size, writer and writeTmpfile, which are switched when falling back from streaming to temp file
+	sync.Mutex
 }
 
 var _ base.FileHandle = &s3FileHandle{}
@@ -690,17 +837,20 @@ func (fh *s3FileHandle) Read(buf []byte, off int64) (res fuse.ReadResult, code f
 		Key:    &fullPath,
 	}
 	l := int64(len(buf))
-	if off >= fh.size {
+	fh.Lock()
+	size := fh.size
+	fh.Unlock()
+	if off >= size {
 		return fuse.ReadResultData(buf[0:0]), fuse.OK
 	}
-	if fh.size == 0 {
+	if size == 0 {
 		return fuse.ReadResultData(buf[0:0]), fuse.OK
 	}
 	// Range: https://www.w3.org/Protocols/rfc2616/rfc2616-sec14.html#sec14.35
 	if l > 0 {
 		endPos := off + l
-		if endPos > fh.size {
-			endPos = fh.size
+		if endPos > size {
+			endPos = size
 		}
 		r := fmt.Sprintf("bytes=%d-%d", off, endPos-1)
 		request.Range = &r
@@ -724,6 +874,18 @@ func (fh *s3FileHandle) Read(buf []byte, off int64) (res fuse.ReadResult, code f
 func (fh *s3FileHandle) Write(data []byte, off int64) (uint32, fuse.Status) {
 	log.Debugf("S3 Write: fh.name[%s]", fh.name)
 	fullPath := fh.fs.getFullPath(fh.name)
+	fh.Lock()
+	defer fh.Unlock()
+	if fh.writer != nil {
+		n, err := fh.writer.Write(data, off)
+		if err != errNonSequentialWrite {
+			return uint32(n), fuse.ToStatus(err)
+		}
+		log.Debugf("S3 Write: fh.name[%s] write at %d is not sequential, fallback to temp file", fh.name, off)
+		if err = fh.fallbackToTmpfile(); err != nil {
+			return 0, fuse.ToStatus(err)
+		}
+	}
 	if fh.writeTmpfile != nil {
 		if fh.canWrite != nil {
 			select {
@@ -745,8 +907,37 @@ func (fh *s3FileHandle) Write(data []byte, off int64) (uint32, fuse.Status) {
 	return uint32(len(data)), fuse.ToStatus(err)
 }
 
+// fallbackToTmpfile move data written by streaming to temp file, which supports random write, fh should be locked
+func (fh *s3FileHandle) fallbackToTmpfile() error {
+	writer := fh.writer
+	fh.writer = nil
+	if !writer.uploaded() {
+		// all data are still in buffer
+		fh.size = 0
+		if err := fh.fs.openForWrite(fh); err != nil {
+			return err
+		}
+		_, err := fh.writeTmpfile.Write(writer.buf)
+		return err
+	}
+	// complete the upload, then download the object to temp file
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	fh.size = writer.offset
+	return fh.fs.openForWrite(fh)
+}
+
 func (fh *s3FileHandle) Release() {
 	log.Debugf("S3 Release: fh.name[%s]", fh.name)
+	fh.Lock()
+	defer fh.Unlock()
+	if fh.writer != nil {
+		if err := fh.writer.Close(); err != nil {
+			log.Errorf("upload object[%s] error: [%+v]", fh.name, err)
+		}
+		fh.writer = nil
+	}
 	if fh.writeTmpfile != nil {
 		if fh.canWrite != nil {
 			select {
@@ -806,6 +997,11 @@ func (fh *s3FileHandle) Truncate(size uint64) fuse.Status {
 	}
 
 	// 已经打开写，则清空已写内容
+	fh.Lock()
+	defer fh.Unlock()
+	if fh.writer != nil {
+		fh.writer.Reset()
+	}
 	if fh.writeTmpfile != nil {
 		if fh.writeSrcReader != nil {
 			// 关闭reader会导致io.copy结束
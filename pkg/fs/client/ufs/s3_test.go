@@ -80,4 +80,47 @@ func TestS3(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Less(t, 0, len(entries))
 
+	// symlink is listed with its type
+	fs.Unlink("hello-link")
+	assert.NoError(t, fs.Symlink("hello", "hello-link"))
+	entries, err = fs.ReadDir("")
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Name == "hello-link" {
+			assert.Equal(t, uint32(syscall.S_IFLNK), entry.Mode&syscall.S_IFMT)
+		} else if entry.Name == "hello" {
+			assert.Equal(t, uint32(syscall.S_IFREG), entry.Mode&syscall.S_IFMT)
+		}
+	}
+	fs.Unlink("hello-link")
+
+}
+
+func TestGetSymlinkTarget(t *testing.T) {
+	target := "../data"
+	_, ok := getSymlinkTarget(nil)
+	assert.False(t, ok)
+	value, ok := getSymlinkTarget(map[string]*string{"symlink-target": &target})
+	assert.True(t, ok)
+	assert.Equal(t, target, value)
+}
+
+func TestCheckSymlinks(t *testing.T) {
+	// objects which cannot be markers of symlinks and cached results do not need head, s3 client is not used
+	fs := &s3FileSystem{
+		symlinks: map[string]symlinkCheck{
+			"test/link": {mtime: 100, isLink: true},
+			"test/file": {mtime: 100, isLink: false},
+		},
+	}
+	links := fs.checkSymlinks([]base.FileInfo{
+		{Path: "test/dir/", Size: 4096, IsDir: true},
+		{Path: "test/data", Size: 10, Mtime: 100},
+		{Path: "test/link", Size: 0, Mtime: 100},
+		{Path: "test/file", Size: 0, Mtime: 100},
+	})
+	assert.True(t, links["test/link"])
+	assert.False(t, links["test/file"])
+	assert.False(t, links["test/data"])
+	assert.False(t, links["test/dir/"])
 }
@@ -0,0 +1,252 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// s3 requires every part except the last one to be at least 5MB, and at most 10000 parts in one upload
+	S3PartSize          = 16 * 1024 * 1024
+	S3UploadConcurrency = 4
+)
+
+var errNonSequentialWrite = errors.New("non-sequential write")
+
+// s3MultipartWriter streams sequential writes to s3 as parts of a multipart upload.
+// Memory is bounded by one buffering part plus S3UploadConcurrency parts being uploaded.
+// Files smaller than one part are uploaded by a single PutObject when closed.
+type s3MultipartWriter struct {
+	client   s3iface.S3API
+	bucket   string
+	key      string
+	partSize int
+	uploadID string
+	buf      []byte
+	// offset is the size of data written so far, the next write must start from it
+	offset int64
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	sync.Mutex
+
+	// parts and err are updated by uploading goroutines
+	stateLock sync.Mutex
+	parts     []*s3.CompletedPart
+	err       error
+}
+
+func newS3MultipartWriter(client s3iface.S3API, bucket, key string, partSize, concurrency int) *s3MultipartWriter {
+	return &s3MultipartWriter{
+		client:   client,
+		bucket:   bucket,
+		key:      key,
+		partSize: partSize,
+		sem:      make(chan struct{}, concurrency),
+	}
+}
+
+// Write buffers data and uploads every full part in background.
+// errNonSequentialWrite is returned if off is not the end of written data.
+// The writer is failed once a part can not be uploaded, and the error is returned by all later writes and close.
+func (w *s3MultipartWriter) Write(data []byte, off int64) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if err := w.getErr(); err != nil {
+		return 0, err
+	}
+	if off != w.offset {
+		return 0, errNonSequentialWrite
+	}
+	w.buf = append(w.buf, data...)
+	w.offset += int64(len(data))
+	for len(w.buf) >= w.partSize {
+		part := make([]byte, w.partSize)
+		copy(part, w.buf)
+		if err := w.uploadPart(part); err != nil {
+			// buf and offset have been advanced, so the writer can not continue
+			w.setErr(err)
+			return 0, err
+		}
+		w.buf = append(w.buf[:0], w.buf[w.partSize:]...)
+	}
+	return len(data), nil
+}
+
+func (w *s3MultipartWriter) uploadPart(data []byte) error {
+	if w.uploadID == "" {
+		response, err := w.client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+			Bucket: &w.bucket,
+			Key:    &w.key,
+		})
+		if err != nil {
+			log.Errorf("create multipart upload of [%s] failed: %v", w.key, err)
+			return err
+		}
+		w.uploadID = *response.UploadId
+	}
+	w.stateLock.Lock()
+	partNumber := int64(len(w.parts) + 1)
+	w.parts = append(w.parts, nil)
+	w.stateLock.Unlock()
+	// block until one of the uploading parts finished, which bounds the memory of buffered parts
+	w.sem <- struct{}{}
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			<-w.sem
+			w.wg.Done()
+		}()
+		response, err := w.client.UploadPart(&s3.UploadPartInput{
+			Bucket:     &w.bucket,
+			Key:        &w.key,
+			UploadId:   &w.uploadID,
+			PartNumber: aws.Int64(partNumber),
+			Body:       bytes.NewReader(data),
+		})
+		if err != nil {
+			log.Errorf("upload part[%d] of [%s] failed: %v", partNumber, w.key, err)
+			w.setErr(err)
+			return
+		}
+		w.setPart(partNumber, response.ETag)
+	}()
+	return nil
+}
+
+// Close uploads the remaining data and completes the upload, the upload is aborted if any part failed
+func (w *s3MultipartWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if err := w.getErr(); err != nil {
+		// data written before is incomplete, do not upload it
+		if w.uploadID != "" {
+			w.wg.Wait()
+			w.abort()
+		}
+		w.buf = nil
+		return err
+	}
+	if w.uploadID == "" {
+		_, err := w.client.PutObject(&s3.PutObjectInput{
+			Bucket: &w.bucket,
+			Key:    &w.key,
+			Body:   bytes.NewReader(w.buf),
+		})
+		w.buf = nil
+		return err
+	}
+	if len(w.buf) > 0 {
+		if err := w.uploadPart(w.buf); err != nil {
+			w.abort()
+			return err
+		}
+		w.buf = nil
+	}
+	w.wg.Wait()
+	if err := w.getErr(); err != nil {
+		w.abort()
+		return err
+	}
+	w.stateLock.Lock()
+	parts := make([]*s3.CompletedPart, len(w.parts))
+	copy(parts, w.parts)
+	w.stateLock.Unlock()
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNumber < *parts[j].PartNumber
+	})
+	_, err := w.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          &w.bucket,
+		Key:             &w.key,
+		UploadId:        &w.uploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		log.Errorf("complete multipart upload of [%s] failed: %v", w.key, err)
+		w.abort()
+		return err
+	}
+	w.uploadID = ""
+	return nil
+}
+
+// Reset aborts the upload and drops written data, which is used by truncate
+func (w *s3MultipartWriter) Reset() {
+	w.Lock()
+	defer w.Unlock()
+	if w.uploadID != "" {
+		w.wg.Wait()
+		w.abort()
+	}
+	w.buf = nil
+	w.offset = 0
+	w.stateLock.Lock()
+	w.parts = nil
+	w.err = nil
+	w.stateLock.Unlock()
+}
+
+// uploaded indicate whether any part has been uploaded to s3
+func (w *s3MultipartWriter) uploaded() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.uploadID != ""
+}
+
+func (w *s3MultipartWriter) abort() {
+	_, err := w.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   &w.bucket,
+		Key:      &w.key,
+		UploadId: &w.uploadID,
+	})
+	if err != nil {
+		log.Errorf("abort multipart upload of [%s] failed: %v", w.key, err)
+	}
+	w.uploadID = ""
+}
+
+func (w *s3MultipartWriter) setPart(partNumber int64, etag *string) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+	w.parts[partNumber-1] = &s3.CompletedPart{
+		ETag:       etag,
+		PartNumber: aws.Int64(partNumber),
+	}
+}
+
+// setErr keeps the first error of uploading parts
+func (w *s3MultipartWriter) setErr(err error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *s3MultipartWriter) getErr() error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+	return w.err
+}
@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ufs
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3Client keeps objects and uploading parts in memory
+type fakeS3Client struct {
+	s3iface.S3API
+	sync.Mutex
+	objects     map[string][]byte
+	parts       map[int64][]byte
+	aborted     bool
+	failPart    int64
+	failCreate  bool
+	maxPartSize int
+}
+
+func newFakeS3Client() *fakeS3Client {
+	return &fakeS3Client{objects: map[string][]byte{}, parts: map[int64][]byte{}}
+}
+
+func (c *fakeS3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, _ := ioutil.ReadAll(input.Body)
+	c.Lock()
+	defer c.Unlock()
+	c.objects[*input.Key] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (c *fakeS3Client) CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	if c.failCreate {
+		return nil, errors.New("create multipart upload failed")
+	}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-id")}, nil
+}
+
+func (c *fakeS3Client) UploadPart(input *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+	if *input.PartNumber == c.failPart {
+		return nil, errors.New("upload part failed")
+	}
+	data, _ := ioutil.ReadAll(input.Body)
+	c.Lock()
+	defer c.Unlock()
+	c.parts[*input.PartNumber] = data
+	if len(data) > c.maxPartSize {
+		c.maxPartSize = len(data)
+	}
+	return &s3.UploadPartOutput{ETag: aws.String("etag")}, nil
+}
+
+func (c *fakeS3Client) CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	c.Lock()
+	defer c.Unlock()
+	numbers := make([]int64, 0, len(input.MultipartUpload.Parts))
+	for _, part := range input.MultipartUpload.Parts {
+		numbers = append(numbers, *part.PartNumber)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	var buf bytes.Buffer
+	for _, number := range numbers {
+		buf.Write(c.parts[number])
+	}
+	c.objects[*input.Key] = buf.Bytes()
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (c *fakeS3Client) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func TestS3MultipartWriter(t *testing.T) {
+	client := newFakeS3Client()
+	writer := newS3MultipartWriter(client, "bucket", "large", 4, 2)
+	content := []byte("hello world, hello s3")
+	var off int64
+	for _, chunk := range [][]byte{content[:3], content[3:10], content[10:]} {
+		n, err := writer.Write(chunk, off)
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+		off += int64(n)
+	}
+	assert.True(t, writer.uploaded())
+	_, err := writer.Write([]byte("x"), 1)
+	assert.Equal(t, errNonSequentialWrite, err)
+
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, content, client.objects["large"])
+	assert.Equal(t, 4, client.maxPartSize)
+	assert.Equal(t, 6, len(client.parts))
+}
+
+func TestS3MultipartWriterSmallFile(t *testing.T) {
+	client := newFakeS3Client()
+	writer := newS3MultipartWriter(client, "bucket", "small", 1024, 2)
+	_, err := writer.Write([]byte("hello"), 0)
+	assert.NoError(t, err)
+	assert.False(t, writer.uploaded())
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, []byte("hello"), client.objects["small"])
+	assert.Empty(t, client.parts)
+}
+
+func TestS3MultipartWriterFailed(t *testing.T) {
+	client := newFakeS3Client()
+	client.failPart = 2
+	writer := newS3MultipartWriter(client, "bucket", "failed", 4, 1)
+	_, err := writer.Write([]byte("hello world"), 0)
+	assert.NoError(t, err)
+	assert.Error(t, writer.Close())
+	assert.True(t, client.aborted)
+	_, found := client.objects["failed"]
+	assert.False(t, found)
+}
+
+func TestS3MultipartWriterCreateFailed(t *testing.T) {
+	client := newFakeS3Client()
+	client.failCreate = true
+	writer := newS3MultipartWriter(client, "bucket", "failed", 4, 1)
+	_, err := writer.Write([]byte("hello"), 0)
+	assert.Error(t, err)
+	// writer is failed, retry of the write and close return the error instead of uploading incomplete data
+	_, err = writer.Write([]byte("hello"), 0)
+	assert.Error(t, err)
+	assert.NotEqual(t, errNonSequentialWrite, err)
+	assert.Error(t, writer.Close())
+	_, found := client.objects["failed"]
+	assert.False(t, found)
+}
+
+func TestS3MultipartWriterReset(t *testing.T) {
+	client := newFakeS3Client()
+	writer := newS3MultipartWriter(client, "bucket", "reset", 4, 2)
+	_, err := writer.Write([]byte("hello world"), 0)
+	assert.NoError(t, err)
+	writer.Reset()
+	assert.True(t, client.aborted)
+	_, err = writer.Write([]byte("new"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, []byte("new"), client.objects["reset"])
+}
@@ -147,7 +147,14 @@ func (fs *sftpFileSystem) statFromFileInfo(finfo os.FileInfo) *syscall.Stat_t {
 // hardlinks incurs a performance hit.
 func (fs *sftpFileSystem) GetAttr(name string) (*base.FileInfo, error) {
 	log.Debugf("the path is %v", fs.GetPath(name))
-	info, err := fs.sc.sftpClient.Stat(fs.GetPath(name))
+	var info os.FileInfo
+	var err error
+	if name == "" {
+		info, err = fs.sc.sftpClient.Stat(fs.GetPath(name))
+	} else {
+		// do not follow symlink, which is resolved by kernel with readlink
+		info, err = fs.sc.sftpClient.Lstat(fs.GetPath(name))
+	}
 
 	if err != nil {
 		return nil, err
@@ -276,7 +283,8 @@ func (fs *sftpFileSystem) ReadDir(name string) (stream []base.DirEntry, err erro
 
 // Symlinks.
 func (fs *sftpFileSystem) Symlink(value string, linkName string) error {
-	return fs.sc.sftpClient.Symlink(fs.GetPath(value), fs.GetPath(linkName))
+	// target of symlink is kept as it is, relative target is resolved from the directory of link
+	return fs.sc.sftpClient.Symlink(value, fs.GetPath(linkName))
 }
 
 func (fs *sftpFileSystem) Readlink(name string) (string, error) {
@@ -63,6 +63,11 @@ func ToSyscallErrno(err error) syscall.Errno {
 		return syscall.EEXIST
 	}
 
+	// e.g. readlink on a file which is not symlink
+	if strings.Contains(err.Error(), "invalid argument") {
+		return syscall.EINVAL
+	}
+
 	if strings.Contains(err.Error(), "Operation unsupported") {
 		return syscall.ENOSYS
 	}
@@ -24,6 +24,10 @@ import (
 	ufslib "paddleflow/pkg/fs/client/ufs"
 )
 
+const (
+	flockLocked = 1 << iota
+)
+
 type handle struct {
 	sync.Mutex
 	fh       uint64
@@ -31,6 +35,9 @@ type handle struct {
 	reader   FileReader
 	writer   FileWriter
 	children []*meta.Entry
+	// locks records kinds of locks put by the handle, they are released on flush and release
+	locks      uint8
+	flockOwner uint64
 }
 
 func (v *VFS) newHandle(inode Ino) *handle {
@@ -37,12 +37,13 @@ type DataReader interface {
 	Open(inode Ino, length uint64, ufs ufslib.UnderFileStorage, path string) (FileReader, error)
 }
 
-func NewDataReader(m meta.Meta, blockSize int, store cache.Store) DataReader {
+func NewDataReader(m meta.Meta, blockSize int, readAhead int, store cache.Store) DataReader {
 	r := &dataReader{
 		m:         m,
 		files:     make(map[Ino]*fileReader),
 		store:     store,
 		blockSize: blockSize,
+		readAhead: readAhead,
 	}
 	return r
 }
@@ -60,6 +61,12 @@ type fileReader struct {
 
 	// TODO: 先用base.FileHandle跑通流程，后续修改ufs接口
 	fd base.FileHandle
+
+	// 顺序读检测：nextOff为上次读取的结束位置，aheadIndex之前的block已经预读
+	nextOff    uint64
+	aheadIndex uint64
+	// prefetches read blocks with fd, which is released after they finish
+	prefetches sync.WaitGroup
 }
 
 type dataReader struct {
@@ -69,6 +76,7 @@ type dataReader struct {
 	ufsMap    *ufsMap
 	store     cache.Store
 	blockSize int
+	readAhead int
 }
 
 func (f *fileReader) Read(buf []byte, off uint64) (int, syscall.Errno) {
@@ -82,6 +90,7 @@ func (f *fileReader) Read(buf []byte, off uint64) (int, syscall.Errno) {
 			log.Errorf("fileReader read err: %v", err)
 			return 0, syscall.EBADF
 		}
+		f.readAhead(off, n, ufsHandle)
 	} else {
 		n, err = ufsHandle.ReadAt(buf, int64(off))
 		if err != nil {
@@ -93,6 +102,45 @@ func (f *fileReader) Read(buf []byte, off uint64) (int, syscall.Errno) {
 	return n, syscall.F_OK
 }
 
+// readAhead prefetches at most readAhead blocks after off into cache if the file is read sequentially.
+// Reads within one block of the end of last read are taken as sequential, as kernel may
+// send reads of a file slightly out of order.
+func (f *fileReader) readAhead(off uint64, n int, ufsHandle ufslib.FileHandle) {
+	d := f.reader
+	if d.readAhead <= 0 || d.blockSize <= 0 || n == 0 || f.length == 0 {
+		return
+	}
+	blockSize := uint64(d.blockSize)
+	f.Lock()
+	defer f.Unlock()
+	sequential := off+blockSize > f.nextOff && off < f.nextOff+blockSize
+	f.nextOff = off + uint64(n)
+	if !sequential {
+		f.aheadIndex = 0
+		return
+	}
+	index := off / blockSize
+	start := index + 1
+	if f.aheadIndex > start {
+		start = f.aheadIndex
+	}
+	end := index + uint64(d.readAhead)
+	if last := (f.length - 1) / blockSize; end > last {
+		end = last
+	}
+	if start > end {
+		return
+	}
+	reader := d.store.NewReader(f.name, ufsHandle)
+	for i := start; i <= end; i++ {
+		// blocks not scheduled are prefetched by following reads
+		if !reader.Prefetch(int(i), &f.prefetches) {
+			break
+		}
+		f.aheadIndex = i + 1
+	}
+}
+
 func (f *fileReader) Close() {
 	f.Lock()
 	f.release()
@@ -102,6 +150,7 @@ func (f *fileReader) Close() {
 func (f *fileReader) release() {
 	// todo:: 硬链接的情况下，需要增加refer判断，不能直接删除
 	delete(f.reader.files, f.inode)
+	f.prefetches.Wait()
 	f.fd.Release()
 }
 
@@ -0,0 +1,130 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/fs/client/base"
+	"paddleflow/pkg/fs/client/cache"
+	ufslib "paddleflow/pkg/fs/client/ufs"
+)
+
+// prefetchStore records blocks prefetched by readers, at most limit blocks are scheduled if limit is set
+type prefetchStore struct {
+	cache.Store
+	prefetched []int
+	limit      int
+	// prefetches not finished yet
+	inflight []*sync.WaitGroup
+}
+
+func (s *prefetchStore) NewReader(name string, ufsFh ufslib.FileHandle) cache.Reader {
+	return &prefetchReader{store: s}
+}
+
+type prefetchReader struct {
+	cache.Reader
+	store *prefetchStore
+}
+
+func (r *prefetchReader) Prefetch(index int, wg *sync.WaitGroup) bool {
+	if r.store.limit > 0 && len(r.store.prefetched) >= r.store.limit {
+		return false
+	}
+	r.store.prefetched = append(r.store.prefetched, index)
+	if wg != nil {
+		wg.Add(1)
+		r.store.inflight = append(r.store.inflight, wg)
+	}
+	return true
+}
+
+// releaseFile records whether the file handle is released
+type releaseFile struct {
+	base.FileHandle
+	released chan struct{}
+}
+
+func (f *releaseFile) Release() {
+	close(f.released)
+}
+
+func TestFileReaderReadAhead(t *testing.T) {
+	store := &prefetchStore{}
+	d := &dataReader{store: store, blockSize: 4, readAhead: 2}
+	// the file has blocks 0-4
+	f := &fileReader{reader: d, name: "/file", length: 18}
+	readAhead := func(off uint64, n int) []int {
+		store.prefetched = nil
+		f.readAhead(off, n, ufslib.FileHandle{})
+		return store.prefetched
+	}
+
+	// sequential reads prefetch following blocks, each block is prefetched once
+	assert.Equal(t, []int{1, 2}, readAhead(0, 4))
+	assert.Equal(t, []int{3}, readAhead(4, 4))
+	// reads slightly out of order are still sequential
+	assert.Equal(t, []int(nil), readAhead(6, 2))
+	assert.Equal(t, []int{4}, readAhead(8, 4))
+	// no block is prefetched beyond the end of file
+	assert.Equal(t, []int(nil), readAhead(12, 4))
+
+	// random read stops prefetching, and the next sequential read starts again
+	assert.Equal(t, []int(nil), readAhead(0, 4))
+	assert.Equal(t, []int{2, 3}, readAhead(4, 4))
+
+	// blocks not scheduled are prefetched by the next sequential read
+	assert.Equal(t, []int(nil), readAhead(0, 4))
+	store.limit = 1
+	assert.Equal(t, []int{2}, readAhead(4, 4))
+	store.limit = 0
+	assert.Equal(t, []int{3, 4}, readAhead(8, 4))
+
+	// read ahead is disabled
+	d.readAhead = 0
+	assert.Equal(t, []int(nil), readAhead(8, 4))
+}
+
+func TestFileReaderCloseWaitPrefetch(t *testing.T) {
+	store := &prefetchStore{}
+	d := &dataReader{store: store, blockSize: 4, readAhead: 2}
+	fd := &releaseFile{released: make(chan struct{})}
+	f := &fileReader{reader: d, name: "/file", length: 18, fd: fd}
+	f.readAhead(0, 4, ufslib.FileHandle{})
+	assert.Equal(t, 2, len(store.inflight))
+
+	// file handle is released after prefetches reading it finish
+	go f.Close()
+	select {
+	case <-fd.released:
+		t.Fatal("file handle is released before prefetches finish")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for _, wg := range store.inflight {
+		wg.Done()
+	}
+	select {
+	case <-fd.released:
+	case <-time.After(time.Second):
+		t.Fatal("file handle is not released after prefetches finish")
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"paddleflow/pkg/fs/client/meta"
 	ufslib "paddleflow/pkg/fs/client/ufs"
 	"paddleflow/pkg/fs/client/utils"
+	"paddleflow/pkg/fs/utils/lock"
 )
 
 type VFS struct {
@@ -45,6 +46,7 @@ type VFS struct {
 
 type Config struct {
 	Cache *cache.Config
+	Meta  *meta.Config
 }
 
 type Ino = meta.Ino
@@ -65,6 +67,9 @@ func InitConfig(options ...Option) *Config {
 				Expire: 60 * time.Second,
 			},
 		},
+		Meta: &meta.Config{
+			Driver: meta.DefaultName,
+		},
 	}
 	for _, f := range options {
 		f(config)
@@ -96,12 +101,48 @@ func WithBlockSize(size int) Option {
 	}
 }
 
+func WithReadAhead(blocks int) Option {
+	return func(config *Config) {
+		config.Cache.ReadAhead = blocks
+	}
+}
+
 func WithDiskCachePath(path string) Option {
 	return func(config *Config) {
 		config.Cache.Disk.Dir = path
 	}
 }
 
+func WithMetaDriver(driver string) Option {
+	return func(config *Config) {
+		config.Meta.Driver = driver
+	}
+}
+
+func WithMetaCachePath(path string) Option {
+	return func(config *Config) {
+		config.Meta.CachePath = path
+	}
+}
+
+func WithMetaCacheExpire(expire time.Duration) Option {
+	return func(config *Config) {
+		config.Meta.CacheExpire = expire
+	}
+}
+
+func WithMetaMountPoint(mountPoint string) Option {
+	return func(config *Config) {
+		config.Meta.MountPoint = mountPoint
+	}
+}
+
+func WithSharedLocks(shared bool) Option {
+	return func(config *Config) {
+		config.Meta.SharedLocks = shared
+	}
+}
+
 func InitVFS(fsMeta base.FSMeta, links map[string]base.FSMeta, global bool, config *Config) (*VFS, error) {
 	vfs := &VFS{
 		fsMeta: fsMeta,
@@ -109,20 +150,25 @@ func InitVFS(fsMeta base.FSMeta, links map[string]base.FSMeta, global bool, conf
 	inodeHandle := meta.NewInodeHandle()
 	inodeHandle.InitRootNode()
 
-	vfsMeta, err := meta.NewDefaultMeta(fsMeta, links, inodeHandle)
+	var metaConfig *meta.Config
+	if config != nil {
+		metaConfig = config.Meta
+	}
+	vfsMeta, err := meta.NewMeta(fsMeta, links, inodeHandle, metaConfig)
 	if err != nil {
-		log.Errorf("new default meta failed: %v", err)
+		log.Errorf("new meta failed: %v", err)
 		return nil, err
 	}
 	vfs.Meta = vfsMeta
 	var store cache.Store
-	var blockSize int
+	var blockSize, readAhead int
 	if config != nil && config.Cache != nil {
 		store = cache.NewCacheStore(config.Cache)
 		blockSize = config.Cache.BlockSize
+		readAhead = config.Cache.ReadAhead
 	}
 	vfs.Store = store
-	vfs.reader = NewDataReader(vfs.Meta, blockSize, store)
+	vfs.reader = NewDataReader(vfs.Meta, blockSize, readAhead, store)
 	vfs.writer = NewDataWriter(vfs.Meta, blockSize, store)
 	vfs.handleMap = make(map[Ino][]*handle)
 	vfs.nextfh = 1
@@ -256,15 +302,29 @@ func (v *VFS) Rename(ctx *meta.Context, parent Ino, name string, newparent Ino,
 }
 
 func (v *VFS) Link(ctx *meta.Context, ino Ino, newparent Ino, newname string) (entry *meta.Entry, err syscall.Errno) {
-	return nil, syscall.ENOSYS
+	attr := &Attr{}
+	err = v.Meta.Link(ctx, ino, newparent, newname, attr)
+	if utils.IsError(err) {
+		return nil, err
+	}
+	entry = &meta.Entry{Ino: ino, Attr: attr}
+	return entry, err
 }
 
 func (v *VFS) Symlink(ctx *meta.Context, path string, parent Ino, name string) (entry *meta.Entry, err syscall.Errno) {
-	return nil, syscall.ENOSYS
+	var ino Ino
+	attr := &Attr{}
+	err = v.Meta.Symlink(ctx, parent, name, path, &ino, attr)
+	if utils.IsError(err) {
+		return nil, err
+	}
+	entry = &meta.Entry{Ino: ino, Attr: attr}
+	return entry, err
 }
 
 func (v *VFS) Readlink(ctx *meta.Context, ino Ino) (path []byte, err syscall.Errno) {
-	return nil, syscall.ENOSYS
+	err = v.Meta.ReadLink(ctx, ino, &path)
+	return path, err
 }
 
 func (v *VFS) Access(ctx *meta.Context, ino Ino, mask uint32) (err syscall.Errno) {
@@ -372,16 +432,45 @@ func (v *VFS) Read(ctx *meta.Context, ino Ino, buf []byte, off uint64, fh uint64
 }
 
 // File locking
-func (v *VFS) GetLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, len *uint64, typ *uint32, pid *uint32) (err syscall.Errno) {
-	return syscall.ENOSYS
+func (v *VFS) GetLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end *uint64, typ *uint32, pid *uint32) (err syscall.Errno) {
+	if v.findHandle(ino, fh) == nil {
+		return syscall.EBADF
+	}
+	return v.Meta.Getlk(ctx, ino, owner, typ, start, end, pid)
 }
 
-func (v *VFS) SetLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, block bool) (err syscall.Errno) {
-	return syscall.ENOSYS
+func (v *VFS) SetLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, flock bool) (err syscall.Errno) {
+	return v.setLk(ctx, ino, fh, owner, start, end, typ, pid, flock, false)
 }
 
-func (v *VFS) SetLkw(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, block bool) (err syscall.Errno) {
-	return syscall.ENOSYS
+func (v *VFS) SetLkw(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, flock bool) (err syscall.Errno) {
+	return v.setLk(ctx, ino, fh, owner, start, end, typ, pid, flock, true)
+}
+
+// setLk puts a BSD lock if flock is true, otherwise a posix range lock
+func (v *VFS) setLk(ctx *meta.Context, ino Ino, fh uint64, owner uint64, start, end uint64, typ uint32, pid uint32, flock, block bool) (err syscall.Errno) {
+	h := v.findHandle(ino, fh)
+	if h == nil {
+		return syscall.EBADF
+	}
+	if flock {
+		err = v.Meta.Flock(ctx, ino, owner, typ, block)
+	} else {
+		err = v.Meta.Setlk(ctx, ino, owner, block, typ, start, end, pid)
+	}
+	if err != syscall.F_OK {
+		return err
+	}
+	h.Lock()
+	defer h.Unlock()
+	switch {
+	case flock && typ == lock.Unlock:
+		h.locks &^= flockLocked
+	case flock:
+		h.locks |= flockLocked
+		h.flockOwner = owner
+	}
+	return syscall.F_OK
 }
 
 func (v *VFS) Write(ctx *meta.Context, ino Ino, buf []byte, off, fh uint64) (err syscall.Errno) {
@@ -413,6 +502,11 @@ func (v *VFS) Flush(ctx *meta.Context, ino Ino, fh uint64, lockOwner uint64) (er
 	if h.writer != nil {
 		err = h.writer.Flush()
 	}
+	// posix locks are released when any fd of the owner is closed,
+	// even if the locks are not acquired through this fd
+	if lockOwner != 0 {
+		v.Meta.Setlk(ctx, ino, lockOwner, false, lock.Unlock, 0, lock.MaxOffset, 0)
+	}
 	return err
 }
 
@@ -465,7 +559,21 @@ func (v *VFS) ReleaseDir(ctx *meta.Context, ino Ino, fh uint64) {
 
 func (v *VFS) Release(ctx *meta.Context, ino Ino, fh uint64) {
 	if fh > 0 {
+		h := v.findHandle(ino, fh)
 		v.releaseFileHandle(ino, fh)
+		// flock is released when the last fd sharing the handle is closed
+		if h != nil {
+			h.Lock()
+			locks, owner := h.locks, h.flockOwner
+			h.Unlock()
+			if locks&flockLocked != 0 {
+				v.Meta.Flock(ctx, ino, owner, lock.Unlock, false)
+			}
+		}
+		// meta may cache attributes which are changed by writing, DefaultMeta does not implement Close
+		if h != nil && h.writer != nil {
+			v.Meta.Close(ctx, ino)
+		}
 		log.Debugf("release inode %v", ino)
 		return
 	}
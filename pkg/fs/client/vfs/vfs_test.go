@@ -0,0 +1,57 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/fs/client/meta"
+	"paddleflow/pkg/fs/utils/lock"
+)
+
+// lockMeta records posix locks released by vfs
+type lockMeta struct {
+	meta.Meta
+	unlocked []uint64
+}
+
+func (m *lockMeta) Setlk(ctx *meta.Context, inode Ino, owner uint64, block bool, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
+	if ltype == lock.Unlock {
+		m.unlocked = append(m.unlocked, owner)
+	}
+	return syscall.F_OK
+}
+
+func TestFlushReleasePosixLocks(t *testing.T) {
+	m := &lockMeta{}
+	v := &VFS{Meta: m, handleMap: make(map[Ino][]*handle)}
+	ctx := meta.NewEmptyContext()
+	ino := Ino(2)
+	locked := v.newHandle(ino)
+	other := v.newHandle(ino)
+
+	assert.Equal(t, syscall.F_OK, v.SetLk(ctx, ino, locked.fh, 1, 0, lock.MaxOffset, lock.WriteLock, 0, false))
+	// closing another fd of the owner releases its locks
+	assert.Equal(t, syscall.F_OK, v.Flush(ctx, ino, other.fh, 1))
+	assert.Equal(t, []uint64{1}, m.unlocked)
+	// no lock owner is given when the fd is not closed by process, such as flush for fsync
+	assert.Equal(t, syscall.F_OK, v.Flush(ctx, ino, locked.fh, 0))
+	assert.Equal(t, []uint64{1}, m.unlocked)
+}
@@ -54,6 +54,9 @@ const (
 	NamespaceNotFound           = "NamespaceNotFound"
 	GetNamespaceFail            = "GetNamespaceFail"
 	LinkMetaPersistError        = "LinkMetaPersistError"
+	FileLockConflict            = "FileLockConflict"
+	InvalidFileLock             = "InvalidFileLock"
+	FileLockLeaseNotFound       = "FileLockLeaseNotFound"
 )
 
 var errorHTTPStatus = map[string]int{
@@ -88,6 +91,9 @@ var errorHTTPStatus = map[string]int{
 	NamespaceNotFound:           http.StatusBadRequest,
 	GetNamespaceFail:            http.StatusInternalServerError,
 	LinkMetaPersistError:        http.StatusBadRequest,
+	FileLockConflict:            http.StatusConflict,
+	InvalidFileLock:             http.StatusBadRequest,
+	FileLockLeaseNotFound:       http.StatusNotFound,
 }
 
 var errorMessage = map[string]string{
@@ -122,6 +128,9 @@ var errorMessage = map[string]string{
 	InvalidPVClaimsParams:      "Invalid persistent volume claims params",
 	NamespaceNotFound:          "Namespace not found",
 	GetNamespaceFail:           "Get namespace fail",
+	FileLockConflict:           "File is locked by others",
+	InvalidFileLock:            "Invalid file lock",
+	FileLockLeaseNotFound:      "File lock lease not found or expired",
 }
 
 type ErrorResponse struct {
@@ -0,0 +1,260 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+
+	apicommon "paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/apiserver/router/util"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/fs/server/api/common"
+	"paddleflow/pkg/fs/server/api/request"
+	"paddleflow/pkg/fs/server/service"
+	"paddleflow/pkg/fs/server/utils/fs"
+	"paddleflow/pkg/fs/utils/lock"
+)
+
+const (
+	paramClientID = "clientID"
+	queryOwner    = "owner"
+	queryType     = "type"
+	queryStart    = "start"
+	queryEnd      = "end"
+)
+
+type FileLockRouter struct{}
+
+func (lr *FileLockRouter) Name() string {
+	return "FileLockRouter"
+}
+
+func (lr *FileLockRouter) AddRouter(r chi.Router) {
+	log.Info("add file lock router")
+	r.Post("/fslock", lr.SetFileLock)
+	r.Get("/fslock", lr.GetFileLock)
+	r.Put("/fslock/lease/{clientID}", lr.RenewLease)
+	r.Delete("/fslock/lease/{clientID}", lr.ReleaseClient)
+}
+
+// SetFileLock the function that handle the set file lock request
+// @Summary SetFileLock
+// @Description 加锁或解锁文件，用于多个挂载点之间的flock和posix锁
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param request body request.FileLockRequest true "request body"
+// @Success 200
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /api/paddleflow/v1/fslock [post]
+func (lr *FileLockRouter) SetFileLock(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	var lockRequest request.FileLockRequest
+	if err := common.BindJSON(r, &lockRequest); err != nil {
+		ctx.Logging().Errorf("SetFileLock bindjson failed. err:%s", err.Error())
+		common.RenderErr(w, ctx.RequestID, common.MalformedJSON)
+		return
+	}
+	log.Debugf("set file lock with req[%v]", lockRequest)
+
+	fsID, err := validateFileLock(&ctx, &lockRequest)
+	if err != nil {
+		ctx.Logging().Errorf("set file lock params error: %v", err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	if err = service.GetFileLockService().SetFileLock(&ctx, fsID, &lockRequest); err != nil {
+		ctx.Logging().Debugf("set file lock failed: %v", err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, nil)
+}
+
+// GetFileLock the function that handle the get file lock request
+// @Summary GetFileLock
+// @Description 获取与请求冲突的posix锁，无冲突时返回的type为F_UNLCK
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param fsName query string true "文件系统名称"
+// @Param path query string true "文件路径"
+// @Param clientID query string true "客户端ID"
+// @Param owner query uint64 false "锁的持有者"
+// @Param type query uint32 true "锁类型"
+// @Param start query uint64 false "起始位置"
+// @Param end query uint64 false "结束位置"
+// @Success 200 {object} response.GetFileLockResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /api/paddleflow/v1/fslock [get]
+func (lr *FileLockRouter) GetFileLock(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+
+	query := r.URL.Query()
+	lockRequest := request.FileLockRequest{
+		FsName:   query.Get(util.QueryFsName),
+		Username: query.Get(util.QueryKeyUserName),
+		Path:     query.Get(util.QueryPath),
+		ClientID: query.Get(paramClientID),
+	}
+	var ltype uint64
+	values := map[string]*uint64{
+		queryOwner: &lockRequest.Owner,
+		queryType:  &ltype,
+		queryStart: &lockRequest.Start,
+		queryEnd:   &lockRequest.End,
+	}
+	for key, value := range values {
+		var err error
+		if *value, err = parseUintQuery(r, key); err != nil {
+			ctx.Logging().Errorf("get file lock params error: %v", err)
+			ctx.ErrorCode = common.InvalidFileLock
+			common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+			return
+		}
+	}
+	if ltype > math.MaxUint32 {
+		ltype = math.MaxUint32
+	}
+	lockRequest.Type = uint32(ltype)
+	log.Debugf("get file lock with req[%v]", lockRequest)
+
+	fsID, err := validateFileLock(&ctx, &lockRequest)
+	if err != nil {
+		ctx.Logging().Errorf("get file lock params error: %v", err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, service.GetFileLockService().GetFileLock(&ctx, fsID, &lockRequest))
+}
+
+// RenewLease the function that handle the renew lease request
+// @Summary RenewLease
+// @Description 续约客户端的锁，超时未续约的客户端持有的锁会被释放
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param clientID path string true "客户端ID"
+// @Success 200
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /api/paddleflow/v1/fslock/lease/{clientID} [put]
+func (lr *FileLockRouter) RenewLease(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clientID := chi.URLParam(r, paramClientID)
+	if err := service.GetFileLockService().RenewLease(&ctx, clientID); err != nil {
+		ctx.Logging().Errorf("renew lease of client[%s] failed: %v", clientID, err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, nil)
+}
+
+// ReleaseClient the function that handle the release client request
+// @Summary ReleaseClient
+// @Description 释放客户端持有的所有锁
+// @tag fs
+// @Accept   json
+// @Produce  json
+// @Param clientID path string true "客户端ID"
+// @Success 200
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /api/paddleflow/v1/fslock/lease/{clientID} [delete]
+func (lr *FileLockRouter) ReleaseClient(w http.ResponseWriter, r *http.Request) {
+	ctx := common.GetRequestContext(r)
+	clientID := chi.URLParam(r, paramClientID)
+	if err := service.GetFileLockService().ReleaseClient(&ctx, clientID); err != nil {
+		ctx.Logging().Errorf("release client[%s] failed: %v", clientID, err)
+		common.RenderErrWithMessage(w, ctx.RequestID, ctx.ErrorCode, err.Error())
+		return
+	}
+	common.Render(w, http.StatusOK, nil)
+}
+
+func parseUintQuery(r *http.Request, key string) (uint64, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return 0, nil
+	}
+	result, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, common.InvalidField(key, err.Error())
+	}
+	return result, nil
+}
+
+func validateFileLock(ctx *logger.RequestContext, req *request.FileLockRequest) (string, error) {
+	// 只有root用户可以使用其他用户的文件系统名称
+	if req.Username != "" && req.Username != ctx.UserName && ctx.UserName != fs.UserRoot {
+		ctx.Logging().Errorf("user[%s] can not lock files as user[%s]", ctx.UserName, req.Username)
+		ctx.ErrorCode = common.AuthFailed
+		return "", common.InvalidField("userName", "only root user can set userName")
+	}
+	if req.Username == "" {
+		req.Username = ctx.UserName
+	}
+	if req.Username == "" {
+		ctx.Logging().Error("userName is empty")
+		ctx.ErrorCode = common.AuthFailed
+		return "", common.InvalidField("userName", "userName is empty")
+	}
+	if req.FsName == "" {
+		ctx.ErrorCode = common.InvalidFileLock
+		return "", common.InvalidField("fsName", "fsName is empty")
+	}
+	if req.Path == "" {
+		ctx.ErrorCode = common.InvalidFileLock
+		return "", common.InvalidField("path", "path is empty")
+	}
+	if req.ClientID == "" {
+		ctx.ErrorCode = common.InvalidFileLock
+		return "", common.InvalidField("clientID", "clientID is empty")
+	}
+	if req.Type != lock.ReadLock && req.Type != lock.WriteLock && req.Type != lock.Unlock {
+		ctx.ErrorCode = common.InvalidFileLock
+		return "", common.InvalidField("type", fmt.Sprintf("lock type %d is not supported", req.Type))
+	}
+	// trans fsName to real fsID, fuse client uses fsID
+	fsID := fs.NameToFsID(req.FsName, req.Username)
+	fsModel, err := models.GetFileSystemWithFsID(fsID)
+	if err != nil {
+		ctx.Logging().Errorf("get file system[%s] failed: %v", fsID, err)
+		ctx.ErrorCode = common.FileSystemDataBaseError
+		return "", err
+	}
+	if fsModel.ID == "" {
+		ctx.Logging().Errorf("file system[%s] is not exist", fsID)
+		ctx.ErrorCode = common.FileSystemNotExist
+		return "", common.InvalidField("fsName", fmt.Sprintf("user[%s] fsName[%s] is not exist", req.Username, req.FsName))
+	}
+	if fsModel.UserName != ctx.UserName && !models.HasAccessToResource(ctx, apicommon.ResourceTypeFs, fsID) {
+		ctx.ErrorCode = common.AuthFailed
+		return "", fmt.Errorf("user[%s] has no access to fs[%s]", ctx.UserName, req.FsName)
+	}
+	return fsID, nil
+}
@@ -0,0 +1,30 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package request
+
+type FileLockRequest struct {
+	FsName   string `json:"fsName"`
+	Username string `json:"username"`
+	Path     string `json:"path"`
+	ClientID string `json:"clientID"`
+	Owner    uint64 `json:"owner"`
+	Flock    bool   `json:"flock"`
+	Type     uint32 `json:"type"`
+	Start    uint64 `json:"start"`
+	End      uint64 `json:"end"`
+	Pid      uint32 `json:"pid"`
+}
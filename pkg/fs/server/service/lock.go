@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/fs/server/api/common"
+	"paddleflow/pkg/fs/server/api/request"
+	"paddleflow/pkg/fs/server/api/response"
+	"paddleflow/pkg/fs/server/utils/fs"
+	"paddleflow/pkg/fs/utils/lock"
+)
+
+const (
+	// FileLockLeaseTimeout locks of a client are released if it does not renew lease in time
+	FileLockLeaseTimeout = 60 * time.Second
+	fileLockCheckPeriod  = 10 * time.Second
+	// expiredLeaseKeepTime is how long an expired client is remembered and refused
+	expiredLeaseKeepTime = 24 * time.Hour
+)
+
+// FileLockService keeps advisory locks shared by fuse clients of the same file system.
+// Locks and leases live in the memory of a single pfs server process, they are lost when
+// the server restarts and are not shared by replicas, so all clients of a file system
+// must use the same server instance.
+type FileLockService struct {
+	table  *lock.Table
+	mutex  sync.Mutex
+	leases map[string]*lockLease
+	// expired keeps clients whose locks have been released by lease timeout, so that
+	// they notice the loss instead of taking new locks as if nothing happened
+	expired map[string]time.Time
+}
+
+// lockLease is created by the first lock request of a client and bound to its user
+type lockLease struct {
+	userName  string
+	renewTime time.Time
+}
+
+var fileLockService *FileLockService
+var fileLockServiceOnce sync.Once
+
+// GetFileLockService returns the instance of file lock service
+func GetFileLockService() *FileLockService {
+	fileLockServiceOnce.Do(func() {
+		fileLockService = &FileLockService{
+			table:   lock.NewTable(),
+			leases:  make(map[string]*lockLease),
+			expired: make(map[string]time.Time),
+		}
+		go fileLockService.expireLeases(fileLockCheckPeriod)
+	})
+	return fileLockService
+}
+
+func lockKey(fsID, path string) string {
+	return fsID + ":" + path
+}
+
+// SetFileLock puts or releases a flock or posix lock, it fails with FileLockConflict if the lock is held by others
+func (s *FileLockService) SetFileLock(ctx *logger.RequestContext, fsID string, req *request.FileLockRequest) error {
+	if err := s.renew(ctx, req.ClientID, true); err != nil {
+		return err
+	}
+	key := lockKey(fsID, req.Path)
+	owner := lock.Owner{Client: req.ClientID, ID: req.Owner}
+	var errno syscall.Errno
+	if req.Flock {
+		errno = s.table.Flock(key, owner, req.Type)
+	} else {
+		errno = s.table.Setlk(key, owner, req.Type, req.Start, req.End, req.Pid)
+	}
+	switch errno {
+	case 0:
+		return nil
+	case syscall.EAGAIN:
+		ctx.ErrorCode = common.FileLockConflict
+		return fmt.Errorf("file[%s] of fs[%s] is locked by others", req.Path, fsID)
+	default:
+		ctx.ErrorCode = common.InvalidFileLock
+		return fmt.Errorf("set lock of file[%s] failed: %v", req.Path, errno)
+	}
+}
+
+// GetFileLock returns the posix lock conflicting with the request, type is unlock if there is no conflict
+func (s *FileLockService) GetFileLock(ctx *logger.RequestContext, fsID string, req *request.FileLockRequest) *response.GetFileLockResponse {
+	owner := lock.Owner{Client: req.ClientID, ID: req.Owner}
+	conflict, ok := s.table.Getlk(lockKey(fsID, req.Path), owner, req.Type, req.Start, req.End)
+	if !ok {
+		return &response.GetFileLockResponse{Type: lock.Unlock}
+	}
+	resp := &response.GetFileLockResponse{
+		Type:  conflict.Type,
+		Start: conflict.Start,
+		End:   conflict.End,
+	}
+	// pid is meaningless for locks held by other mounts
+	if conflict.Owner.Client == req.ClientID {
+		resp.Pid = conflict.Pid
+	}
+	return resp
+}
+
+// RenewLease keeps locks of client alive, it fails with FileLockLeaseNotFound if the lease
+// has expired, which means locks of the client have been released.
+func (s *FileLockService) RenewLease(ctx *logger.RequestContext, clientID string) error {
+	return s.renew(ctx, clientID, false)
+}
+
+// ReleaseClient releases all locks of client, it is called when client is unmounted
+func (s *FileLockService) ReleaseClient(ctx *logger.RequestContext, clientID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.getLease(ctx, clientID); err != nil {
+		return err
+	}
+	delete(s.leases, clientID)
+	s.table.ReleaseClient(clientID)
+	return nil
+}
+
+func (s *FileLockService) renew(ctx *logger.RequestContext, clientID string, create bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.leases[clientID]; !ok && create {
+		if _, expired := s.expired[clientID]; !expired {
+			s.leases[clientID] = &lockLease{userName: ctx.UserName}
+		}
+	}
+	lease, err := s.getLease(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	lease.renewTime = time.Now()
+	return nil
+}
+
+// getLease returns lease of client, which could only be used by its user or root
+func (s *FileLockService) getLease(ctx *logger.RequestContext, clientID string) (*lockLease, error) {
+	lease, ok := s.leases[clientID]
+	if !ok {
+		ctx.ErrorCode = common.FileLockLeaseNotFound
+		if _, expired := s.expired[clientID]; expired {
+			return nil, fmt.Errorf("lease of client[%s] has expired and its locks were released", clientID)
+		}
+		return nil, fmt.Errorf("lease of client[%s] not found", clientID)
+	}
+	if lease.userName != ctx.UserName && ctx.UserName != fs.UserRoot {
+		ctx.ErrorCode = common.AuthFailed
+		return nil, fmt.Errorf("user[%s] can not use lease of client[%s]", ctx.UserName, clientID)
+	}
+	return lease, nil
+}
+
+func (s *FileLockService) expireLeases(period time.Duration) {
+	for {
+		time.Sleep(period)
+		s.expire(time.Now())
+	}
+}
+
+// expire releases locks of clients not renewing lease in time, clients which really
+// lose locks are remembered, so that their following requests fail.
+func (s *FileLockService) expire(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for clientID, lease := range s.leases {
+		if now.Sub(lease.renewTime) <= FileLockLeaseTimeout {
+			continue
+		}
+		delete(s.leases, clientID)
+		if s.table.ReleaseClient(clientID) {
+			log.Infof("lease of client[%s] expired, its file locks are released", clientID)
+			s.expired[clientID] = now
+		}
+	}
+	for clientID, expireTime := range s.expired {
+		if now.Sub(expireTime) > expiredLeaseKeepTime {
+			delete(s.expired, clientID)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/fs/server/api/common"
+	"paddleflow/pkg/fs/server/api/request"
+	"paddleflow/pkg/fs/utils/lock"
+)
+
+func newTestFileLockService() *FileLockService {
+	return &FileLockService{
+		table:   lock.NewTable(),
+		leases:  make(map[string]*lockLease),
+		expired: make(map[string]time.Time),
+	}
+}
+
+func TestFileLockLease(t *testing.T) {
+	s := newTestFileLockService()
+	req := &request.FileLockRequest{Path: "/a", ClientID: "client", Flock: true, Type: lock.WriteLock}
+
+	// lease is created by the first lock request and bound to its user
+	assert.Equal(t, common.FileLockLeaseNotFound, renewLease(s, "alice", "client"))
+	assert.Equal(t, "", setFileLock(s, "alice", req))
+	assert.Equal(t, "", renewLease(s, "alice", "client"))
+	assert.Equal(t, "", renewLease(s, "root", "client"))
+	assert.Equal(t, common.AuthFailed, setFileLock(s, "bob", req))
+	assert.Equal(t, common.AuthFailed, renewLease(s, "bob", "client"))
+	assert.Error(t, s.ReleaseClient(userContext("bob"), "client"))
+
+	// locks are released when lease expires, and the client is refused then
+	s.expire(time.Now().Add(2 * FileLockLeaseTimeout))
+	assert.Equal(t, common.FileLockLeaseNotFound, renewLease(s, "alice", "client"))
+	assert.Equal(t, common.FileLockLeaseNotFound, setFileLock(s, "alice", req))
+	other := &request.FileLockRequest{Path: "/a", ClientID: "other", Flock: true, Type: lock.WriteLock}
+	assert.Equal(t, "", setFileLock(s, "alice", other))
+
+	// client holding no lock is not refused after lease expires
+	empty := &request.FileLockRequest{Path: "/b", ClientID: "empty", Flock: true, Type: lock.Unlock}
+	assert.Equal(t, "", setFileLock(s, "alice", empty))
+	s.expire(time.Now().Add(2 * FileLockLeaseTimeout))
+	assert.Equal(t, "", setFileLock(s, "alice", empty))
+	assert.NoError(t, s.ReleaseClient(userContext("alice"), "empty"))
+	assert.Equal(t, common.FileLockLeaseNotFound, renewLease(s, "alice", "empty"))
+}
+
+func userContext(userName string) *logger.RequestContext {
+	return &logger.RequestContext{UserName: userName}
+}
+
+// setFileLock returns error code of the request, which is empty on success
+func setFileLock(s *FileLockService, userName string, req *request.FileLockRequest) string {
+	ctx := userContext(userName)
+	s.SetFileLock(ctx, "fs-alice-a", req)
+	return ctx.ErrorCode
+}
+
+func renewLease(s *FileLockService, userName, clientID string) string {
+	ctx := userContext(userName)
+	s.RenewLease(ctx, clientID)
+	return ctx.ErrorCode
+}
@@ -0,0 +1,300 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+const (
+	ReadLock  = uint32(syscall.F_RDLCK)
+	WriteLock = uint32(syscall.F_WRLCK)
+	Unlock    = uint32(syscall.F_UNLCK)
+
+	// MaxOffset is the end of a lock that covers the whole file
+	MaxOffset = uint64(math.MaxInt64)
+)
+
+// Owner identifies a lock holder, Client distinguishes mounts when locks
+// are coordinated by pfs server, it is empty for locks kept in-process.
+type Owner struct {
+	Client string
+	ID     uint64
+}
+
+// Range is a posix lock on [Start, End] of a file, End is inclusive.
+type Range struct {
+	Type  uint32
+	Start uint64
+	End   uint64
+	Pid   uint32
+	Owner Owner
+}
+
+func (r Range) overlap(start, end uint64) bool {
+	return r.Start <= end && start <= r.End
+}
+
+var released = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+type fileLocks struct {
+	flocks map[Owner]uint32
+	plocks map[Owner][]Range
+	// waiter is closed and replaced when any lock of the file is released
+	waiter chan struct{}
+}
+
+func (f *fileLocks) empty() bool {
+	return len(f.flocks) == 0 && len(f.plocks) == 0
+}
+
+func (f *fileLocks) wakeup() {
+	if f.waiter != nil {
+		close(f.waiter)
+		f.waiter = nil
+	}
+}
+
+// Table keeps flock and posix locks of files, files are identified by key,
+// which is inode for single mount and path for locks shared across mounts.
+type Table struct {
+	sync.Mutex
+	files map[string]*fileLocks
+}
+
+func NewTable() *Table {
+	return &Table{files: make(map[string]*fileLocks)}
+}
+
+func (t *Table) get(key string) *fileLocks {
+	f, ok := t.files[key]
+	if !ok {
+		f = &fileLocks{
+			flocks: make(map[Owner]uint32),
+			plocks: make(map[Owner][]Range),
+		}
+		t.files[key] = f
+	}
+	return f
+}
+
+// release wakes up waiters of the file and drops it when no lock is held.
+func (t *Table) release(key string, f *fileLocks) {
+	f.wakeup()
+	if f.empty() {
+		delete(t.files, key)
+	}
+}
+
+// Wait returns a channel which is closed when a lock of the file is released,
+// callers should get it before trying a lock so that no release is missed.
+func (t *Table) Wait(key string) <-chan struct{} {
+	t.Lock()
+	defer t.Unlock()
+	f, ok := t.files[key]
+	if !ok {
+		// no lock is held on the file
+		return released
+	}
+	if f.waiter == nil {
+		f.waiter = make(chan struct{})
+	}
+	return f.waiter
+}
+
+// Flock puts a BSD lock of ltype on file, EAGAIN is returned on conflict.
+func (t *Table) Flock(key string, owner Owner, ltype uint32) syscall.Errno {
+	t.Lock()
+	defer t.Unlock()
+	f := t.get(key)
+	switch ltype {
+	case Unlock:
+		delete(f.flocks, owner)
+		t.release(key, f)
+		return 0
+	case ReadLock, WriteLock:
+	default:
+		t.release(key, f)
+		return syscall.EINVAL
+	}
+	for o, typ := range f.flocks {
+		if o != owner && (ltype == WriteLock || typ == WriteLock) {
+			return syscall.EAGAIN
+		}
+	}
+	old, held := f.flocks[owner]
+	f.flocks[owner] = ltype
+	if held && old == WriteLock && ltype == ReadLock {
+		// downgrade lets other readers in
+		f.wakeup()
+	}
+	return 0
+}
+
+// Getlk returns the first lock conflicting with ltype on [start, end],
+// ok is false if the range could be locked by owner.
+func (t *Table) Getlk(key string, owner Owner, ltype uint32, start, end uint64) (conflict Range, ok bool) {
+	t.Lock()
+	defer t.Unlock()
+	f, exist := t.files[key]
+	if !exist {
+		return Range{}, false
+	}
+	return f.conflict(owner, ltype, start, end)
+}
+
+func (f *fileLocks) conflict(owner Owner, ltype uint32, start, end uint64) (Range, bool) {
+	for o, ranges := range f.plocks {
+		if o == owner {
+			continue
+		}
+		for _, r := range ranges {
+			if r.overlap(start, end) && (ltype == WriteLock || r.Type == WriteLock) {
+				return r, true
+			}
+		}
+	}
+	return Range{}, false
+}
+
+// Setlk puts a posix lock of ltype on [start, end] for owner, ranges already held
+// by owner are split or merged, EAGAIN is returned on conflict.
+func (t *Table) Setlk(key string, owner Owner, ltype uint32, start, end uint64, pid uint32) syscall.Errno {
+	if start > end {
+		return syscall.EINVAL
+	}
+	t.Lock()
+	defer t.Unlock()
+	f := t.get(key)
+	switch ltype {
+	case Unlock:
+	case ReadLock, WriteLock:
+		if _, ok := f.conflict(owner, ltype, start, end); ok {
+			return syscall.EAGAIN
+		}
+	default:
+		t.release(key, f)
+		return syscall.EINVAL
+	}
+	ranges := cutRanges(f.plocks[owner], start, end)
+	if ltype != Unlock {
+		ranges = append(ranges, Range{Type: ltype, Start: start, End: end, Pid: pid, Owner: owner})
+	}
+	ranges = mergeRanges(ranges)
+	if len(ranges) == 0 {
+		delete(f.plocks, owner)
+	} else {
+		f.plocks[owner] = ranges
+	}
+	if ltype != WriteLock {
+		t.release(key, f)
+	}
+	return 0
+}
+
+// ReleaseOwner drops all posix locks of owner on file, it is called when the
+// owner closes the file.
+func (t *Table) ReleaseOwner(key string, owner Owner) {
+	t.Lock()
+	defer t.Unlock()
+	f, ok := t.files[key]
+	if !ok {
+		return
+	}
+	if _, held := f.plocks[owner]; held {
+		delete(f.plocks, owner)
+		t.release(key, f)
+	}
+}
+
+// ReleaseClient drops all locks held by client, it is called when a mount
+// goes away or its lease expires. It returns true if any lock is dropped.
+func (t *Table) ReleaseClient(client string) (dropped bool) {
+	t.Lock()
+	defer t.Unlock()
+	for key, f := range t.files {
+		released := false
+		for o := range f.flocks {
+			if o.Client == client {
+				delete(f.flocks, o)
+				released = true
+			}
+		}
+		for o := range f.plocks {
+			if o.Client == client {
+				delete(f.plocks, o)
+				released = true
+			}
+		}
+		if released {
+			t.release(key, f)
+			dropped = true
+		}
+	}
+	return dropped
+}
+
+// cutRanges removes [start, end] from ranges, splitting those partially covered.
+func cutRanges(ranges []Range, start, end uint64) []Range {
+	result := make([]Range, 0, len(ranges)+1)
+	for _, r := range ranges {
+		if !r.overlap(start, end) {
+			result = append(result, r)
+			continue
+		}
+		if r.Start < start {
+			left := r
+			left.End = start - 1
+			result = append(result, left)
+		}
+		if r.End > end {
+			right := r
+			right.Start = end + 1
+			result = append(result, right)
+		}
+	}
+	return result
+}
+
+// mergeRanges sorts ranges and merges adjacent ones of the same type.
+func mergeRanges(ranges []Range) []Range {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	result := []Range{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &result[len(result)-1]
+		if last.Type == r.Type && last.End != math.MaxUint64 && last.End+1 >= r.Start {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlock(t *testing.T) {
+	table := NewTable()
+	o1, o2 := Owner{ID: 1}, Owner{ID: 2}
+
+	assert.Equal(t, syscall.Errno(0), table.Flock("1", o1, ReadLock))
+	assert.Equal(t, syscall.Errno(0), table.Flock("1", o2, ReadLock))
+	assert.Equal(t, syscall.EAGAIN, table.Flock("1", o1, WriteLock))
+
+	wait := table.Wait("1")
+	assert.Equal(t, syscall.Errno(0), table.Flock("1", o2, Unlock))
+	select {
+	case <-wait:
+	default:
+		t.Fatal("waiter should be woken up by unlock")
+	}
+	assert.Equal(t, syscall.Errno(0), table.Flock("1", o1, WriteLock))
+	assert.Equal(t, syscall.EAGAIN, table.Flock("1", o2, ReadLock))
+	assert.Equal(t, syscall.EINVAL, table.Flock("1", o2, 100))
+
+	table.ReleaseClient("")
+	assert.Equal(t, 0, len(table.files))
+}
+
+func TestSetlk(t *testing.T) {
+	table := NewTable()
+	o1, o2 := Owner{ID: 1}, Owner{ID: 2}
+
+	assert.Equal(t, syscall.Errno(0), table.Setlk("1", o1, WriteLock, 0, 99, 10))
+	assert.Equal(t, syscall.EAGAIN, table.Setlk("1", o2, ReadLock, 50, 150, 20))
+	assert.Equal(t, syscall.Errno(0), table.Setlk("1", o2, ReadLock, 100, 150, 20))
+
+	conflict, ok := table.Getlk("1", o2, WriteLock, 0, MaxOffset)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(10), conflict.Pid)
+	_, ok = table.Getlk("1", o1, WriteLock, 0, 99)
+	assert.False(t, ok)
+
+	// unlock the middle of a range splits it
+	assert.Equal(t, syscall.Errno(0), table.Setlk("1", o1, Unlock, 40, 59, 10))
+	assert.Equal(t, []Range{
+		{Type: WriteLock, Start: 0, End: 39, Pid: 10, Owner: o1},
+		{Type: WriteLock, Start: 60, End: 99, Pid: 10, Owner: o1},
+	}, table.files["1"].plocks[o1])
+	assert.Equal(t, syscall.Errno(0), table.Setlk("1", o2, WriteLock, 40, 59, 20))
+
+	// adjacent ranges of the same type are merged
+	assert.Equal(t, syscall.Errno(0), table.Setlk("1", o2, ReadLock, 40, 59, 20))
+	assert.Equal(t, []Range{
+		{Type: ReadLock, Start: 40, End: 59, Pid: 20, Owner: o2},
+		{Type: ReadLock, Start: 100, End: 150, Pid: 20, Owner: o2},
+	}, table.files["1"].plocks[o2])
+	assert.Equal(t, syscall.EAGAIN, table.Setlk("1", o2, ReadLock, 60, 99, 20))
+
+	table.ReleaseOwner("1", o1)
+	assert.Equal(t, syscall.Errno(0), table.Setlk("1", o2, ReadLock, 0, MaxOffset, 20))
+	assert.Equal(t, []Range{
+		{Type: ReadLock, Start: 0, End: MaxOffset, Pid: 20, Owner: o2},
+	}, table.files["1"].plocks[o2])
+	assert.Equal(t, syscall.EINVAL, table.Setlk("1", o2, ReadLock, 10, 5, 20))
+
+	table.ReleaseOwner("1", o2)
+	assert.Equal(t, 0, len(table.files))
+}
+
+func TestReleaseClient(t *testing.T) {
+	table := NewTable()
+	a, b := Owner{Client: "a", ID: 1}, Owner{Client: "b", ID: 1}
+
+	assert.Equal(t, syscall.Errno(0), table.Setlk("/f", a, WriteLock, 0, MaxOffset, 1))
+	assert.Equal(t, syscall.Errno(0), table.Flock("/f", a, WriteLock))
+	assert.Equal(t, syscall.EAGAIN, table.Setlk("/f", b, WriteLock, 0, 0, 1))
+	assert.Equal(t, syscall.EAGAIN, table.Flock("/f", b, ReadLock))
+
+	assert.True(t, table.ReleaseClient("a"))
+	assert.False(t, table.ReleaseClient("a"))
+	assert.Equal(t, syscall.Errno(0), table.Setlk("/f", b, WriteLock, 0, 0, 1))
+	assert.Equal(t, syscall.Errno(0), table.Flock("/f", b, ReadLock))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/errors"
+	"paddleflow/pkg/common/k8s"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job/submitter"
+)
+
+const defaultBatchJobReplicas int32 = 1
+
+// BatchJob is backed by kubernetes batch/v1 Job, and runs on clusters without volcano or spark-operator
+type BatchJob struct {
+}
+
+// getBatchJobYamlPath get job yaml path
+// if EnvJobYamlPath not exist, default path would be "$DefaultYamlParentDir/batchjob.yaml"
+func getBatchJobYamlPath(conf *models.Conf) string {
+	jobType := conf.Env[schema.EnvJobType]
+	return fmt.Sprintf("%s/%s.yaml", config.GlobalServerConfig.Job.DefaultJobYamlDir, jobType)
+}
+
+// patchBatchJobVariable patch env variable to batch job, replicas of job is set to both parallelism and completions
+func patchBatchJobVariable(jobApp *batchv1.Job, jobID string, conf *models.Conf) error {
+	jobApp.Name = jobID
+	// metadata
+	if namespace, exist := conf.Env[schema.EnvJobNamespace]; exist {
+		jobApp.Namespace = namespace
+	}
+	if jobApp.Labels == nil {
+		jobApp.Labels = map[string]string{}
+	}
+	jobApp.Labels[schema.JobOwnerLabel] = schema.JobOwnerValue
+	jobApp.Labels[schema.JobIDLabel] = jobID
+
+	// replicas
+	if replicasStr, found := conf.Env[schema.EnvJobReplicas]; found {
+		replicasInt, _ := strconv.Atoi(replicasStr)
+		replicas := int32(replicasInt)
+		jobApp.Spec.Parallelism = &replicas
+		jobApp.Spec.Completions = &replicas
+	}
+	if jobApp.Spec.Parallelism == nil || *jobApp.Spec.Parallelism <= 0 {
+		replicas := defaultBatchJobReplicas
+		jobApp.Spec.Parallelism = &replicas
+		jobApp.Spec.Completions = &replicas
+	}
+
+	// pod template
+	template := &jobApp.Spec.Template
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	template.Labels[schema.JobIDLabel] = jobID
+	if len(template.Spec.Containers) == 0 {
+		return fmt.Errorf("the container of batch job[%s] is nil", jobID)
+	}
+	fillContainerInTask(&template.Spec.Containers[0], conf, conf.Env[schema.EnvJobFlavour], conf.Command)
+	template.Spec.Volumes = appendVolumeIfAbsent(template.Spec.Volumes,
+		generateVolume(conf.Env[schema.EnvJobFsID], conf.Env[schema.EnvJobPVCName]))
+	if template.Spec.RestartPolicy == "" || template.Spec.RestartPolicy == corev1.RestartPolicyAlways {
+		template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	return nil
+}
+
+func (batchJob *BatchJob) CreateJob(conf *models.Conf) (string, error) {
+	jobID := generateJobID(conf.Name)
+	log.Debugf("begin create job jobID:[%s]", jobID)
+
+	jobApp := &batchv1.Job{}
+	if err := createJobFromYaml(conf, jobApp); err != nil {
+		log.Errorf("create job failed, err %v", err)
+		return "", err
+	}
+
+	if err := patchBatchJobVariable(jobApp, jobID, conf); err != nil {
+		log.Errorf("patch batch job failed, err %v", err)
+		return "", err
+	}
+
+	job := &models.Job{
+		ID:        jobID,
+		Type:      conf.Env[schema.EnvJobType],
+		UserName:  conf.Env[schema.EnvJobUserName],
+		QueueName: conf.Env[schema.EnvJobQueueName],
+		Config:    *conf,
+	}
+	log.Debugf("begin submit job jobID:[%s] job:[%s]", jobID, config.PrettyFormat(job))
+	err := persistAndExecuteJob(job, func() error {
+		return submitter.JobExecutor.StartJob(job.QueueName, jobApp, k8s.BatchJobGVK)
+	})
+	if err != nil {
+		log.Errorf("create job %v failed, err %v", job, err)
+		return "", err
+	}
+	return jobID, nil
+}
+
+func (batchJob *BatchJob) StopJobByID(jobID string) error {
+	job, err := GetJobByID(jobID)
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.Env[schema.EnvJobNamespace]
+	if err = submitter.JobExecutor.StopJob(job.QueueName, namespace, job.ID, k8s.BatchJobGVK); err != nil {
+		log.Errorf("stop batch job %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
+
+// UpdateJobPriority is not supported, as pod template of kubernetes job is immutable
+func (batchJob *BatchJob) UpdateJobPriority(job *models.Job, priority string) error {
+	return errors.UnSupportedOperate(fmt.Sprintf("update priority of %s", schema.TypeBatchJob))
+}
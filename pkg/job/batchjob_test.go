@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/schema"
+)
+
+func TestPatchBatchJobVariable(t *testing.T) {
+	confEnv := make(map[string]string)
+	initConfigsForTest(confEnv)
+	confEnv[schema.EnvJobType] = string(schema.TypeBatchJob)
+
+	tests := []struct {
+		caseName       string
+		additionalEnv  map[string]string
+		expectReplicas int32
+	}{
+		{
+			caseName:       "default_replicas",
+			expectReplicas: 1,
+		},
+		{
+			caseName: "replicas_from_env",
+			additionalEnv: map[string]string{
+				schema.EnvJobReplicas: "3",
+			},
+			expectReplicas: 3,
+		},
+	}
+
+	for _, test := range tests {
+		for k, v := range test.additionalEnv {
+			confEnv[k] = v
+		}
+		conf := &models.Conf{
+			Env:     confEnv,
+			Command: "sleep 3600",
+			Image:   "test",
+		}
+
+		jobApp := &batchv1.Job{}
+		err := createJobFromYaml(conf, jobApp)
+		assert.NoError(t, err)
+
+		jobID := generateJobID(conf.Name)
+		err = patchBatchJobVariable(jobApp, jobID, conf)
+		assert.NoError(t, err)
+		t.Logf("case[%s] jobApp=%+v", test.caseName, *jobApp)
+
+		assert.Equal(t, jobID, jobApp.Name)
+		assert.Equal(t, "N1", jobApp.Namespace)
+		assert.Equal(t, schema.JobOwnerValue, jobApp.Labels[schema.JobOwnerLabel])
+		assert.Equal(t, jobID, jobApp.Spec.Template.Labels[schema.JobIDLabel])
+		assert.Equal(t, test.expectReplicas, *jobApp.Spec.Parallelism)
+		assert.Equal(t, test.expectReplicas, *jobApp.Spec.Completions)
+		assert.Equal(t, corev1.RestartPolicyNever, jobApp.Spec.Template.Spec.RestartPolicy)
+		assert.NotEmpty(t, jobApp.Spec.Template.Spec.Volumes)
+		assert.NotEmpty(t, jobApp.Spec.Template.Spec.Containers[0].VolumeMounts)
+	}
+}
@@ -22,24 +22,28 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var controllers = map[string]Controller{}
+// NewControllerFunc 每个集群需要一组独立的 controller 实例，因此注册的是 controller 的构造函数
+type NewControllerFunc func() Controller
 
-func RegisterController(ctrl Controller) error {
-	if ctrl == nil {
+var controllers = map[string]NewControllerFunc{}
+
+func RegisterController(name string, newFunc NewControllerFunc) error {
+	if newFunc == nil {
 		return fmt.Errorf("register controller input controller is nil")
 	}
-	if _, found := controllers[ctrl.Name()]; found {
-		log.Infof("job controller[%s] has alread registered", ctrl.Name())
+	if _, found := controllers[name]; found {
+		log.Infof("job controller[%s] has alread registered", name)
 	} else {
-		controllers[ctrl.Name()] = ctrl
+		controllers[name] = newFunc
 	}
-	log.Infof("register job controller[%s] succeed.", ctrl.Name())
+	log.Infof("register job controller[%s] succeed.", name)
 	return nil
 }
 
+// ForeachController create new instances of all registered controllers, and apply fn to them
 func ForeachController(fn func(controller Controller)) {
-	for _, ctrl := range controllers {
-		log.Infof("begin to init controller[%s]", ctrl.Name())
-		fn(ctrl)
+	for name, newFunc := range controllers {
+		log.Infof("begin to init controller[%s]", name)
+		fn(newFunc())
 	}
 }
@@ -22,6 +22,7 @@ import (
 )
 
 type ControllerOption struct {
+	ClusterName    string
 	DynamicClient  dynamic.Interface
 	DynamicFactory dynamicinformer.DynamicSharedInformerFactory
 }
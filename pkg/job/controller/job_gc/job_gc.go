@@ -35,7 +35,9 @@ import (
 )
 
 func init() {
-	err := framework.RegisterController(&JobGarbageCollector{})
+	err := framework.RegisterController("JobGarbageCollector", func() framework.Controller {
+		return &JobGarbageCollector{}
+	})
 	if err != nil {
 		log.Errorf("init JobSync failed. error:%s", err.Error())
 	}
@@ -51,6 +53,12 @@ type JobGarbageCollector struct {
 
 	sparkApplicationInformer cache.SharedIndexInformer
 	sparkApplicationLister   cache.GenericLister
+
+	batchJobInformer cache.SharedIndexInformer
+	batchJobLister   cache.GenericLister
+
+	paddleJobInformer cache.SharedIndexInformer
+	paddleJobLister   cache.GenericLister
 }
 
 func (j *JobGarbageCollector) Name() string {
@@ -82,16 +90,43 @@ func (j *JobGarbageCollector) Initialize(opt *framework.ControllerOption) error
 		})
 	}
 
+	batchJobGVR, err := k8s.GetGVRByGVK(k8s.BatchJobGVK)
+	if err != nil {
+		log.Warnf("cann't find GroupVersionKind [%s]", k8s.BatchJobGVK)
+	} else {
+		j.batchJobInformer = j.GetDynamicInformer(batchJobGVR)
+		j.batchJobLister = j.GetDynamicLister(batchJobGVR)
+		j.batchJobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: j.updateBatchJob,
+		})
+	}
+
+	paddleJobGVR, err := k8s.GetGVRByGVK(k8s.PaddleJobGVK)
+	if err != nil {
+		log.Warnf("cann't find GroupVersionKind [%s]", k8s.PaddleJobGVK)
+	} else {
+		j.paddleJobInformer = j.GetDynamicInformer(paddleJobGVR)
+		j.paddleJobLister = j.GetDynamicLister(paddleJobGVR)
+		j.paddleJobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: j.updatePaddleJob,
+		})
+	}
+
 	return nil
 }
 
 func (j *JobGarbageCollector) Run(stopCh <-chan struct{}) {
+	// shut down queue when controller of cluster is stopped, so that worker exits
+	go func() {
+		<-stopCh
+		j.WaitedCleanQueue.ShutDown()
+	}()
 	if !config.GlobalServerConfig.Job.Reclaim.CleanJob {
 		log.Infof("Skip %s controller!", j.Name())
 		return
 	}
 
-	log.Infof("Start %s controller!", j.Name())
+	log.Infof("Start %s controller of cluster[%s]!", j.Name(), j.opt.ClusterName)
 	go j.opt.DynamicFactory.Start(stopCh)
 
 	if j.sparkApplicationInformer != nil {
@@ -106,6 +141,18 @@ func (j *JobGarbageCollector) Run(stopCh <-chan struct{}) {
 			return
 		}
 	}
+	if j.batchJobInformer != nil {
+		if !cache.WaitForCacheSync(stopCh, j.batchJobInformer.HasSynced) {
+			runtime.HandleError(fmt.Errorf("timed out waiting for caches to job_sync"))
+			return
+		}
+	}
+	if j.paddleJobInformer != nil {
+		if !cache.WaitForCacheSync(stopCh, j.paddleJobInformer.HasSynced) {
+			runtime.HandleError(fmt.Errorf("timed out waiting for caches to job_sync"))
+			return
+		}
+	}
 	// clean exist & completed job
 	j.preCleanFinishedJob()
 	// watch job event to handle new job_gc events
@@ -144,8 +191,10 @@ func (j *JobGarbageCollector) processWorkItem() bool {
 		return true
 	}
 
+	// delete dependents in background, otherwise pods of batch job will be orphaned
+	propagationPolicy := metav1.DeletePropagationBackground
 	err = j.opt.DynamicClient.Resource(gvr).Namespace(info.Namespace).Delete(context.TODO(),
-		info.Name, metav1.DeleteOptions{})
+		info.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
 	if err != nil {
 		log.Errorf("clean [%s] job [%s/%s] failed, error：%v",
 			info.GVK, info.Namespace, info.Name, err.Error())
@@ -164,4 +213,10 @@ func (j *JobGarbageCollector) preCleanFinishedJob() {
 	if j.sparkApplicationLister != nil {
 		j.preCleanSparkApp()
 	}
+	if j.batchJobLister != nil {
+		j.preCleanBatchJob()
+	}
+	if j.paddleJobLister != nil {
+		j.preCleanPaddleJob()
+	}
 }
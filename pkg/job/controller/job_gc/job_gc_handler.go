@@ -21,6 +21,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -28,10 +29,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
 
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
 	sparkoperatorv1beta2 "paddleflow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
 	"paddleflow/pkg/common/config"
 	"paddleflow/pkg/common/k8s"
 	commonschema "paddleflow/pkg/common/schema"
+	pfjob "paddleflow/pkg/job"
 	"paddleflow/pkg/job/controller/framework"
 )
 
@@ -61,6 +64,32 @@ func (j *JobGarbageCollector) preCleanSparkApp() error {
 	return nil
 }
 
+func (j *JobGarbageCollector) preCleanBatchJob() error {
+	batchJobs, err := j.batchJobLister.List(labels.NewSelector())
+	if err != nil {
+		log.Errorf("list batch job with dynamic client failed: [%+v].", err)
+		return err
+	}
+	for _, job := range batchJobs {
+		batchJob := job.(*unstructured.Unstructured)
+		j.updateBatchJob(nil, batchJob)
+	}
+	return nil
+}
+
+func (j *JobGarbageCollector) preCleanPaddleJob() error {
+	paddleJobs, err := j.paddleJobLister.List(labels.NewSelector())
+	if err != nil {
+		log.Errorf("list paddle job with dynamic client failed: [%+v].", err)
+		return err
+	}
+	for _, job := range paddleJobs {
+		paddleJob := job.(*unstructured.Unstructured)
+		j.updatePaddleJob(nil, paddleJob)
+	}
+	return nil
+}
+
 func (j *JobGarbageCollector) updateVCJob(old, new interface{}) {
 	log.Infof("update VCJob")
 
@@ -90,6 +119,7 @@ func (j *JobGarbageCollector) updateVCJob(old, new interface{}) {
 				Name:            vcjob.Name,
 				Namespace:       vcjob.Namespace,
 				GVK:             k8s.VCJobGVK,
+				Status:          jobStatus,
 				OwnerReferences: vcjob.OwnerReferences,
 			}
 			j.finishedJobDelayEnqueue(finishedJob)
@@ -121,6 +151,7 @@ func (j *JobGarbageCollector) updateSparkApp(old, new interface{}) {
 				Name:            sparkApp.Name,
 				Namespace:       sparkApp.Namespace,
 				GVK:             k8s.SparkAppGVK,
+				Status:          jobStatus,
 				OwnerReferences: sparkApp.OwnerReferences,
 			}
 			j.finishedJobDelayEnqueue(finishedJob)
@@ -128,13 +159,88 @@ func (j *JobGarbageCollector) updateSparkApp(old, new interface{}) {
 	}
 }
 
+func (j *JobGarbageCollector) updateBatchJob(old, new interface{}) {
+	log.Infof("update BatchJob")
+	oldBatchJob := &batchv1.Job{}
+	if old != nil {
+		oldJob := old.(*unstructured.Unstructured)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(oldJob.Object, oldBatchJob); err != nil {
+			log.Errorf("convert unstructured object[%+v] to batch job failed: %v", old, err)
+			return
+		}
+	}
+	job := new.(*unstructured.Unstructured)
+	batchJob := &batchv1.Job{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(job.Object, batchJob); err != nil {
+		log.Errorf("convert unstructured object[%+v] to batch job failed: %v", new, err)
+		return
+	}
+	// only batch job created by paddleflow will be cleaned
+	if batchJob.Labels[commonschema.JobOwnerLabel] != commonschema.JobOwnerValue {
+		return
+	}
+
+	oldStatus, _ := commonschema.GetBatchJobStatus(oldBatchJob.Status)
+	jobStatus, _ := commonschema.GetBatchJobStatus(batchJob.Status)
+	if jobStatus != oldStatus && j.isCleanJob(jobStatus) {
+		finishedJob := FinishedJobInfo{
+			Name:            batchJob.Name,
+			Namespace:       batchJob.Namespace,
+			GVK:             k8s.BatchJobGVK,
+			Status:          jobStatus,
+			OwnerReferences: batchJob.OwnerReferences,
+		}
+		if batchJob.Status.CompletionTime != nil {
+			finishedJob.LastTransitionTime = *batchJob.Status.CompletionTime
+		}
+		j.finishedJobDelayEnqueue(finishedJob)
+	}
+}
+
+func (j *JobGarbageCollector) updatePaddleJob(old, new interface{}) {
+	log.Infof("update PaddleJob")
+	oldPaddleJob := &paddlev1.PaddleJob{}
+	if old != nil {
+		oldJob := old.(*unstructured.Unstructured)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(oldJob.Object, oldPaddleJob); err != nil {
+			log.Errorf("convert unstructured object[%+v] to paddle job failed: %v", old, err)
+			return
+		}
+	}
+	job := new.(*unstructured.Unstructured)
+	paddleJob := &paddlev1.PaddleJob{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(job.Object, paddleJob); err != nil {
+		log.Errorf("convert unstructured object[%+v] to paddle job failed: %v", new, err)
+		return
+	}
+	if paddleJob.Labels[commonschema.JobOwnerLabel] != commonschema.JobOwnerValue {
+		return
+	}
+
+	if paddleJob.Status.Phase != oldPaddleJob.Status.Phase && paddleJob.Status.Phase != "" {
+		log.Infof("update PaddleJob status=[%s]", paddleJob.Status.Phase)
+		jobStatus, _ := commonschema.GetPaddleJobStatus(paddleJob.Status.Phase)
+		if j.isCleanJob(jobStatus) {
+			finishedJob := FinishedJobInfo{
+				Name:            paddleJob.Name,
+				Namespace:       paddleJob.Namespace,
+				GVK:             k8s.PaddleJobGVK,
+				Status:          jobStatus,
+				OwnerReferences: paddleJob.OwnerReferences,
+			}
+			if paddleJob.Status.CompletionTime != nil {
+				finishedJob.LastTransitionTime = *paddleJob.Status.CompletionTime
+			}
+			j.finishedJobDelayEnqueue(finishedJob)
+		}
+	}
+}
+
+// isCleanJob check whether job is finished, and whether it should be cleaned is determined by reclaim policy of job
 func (j *JobGarbageCollector) isCleanJob(jobStatus commonschema.JobStatus) bool {
 	if !config.GlobalServerConfig.Job.Reclaim.CleanJob {
 		return false
 	}
-	if config.GlobalServerConfig.Job.Reclaim.SkipCleanFailedJob {
-		return commonschema.StatusJobSucceeded == jobStatus
-	}
 	return commonschema.StatusJobSucceeded == jobStatus || commonschema.StatusJobTerminated == jobStatus || commonschema.StatusJobFailed == jobStatus
 }
 
@@ -142,12 +248,27 @@ type FinishedJobInfo struct {
 	Name               string
 	Namespace          string
 	GVK                schema.GroupVersionKind
+	Status             commonschema.JobStatus
 	LastTransitionTime v1.Time
 	OwnerReferences    []v1.OwnerReference
 }
 
+// getCleanDuration get ttl of finished job from reclaim policy, name of job created by paddleflow is job id
+func (j *JobGarbageCollector) getCleanDuration(job FinishedJobInfo) (time.Duration, bool) {
+	policy := pfjob.GetReclaimPolicy(job.Name)
+	ttlSeconds, ok := policy.GetTTLSeconds(job.Status)
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(ttlSeconds) * time.Second, true
+}
+
 func (j *JobGarbageCollector) finishedJobDelayEnqueue(job FinishedJobInfo) {
-	duration := time.Duration(config.GlobalServerConfig.Job.Reclaim.JobTTLSeconds) * time.Second
+	duration, ok := j.getCleanDuration(job)
+	if !ok {
+		log.Infof("skip clean [%s] job [%s/%s] in status[%s] by reclaim policy", job.GVK, job.Namespace, job.Name, job.Status)
+		return
+	}
 	if !job.LastTransitionTime.IsZero() && time.Now().After(job.LastTransitionTime.Add(duration)) {
 		duration = 0
 	}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job_sync
+
+import (
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+func (j *JobSync) convertToBatchJobObj(obj interface{}) (*batchv1.Job, error) {
+	job := obj.(*unstructured.Unstructured)
+	batchJob := &batchv1.Job{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(job.Object, batchJob); err != nil {
+		log.Errorf("convert unstructured object[%#v] to batch job failed. error:[%s]", job, err.Error())
+		return nil, err
+	}
+	return batchJob, nil
+}
+
+func (j *JobSync) responsibleForBatchJob(obj interface{}) bool {
+	batchJob, err := j.convertToBatchJobObj(obj)
+	if err != nil {
+		log.Errorf("responsible for batch job skip job. error:[%s]", err.Error())
+		return false
+	}
+	if batchJob.Labels[commonschema.JobOwnerLabel] == commonschema.JobOwnerValue {
+		log.Debugf("responsible for batch job handle job. jobName:[%s]", batchJob.Name)
+		return true
+	}
+	log.Debugf("responsible for batch job skip job. jobName:[%s]", batchJob.Name)
+	return false
+}
+
+func (j *JobSync) addBatchJob(obj interface{}) {
+	batchJob, err := j.convertToBatchJobObj(obj)
+	if err != nil {
+		return
+	}
+
+	jobID := batchJob.Labels[commonschema.JobIDLabel]
+	log.Infof("add batch job. jobName:[%s] namespace:[%s] jobID:[%s]", batchJob.Name, batchJob.Namespace, jobID)
+
+	jobStatus, message := commonschema.GetBatchJobStatus(batchJob.Status)
+	jobInfo := &JobSyncInfo{
+		ID:      jobID,
+		Status:  jobStatus,
+		Runtime: obj,
+		Message: message,
+		Type:    commonschema.TypeBatchJob,
+		Action:  commonschema.Update,
+	}
+	j.jobQueue.Add(jobInfo)
+	if jobStatus == commonschema.StatusJobPending {
+		j.pendingJobDelayTerminate(jobID, batchJob.CreationTimestamp)
+	}
+}
+
+func (j *JobSync) updateBatchJob(oldObj, newObj interface{}) {
+	log.Info("update batch job")
+	oldBatchJob, err := j.convertToBatchJobObj(oldObj)
+	if err != nil {
+		return
+	}
+	newBatchJob, err := j.convertToBatchJobObj(newObj)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("update batch job. newJobName:[%s] namespace:[%s]", newBatchJob.Name, newBatchJob.Namespace)
+
+	oldStatus, _ := commonschema.GetBatchJobStatus(oldBatchJob.Status)
+	newStatus, message := commonschema.GetBatchJobStatus(newBatchJob.Status)
+	if oldBatchJob.ResourceVersion == newBatchJob.ResourceVersion && oldStatus == newStatus {
+		log.Debugf("skip update batch job. jobID:[%s] resourceVersion:[%s] status:[%s]",
+			newBatchJob.Name, newBatchJob.ResourceVersion, newStatus)
+		return
+	}
+
+	jobInfo := &JobSyncInfo{
+		ID:      newBatchJob.Labels[commonschema.JobIDLabel],
+		Status:  newStatus,
+		Runtime: newObj,
+		Message: message,
+		Type:    commonschema.TypeBatchJob,
+		Action:  commonschema.Update,
+	}
+	j.jobQueue.Add(jobInfo)
+	log.Infof("update batch job enqueue. jobID:[%s] status:[%s] message:[%s]",
+		jobInfo.ID, jobInfo.Status, jobInfo.Message)
+}
+
+func (j *JobSync) deleteBatchJob(obj interface{}) {
+	log.Info("delete batch job")
+	batchJob, err := j.convertToBatchJobObj(obj)
+	if err != nil {
+		return
+	}
+	jobStatus, message := commonschema.GetBatchJobStatus(batchJob.Status)
+	jobInfo := &JobSyncInfo{
+		ID:      batchJob.Labels[commonschema.JobIDLabel],
+		Status:  jobStatus,
+		Runtime: obj,
+		Message: message,
+		Type:    commonschema.TypeBatchJob,
+		Action:  commonschema.Delete,
+	}
+	j.jobQueue.Add(jobInfo)
+	log.Infof("delete batch job enqueue. jobID:[%s]", jobInfo.ID)
+}
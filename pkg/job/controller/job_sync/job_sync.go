@@ -19,8 +19,10 @@ package job_sync
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -45,7 +47,9 @@ type JobSyncInfo struct {
 }
 
 func init() {
-	err := framework.RegisterController(&JobSync{})
+	err := framework.RegisterController("JobSync", func() framework.Controller {
+		return &JobSync{}
+	})
 	if err != nil {
 		log.Errorf("init JobSync failed. error:%s", err.Error())
 	}
@@ -55,10 +59,14 @@ type JobSync struct {
 	sync.Mutex
 	opt                      *framework.ControllerOption
 	jobQueue                 workqueue.RateLimitingInterface
+	taskQueue                workqueue.RateLimitingInterface
 	vcjobInformer            cache.SharedIndexInformer
 	sparkApplicationInformer cache.SharedIndexInformer
+	batchJobInformer         cache.SharedIndexInformer
+	paddleJobInformer        cache.SharedIndexInformer
 	podInformer              cache.SharedIndexInformer
 	podLister                cache.GenericLister
+	eventInformer            cache.SharedIndexInformer
 }
 
 func (j *JobSync) Name() string {
@@ -96,6 +104,35 @@ func (j *JobSync) Initialize(opt *framework.ControllerOption) error {
 			},
 		})
 	}
+	batchJobGVR, err := k8s.GetGVRByGVK(k8s.BatchJobGVK)
+	if err != nil {
+		log.Warnf("cann't find GroupVersionKind [%s]", k8s.BatchJobGVK)
+	} else {
+		j.batchJobInformer = j.opt.DynamicFactory.ForResource(batchJobGVR).Informer()
+		j.batchJobInformer.AddEventHandler(cache.FilteringResourceEventHandler{
+			FilterFunc: j.responsibleForBatchJob,
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    j.addBatchJob,
+				UpdateFunc: j.updateBatchJob,
+				DeleteFunc: j.deleteBatchJob,
+			},
+		})
+	}
+
+	paddleJobGVR, err := k8s.GetGVRByGVK(k8s.PaddleJobGVK)
+	if err != nil {
+		log.Warnf("cann't find GroupVersionKind [%s]", k8s.PaddleJobGVK)
+	} else {
+		j.paddleJobInformer = j.opt.DynamicFactory.ForResource(paddleJobGVR).Informer()
+		j.paddleJobInformer.AddEventHandler(cache.FilteringResourceEventHandler{
+			FilterFunc: j.responsibleForPaddleJob,
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    j.addPaddleJob,
+				UpdateFunc: j.updatePaddleJob,
+				DeleteFunc: j.deletePaddleJob,
+			},
+		})
+	}
 
 	podGVR, err := k8s.GetGVRByGVK(k8s.PodGVK)
 	if err != nil {
@@ -103,15 +140,35 @@ func (j *JobSync) Initialize(opt *framework.ControllerOption) error {
 	}
 	j.podInformer = j.opt.DynamicFactory.ForResource(podGVR).Informer()
 	j.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    j.addPod,
 		UpdateFunc: j.updatePod,
+		DeleteFunc: j.deletePod,
 	})
 	j.podLister = j.opt.DynamicFactory.ForResource(podGVR).Lister()
+
+	eventGVR, err := k8s.GetGVRByGVK(k8s.EventGVK)
+	if err != nil {
+		log.Warnf("cann't find GroupVersionKind [%s]", k8s.EventGVK)
+	} else {
+		j.eventInformer = j.opt.DynamicFactory.ForResource(eventGVR).Informer()
+		j.eventInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    j.addEvent,
+			UpdateFunc: j.updateEvent,
+		})
+	}
 	j.jobQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	j.taskQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 	return nil
 }
 
 func (j *JobSync) Run(stopCh <-chan struct{}) {
-	log.Infof("Start %s controller!", j.Name())
+	log.Infof("Start %s controller of cluster[%s]!", j.Name(), j.opt.ClusterName)
+	// shut down queues when controller of cluster is stopped, so that workers exit
+	go func() {
+		<-stopCh
+		j.jobQueue.ShutDown()
+		j.taskQueue.ShutDown()
+	}()
 	go j.opt.DynamicFactory.Start(stopCh)
 
 	if j.sparkApplicationInformer != nil {
@@ -126,11 +183,30 @@ func (j *JobSync) Run(stopCh <-chan struct{}) {
 			return
 		}
 	}
+	if j.batchJobInformer != nil {
+		if !cache.WaitForCacheSync(stopCh, j.batchJobInformer.HasSynced) {
+			utilruntime.HandleError(fmt.Errorf("timed out waiting for caches to job_sync"))
+			return
+		}
+	}
+	if j.paddleJobInformer != nil {
+		if !cache.WaitForCacheSync(stopCh, j.paddleJobInformer.HasSynced) {
+			utilruntime.HandleError(fmt.Errorf("timed out waiting for caches to job_sync"))
+			return
+		}
+	}
 	if !cache.WaitForCacheSync(stopCh, j.podInformer.HasSynced) {
 		utilruntime.HandleError(fmt.Errorf("timed out waiting for caches to job_sync"))
 		return
 	}
+	if j.eventInformer != nil {
+		if !cache.WaitForCacheSync(stopCh, j.eventInformer.HasSynced) {
+			utilruntime.HandleError(fmt.Errorf("timed out waiting for caches to job_sync"))
+			return
+		}
+	}
 	go wait.Until(j.runWorker, 0, stopCh)
+	go wait.Until(j.runTaskWorker, 0, stopCh)
 }
 
 func (j *JobSync) runWorker() {
@@ -209,6 +285,34 @@ func (j *JobSync) doTerminateAction(jobSyncInfo *JobSyncInfo) error {
 	err = job.StopJobByID(jobSyncInfo.ID)
 	if err != nil {
 		log.Errorf("do terminate action failed. jobID[%s] error:[%s]", jobSyncInfo.ID, err.Error())
+		return err
+	}
+	// record the reason why job is terminated
+	if jobSyncInfo.Message != "" {
+		if _, err = job.UpdateJob(jobSyncInfo.ID, "", nil, jobSyncInfo.Message); err != nil {
+			log.Errorf("update message of terminated job failed. jobID[%s] error:[%s]", jobSyncInfo.ID, err.Error())
+		}
+	}
+	return nil
+}
+
+// pendingJobDelayTerminate terminate job if it is still pending when pending ttl of reclaim policy is exceeded
+func (j *JobSync) pendingJobDelayTerminate(jobID string, createTime metav1.Time) {
+	if jobID == "" {
+		return
+	}
+	pendingTTL, ok := job.GetReclaimPolicy(jobID).GetTTLSeconds(commonschema.StatusJobPending)
+	if !ok {
+		return
+	}
+	duration := time.Until(createTime.Add(time.Duration(pendingTTL) * time.Second))
+	if duration < 0 {
+		duration = 0
 	}
-	return err
+	log.Infof("job[%s] will be terminated after %v if it is still pending", jobID, duration)
+	j.jobQueue.AddAfter(&JobSyncInfo{
+		ID:      jobID,
+		Message: fmt.Sprintf("job is terminated as it has been pending for more than %d seconds", pendingTTL),
+		Action:  commonschema.Terminate,
+	}, duration)
 }
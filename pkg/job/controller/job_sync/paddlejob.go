@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job_sync
+
+import (
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+func (j *JobSync) convertToPaddleJobObj(obj interface{}) (*paddlev1.PaddleJob, error) {
+	job := obj.(*unstructured.Unstructured)
+	paddleJob := &paddlev1.PaddleJob{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(job.Object, paddleJob); err != nil {
+		log.Errorf("convert unstructured object[%#v] to paddle job failed. error:[%s]", job, err.Error())
+		return nil, err
+	}
+	return paddleJob, nil
+}
+
+func (j *JobSync) responsibleForPaddleJob(obj interface{}) bool {
+	paddleJob, err := j.convertToPaddleJobObj(obj)
+	if err != nil {
+		log.Errorf("responsible for paddle job skip job. error:[%s]", err.Error())
+		return false
+	}
+	if paddleJob.Labels[commonschema.JobOwnerLabel] == commonschema.JobOwnerValue {
+		log.Debugf("responsible for paddle job handle job. jobName:[%s]", paddleJob.Name)
+		return true
+	}
+	log.Debugf("responsible for paddle job skip job. jobName:[%s]", paddleJob.Name)
+	return false
+}
+
+func (j *JobSync) addPaddleJob(obj interface{}) {
+	paddleJob, err := j.convertToPaddleJobObj(obj)
+	if err != nil {
+		return
+	}
+
+	jobID := paddleJob.Labels[commonschema.JobIDLabel]
+	log.Infof("add paddle job. jobName:[%s] namespace:[%s] jobID:[%s]", paddleJob.Name, paddleJob.Namespace, jobID)
+
+	// phase of paddle job is empty before it is handled by paddle-operator
+	jobStatus, _ := commonschema.GetPaddleJobStatus(paddleJob.Status.Phase)
+	if jobStatus == "" {
+		jobStatus = commonschema.StatusJobPending
+	}
+	jobInfo := &JobSyncInfo{
+		ID:      jobID,
+		Status:  jobStatus,
+		Runtime: obj,
+		Type:    commonschema.TypePaddleJob,
+		Action:  commonschema.Update,
+	}
+	j.jobQueue.Add(jobInfo)
+	if jobStatus == commonschema.StatusJobPending {
+		j.pendingJobDelayTerminate(jobID, paddleJob.CreationTimestamp)
+	}
+}
+
+func (j *JobSync) updatePaddleJob(oldObj, newObj interface{}) {
+	log.Info("update paddle job")
+	oldPaddleJob, err := j.convertToPaddleJobObj(oldObj)
+	if err != nil {
+		return
+	}
+	newPaddleJob, err := j.convertToPaddleJobObj(newObj)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("update paddle job. newJobName:[%s] namespace:[%s]", newPaddleJob.Name, newPaddleJob.Namespace)
+
+	if oldPaddleJob.ResourceVersion == newPaddleJob.ResourceVersion &&
+		oldPaddleJob.Status.Phase == newPaddleJob.Status.Phase {
+		log.Debugf("skip update paddle job. jobID:[%s] resourceVersion:[%s] phase:[%s]",
+			newPaddleJob.Name, newPaddleJob.ResourceVersion, newPaddleJob.Status.Phase)
+		return
+	}
+
+	jobID := newPaddleJob.Labels[commonschema.JobIDLabel]
+	jobStatus, err := commonschema.GetPaddleJobStatus(newPaddleJob.Status.Phase)
+	if err != nil {
+		log.Errorf("update paddle job sync get job status failed. jobID:[%s] error:[%s]", jobID, err.Error())
+		return
+	}
+	jobInfo := &JobSyncInfo{
+		ID:      jobID,
+		Status:  jobStatus,
+		Runtime: newObj,
+		Type:    commonschema.TypePaddleJob,
+		Action:  commonschema.Update,
+	}
+	j.jobQueue.Add(jobInfo)
+	log.Infof("update paddle job enqueue. jobID:[%s] status:[%s]", jobInfo.ID, jobInfo.Status)
+}
+
+func (j *JobSync) deletePaddleJob(obj interface{}) {
+	log.Info("delete paddle job")
+	paddleJob, err := j.convertToPaddleJobObj(obj)
+	if err != nil {
+		return
+	}
+	jobID := paddleJob.Labels[commonschema.JobIDLabel]
+	jobStatus, err := commonschema.GetPaddleJobStatus(paddleJob.Status.Phase)
+	if err != nil {
+		log.Errorf("delete paddle job sync get job status failed. jobID:[%s] error:[%s]", jobID, err)
+		return
+	}
+	jobInfo := &JobSyncInfo{
+		ID:      jobID,
+		Status:  jobStatus,
+		Runtime: obj,
+		Type:    commonschema.TypePaddleJob,
+		Action:  commonschema.Delete,
+	}
+	j.jobQueue.Add(jobInfo)
+	log.Infof("delete paddle job enqueue. jobID:[%s]", jobInfo.ID)
+}
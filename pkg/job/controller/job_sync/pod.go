@@ -25,13 +25,11 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
-	"paddleflow/pkg/common/config"
 	commonschema "paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job"
 )
 
 const (
-	DefaultJobPendingTTLSeconds = 300
-
 	PodInitializing   = "PodInitializing"
 	ContainerCreating = "ContainerCreating"
 )
@@ -53,12 +51,22 @@ func (j *JobSync) isValidWaitingState(s *v1.ContainerStateWaiting) bool {
 	return false
 }
 
+// getPodJobID returns the paddleflow job id of pods created by vcjob, batch job or paddle job
+func (j *JobSync) getPodJobID(pod *v1.Pod) (string, bool) {
+	if jobName, ok := pod.Labels[commonschema.VolcanoJobNameLabel]; ok {
+		return jobName, true
+	}
+	// pods of batch job and paddle job inherit the job id label from pod template
+	jobID, ok := pod.Labels[commonschema.JobIDLabel]
+	return jobID, ok
+}
+
 func (j *JobSync) updatePod(oldObj, newObj interface{}) {
 	oldPod, err := j.convertToPodObj(oldObj)
 	if err != nil {
 		return
 	}
-	_, ok := oldPod.Labels[commonschema.VolcanoJobNameLabel]
+	_, ok := j.getPodJobID(oldPod)
 	if !ok {
 		return
 	}
@@ -67,10 +75,12 @@ func (j *JobSync) updatePod(oldObj, newObj interface{}) {
 	if err != nil {
 		return
 	}
-	jobName, ok := newPod.Labels[commonschema.VolcanoJobNameLabel]
+	jobName, ok := j.getPodJobID(newPod)
 	if !ok {
 		return
 	}
+	j.updateTask(jobName, oldPod, newPod)
+
 	if newPod.Status.Phase != v1.PodPending {
 		return
 	}
@@ -106,13 +116,15 @@ func (j *JobSync) updatePod(oldObj, newObj interface{}) {
 	if isValidWaitingState {
 		return
 	}
-	terminateJobInfo := &JobSyncInfo{
-		ID:     jobName,
-		Action: commonschema.Terminate,
+	// pods which cannot be started are killed after pending ttl, unless it is disabled by reclaim policy
+	terminateDuration, ok := job.GetPodPendingTTLSeconds(jobName)
+	if !ok {
+		return
 	}
-	terminateDuration := DefaultJobPendingTTLSeconds
-	if config.GlobalServerConfig.Job.Reclaim.JobPendingTTLSeconds > 0 {
-		terminateDuration = config.GlobalServerConfig.Job.Reclaim.JobPendingTTLSeconds
+	terminateJobInfo := &JobSyncInfo{
+		ID:      jobName,
+		Message: fmt.Sprintf("job is terminated as pod %s cannot be started in %d seconds, %s", newPod.Name, terminateDuration, message),
+		Action:  commonschema.Terminate,
 	}
 	log.Infof("terminate job. namespace:[%s] jobName:[%s]", newPod.Namespace, jobName)
 	j.jobQueue.AddAfter(terminateJobInfo, time.Duration(terminateDuration)*time.Second)
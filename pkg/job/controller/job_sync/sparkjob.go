@@ -72,6 +72,9 @@ func (j *JobSync) addSparkJob(obj interface{}) {
 		Action:  commonschema.Update,
 	}
 	j.jobQueue.Add(jobInfo)
+	if jobStatus == commonschema.StatusJobPending {
+		j.pendingJobDelayTerminate(jobID, sparkjob.CreationTimestamp)
+	}
 }
 
 func (j *JobSync) updateSparkJob(oldObj, newObj interface{}) {
@@ -0,0 +1,273 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job_sync
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+const (
+	// SparkRoleLabel is added to driver and executor pods by spark-operator
+	SparkRoleLabel = "spark-role"
+
+	ContainerStateWaiting    = "waiting"
+	ContainerStateRunning    = "running"
+	ContainerStateTerminated = "terminated"
+)
+
+// TaskSyncInfo status of task is updated if Event is nil, otherwise Event is added to task
+type TaskSyncInfo struct {
+	Task       *models.JobTask
+	Event      *models.TaskEvent
+	Action     commonschema.ActionOnJob
+	RetryTimes int
+}
+
+func (j *JobSync) addPod(obj interface{}) {
+	pod, err := j.convertToPodObj(obj)
+	if err != nil {
+		return
+	}
+	jobID, ok := j.getPodJobID(pod)
+	if !ok {
+		return
+	}
+	j.taskQueue.Add(&TaskSyncInfo{
+		Task:   buildJobTask(jobID, pod),
+		Action: commonschema.Update,
+	})
+}
+
+// updateTask enqueue status of task only when it is changed
+func (j *JobSync) updateTask(jobID string, oldPod, newPod *v1.Pod) {
+	oldTask, newTask := buildJobTask(jobID, oldPod), buildJobTask(jobID, newPod)
+	if !isTaskStatusChanged(oldTask, newTask) {
+		return
+	}
+	log.Debugf("update task. jobID:[%s] podName:[%s] phase:[%s]", jobID, newPod.Name, newTask.Phase)
+	j.taskQueue.Add(&TaskSyncInfo{
+		Task:   newTask,
+		Action: commonschema.Update,
+	})
+}
+
+func (j *JobSync) deletePod(obj interface{}) {
+	if deleted, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = deleted.Obj
+	}
+	if _, ok := obj.(*unstructured.Unstructured); !ok {
+		return
+	}
+	pod, err := j.convertToPodObj(obj)
+	if err != nil {
+		return
+	}
+	jobID, ok := j.getPodJobID(pod)
+	if !ok {
+		return
+	}
+	task := buildJobTask(jobID, pod)
+	task.Deleted = true
+	j.taskQueue.Add(&TaskSyncInfo{
+		Task:   task,
+		Action: commonschema.Delete,
+	})
+}
+
+func (j *JobSync) convertToEventObj(obj interface{}) (*v1.Event, error) {
+	unstructuredEvent := obj.(*unstructured.Unstructured)
+	event := &v1.Event{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredEvent.Object, event); err != nil {
+		log.Errorf("convert unstructured object[%#v] to event failed. error:[%s]", unstructuredEvent, err.Error())
+		return nil, err
+	}
+	return event, nil
+}
+
+// addEvent add event of pods which belong to paddleflow job
+func (j *JobSync) addEvent(obj interface{}) {
+	event, err := j.convertToEventObj(obj)
+	if err != nil {
+		return
+	}
+	if event.InvolvedObject.Kind != "Pod" {
+		return
+	}
+	podObj, err := j.podLister.ByNamespace(event.InvolvedObject.Namespace).Get(event.InvolvedObject.Name)
+	if err != nil {
+		log.Debugf("skip event[%s], pod[%s/%s] not found", event.Name,
+			event.InvolvedObject.Namespace, event.InvolvedObject.Name)
+		return
+	}
+	pod, err := j.convertToPodObj(podObj)
+	if err != nil {
+		return
+	}
+	jobID, ok := j.getPodJobID(pod)
+	if !ok {
+		return
+	}
+	lastTimestamp := event.LastTimestamp.Time
+	if lastTimestamp.IsZero() {
+		lastTimestamp = event.EventTime.Time
+	}
+	j.taskQueue.Add(&TaskSyncInfo{
+		Task: buildJobTask(jobID, pod),
+		Event: &models.TaskEvent{
+			Name:           event.Name,
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Count:          event.Count,
+			FirstTimestamp: event.FirstTimestamp.Time,
+			LastTimestamp:  lastTimestamp,
+		},
+		Action: commonschema.Update,
+	})
+}
+
+func (j *JobSync) updateEvent(oldObj, newObj interface{}) {
+	j.addEvent(newObj)
+}
+
+func (j *JobSync) runTaskWorker() {
+	for j.processTaskItem() {
+	}
+}
+
+func (j *JobSync) processTaskItem() bool {
+	obj, shutdown := j.taskQueue.Get()
+	if shutdown {
+		return false
+	}
+	taskSyncInfo := obj.(*TaskSyncInfo)
+	defer j.taskQueue.Done(taskSyncInfo)
+
+	if err := j.syncTask(taskSyncInfo); err != nil {
+		log.Errorf("sync task failed. taskID:[%s] jobID:[%s] err:[%s]",
+			taskSyncInfo.Task.ID, taskSyncInfo.Task.JobID, err.Error())
+		if taskSyncInfo.RetryTimes < DefaultSyncRetryTimes {
+			taskSyncInfo.RetryTimes += 1
+			j.taskQueue.AddRateLimited(taskSyncInfo)
+		}
+	}
+	j.taskQueue.Forget(taskSyncInfo)
+	return true
+}
+
+func (j *JobSync) syncTask(taskSyncInfo *TaskSyncInfo) error {
+	logEntry := logger.LoggerForJob(taskSyncInfo.Task.JobID)
+	if taskSyncInfo.Event == nil {
+		return models.UpdateTask(logEntry, taskSyncInfo.Task)
+	}
+	// the event is added to persisted task, task from pod is used if task is not persisted yet
+	task, err := models.GetTaskByID(logEntry, taskSyncInfo.Task.ID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		task = *taskSyncInfo.Task
+	}
+	task.AddEvent(*taskSyncInfo.Event)
+	return models.UpdateTask(logEntry, &task)
+}
+
+// buildJobTask build task from pod, only status of pod and containers are filled
+func buildJobTask(jobID string, pod *v1.Pod) *models.JobTask {
+	task := &models.JobTask{
+		ID:                string(pod.UID),
+		JobID:             jobID,
+		Namespace:         pod.Namespace,
+		Name:              pod.Name,
+		Phase:             string(pod.Status.Phase),
+		NodeName:          pod.Spec.NodeName,
+		Reason:            pod.Status.Reason,
+		Message:           pod.Status.Message,
+		ContainerStatuses: []models.TaskContainerStatus{},
+	}
+	if taskName, ok := pod.Annotations[batchv1alpha1.TaskSpecKey]; ok {
+		// name of vcjob pod is ${jobName}-${taskName}-${index}
+		task.MemberRole = taskName
+		if i := strings.LastIndex(pod.Name, "-"); i >= 0 {
+			task.ReplicaIndex, _ = strconv.Atoi(pod.Name[i+1:])
+		}
+	} else if role, ok := pod.Labels[SparkRoleLabel]; ok {
+		task.MemberRole = role
+	}
+
+	statuses := append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		containerStatus := models.TaskContainerStatus{
+			Name:         status.Name,
+			Ready:        status.Ready,
+			RestartCount: status.RestartCount,
+		}
+		switch {
+		case status.State.Waiting != nil:
+			containerStatus.State = ContainerStateWaiting
+			containerStatus.Reason = status.State.Waiting.Reason
+			containerStatus.Message = status.State.Waiting.Message
+		case status.State.Terminated != nil:
+			exitCode := status.State.Terminated.ExitCode
+			containerStatus.State = ContainerStateTerminated
+			containerStatus.ExitCode = &exitCode
+			containerStatus.Reason = status.State.Terminated.Reason
+			containerStatus.Message = status.State.Terminated.Message
+		case status.State.Running != nil:
+			containerStatus.State = ContainerStateRunning
+		}
+		task.RestartCount += status.RestartCount
+		task.ContainerStatuses = append(task.ContainerStatuses, containerStatus)
+
+		// the first abnormal container explains the status of pod
+		if task.Reason == "" && containerStatus.Reason != "" &&
+			(containerStatus.State == ContainerStateWaiting || *containerStatus.ExitCode != 0) {
+			task.Reason = containerStatus.Reason
+			task.Message = containerStatus.Message
+		}
+		if containerStatus.ExitCode != nil && (task.ExitCode == nil || *task.ExitCode == 0) {
+			task.ExitCode = containerStatus.ExitCode
+		}
+	}
+	return task
+}
+
+func isTaskStatusChanged(oldTask, newTask *models.JobTask) bool {
+	return oldTask.ID != newTask.ID ||
+		oldTask.Phase != newTask.Phase ||
+		oldTask.NodeName != newTask.NodeName ||
+		oldTask.RestartCount != newTask.RestartCount ||
+		oldTask.Reason != newTask.Reason ||
+		oldTask.Message != newTask.Message ||
+		!reflect.DeepEqual(oldTask.ExitCode, newTask.ExitCode) ||
+		!reflect.DeepEqual(oldTask.ContainerStatuses, newTask.ContainerStatuses)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job_sync
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1alpha1 "volcano.sh/apis/pkg/apis/batch/v1alpha1"
+
+	"paddleflow/pkg/apiserver/models"
+)
+
+func TestBuildJobTask(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "job-000001-worker-3",
+			Namespace:   "default",
+			UID:         "uid-1",
+			Annotations: map[string]string{batchv1alpha1.TaskSpecKey: "worker"},
+		},
+		Spec: v1.PodSpec{NodeName: "node-1"},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:         "sidecar",
+					RestartCount: 1,
+					State:        v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				},
+				{
+					Name:         "main",
+					RestartCount: 2,
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+						ExitCode: 137,
+						Reason:   "OOMKilled",
+					}},
+				},
+			},
+		},
+	}
+
+	task := buildJobTask("job-000001", pod)
+	assert.Equal(t, "uid-1", task.ID)
+	assert.Equal(t, "job-000001", task.JobID)
+	assert.Equal(t, "worker", task.MemberRole)
+	assert.Equal(t, 3, task.ReplicaIndex)
+	assert.Equal(t, "node-1", task.NodeName)
+	assert.Equal(t, int32(3), task.RestartCount)
+	assert.Equal(t, int32(137), *task.ExitCode)
+	assert.Equal(t, "OOMKilled", task.Reason)
+	assert.Equal(t, 2, len(task.ContainerStatuses))
+	assert.Equal(t, ContainerStateRunning, task.ContainerStatuses[0].State)
+	assert.Equal(t, ContainerStateTerminated, task.ContainerStatuses[1].State)
+
+	// status is not changed if pod is not changed
+	assert.False(t, isTaskStatusChanged(task, buildJobTask("job-000001", pod)))
+	pod.Status.ContainerStatuses[0].RestartCount = 2
+	assert.True(t, isTaskStatusChanged(task, buildJobTask("job-000001", pod)))
+}
+
+func TestTaskAddEvent(t *testing.T) {
+	task := &models.JobTask{}
+	for i := 0; i < models.MaxTaskEvents+2; i++ {
+		task.AddEvent(models.TaskEvent{Name: fmt.Sprintf("event-%d", i), Count: 1})
+	}
+	assert.Equal(t, models.MaxTaskEvents, len(task.Events))
+	assert.Equal(t, "event-2", task.Events[0].Name)
+
+	// event with same name is refreshed and moved to the end
+	task.AddEvent(models.TaskEvent{Name: "event-2", Count: 2})
+	assert.Equal(t, models.MaxTaskEvents, len(task.Events))
+	assert.Equal(t, "event-3", task.Events[0].Name)
+	assert.Equal(t, int32(2), task.Events[models.MaxTaskEvents-1].Count)
+}
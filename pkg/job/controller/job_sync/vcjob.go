@@ -72,6 +72,9 @@ func (j *JobSync) addVCJob(obj interface{}) {
 		Action:  commonschema.Update,
 	}
 	j.jobQueue.Add(jobInfo)
+	if jobStatus == commonschema.StatusJobPending {
+		j.pendingJobDelayTerminate(jobID, vcjob.CreationTimestamp)
+	}
 }
 
 func (j *JobSync) updateVCJob(oldObj, newObj interface{}) {
@@ -17,26 +17,101 @@ limitations under the License.
 package controller
 
 import (
+	"time"
+
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/rest"
+
+	"paddleflow/pkg/apiserver/controller/cluster"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
 	framework2 "paddleflow/pkg/job/controller/framework"
 	_ "paddleflow/pkg/job/controller/job_gc"
 	_ "paddleflow/pkg/job/controller/job_sync"
 )
 
+// DefaultClusterName is the name of cluster which server runs on
+const DefaultClusterName = "default"
+
+// ClusterSyncPeriod is the period of checking registered clusters
+var ClusterSyncPeriod = 30 * time.Second
+
+type clusterControllers struct {
+	credential string
+	stopCh     chan struct{}
+}
+
+// Run start job controllers for the cluster which server runs on, and for each online registered cluster.
+// controllers of cluster are restarted if its credential changed, and stopped if it becomes offline or deleted
 func Run(config *rest.Config, stopCh <-chan struct{}) error {
+	if err := runControllers(DefaultClusterName, config, stopCh); err != nil {
+		return err
+	}
+	running := map[string]*clusterControllers{}
+	wait.Until(func() {
+		syncClusters(running, stopCh)
+	}, ClusterSyncPeriod, stopCh)
+	for _, cc := range running {
+		close(cc.stopCh)
+	}
+	return nil
+}
+
+func syncClusters(running map[string]*clusterControllers, stopCh <-chan struct{}) {
+	clusterList, err := models.ListCluster(&logger.RequestContext{}, 0, 0, nil, models.ClusterStatusOnLine)
+	if err != nil {
+		log.Errorf("list online clusters failed. error:%s", err.Error())
+		return
+	}
+	online := map[string]models.ClusterInfo{}
+	for _, clusterInfo := range clusterList {
+		online[clusterInfo.Name] = clusterInfo
+	}
+	// stop controllers of offline, deleted or credential changed clusters
+	for name, cc := range running {
+		if clusterInfo, ok := online[name]; !ok || clusterInfo.Credential != cc.credential {
+			log.Infof("stop job controllers of cluster[%s]", name)
+			close(cc.stopCh)
+			delete(running, name)
+		}
+	}
+	for name, clusterInfo := range online {
+		if _, ok := running[name]; ok {
+			continue
+		}
+		config, err := cluster.GetKubeConfig(clusterInfo.Name, clusterInfo.Credential)
+		if err != nil {
+			log.Errorf("get config of cluster[%s] failed. error:%s", name, err.Error())
+			continue
+		}
+		clusterStopCh := make(chan struct{})
+		if err := runControllers(name, config, clusterStopCh); err != nil {
+			log.Errorf("run job controllers of cluster[%s] failed. error:%s", name, err.Error())
+			close(clusterStopCh)
+			continue
+		}
+		running[name] = &clusterControllers{
+			credential: clusterInfo.Credential,
+			stopCh:     clusterStopCh,
+		}
+	}
+}
+
+func runControllers(clusterName string, config *rest.Config, stopCh <-chan struct{}) error {
+	log.Infof("run job controllers of cluster[%s]", clusterName)
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		log.Errorf("Init dynamic client failed. error:%s", err.Error())
 		return err
 	}
 	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
-	controllerOpt := framework2.ControllerOption{DynamicClient: dynamicClient, DynamicFactory: factory}
+	controllerOpt := framework2.ControllerOption{ClusterName: clusterName, DynamicClient: dynamicClient, DynamicFactory: factory}
 	framework2.ForeachController(func(c framework2.Controller) {
 		if err := c.Initialize(&controllerOpt); err != nil {
-			log.Errorf("Failed to initialize controller <%s>: %v", c.Name(), err)
+			log.Errorf("Failed to initialize controller <%s> of cluster[%s]: %v", c.Name(), clusterName, err)
 			return
 		}
 		go c.Run(stopCh)
@@ -33,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
 
+	sparkapp "paddleflow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
 	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/apiserver/handler"
 	"paddleflow/pkg/apiserver/models"
@@ -50,22 +51,27 @@ import (
 type Interface interface {
 	CreateJob(conf *models.Conf) (string, error)
 	StopJobByID(jobID string) error
+	UpdateJobPriority(job *models.Job, priority string) error
 }
 
 var JobMap = map[schema.JobType]Interface{
-	schema.TypeVcJob:    &VCJob{},
-	schema.TypeSparkJob: &SparkJob{},
+	schema.TypeVcJob:     &VCJob{},
+	schema.TypeSparkJob:  &SparkJob{},
+	schema.TypeBatchJob:  &BatchJob{},
+	schema.TypePaddleJob: &PaddleJob{},
 }
 
 // defaultJobContent indicate bytes of default job from template yaml file for special jobType
 var defaultJobContent = map[schema.JobType]func(conf *models.Conf) string{
-	schema.TypeVcJob:    getVCJobFromDefaultPath,
-	schema.TypeSparkJob: getSparkJobYamlPath,
+	schema.TypeVcJob:     getVCJobFromDefaultPath,
+	schema.TypeSparkJob:  getSparkJobYamlPath,
+	schema.TypeBatchJob:  getBatchJobYamlPath,
+	schema.TypePaddleJob: getPaddleJobYamlPath,
 }
 
 func CreateJob(conf *models.Conf) (string, error) {
 	if err := ValidateJob(conf); err != nil {
-		return "", err
+		return "", errors.InvalidJobConfError(err)
 	}
 	if err := checkResource(conf); err != nil {
 		return "", err
@@ -100,6 +106,14 @@ func ValidateJob(conf *models.Conf) error {
 	if fsID, found := conf.Env[schema.EnvJobFsID]; !found || len(fsID) == 0 {
 		return errors.EmptyFSIDError()
 	}
+	if _, err := parseJobReclaimPolicy(conf.Env); err != nil {
+		return err
+	}
+	if priority, found := conf.Env[schema.EnvJobPriority]; found {
+		if err := ValidateJobPriority(priority); err != nil {
+			return err
+		}
+	}
 
 	if jobType, found := conf.Env[schema.EnvJobType]; !found {
 		return errors.EmptyJobTypeError()
@@ -121,6 +135,21 @@ func ValidateJob(conf *models.Conf) error {
 			}
 		} else if jobType == string(schema.TypeSparkJob) {
 			err = validateSparkMode(conf)
+		} else if jobType == string(schema.TypeBatchJob) {
+			// batch job runs pods like vcjob in pod mode
+			err = validatePodMode(conf)
+		} else if jobType == string(schema.TypePaddleJob) {
+			// paddle job only supports ps and collective mode of paddle-operator
+			switch mode := conf.Env[schema.EnvJobMode]; mode {
+			case schema.EnvJobModePS:
+				err = validatePSMode(conf)
+			case schema.EnvJobModeCollective:
+				err = validateCollectiveMode(conf)
+			case "":
+				return errors.EmptyJobModeError()
+			default:
+				return errors.InvalidJobModeError(mode)
+			}
 		} else {
 			return errors.InvalidJobTypeError(jobType)
 		}
@@ -133,11 +162,16 @@ func ValidateJob(conf *models.Conf) error {
 }
 
 func checkResource(conf *models.Conf) error {
-	var namespace string
-	var err error
-	if namespace, err = getNamespaceByQueueName(conf.Env[schema.EnvJobQueueName]); err != nil {
+	queueName := conf.Env[schema.EnvJobQueueName]
+	queue, err := models.GetQueueByName(&logger.RequestContext{}, queueName)
+	if err != nil {
+		log.Errorf("get queue %s failed, err %v", queueName, err)
+		return fmt.Errorf("queueName[%s] is not exist\n", queueName)
+	}
+	if err := checkQueueQuota(conf, &queue); err != nil {
 		return err
 	}
+	namespace := queue.Namespace
 	conf.Env[schema.EnvJobNamespace] = namespace
 
 	fsID, _ := conf.Env[schema.EnvJobFsID]
@@ -148,12 +182,8 @@ func checkResource(conf *models.Conf) error {
 		conf.Env[schema.EnvJobPVCName] = pvcName
 	}
 
-	if priority, found := conf.Env[schema.EnvJobPriority]; !found {
+	if _, found := conf.Env[schema.EnvJobPriority]; !found {
 		conf.Env[schema.EnvJobPriority] = schema.EnvJobNormalPriority
-	} else {
-		if priority != schema.EnvJobLowPriority && priority != schema.EnvJobNormalPriority && priority != schema.EnvJobHighPriority {
-			return errors.InvalidJobPriorityError(priority)
-		}
 	}
 
 	return nil
@@ -250,6 +280,26 @@ func validateSparkMode(conf *models.Conf) error {
 			return err
 		}
 	}
+	if appType, found := conf.Env[schema.EnvJobSparkAppType]; found {
+		switch sparkapp.SparkApplicationType(appType) {
+		case sparkapp.JavaApplicationType, sparkapp.ScalaApplicationType:
+			if mainClass := conf.Env[schema.EnvJobSparkMainClass]; len(mainClass) == 0 {
+				return errors.EmptySparkMainClassError(appType)
+			}
+		case sparkapp.PythonApplicationType, sparkapp.RApplicationType:
+		default:
+			return errors.InvalidSparkAppTypeError(appType)
+		}
+	}
+	if version, found := conf.Env[schema.EnvJobSparkPythonVersion]; found && version != "2" && version != "3" {
+		return errors.InvalidSparkPythonVersionError(version)
+	}
+	if _, err := parseSparkConf(conf.Env[schema.EnvJobSparkConf]); err != nil {
+		return err
+	}
+	if _, err := getSparkDynamicAllocation(conf); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -313,7 +363,8 @@ func DeleteJobByID(jobID string) error {
 		logger.LoggerForJob(jobID).Errorf("delete job failed, err %v", tx.Error)
 		return tx.Error
 	}
-	return nil
+	// tasks are useless after job is deleted
+	return models.DeleteTaskByJobID(logger.LoggerForJob(jobID), jobID)
 }
 
 func IsImmutableJobStatus(status schema.JobStatus) bool {
@@ -329,15 +380,6 @@ func generateJobID(param string) string {
 	return uuid.GenerateID(fmt.Sprintf("%s-%s", schema.JobPrefix, param))
 }
 
-func getNamespaceByQueueName(queueName string) (string, error) {
-	queue, err := models.GetQueueByName(&logger.RequestContext{}, queueName)
-	if err != nil {
-		log.Errorf("get queue %s failed, err %v", queueName, err)
-		return "", fmt.Errorf("queueName[%s] is not exist\n", queueName)
-	}
-	return queue.Namespace, nil
-}
-
 func persistAndExecuteJob(job *models.Job, executeFunc func() error) error {
 	return database.DB.Table("job").Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(job).Error; err != nil {
@@ -451,9 +493,12 @@ func getPriorityClass(priority string) string {
 	return schema.PriorityClassNormal
 }
 
-// getJobYamlContent get job from yaml path
+// getJobYamlContent get job from yaml path, or from job template registered by api
 func getJobYamlContent(conf *models.Conf) ([]byte, error) {
 	yamlFilePath, found := conf.Env[schema.EnvJobYamlPath]
+	if !found && conf.Env[schema.EnvJobTemplate] != "" {
+		return getJobTemplateContent(conf)
+	}
 	if !found {
 		// get job from template
 		jobType := schema.JobType(conf.Env[schema.EnvJobType])
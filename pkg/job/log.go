@@ -0,0 +1,63 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"context"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job/submitter"
+)
+
+// fillJobLogRequest namespace and type of job are determined by job, not by request
+func fillJobLogRequest(jobInfo models.Job, request *schema.JobLogRequest) {
+	request.JobID = jobInfo.ID
+	request.JobType = jobInfo.Type
+	request.Namespace = jobInfo.Config.Env[schema.EnvJobNamespace]
+	if request.PageNo <= 0 {
+		request.PageNo = schema.DefaultLogPageNo
+	}
+	if request.LineLimit <= 0 {
+		request.LineLimit = schema.DefaultLogLineLimit
+	}
+}
+
+// GetJobLog get logs of job from the cluster which job runs on
+func GetJobLog(jobInfo models.Job, request schema.JobLogRequest) (schema.JobLogInfo, error) {
+	fillJobLogRequest(jobInfo, &request)
+	jobLogInfo, err := submitter.JobExecutor.GetJobLog(jobInfo.QueueName, request)
+	if err != nil {
+		log.Errorf("get log of job[%s] failed, err %v", jobInfo.ID, err)
+		return schema.JobLogInfo{}, err
+	}
+	return jobLogInfo, nil
+}
+
+// GetJobLogStream follow log of one container of job until ctx is done, caller should close the returned stream
+func GetJobLogStream(ctx context.Context, jobInfo models.Job, request schema.JobLogRequest) (io.ReadCloser, error) {
+	fillJobLogRequest(jobInfo, &request)
+	stream, err := submitter.JobExecutor.GetJobLogStream(ctx, jobInfo.QueueName, request)
+	if err != nil {
+		log.Errorf("get log stream of job[%s] failed, err %v", jobInfo.ID, err)
+		return nil, err
+	}
+	return stream, nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/k8s"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job/submitter"
+)
+
+// PaddleJob is backed by PaddleJob crd of paddle-operator, which supports ps and collective mode natively
+type PaddleJob struct {
+}
+
+// getPaddleJobYamlPath get job yaml default path
+// if EnvJobYamlPath not exist, default path would be the following
+// "$DefaultYamlParentDir/paddlejob_ps.yaml"
+// "$DefaultYamlParentDir/paddlejob_collective.yaml"
+func getPaddleJobYamlPath(conf *models.Conf) string {
+	jobType := conf.Env[schema.EnvJobType]
+	return fmt.Sprintf("%s/%s_%s.yaml", config.GlobalServerConfig.Job.DefaultJobYamlDir, jobType, strings.ToLower(conf.Env[schema.EnvJobMode]))
+}
+
+// patchPaddleJobVariable patch env variable to paddleJob, the order of patches following paddleJob crd
+func patchPaddleJobVariable(jobApp *paddlev1.PaddleJob, jobID string, conf *models.Conf) error {
+	jobApp.Name = jobID
+	// metadata
+	if namespace, exist := conf.Env[schema.EnvJobNamespace]; exist {
+		jobApp.Namespace = namespace
+	}
+	if jobApp.Labels == nil {
+		jobApp.Labels = map[string]string{}
+	}
+	jobApp.Labels[schema.JobOwnerLabel] = schema.JobOwnerValue
+	jobApp.Labels[schema.JobIDLabel] = jobID
+
+	// scheduling policy
+	if queueName, exist := conf.Env[schema.EnvJobQueueName]; exist {
+		if jobApp.Spec.SchedulingPolicy == nil {
+			jobApp.Spec.SchedulingPolicy = &paddlev1.SchedulingPolicy{}
+		}
+		jobApp.Spec.SchedulingPolicy.Queue = queueName
+		jobApp.Spec.SchedulingPolicy.PriorityClass = getPriorityClass(conf.Env[schema.EnvJobPriority])
+	}
+
+	var err error
+	switch conf.Env[schema.EnvJobMode] {
+	case schema.EnvJobModePS:
+		err = fillPaddleJobPSSpec(jobApp, conf)
+	case schema.EnvJobModeCollective:
+		err = fillPaddleJobCollectiveSpec(jobApp, conf)
+	default:
+		err = fmt.Errorf("mode [%s] is not supported by paddle job", conf.Env[schema.EnvJobMode])
+	}
+	if err != nil {
+		log.Errorf("patchPaddleJobVariable failed, err=[%v]", err)
+		return err
+	}
+	return nil
+}
+
+func fillPaddleJobPSSpec(jobApp *paddlev1.PaddleJob, conf *models.Conf) error {
+	if jobApp.Spec.PS == nil || jobApp.Spec.Worker == nil {
+		return fmt.Errorf("paddle job[%s] in ps mode must contain ps and worker", jobApp.Name)
+	}
+	// ps server
+	fillPaddleJobResourceSpec(jobApp.Spec.PS, conf, jobApp.Name, conf.Env[schema.EnvJobPServerReplicas],
+		conf.Env[schema.EnvJobPServerFlavour], conf.Env[schema.EnvJobPServerCommand], defaultPSReplicas)
+	// worker
+	fillPaddleJobResourceSpec(jobApp.Spec.Worker, conf, jobApp.Name, conf.Env[schema.EnvJobWorkerReplicas],
+		conf.Env[schema.EnvJobWorkerFlavour], conf.Env[schema.EnvJobWorkerCommand], defaultPSReplicas)
+	return nil
+}
+
+func fillPaddleJobCollectiveSpec(jobApp *paddlev1.PaddleJob, conf *models.Conf) error {
+	if jobApp.Spec.Worker == nil {
+		return fmt.Errorf("paddle job[%s] in collective mode must contain worker", jobApp.Name)
+	}
+	// there is no ps server in collective mode
+	jobApp.Spec.PS = nil
+	fillPaddleJobResourceSpec(jobApp.Spec.Worker, conf, jobApp.Name, conf.Env[schema.EnvJobReplicas],
+		conf.Env[schema.EnvJobFlavour], conf.Command, defaultCollectiveReplicas)
+	return nil
+}
+
+// fillPaddleJobResourceSpec fill replicas and pod template of ps or worker
+func fillPaddleJobResourceSpec(resourceSpec *paddlev1.ResourceSpec, conf *models.Conf, jobName, replicasStr,
+	flavourKey, command string, defaultReplicas int) {
+	if replicasStr != "" {
+		resourceSpec.Replicas, _ = strconv.Atoi(replicasStr)
+	}
+	if resourceSpec.Replicas <= 0 {
+		resourceSpec.Replicas = defaultReplicas
+	}
+
+	template := &resourceSpec.Template
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	template.Labels[schema.JobIDLabel] = jobName
+	if len(template.Spec.Containers) == 0 {
+		template.Spec.Containers = []corev1.Container{{}}
+	}
+	fillContainerInTask(&template.Spec.Containers[0], conf, flavourKey, command)
+	template.Spec.Volumes = appendVolumeIfAbsent(template.Spec.Volumes,
+		generateVolume(conf.Env[schema.EnvJobFsID], conf.Env[schema.EnvJobPVCName]))
+}
+
+func (paddleJob *PaddleJob) CreateJob(conf *models.Conf) (string, error) {
+	jobID := generateJobID(conf.Name)
+	log.Debugf("begin create job jobID:[%s]", jobID)
+
+	jobApp := &paddlev1.PaddleJob{}
+	if err := createJobFromYaml(conf, jobApp); err != nil {
+		log.Errorf("create job failed, err %v", err)
+		return "", err
+	}
+
+	if err := patchPaddleJobVariable(jobApp, jobID, conf); err != nil {
+		log.Errorf("patch paddle job failed, err %v", err)
+		return "", err
+	}
+
+	job := &models.Job{
+		ID:        jobID,
+		Type:      conf.Env[schema.EnvJobType],
+		UserName:  conf.Env[schema.EnvJobUserName],
+		QueueName: conf.Env[schema.EnvJobQueueName],
+		Config:    *conf,
+	}
+	log.Debugf("begin submit job jobID:[%s] job:[%s]", jobID, config.PrettyFormat(job))
+	err := persistAndExecuteJob(job, func() error {
+		return submitter.JobExecutor.StartJob(job.QueueName, jobApp, k8s.PaddleJobGVK)
+	})
+	if err != nil {
+		log.Errorf("create job %v failed, err %v", job, err)
+		return "", err
+	}
+	return jobID, nil
+}
+
+func (paddleJob *PaddleJob) StopJobByID(jobID string) error {
+	job, err := GetJobByID(jobID)
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.Env[schema.EnvJobNamespace]
+	if err = submitter.JobExecutor.StopJob(job.QueueName, namespace, job.ID, k8s.PaddleJobGVK); err != nil {
+		log.Errorf("stop paddle job %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
+
+// UpdateJobPriority patch priority class in scheduling policy of paddle job
+func (paddleJob *PaddleJob) UpdateJobPriority(job *models.Job, priority string) error {
+	patchData, err := generatePriorityPatch(priority, "spec", "schedulingPolicy", "priorityClass")
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.Env[schema.EnvJobNamespace]
+	if err = submitter.JobExecutor.UpdateJob(job.QueueName, namespace, job.ID, k8s.PaddleJobGVK, patchData); err != nil {
+		log.Errorf("update priority of paddlejob %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/schema"
+)
+
+func TestPatchPaddleJobVariable(t *testing.T) {
+	confEnv := make(map[string]string)
+	initConfigsForTest(confEnv)
+	confEnv[schema.EnvJobType] = string(schema.TypePaddleJob)
+	confEnv[schema.EnvJobQueueName] = "q1"
+	// init for paddle job's ps mode
+	confEnv[schema.EnvJobPServerCommand] = "sleep 30"
+	confEnv[schema.EnvJobWorkerCommand] = "sleep 30"
+	confEnv[schema.EnvJobPServerReplicas] = "2"
+	confEnv[schema.EnvJobWorkerReplicas] = "3"
+	confEnv[schema.EnvJobPServerFlavour] = "ss"
+	confEnv[schema.EnvJobWorkerFlavour] = "ss"
+	// init for paddle job's collective mode
+	confEnv[schema.EnvJobFlavour] = "ss"
+	confEnv[schema.EnvJobReplicas] = "4"
+
+	tests := []struct {
+		caseName     string
+		jobMode      string
+		expectPS     int
+		expectWorker int
+		expectErr    bool
+	}{
+		{
+			caseName:     "psMode",
+			jobMode:      schema.EnvJobModePS,
+			expectPS:     2,
+			expectWorker: 3,
+		},
+		{
+			caseName:     "collectiveMode",
+			jobMode:      schema.EnvJobModeCollective,
+			expectWorker: 4,
+		},
+		{
+			caseName:  "podMode",
+			jobMode:   schema.EnvJobModePod,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		confEnv[schema.EnvJobMode] = test.jobMode
+		conf := &models.Conf{
+			Env:     confEnv,
+			Command: "sleep 3600",
+			Image:   "test",
+		}
+		jobApp := &paddlev1.PaddleJob{}
+		if test.expectErr {
+			// there is no default yaml for pod mode, patch an empty job directly
+			err := patchPaddleJobVariable(jobApp, generateJobID(conf.Name), conf)
+			assert.Error(t, err)
+			continue
+		}
+		err := createJobFromYaml(conf, jobApp)
+		assert.NoError(t, err)
+
+		jobID := generateJobID(conf.Name)
+		err = patchPaddleJobVariable(jobApp, jobID, conf)
+		assert.NoError(t, err)
+		t.Logf("case[%s] jobApp=%+v", test.caseName, *jobApp)
+
+		assert.Equal(t, jobID, jobApp.Name)
+		assert.Equal(t, schema.JobOwnerValue, jobApp.Labels[schema.JobOwnerLabel])
+		assert.Equal(t, "q1", jobApp.Spec.SchedulingPolicy.Queue)
+		assert.Equal(t, test.expectWorker, jobApp.Spec.Worker.Replicas)
+		assert.Equal(t, jobID, jobApp.Spec.Worker.Template.Labels[schema.JobIDLabel])
+		assert.NotEmpty(t, jobApp.Spec.Worker.Template.Spec.Volumes)
+		assert.NotEmpty(t, jobApp.Spec.Worker.Template.Spec.Containers[0].VolumeMounts)
+		if test.expectPS > 0 {
+			assert.Equal(t, test.expectPS, jobApp.Spec.PS.Replicas)
+		} else {
+			assert.Nil(t, jobApp.Spec.PS)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"encoding/json"
+	"sort"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/errors"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+// priorityRank indicate scheduling order of job priority, the higher rank is scheduled first,
+// it also contains all priorities that can be set to job
+var priorityRank = map[string]int{
+	schema.EnvJobLowPriority:    0,
+	schema.EnvJobNormalPriority: 1,
+	schema.EnvJobHighPriority:   2,
+}
+
+func ValidateJobPriority(priority string) error {
+	if _, ok := priorityRank[priority]; !ok {
+		return errors.InvalidJobPriorityError(priority)
+	}
+	return nil
+}
+
+func getJobPriority(job *models.Job) string {
+	if priority, found := job.Config.Env[schema.EnvJobPriority]; found {
+		return priority
+	}
+	return schema.EnvJobNormalPriority
+}
+
+// generatePriorityPatch generate json merge patch which set priority class on path of job spec
+func generatePriorityPatch(priority string, path ...string) ([]byte, error) {
+	var patch interface{} = getPriorityClass(priority)
+	for i := len(path) - 1; i >= 0; i-- {
+		patch = map[string]interface{}{path[i]: patch}
+	}
+	return json.Marshal(patch)
+}
+
+// UpdateJobPriority update priority of pending job both in cluster and in database
+func UpdateJobPriority(jobID, priority string) error {
+	if err := ValidateJobPriority(priority); err != nil {
+		return err
+	}
+	job, err := GetJobByID(jobID)
+	if err != nil {
+		return errors.JobIDNotFoundError(jobID)
+	}
+	if getJobPriority(&job) == priority {
+		return nil
+	}
+	jobType := schema.JobType(job.Type)
+	logger.LoggerForJob(jobID).Infof("update priority of %s job to %s", jobType, priority)
+	if err := JobMap[jobType].UpdateJobPriority(&job, priority); err != nil {
+		logger.LoggerForJob(jobID).Errorf("update priority of job in cluster failed, err %v", err)
+		return err
+	}
+	job.Config.Env[schema.EnvJobPriority] = priority
+	tx := database.DB.Table("job").Where("id = ?", jobID).Update("config", job.Config)
+	if tx.Error != nil {
+		logger.LoggerForJob(jobID).Errorf("update priority of job failed, err %v", tx.Error)
+		return tx.Error
+	}
+	return nil
+}
+
+// ListPendingJob list pending jobs of queue in scheduling order,
+// jobs with higher priority are in front, and jobs with same priority are ordered by create time
+func ListPendingJob(queueName string) ([]models.Job, error) {
+	var jobs []models.Job
+	tx := database.DB.Table("job").Where("queue_name = ? AND status = ?", queueName, schema.StatusJobPending).
+		Order("created_at").Find(&jobs)
+	if tx.Error != nil {
+		logger.Logger().Errorf("list pending job of queue[%s] failed, err %v", queueName, tx.Error)
+		return nil, tx.Error
+	}
+	sortJobByPriority(jobs)
+	return jobs, nil
+}
+
+func sortJobByPriority(jobs []models.Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return priorityRank[getJobPriority(&jobs[i])] > priorityRank[getJobPriority(&jobs[j])]
+	})
+}
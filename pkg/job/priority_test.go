@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/database/db_fake"
+	"paddleflow/pkg/common/k8s"
+	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/job/submitter"
+)
+
+// fakeUpdateExecutor records the object patched by UpdateJob
+type fakeUpdateExecutor struct {
+	submitter.JobExecutorInterface
+	gvk   k8sschema.GroupVersionKind
+	name  string
+	patch string
+}
+
+func (f *fakeUpdateExecutor) UpdateJob(queueName, namespace, name string, gvk k8sschema.GroupVersionKind, patchData []byte) error {
+	f.gvk, f.name, f.patch = gvk, name, string(patchData)
+	return nil
+}
+
+func TestGeneratePriorityPatch(t *testing.T) {
+	patch, err := generatePriorityPatch(schema.EnvJobHighPriority, "spec", "batchSchedulerOptions", "priorityClassName")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"spec":{"batchSchedulerOptions":{"priorityClassName":"high"}}}`, string(patch))
+}
+
+func TestListPendingJob(t *testing.T) {
+	db_fake.InitFakeDB()
+	now := time.Now()
+	testJobs := []struct {
+		id       string
+		priority string
+		status   schema.JobStatus
+	}{
+		{id: "job-1", priority: schema.EnvJobNormalPriority, status: schema.StatusJobPending},
+		{id: "job-2", priority: schema.EnvJobLowPriority, status: schema.StatusJobPending},
+		{id: "job-3", priority: schema.EnvJobHighPriority, status: schema.StatusJobPending},
+		{id: "job-4", status: schema.StatusJobPending},
+		{id: "job-5", priority: schema.EnvJobHighPriority, status: schema.StatusJobRunning},
+	}
+	for i, testJob := range testJobs {
+		job := &models.Job{
+			ID:        testJob.id,
+			QueueName: "q1",
+			Status:    testJob.status,
+			Config:    models.Conf{Name: testJob.id, Env: map[string]string{}},
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		}
+		if testJob.priority != "" {
+			job.Config.Env[schema.EnvJobPriority] = testJob.priority
+		}
+		assert.NoError(t, database.DB.Create(job).Error)
+	}
+
+	pendingJobs, err := ListPendingJob("q1")
+	assert.NoError(t, err)
+	var jobIDs []string
+	for _, job := range pendingJobs {
+		jobIDs = append(jobIDs, job.ID)
+	}
+	assert.Equal(t, []string{"job-3", "job-1", "job-4", "job-2"}, jobIDs)
+}
+
+func TestValidateJobPriority(t *testing.T) {
+	for priority := range priorityRank {
+		assert.NoError(t, ValidateJobPriority(priority))
+	}
+	assert.Error(t, ValidateJobPriority(schema.EnvJobVeryHighPriority))
+	assert.Error(t, ValidateJobPriority(""))
+}
+
+func TestUpdateVCJobPriority(t *testing.T) {
+	executor := &fakeUpdateExecutor{}
+	submitter.JobExecutor = executor
+	defer func() {
+		submitter.JobExecutor = nil
+	}()
+
+	// spec of vcjob is immutable for volcano admission webhook, priority is updated on its podgroup
+	job := &models.Job{
+		ID:     "job-1",
+		Config: models.Conf{Env: map[string]string{schema.EnvJobNamespace: "default"}},
+	}
+	err := JobMap[schema.TypeVcJob].UpdateJobPriority(job, schema.EnvJobHighPriority)
+	assert.NoError(t, err)
+	assert.Equal(t, k8s.PodGroupGVK, executor.gvk)
+	assert.Equal(t, "job-1", executor.name)
+	assert.Equal(t, `{"spec":{"priorityClassName":"high"}}`, executor.patch)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"strconv"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+// DefaultJobPendingTTLSeconds is used to kill jobs whose pods cannot be started, such as image pulling failed
+const DefaultJobPendingTTLSeconds = 300
+
+func newTTLSeconds(ttl int) *int {
+	return &ttl
+}
+
+// getServerReclaimPolicy convert reclaim config of server to reclaim policy. pending ttl is never set by server,
+// as jobs may wait for resources of queue for a long time, they are only killed when queue or job opts in
+func getServerReclaimPolicy() schema.ReclaimPolicy {
+	reclaimConf := config.GlobalServerConfig.Job.Reclaim
+	policy := schema.ReclaimPolicy{
+		SucceededTTLSeconds:  newTTLSeconds(reclaimConf.JobTTLSeconds),
+		FailedTTLSeconds:     newTTLSeconds(reclaimConf.JobTTLSeconds),
+		TerminatedTTLSeconds: newTTLSeconds(reclaimConf.JobTTLSeconds),
+	}
+	if reclaimConf.SkipCleanFailedJob {
+		policy.FailedTTLSeconds = newTTLSeconds(schema.NeverReclaimTTLSeconds)
+		policy.TerminatedTTLSeconds = newTTLSeconds(schema.NeverReclaimTTLSeconds)
+	}
+	policy.Merge(&schema.ReclaimPolicy{
+		SucceededTTLSeconds:  reclaimConf.SucceededJobTTLSeconds,
+		FailedTTLSeconds:     reclaimConf.FailedJobTTLSeconds,
+		TerminatedTTLSeconds: reclaimConf.TerminatedJobTTLSeconds,
+	})
+	return policy
+}
+
+// parseJobReclaimPolicy parse reclaim policy of single job from env
+func parseJobReclaimPolicy(env map[string]string) (*schema.ReclaimPolicy, error) {
+	policy := &schema.ReclaimPolicy{}
+	for key, field := range map[string]**int{
+		schema.EnvJobSucceededTTLSeconds:  &policy.SucceededTTLSeconds,
+		schema.EnvJobFailedTTLSeconds:     &policy.FailedTTLSeconds,
+		schema.EnvJobTerminatedTTLSeconds: &policy.TerminatedTTLSeconds,
+		schema.EnvJobPendingTTLSeconds:    &policy.PendingTTLSeconds,
+	} {
+		value, found := env[key]
+		if !found || value == "" {
+			continue
+		}
+		ttl, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env %s[%s], should be an integer", key, value)
+		}
+		*field = newTTLSeconds(ttl)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetReclaimPolicy get reclaim policy of job, which merges server config, policy of queue and env of job in order.
+// jobs not created by paddleflow use reclaim policy of server
+func GetReclaimPolicy(jobID string) schema.ReclaimPolicy {
+	policy := getServerReclaimPolicy()
+	job, err := GetJobByID(jobID)
+	if err != nil {
+		return policy
+	}
+	if job.QueueName != "" {
+		queue, err := models.GetQueueByName(&logger.RequestContext{}, job.QueueName)
+		if err != nil {
+			logger.LoggerForJob(jobID).Warnf("get queue %s failed, reclaim policy of queue is ignored. err %v", job.QueueName, err)
+		} else {
+			policy.Merge(queue.ReclaimPolicy)
+		}
+	}
+	if jobPolicy, err := parseJobReclaimPolicy(job.Config.Env); err == nil {
+		policy.Merge(jobPolicy)
+	}
+	return policy
+}
+
+// GetPodPendingTTLSeconds get ttl of job whose pods cannot be started, such as image pulling failed.
+// it is JobPendingTTLSeconds of server by default, and can be overridden by pending ttl of queue or job,
+// the second return value is false if pending ttl is disabled by reclaim policy
+func GetPodPendingTTLSeconds(jobID string) (int, bool) {
+	ttl := DefaultJobPendingTTLSeconds
+	if serverTTL := config.GlobalServerConfig.Job.Reclaim.JobPendingTTLSeconds; serverTTL > 0 {
+		ttl = serverTTL
+	}
+	if pendingTTL := GetReclaimPolicy(jobID).PendingTTLSeconds; pendingTTL != nil {
+		if *pendingTTL < 0 {
+			return 0, false
+		}
+		ttl = *pendingTTL
+	}
+	return ttl, true
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/database/db_fake"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+func TestGetReclaimPolicy(t *testing.T) {
+	db_fake.InitFakeDB()
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.Job.Reclaim = config.ReclaimConfig{
+		CleanJob:             true,
+		SkipCleanFailedJob:   true,
+		JobTTLSeconds:        600,
+		JobPendingTTLSeconds: 1800,
+	}
+
+	// job not created by paddleflow use policy of server
+	policy := GetReclaimPolicy("not-exist")
+	ttl, ok := policy.GetTTLSeconds(schema.StatusJobSucceeded)
+	assert.True(t, ok)
+	assert.Equal(t, 600, ttl)
+	_, ok = policy.GetTTLSeconds(schema.StatusJobFailed)
+	assert.False(t, ok)
+	// pending ttl of server only applies to jobs whose pods cannot be started
+	_, ok = policy.GetTTLSeconds(schema.StatusJobPending)
+	assert.False(t, ok)
+	ttl, ok = GetPodPendingTTLSeconds("not-exist")
+	assert.True(t, ok)
+	assert.Equal(t, 1800, ttl)
+
+	queue := &models.Queue{
+		QueueInfo: models.QueueInfo{
+			Name: "research",
+			ReclaimPolicy: &schema.ReclaimPolicy{
+				FailedTTLSeconds:  newTTLSeconds(3600),
+				PendingTTLSeconds: newTTLSeconds(7200),
+			},
+		},
+	}
+	assert.NoError(t, models.CreateQueue(&logger.RequestContext{}, queue))
+	job := &models.Job{
+		ID:        "job-1",
+		QueueName: "research",
+		Status:    schema.StatusJobPending,
+		Config: models.Conf{
+			Name: "job-1",
+			Env:  map[string]string{schema.EnvJobPendingTTLSeconds: "60"},
+		},
+	}
+	assert.NoError(t, database.DB.Create(job).Error)
+
+	policy = GetReclaimPolicy("job-1")
+	ttl, ok = policy.GetTTLSeconds(schema.StatusJobSucceeded)
+	assert.True(t, ok)
+	assert.Equal(t, 600, ttl)
+	// failed ttl is overridden by queue
+	ttl, ok = policy.GetTTLSeconds(schema.StatusJobFailed)
+	assert.True(t, ok)
+	assert.Equal(t, 3600, ttl)
+	// pending ttl is overridden by job
+	ttl, ok = policy.GetTTLSeconds(schema.StatusJobPending)
+	assert.True(t, ok)
+	assert.Equal(t, 60, ttl)
+	ttl, ok = GetPodPendingTTLSeconds("job-1")
+	assert.True(t, ok)
+	assert.Equal(t, 60, ttl)
+}
+
+func TestParseJobReclaimPolicy(t *testing.T) {
+	policy, err := parseJobReclaimPolicy(map[string]string{
+		schema.EnvJobSucceededTTLSeconds:  "0",
+		schema.EnvJobTerminatedTTLSeconds: "-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, *policy.SucceededTTLSeconds)
+	assert.Nil(t, policy.FailedTTLSeconds)
+	_, ok := policy.GetTTLSeconds(schema.StatusJobTerminated)
+	assert.False(t, ok)
+
+	_, err = parseJobReclaimPolicy(map[string]string{schema.EnvJobFailedTTLSeconds: "abc"})
+	assert.Error(t, err)
+	_, err = parseJobReclaimPolicy(map[string]string{schema.EnvJobFailedTTLSeconds: "-2"})
+	assert.Error(t, err)
+}
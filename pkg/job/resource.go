@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/errors"
+	"paddleflow/pkg/common/schema"
+)
+
+// jobMember indicate replicas and flavour of one kind of pods in job, such as ps and worker in PS mode
+type jobMember struct {
+	replicas int
+	flavour  string
+}
+
+func newJobMember(conf *models.Conf, replicasKey, flavourKey string, defaultReplicas int) jobMember {
+	member := jobMember{
+		replicas: defaultReplicas,
+		flavour:  conf.Env[flavourKey],
+	}
+	if replicasStr, found := conf.Env[replicasKey]; found {
+		if replicas, err := strconv.Atoi(replicasStr); err == nil && replicas > 0 {
+			member.replicas = replicas
+		}
+	}
+	return member
+}
+
+// getJobMembers get members of job, replicas are defaulted in the same way as job creating
+func getJobMembers(conf *models.Conf) []jobMember {
+	jobType := schema.JobType(conf.Env[schema.EnvJobType])
+	mode := conf.Env[schema.EnvJobMode]
+	switch {
+	case jobType == schema.TypeSparkJob:
+		executorReplicasKey := schema.EnvJobExecutorReplicas
+		// executors may scale up to max executors when dynamic allocation is enabled
+		if _, found := conf.Env[schema.EnvJobSparkMaxExecutors]; found {
+			executorReplicasKey = schema.EnvJobSparkMaxExecutors
+		}
+		return []jobMember{
+			{replicas: 1, flavour: conf.Env[schema.EnvJobDriverFlavour]},
+			newJobMember(conf, executorReplicasKey, schema.EnvJobExecutorFlavour, int(defaultExecutorInstances)),
+		}
+	case jobType == schema.TypeBatchJob:
+		return []jobMember{newJobMember(conf, schema.EnvJobReplicas, schema.EnvJobFlavour, int(defaultBatchJobReplicas))}
+	case mode == schema.EnvJobModePS:
+		return []jobMember{
+			newJobMember(conf, schema.EnvJobPServerReplicas, schema.EnvJobPServerFlavour, defaultPSReplicas),
+			newJobMember(conf, schema.EnvJobWorkerReplicas, schema.EnvJobWorkerFlavour, defaultPSReplicas),
+		}
+	case mode == schema.EnvJobModeCollective:
+		return []jobMember{newJobMember(conf, schema.EnvJobReplicas, schema.EnvJobFlavour, defaultCollectiveReplicas)}
+	default:
+		return []jobMember{newJobMember(conf, schema.EnvJobReplicas, schema.EnvJobFlavour, defaultPodReplicas)}
+	}
+}
+
+// getJobTotalResource compute total resource request of job, which is sum of replicas * flavour of all members
+func getJobTotalResource(conf *models.Conf) (corev1.ResourceList, error) {
+	total := corev1.ResourceList{}
+	for _, member := range getJobMembers(conf) {
+		flavour, found := config.GlobalServerConfig.FlavourMap[member.flavour]
+		if !found {
+			return nil, errors.InvalidFlavourError(member.flavour)
+		}
+		resources := map[corev1.ResourceName]string{
+			corev1.ResourceCPU:    flavour.Cpu,
+			corev1.ResourceMemory: flavour.Mem,
+		}
+		for name, value := range flavour.ScalarResources {
+			resources[name] = value
+		}
+		for name, value := range resources {
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				log.Errorf("parse resource[%s: %s] of flavour[%s] failed, err %v", name, value, member.flavour, err)
+				return nil, errors.InvalidFlavourError(member.flavour)
+			}
+			for i := 0; i < member.replicas; i++ {
+				sum := total[name]
+				sum.Add(quantity)
+				total[name] = sum
+			}
+		}
+	}
+	return total, nil
+}
+
+// getQueueCapacity get resource capacity of queue, resources which are not set in queue are not limited
+func getQueueCapacity(queue *models.Queue) (corev1.ResourceList, error) {
+	resources := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    queue.Cpu,
+		corev1.ResourceMemory: queue.Mem,
+	}
+	for name, value := range queue.ScalarResources {
+		resources[name] = value
+	}
+	capacity := corev1.ResourceList{}
+	for name, value := range resources {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			log.Errorf("parse resource[%s: %s] of queue[%s] failed, err %v", name, value, queue.Name, err)
+			return nil, errors.QueueResourceNotMatchError(queue.Cpu, queue.Mem)
+		}
+		capacity[name] = quantity
+	}
+	return capacity, nil
+}
+
+// checkQueueQuota reject job whose total resource request exceeds capacity of queue,
+// otherwise the job would be pending forever in the cluster
+func checkQueueQuota(conf *models.Conf, queue *models.Queue) error {
+	request, err := getJobTotalResource(conf)
+	if err != nil {
+		return err
+	}
+	capacity, err := getQueueCapacity(queue)
+	if err != nil {
+		return err
+	}
+	for name, quantity := range request {
+		limit, found := capacity[name]
+		if !found {
+			continue
+		}
+		if quantity.Cmp(limit) > 0 {
+			log.Errorf("job[%s] request %s[%s] exceeds capacity[%s] of queue[%s]", conf.Name, name,
+				quantity.String(), limit.String(), queue.Name)
+			return errors.QueueResourceNotEnoughError(queue.Name, string(name), quantity.String(), limit.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/errors"
+	"paddleflow/pkg/common/schema"
+)
+
+func initFlavourForQuotaTest() {
+	config.GlobalServerConfig = &config.ServerConfig{}
+	config.GlobalServerConfig.FlavourMap = map[string]schema.Flavour{
+		"cpu": {
+			Name:         "cpu",
+			ResourceInfo: schema.ResourceInfo{Cpu: "2", Mem: "4Gi"},
+		},
+		"gpu": {
+			Name: "gpu",
+			ResourceInfo: schema.ResourceInfo{
+				Cpu:             "4",
+				Mem:             "8Gi",
+				ScalarResources: schema.ScalarResourcesType{"nvidia.com/gpu": "1"},
+			},
+		},
+	}
+}
+
+func TestGetJobTotalResource(t *testing.T) {
+	initFlavourForQuotaTest()
+	testCases := []struct {
+		name   string
+		env    map[string]string
+		cpu    string
+		mem    string
+		gpu    string
+		hasErr bool
+	}{
+		{
+			name: "vcjob ps mode",
+			env: map[string]string{
+				schema.EnvJobType:            string(schema.TypeVcJob),
+				schema.EnvJobMode:            schema.EnvJobModePS,
+				schema.EnvJobPServerReplicas: "2",
+				schema.EnvJobPServerFlavour:  "cpu",
+				schema.EnvJobWorkerReplicas:  "3",
+				schema.EnvJobWorkerFlavour:   "gpu",
+			},
+			cpu: "16",
+			mem: "32Gi",
+			gpu: "3",
+		},
+		{
+			name: "paddlejob collective mode with default replicas",
+			env: map[string]string{
+				schema.EnvJobType:    string(schema.TypePaddleJob),
+				schema.EnvJobMode:    schema.EnvJobModeCollective,
+				schema.EnvJobFlavour: "gpu",
+			},
+			cpu: "8",
+			mem: "16Gi",
+			gpu: "2",
+		},
+		{
+			name: "spark job",
+			env: map[string]string{
+				schema.EnvJobType:             string(schema.TypeSparkJob),
+				schema.EnvJobDriverFlavour:    "cpu",
+				schema.EnvJobExecutorFlavour:  "cpu",
+				schema.EnvJobExecutorReplicas: "2",
+			},
+			cpu: "6",
+			mem: "12Gi",
+		},
+		{
+			name: "invalid flavour",
+			env: map[string]string{
+				schema.EnvJobType:    string(schema.TypeBatchJob),
+				schema.EnvJobFlavour: "none",
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			total, err := getJobTotalResource(&models.Conf{Name: tc.name, Env: tc.env})
+			if tc.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 0, resource.MustParse(tc.cpu).Cmp(total[corev1.ResourceCPU]))
+			assert.Equal(t, 0, resource.MustParse(tc.mem).Cmp(total[corev1.ResourceMemory]))
+			if tc.gpu != "" {
+				assert.Equal(t, 0, resource.MustParse(tc.gpu).Cmp(total["nvidia.com/gpu"]))
+			}
+		})
+	}
+}
+
+func TestCheckQueueQuota(t *testing.T) {
+	initFlavourForQuotaTest()
+	conf := &models.Conf{
+		Name: "collective",
+		Env: map[string]string{
+			schema.EnvJobType:     string(schema.TypeVcJob),
+			schema.EnvJobMode:     schema.EnvJobModeCollective,
+			schema.EnvJobFlavour:  "gpu",
+			schema.EnvJobReplicas: "4",
+		},
+	}
+	queue := &models.Queue{
+		QueueInfo: models.QueueInfo{
+			Name: "q1",
+			Cpu:  "20",
+			Mem:  "64Gi",
+		},
+	}
+	// gpu is not limited by queue
+	assert.NoError(t, checkQueueQuota(conf, queue))
+
+	queue.ScalarResources = models.ScalarResourcesType{"nvidia.com/gpu": "2"}
+	err := checkQueueQuota(conf, queue)
+	assert.Error(t, err)
+	pfErr, ok := err.(*errors.PFError)
+	assert.True(t, ok)
+	assert.Equal(t, errors.QueueResourceNotEnough, pfErr.Code)
+
+	queue.ScalarResources = nil
+	queue.Cpu = "8"
+	assert.Error(t, checkQueueQuota(conf, queue))
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package job
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -26,6 +28,7 @@ import (
 	sparkapp "paddleflow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/errors"
 	"paddleflow/pkg/common/k8s"
 	"paddleflow/pkg/common/schema"
 	"paddleflow/pkg/job/submitter"
@@ -72,6 +75,17 @@ func patchSparkMetaData(jobApp *sparkapp.SparkApplication, jobID string, conf *m
 }
 
 func patchSparkSpec(jobApp *sparkapp.SparkApplication, jobID string, conf *models.Conf) {
+	// type of application
+	if appType, exist := conf.Env[schema.EnvJobSparkAppType]; exist {
+		jobApp.Spec.Type = sparkapp.SparkApplicationType(appType)
+		// main class of template is meaningless for python and r application
+		if jobApp.Spec.Type == sparkapp.PythonApplicationType || jobApp.Spec.Type == sparkapp.RApplicationType {
+			jobApp.Spec.MainClass = nil
+		}
+	}
+	if pythonVersion, exist := conf.Env[schema.EnvJobSparkPythonVersion]; exist {
+		jobApp.Spec.PythonVersion = &pythonVersion
+	}
 	// image
 	jobApp.Spec.Image = &conf.Image
 
@@ -84,6 +98,20 @@ func patchSparkSpec(jobApp *sparkapp.SparkApplication, jobID string, conf *model
 	if arguments, exist := conf.Env[schema.EnvJobSparkArguments]; exist && len(arguments) > 0 {
 		jobApp.Spec.Arguments = []string{arguments}
 	}
+	// sparkConf, conf of job overrides conf of template
+	sparkConf, _ := parseSparkConf(conf.Env[schema.EnvJobSparkConf])
+	if len(sparkConf) > 0 && jobApp.Spec.SparkConf == nil {
+		jobApp.Spec.SparkConf = make(map[string]string, len(sparkConf))
+	}
+	for key, value := range sparkConf {
+		jobApp.Spec.SparkConf[key] = value
+	}
+	// deps
+	patchSparkSpecDeps(jobApp, conf)
+	// dynamic allocation
+	if dynamicAllocation, _ := getSparkDynamicAllocation(conf); dynamicAllocation != nil {
+		jobApp.Spec.DynamicAllocation = dynamicAllocation
+	}
 	// BatchScheduler && BatchSchedulerOptions
 	schedulerName := config.GlobalServerConfig.Job.SchedulerName
 	jobApp.Spec.BatchScheduler = &schedulerName
@@ -112,6 +140,69 @@ func patchSparkSpec(jobApp *sparkapp.SparkApplication, jobID string, conf *model
 
 }
 
+// patchSparkSpecDeps append jars, pyFiles and files of job to dependencies of template
+func patchSparkSpecDeps(jobApp *sparkapp.SparkApplication, conf *models.Conf) {
+	deps := &jobApp.Spec.Deps
+	deps.Jars = append(deps.Jars, splitSparkDeps(conf.Env[schema.EnvJobSparkJars])...)
+	deps.PyFiles = append(deps.PyFiles, splitSparkDeps(conf.Env[schema.EnvJobSparkPyFiles])...)
+	deps.Files = append(deps.Files, splitSparkDeps(conf.Env[schema.EnvJobSparkFiles])...)
+}
+
+// splitSparkDeps split dependencies separated by comma, empty items are ignored
+func splitSparkDeps(value string) []string {
+	var deps []string
+	for _, dep := range strings.Split(value, ",") {
+		if dep = strings.TrimSpace(dep); dep != "" {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// parseSparkConf parse spark conf in json format
+func parseSparkConf(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	sparkConf := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &sparkConf); err != nil {
+		return nil, fmt.Errorf("invalid spark conf %s, err: %v", value, err)
+	}
+	return sparkConf, nil
+}
+
+// getSparkDynamicAllocation get dynamic allocation of executors, which is enabled if min or max executors is set
+func getSparkDynamicAllocation(conf *models.Conf) (*sparkapp.DynamicAllocation, error) {
+	minStr, minFound := conf.Env[schema.EnvJobSparkMinExecutors]
+	maxStr, maxFound := conf.Env[schema.EnvJobSparkMaxExecutors]
+	if !minFound && !maxFound {
+		return nil, nil
+	}
+	dynamicAllocation := &sparkapp.DynamicAllocation{Enabled: true}
+	minExecutors, maxExecutors := 0, 0
+	var err error
+	if minFound {
+		if minExecutors, err = strconv.Atoi(minStr); err != nil {
+			log.Errorf("cannot convert %s to int", minStr)
+			return nil, err
+		}
+		minInt32 := int32(minExecutors)
+		dynamicAllocation.MinExecutors = &minInt32
+	}
+	if maxFound {
+		if maxExecutors, err = strconv.Atoi(maxStr); err != nil {
+			log.Errorf("cannot convert %s to int", maxStr)
+			return nil, err
+		}
+		maxInt32 := int32(maxExecutors)
+		dynamicAllocation.MaxExecutors = &maxInt32
+	}
+	if minExecutors < 0 || maxExecutors < 0 || (minFound && maxFound && minExecutors > maxExecutors) {
+		return nil, errors.InvalidSparkExecutorsError(minExecutors, maxExecutors)
+	}
+	return dynamicAllocation, nil
+}
+
 func patchSparkSpecDriver(jobApp *sparkapp.SparkApplication, conf *models.Conf, cores int32, flavour schema.Flavour) {
 	jobApp.Spec.Driver.Cores = &cores
 	jobApp.Spec.Driver.CoreLimit = &flavour.Cpu
@@ -168,14 +259,15 @@ func (sparkJob *SparkJob) CreateJob(conf *models.Conf) (string, error) {
 	patchSparkAppVariable(jobApp, jobID, conf)
 
 	job := &models.Job{
-		ID:       jobID,
-		Type:     conf.Env[schema.EnvJobType],
-		UserName: conf.Env[schema.EnvJobUserName],
-		Config:   *conf,
+		ID:        jobID,
+		Type:      conf.Env[schema.EnvJobType],
+		UserName:  conf.Env[schema.EnvJobUserName],
+		QueueName: conf.Env[schema.EnvJobQueueName],
+		Config:    *conf,
 	}
 	log.Debugf("begin submit job jobID:[%s] job:[%s]", jobID, config.PrettyFormat(job))
 	err := persistAndExecuteJob(job, func() error {
-		return submitter.JobExecutor.StartJob(jobApp, k8s.SparkAppGVK)
+		return submitter.JobExecutor.StartJob(job.QueueName, jobApp, k8s.SparkAppGVK)
 	})
 	if err != nil {
 		log.Errorf("create job %v failed, err %v", job, err)
@@ -190,13 +282,27 @@ func (sparkJob *SparkJob) StopJobByID(jobID string) error {
 		return err
 	}
 	namespace := job.Config.Env[schema.EnvJobNamespace]
-	if err = submitter.JobExecutor.StopJob(namespace, job.ID, k8s.SparkAppGVK); err != nil {
+	if err = submitter.JobExecutor.StopJob(job.QueueName, namespace, job.ID, k8s.SparkAppGVK); err != nil {
 		log.Errorf("stop sparkjob %s in namespace %s failed, err %v", job.ID, namespace, err)
 		return err
 	}
 	return nil
 }
 
+// UpdateJobPriority patch priority class in batch scheduler options of spark application
+func (sparkJob *SparkJob) UpdateJobPriority(job *models.Job, priority string) error {
+	patchData, err := generatePriorityPatch(priority, "spec", "batchSchedulerOptions", "priorityClassName")
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.Env[schema.EnvJobNamespace]
+	if err = submitter.JobExecutor.UpdateJob(job.QueueName, namespace, job.ID, k8s.SparkAppGVK, patchData); err != nil {
+		log.Errorf("update priority of spark job %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
+
 func fillGPUSpec(driverFlavour schema.Flavour, executorFlavour schema.Flavour, jobSpec *sparkapp.SparkApplication) {
 	if num, found := driverFlavour.ScalarResources["nvidia.com/gpu"]; found {
 		quantity, _ := strconv.Atoi(num)
@@ -91,3 +91,97 @@ func TestPatchSparkAppVariable(t *testing.T) {
 		assert.Equal(t, test.expectValue, jobApp.Namespace)
 	}
 }
+
+func TestPatchPySparkApp(t *testing.T) {
+	confEnv := make(map[string]string)
+	initConfigsForTest(confEnv)
+	confEnv[schema.EnvJobType] = string(schema.TypeSparkJob)
+	confEnv[schema.EnvJobSparkAppType] = string(sparkapp.PythonApplicationType)
+	confEnv[schema.EnvJobSparkPythonVersion] = "3"
+	confEnv[schema.EnvJobSparkMainFile] = "local:///opt/spark/examples/src/main/python/pi.py"
+	confEnv[schema.EnvJobSparkPyFiles] = "local:///opt/deps/a.zip, local:///opt/deps/b.py,"
+	confEnv[schema.EnvJobSparkJars] = "local:///opt/deps/c.jar"
+	confEnv[schema.EnvJobSparkConf] = `{"spark.executor.memoryOverhead": "1g"}`
+	confEnv[schema.EnvJobSparkMinExecutors] = "1"
+	confEnv[schema.EnvJobSparkMaxExecutors] = "4"
+	conf := &models.Conf{
+		Env:   confEnv,
+		Image: "test",
+	}
+
+	jobApp := &sparkapp.SparkApplication{}
+	assert.NoError(t, createJobFromYaml(conf, jobApp))
+	patchSparkAppVariable(jobApp, generateJobID(conf.Name), conf)
+
+	assert.Equal(t, sparkapp.PythonApplicationType, jobApp.Spec.Type)
+	assert.Nil(t, jobApp.Spec.MainClass)
+	assert.Equal(t, "3", *jobApp.Spec.PythonVersion)
+	assert.Equal(t, []string{"local:///opt/deps/a.zip", "local:///opt/deps/b.py"}, jobApp.Spec.Deps.PyFiles)
+	assert.Equal(t, []string{"local:///opt/deps/c.jar"}, jobApp.Spec.Deps.Jars)
+	assert.Empty(t, jobApp.Spec.Deps.Files)
+	// conf of job is merged with conf of template
+	assert.Equal(t, "1g", jobApp.Spec.SparkConf["spark.executor.memoryOverhead"])
+	assert.Equal(t, "2", jobApp.Spec.SparkConf["spark.hadoop.mapreduce.fileoutputcommitter.algorithm.version"])
+	assert.NotNil(t, jobApp.Spec.DynamicAllocation)
+	assert.True(t, jobApp.Spec.DynamicAllocation.Enabled)
+	assert.Equal(t, int32(1), *jobApp.Spec.DynamicAllocation.MinExecutors)
+	assert.Equal(t, int32(4), *jobApp.Spec.DynamicAllocation.MaxExecutors)
+}
+
+func TestValidateSparkMode(t *testing.T) {
+	tests := []struct {
+		caseName      string
+		additionalEnv map[string]string
+		wantErr       bool
+	}{
+		{
+			caseName: "python application",
+			additionalEnv: map[string]string{
+				schema.EnvJobSparkAppType:       string(sparkapp.PythonApplicationType),
+				schema.EnvJobSparkPythonVersion: "3",
+			},
+		},
+		{
+			caseName:      "scala application without main class",
+			additionalEnv: map[string]string{schema.EnvJobSparkAppType: string(sparkapp.ScalaApplicationType)},
+			wantErr:       true,
+		},
+		{
+			caseName:      "invalid application type",
+			additionalEnv: map[string]string{schema.EnvJobSparkAppType: "Go"},
+			wantErr:       true,
+		},
+		{
+			caseName:      "invalid python version",
+			additionalEnv: map[string]string{schema.EnvJobSparkPythonVersion: "4"},
+			wantErr:       true,
+		},
+		{
+			caseName:      "invalid spark conf",
+			additionalEnv: map[string]string{schema.EnvJobSparkConf: "spark.executor.memoryOverhead=1g"},
+			wantErr:       true,
+		},
+		{
+			caseName: "min executors greater than max executors",
+			additionalEnv: map[string]string{
+				schema.EnvJobSparkMinExecutors: "4",
+				schema.EnvJobSparkMaxExecutors: "2",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		confEnv := make(map[string]string)
+		initConfigsForTest(confEnv)
+		confEnv[schema.EnvJobType] = string(schema.TypeSparkJob)
+		confEnv[schema.EnvJobSparkMainFile] = "local:///opt/spark/examples/src/main/python/pi.py"
+		confEnv[schema.EnvJobDriverFlavour] = "ss"
+		confEnv[schema.EnvJobExecutorFlavour] = "ss"
+		for k, v := range test.additionalEnv {
+			confEnv[k] = v
+		}
+		err := validateSparkMode(&models.Conf{Env: confEnv})
+		assert.Equal(t, test.wantErr, err != nil, test.caseName)
+	}
+}
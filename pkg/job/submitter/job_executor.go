@@ -18,29 +18,37 @@ package submitter
 
 import (
 	"context"
+	"io"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	"paddleflow/pkg/common/k8s"
+	commonschema "paddleflow/pkg/common/schema"
 )
 
+// JobExecutorInterface queueName is used to find the cluster which the job runs on
 type JobExecutorInterface interface {
-	StartJob(job interface{}, gvk schema.GroupVersionKind) error
-	StopJob(namespace string, name string, gvk schema.GroupVersionKind) error
+	StartJob(queueName string, job interface{}, gvk schema.GroupVersionKind) error
+	StopJob(queueName, namespace, name string, gvk schema.GroupVersionKind) error
+	UpdateJob(queueName, namespace, name string, gvk schema.GroupVersionKind, patchData []byte) error
+	GetJobLog(queueName string, request commonschema.JobLogRequest) (commonschema.JobLogInfo, error)
+	GetJobLogStream(ctx context.Context, queueName string, request commonschema.JobLogRequest) (io.ReadCloser, error)
 }
 
 var JobExecutor JobExecutorInterface
 
 func Init(config *rest.Config) error {
-	executor, err := NewSingleClusterJobExecutor(config)
+	executor, err := NewMultiClusterJobExecutor(config)
 	if err != nil {
-		log.Errorf("new single cluster job executor failed, err %v", err)
+		log.Errorf("new multi cluster job executor failed, err %v", err)
 		return err
 	}
 	JobExecutor = executor
@@ -49,6 +57,7 @@ func Init(config *rest.Config) error {
 
 type SingleClusterJobExecutor struct {
 	dynamicClient dynamic.Interface
+	clientset     kubernetes.Interface
 }
 
 func NewSingleClusterJobExecutor(config *rest.Config) (JobExecutorInterface, error) {
@@ -58,14 +67,21 @@ func NewSingleClusterJobExecutor(config *rest.Config) (JobExecutorInterface, err
 		log.Errorf("Init dynamic client failed: [%v]", err)
 		return nil, err
 	}
+	// Prepare the clientset, which is used to get pods and logs of job
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Errorf("Init kubernetes client failed: [%v]", err)
+		return nil, err
+	}
 
 	executor := SingleClusterJobExecutor{
 		dynamicClient: dynamicClient,
+		clientset:     clientset,
 	}
 	return &executor, nil
 }
 
-func (executor *SingleClusterJobExecutor) StartJob(job interface{}, gvk schema.GroupVersionKind) error {
+func (executor *SingleClusterJobExecutor) StartJob(queueName string, job interface{}, gvk schema.GroupVersionKind) error {
 	log.Debugf("job executor begin start job")
 	gvr, err := k8s.GetGVRByGVK(gvk)
 	if err != nil {
@@ -90,7 +106,7 @@ func (executor *SingleClusterJobExecutor) StartJob(job interface{}, gvk schema.G
 	return err
 }
 
-func (executor *SingleClusterJobExecutor) StopJob(namespace string, name string, gvk schema.GroupVersionKind) error {
+func (executor *SingleClusterJobExecutor) StopJob(queueName, namespace, name string, gvk schema.GroupVersionKind) error {
 	log.Debugf("job executor begin stop job. ns:[%s] name:[%s]", namespace, name)
 	propagationPolicy := v1.DeletePropagationBackground
 	deleteOptions := v1.DeleteOptions{
@@ -106,3 +122,17 @@ func (executor *SingleClusterJobExecutor) StopJob(namespace string, name string,
 	}
 	return err
 }
+
+// UpdateJob patch job in cluster with json merge patch, such as priority of pending job
+func (executor *SingleClusterJobExecutor) UpdateJob(queueName, namespace, name string, gvk schema.GroupVersionKind, patchData []byte) error {
+	log.Debugf("job executor begin update job. ns:[%s] name:[%s] patch:[%s]", namespace, name, string(patchData))
+	gvr, err := k8s.GetGVRByGVK(gvk)
+	if err != nil {
+		return err
+	}
+	_, err = executor.dynamicClient.Resource(gvr).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, patchData, v1.PatchOptions{})
+	if err != nil {
+		log.Errorf("update job failed. error:[%s]", err.Error())
+	}
+	return err
+}
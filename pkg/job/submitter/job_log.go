@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package submitter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+// SparkAppNameLabel is added to driver and executor pods by spark-operator
+const SparkAppNameLabel = "sparkoperator.k8s.io/app-name"
+
+// GetJobLog get paginated logs of each container of each pod of job
+func (executor *SingleClusterJobExecutor) GetJobLog(queueName string, request commonschema.JobLogRequest) (commonschema.JobLogInfo, error) {
+	log.Debugf("job executor begin get job log. request:[%+v]", request)
+	pods, err := executor.ListJobPods(request.Namespace, request.JobID, request.JobType)
+	if err != nil {
+		return commonschema.JobLogInfo{}, err
+	}
+	jobLogInfo := commonschema.JobLogInfo{
+		JobID:    request.JobID,
+		TaskList: []commonschema.TaskLogInfo{},
+	}
+	for _, pod := range pods {
+		if request.TaskName != "" && pod.Name != request.TaskName {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			if request.ContainerName != "" && container.Name != request.ContainerName {
+				continue
+			}
+			taskLogInfo := commonschema.TaskLogInfo{
+				TaskID:        pod.Name,
+				ContainerName: container.Name,
+			}
+			logInfo, err := executor.getContainerLog(pod.Namespace, pod.Name, container.Name, request)
+			if err != nil {
+				// logs of other containers are still returned, e.g. when container of a pod is creating
+				log.Warnf("get log of pod[%s/%s] container[%s] failed. error:[%s]",
+					pod.Namespace, pod.Name, container.Name, err.Error())
+				taskLogInfo.Info = commonschema.LogInfo{
+					PageNo:    request.PageNo,
+					LineLimit: request.LineLimit,
+					Message:   err.Error(),
+				}
+			} else {
+				taskLogInfo.Info = logInfo
+			}
+			jobLogInfo.TaskList = append(jobLogInfo.TaskList, taskLogInfo)
+		}
+	}
+	return jobLogInfo, nil
+}
+
+// GetJobLogStream follow the log of one container, the first pod and container are used if not specified,
+// the stream is closed when ctx is done, e.g. the client of follow request is disconnected
+func (executor *SingleClusterJobExecutor) GetJobLogStream(ctx context.Context, queueName string, request commonschema.JobLogRequest) (io.ReadCloser, error) {
+	log.Debugf("job executor begin get job log stream. request:[%+v]", request)
+	pods, err := executor.ListJobPods(request.Namespace, request.JobID, request.JobType)
+	if err != nil {
+		return nil, err
+	}
+	var pod *corev1.Pod
+	for i := range pods {
+		if request.TaskName == "" || pods[i].Name == request.TaskName {
+			pod = &pods[i]
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("task[%s] of job[%s] not found", request.TaskName, request.JobID)
+	}
+	containerName := request.ContainerName
+	if containerName == "" && len(pod.Spec.Containers) > 0 {
+		containerName = pod.Spec.Containers[0].Name
+	}
+	logOptions := &corev1.PodLogOptions{
+		Container: containerName,
+		Follow:    true,
+	}
+	if request.TailLines > 0 {
+		logOptions.TailLines = &request.TailLines
+	}
+	return executor.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOptions).Stream(ctx)
+}
+
+// ListJobPods list pods of job sorted by name, pods are selected by job id label which is patched into pod template,
+// pods of user defined vcjob and spark app are selected by the labels of volcano and spark-operator
+func (executor *SingleClusterJobExecutor) ListJobPods(namespace, jobID, jobType string) ([]corev1.Pod, error) {
+	selectors := []string{fmt.Sprintf("%s=%s", commonschema.JobIDLabel, jobID)}
+	switch commonschema.JobType(jobType) {
+	case commonschema.TypeVcJob:
+		selectors = append(selectors, fmt.Sprintf("%s=%s", commonschema.VolcanoJobNameLabel, jobID))
+	case commonschema.TypeSparkJob:
+		selectors = append(selectors, fmt.Sprintf("%s=%s", SparkAppNameLabel, jobID))
+	}
+	for _, selector := range selectors {
+		podList, err := executor.clientset.CoreV1().Pods(namespace).List(context.TODO(),
+			metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			log.Errorf("list pods of job[%s/%s] failed. error:[%s]", namespace, jobID, err.Error())
+			return nil, err
+		}
+		if len(podList.Items) > 0 {
+			pods := podList.Items
+			sort.Slice(pods, func(i, j int) bool {
+				return pods[i].Name < pods[j].Name
+			})
+			return pods, nil
+		}
+	}
+	return []corev1.Pod{}, nil
+}
+
+// getContainerLog get one page of log of container, at most MaxLogBytes of log is fetched,
+// and only lines of the page are kept in memory
+func (executor *SingleClusterJobExecutor) getContainerLog(namespace, podName, containerName string,
+	request commonschema.JobLogRequest) (commonschema.LogInfo, error) {
+	limitBytes := commonschema.MaxLogBytes
+	logOptions := &corev1.PodLogOptions{
+		Container:  containerName,
+		LimitBytes: &limitBytes,
+	}
+	if request.TailLines > 0 {
+		logOptions.TailLines = &request.TailLines
+	}
+	reader, err := executor.clientset.CoreV1().Pods(namespace).GetLogs(podName, logOptions).Stream(context.TODO())
+	if err != nil {
+		return commonschema.LogInfo{}, err
+	}
+	defer reader.Close()
+	logInfo, readBytes, err := ReadLogPage(reader, request.PageNo, request.LineLimit)
+	if err != nil {
+		return commonschema.LogInfo{}, err
+	}
+	if readBytes >= limitBytes {
+		logInfo.Message = fmt.Sprintf("log is truncated at %d bytes, use tailLines to get the latest log", limitBytes)
+	}
+	return logInfo, nil
+}
+
+// SplitLogPage return the lines of log in page pageNo, each page contains lineLimit lines
+func SplitLogPage(content string, pageNo, lineLimit int) commonschema.LogInfo {
+	logInfo, _, _ := ReadLogPage(strings.NewReader(content), pageNo, lineLimit)
+	return logInfo
+}
+
+// ReadLogPage read log line by line and only keep the lines in page pageNo, total lines and bytes of log are counted
+func ReadLogPage(reader io.Reader, pageNo, lineLimit int) (commonschema.LogInfo, int64, error) {
+	if pageNo <= 0 {
+		pageNo = commonschema.DefaultLogPageNo
+	}
+	if lineLimit <= 0 {
+		lineLimit = commonschema.DefaultLogLineLimit
+	}
+	logInfo := commonschema.LogInfo{
+		PageNo:    pageNo,
+		LineLimit: lineLimit,
+	}
+	start := (pageNo - 1) * lineLimit
+	end := start + lineLimit
+	var content strings.Builder
+	var readBytes int64
+	bufReader := bufio.NewReader(reader)
+	for {
+		line, err := bufReader.ReadString('\n')
+		if line != "" {
+			readBytes += int64(len(line))
+			if logInfo.LineCount >= start && logInfo.LineCount < end {
+				content.WriteString(line)
+			}
+			logInfo.LineCount++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return commonschema.LogInfo{}, readBytes, err
+		}
+	}
+	logInfo.HasNextPage = logInfo.LineCount > end
+	logInfo.LogContent = content.String()
+	return logInfo, readBytes, nil
+}
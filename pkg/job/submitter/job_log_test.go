@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package submitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+func newFakeJobPod(name, namespace string, labels map[string]string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+	}
+	return pod
+}
+
+func TestGetJobLog(t *testing.T) {
+	jobID := "job-000001"
+	clientset := fake.NewSimpleClientset(
+		newFakeJobPod("job-000001-task-1", "default", map[string]string{commonschema.JobIDLabel: jobID}, "c1", "c2"),
+		newFakeJobPod("job-000001-task-0", "default", map[string]string{commonschema.JobIDLabel: jobID}, "c1"),
+		newFakeJobPod("job-000002-task-0", "default", map[string]string{commonschema.JobIDLabel: "job-000002"}, "c1"),
+		newFakeJobPod("job-000003-task-0", "default", map[string]string{commonschema.VolcanoJobNameLabel: "job-000003"}, "c1"),
+	)
+	executor := &SingleClusterJobExecutor{clientset: clientset}
+
+	// all tasks and containers of job
+	request := commonschema.JobLogRequest{
+		JobID:     jobID,
+		JobType:   string(commonschema.TypeVcJob),
+		Namespace: "default",
+	}
+	logInfo, err := executor.GetJobLog("", request)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(logInfo.TaskList))
+	assert.Equal(t, "job-000001-task-0", logInfo.TaskList[0].TaskID)
+	assert.Equal(t, "c2", logInfo.TaskList[2].ContainerName)
+	// fake clientset returns "fake logs" as content
+	assert.Equal(t, "fake logs", logInfo.TaskList[0].Info.LogContent)
+
+	// specified task and container
+	request.TaskName = "job-000001-task-1"
+	request.ContainerName = "c1"
+	logInfo, err = executor.GetJobLog("", request)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(logInfo.TaskList))
+
+	// pods of vcjob from user yaml are selected by volcano label
+	request = commonschema.JobLogRequest{
+		JobID:     "job-000003",
+		JobType:   string(commonschema.TypeVcJob),
+		Namespace: "default",
+	}
+	logInfo, err = executor.GetJobLog("", request)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(logInfo.TaskList))
+
+	// job without pods
+	request.JobID = "job-000004"
+	logInfo, err = executor.GetJobLog("", request)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(logInfo.TaskList))
+}
+
+func TestSplitLogPage(t *testing.T) {
+	content := "line1\nline2\nline3\nline4\nline5\n"
+
+	logInfo := SplitLogPage(content, 1, 2)
+	assert.Equal(t, "line1\nline2\n", logInfo.LogContent)
+	assert.Equal(t, 5, logInfo.LineCount)
+	assert.True(t, logInfo.HasNextPage)
+
+	logInfo = SplitLogPage(content, 3, 2)
+	assert.Equal(t, "line5\n", logInfo.LogContent)
+	assert.False(t, logInfo.HasNextPage)
+
+	logInfo = SplitLogPage(content, 4, 2)
+	assert.Equal(t, "", logInfo.LogContent)
+	assert.False(t, logInfo.HasNextPage)
+
+	// default page and line limit
+	logInfo = SplitLogPage(content, 0, 0)
+	assert.Equal(t, content, logInfo.LogContent)
+	assert.Equal(t, commonschema.DefaultLogLineLimit, logInfo.LineLimit)
+}
+
+func TestReadLogPage(t *testing.T) {
+	content := "line1\nline2\nline3\nline4\nline5"
+
+	logInfo, readBytes, err := ReadLogPage(strings.NewReader(content), 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "line3\nline4\n", logInfo.LogContent)
+	assert.Equal(t, 5, logInfo.LineCount)
+	assert.True(t, logInfo.HasNextPage)
+	assert.Equal(t, int64(len(content)), readBytes)
+
+	// the last line without "\n" is also counted
+	logInfo, _, err = ReadLogPage(strings.NewReader(content), 3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "line5", logInfo.LogContent)
+	assert.False(t, logInfo.HasNextPage)
+
+	logInfo, readBytes, err = ReadLogPage(strings.NewReader(""), 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "", logInfo.LogContent)
+	assert.Equal(t, 0, logInfo.LineCount)
+	assert.Equal(t, int64(0), readBytes)
+}
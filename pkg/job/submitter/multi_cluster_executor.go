@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package submitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"paddleflow/pkg/apiserver/controller/cluster"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/logger"
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+type clusterExecutor struct {
+	credential string
+	executor   JobExecutorInterface
+}
+
+// MultiClusterJobExecutor dispatch job to the cluster which its queue belongs to,
+// job whose queue has no cluster is dispatched to the cluster which server runs on
+type MultiClusterJobExecutor struct {
+	sync.RWMutex
+	defaultExecutor JobExecutorInterface
+	// clusterName -> executor, rebuilt when credential of cluster changed
+	executors map[string]*clusterExecutor
+	// build executor of registered cluster, could be replaced in unit test
+	newClusterExecutor func(clusterInfo models.ClusterInfo) (JobExecutorInterface, error)
+}
+
+func NewMultiClusterJobExecutor(config *rest.Config) (JobExecutorInterface, error) {
+	defaultExecutor, err := NewSingleClusterJobExecutor(config)
+	if err != nil {
+		log.Errorf("new job executor of default cluster failed, err %v", err)
+		return nil, err
+	}
+	executor := &MultiClusterJobExecutor{
+		defaultExecutor:    defaultExecutor,
+		executors:          map[string]*clusterExecutor{},
+		newClusterExecutor: newClusterExecutor,
+	}
+	return executor, nil
+}
+
+func (executor *MultiClusterJobExecutor) StartJob(queueName string, job interface{}, gvk schema.GroupVersionKind) error {
+	e, err := executor.getExecutor(queueName)
+	if err != nil {
+		log.Errorf("start job failed. error:[%s]", err.Error())
+		return err
+	}
+	return e.StartJob(queueName, job, gvk)
+}
+
+func (executor *MultiClusterJobExecutor) StopJob(queueName, namespace, name string, gvk schema.GroupVersionKind) error {
+	e, err := executor.getExecutor(queueName)
+	if err != nil {
+		log.Errorf("stop job failed. error:[%s]", err.Error())
+		return err
+	}
+	return e.StopJob(queueName, namespace, name, gvk)
+}
+
+func (executor *MultiClusterJobExecutor) UpdateJob(queueName, namespace, name string, gvk schema.GroupVersionKind, patchData []byte) error {
+	e, err := executor.getExecutor(queueName)
+	if err != nil {
+		log.Errorf("update job failed. error:[%s]", err.Error())
+		return err
+	}
+	return e.UpdateJob(queueName, namespace, name, gvk, patchData)
+}
+
+func (executor *MultiClusterJobExecutor) GetJobLog(queueName string, request commonschema.JobLogRequest) (commonschema.JobLogInfo, error) {
+	e, err := executor.getExecutor(queueName)
+	if err != nil {
+		log.Errorf("get job log failed. error:[%s]", err.Error())
+		return commonschema.JobLogInfo{}, err
+	}
+	return e.GetJobLog(queueName, request)
+}
+
+func (executor *MultiClusterJobExecutor) GetJobLogStream(ctx context.Context, queueName string, request commonschema.JobLogRequest) (io.ReadCloser, error) {
+	e, err := executor.getExecutor(queueName)
+	if err != nil {
+		log.Errorf("get job log stream failed. error:[%s]", err.Error())
+		return nil, err
+	}
+	return e.GetJobLogStream(ctx, queueName, request)
+}
+
+func (executor *MultiClusterJobExecutor) getExecutor(queueName string) (JobExecutorInterface, error) {
+	if queueName == "" {
+		return executor.defaultExecutor, nil
+	}
+	ctx := &logger.RequestContext{}
+	queue, err := models.GetQueueByName(ctx, queueName)
+	if err != nil {
+		return nil, fmt.Errorf("get queue[%s] failed, err: %v", queueName, err)
+	}
+	if queue.ClusterName == "" {
+		return executor.defaultExecutor, nil
+	}
+	clusterInfo, err := models.GetClusterByName(ctx, queue.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("get cluster[%s] of queue[%s] failed, err: %v", queue.ClusterName, queueName, err)
+	}
+	if clusterInfo.Status != models.ClusterStatusOnLine {
+		return nil, fmt.Errorf("cluster[%s] of queue[%s] is %s", clusterInfo.Name, queueName, clusterInfo.Status)
+	}
+
+	executor.RLock()
+	cached, found := executor.executors[clusterInfo.Name]
+	executor.RUnlock()
+	if found && cached.credential == clusterInfo.Credential {
+		return cached.executor, nil
+	}
+
+	e, err := executor.newClusterExecutor(clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	executor.Lock()
+	executor.executors[clusterInfo.Name] = &clusterExecutor{
+		credential: clusterInfo.Credential,
+		executor:   e,
+	}
+	executor.Unlock()
+	log.Infof("job executor of cluster[%s] is built", clusterInfo.Name)
+	return e, nil
+}
+
+func newClusterExecutor(clusterInfo models.ClusterInfo) (JobExecutorInterface, error) {
+	config, err := cluster.GetKubeConfig(clusterInfo.Name, clusterInfo.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("init kube config of cluster[%s] failed, err: %v", clusterInfo.Name, err)
+	}
+	return NewSingleClusterJobExecutor(config)
+}
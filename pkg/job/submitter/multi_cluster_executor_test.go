@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package submitter
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/database/db_fake"
+	"paddleflow/pkg/common/k8s"
+	"paddleflow/pkg/common/logger"
+	commonschema "paddleflow/pkg/common/schema"
+)
+
+type fakeExecutor struct {
+	name    string
+	started []string
+	stopped []string
+	updated []string
+}
+
+func (f *fakeExecutor) StartJob(queueName string, job interface{}, gvk schema.GroupVersionKind) error {
+	f.started = append(f.started, queueName)
+	return nil
+}
+
+func (f *fakeExecutor) StopJob(queueName, namespace, name string, gvk schema.GroupVersionKind) error {
+	f.stopped = append(f.stopped, name)
+	return nil
+}
+
+func (f *fakeExecutor) UpdateJob(queueName, namespace, name string, gvk schema.GroupVersionKind, patchData []byte) error {
+	f.updated = append(f.updated, name)
+	return nil
+}
+
+func (f *fakeExecutor) GetJobLog(queueName string, request commonschema.JobLogRequest) (commonschema.JobLogInfo, error) {
+	return commonschema.JobLogInfo{JobID: request.JobID}, nil
+}
+
+func (f *fakeExecutor) GetJobLogStream(ctx context.Context, queueName string, request commonschema.JobLogRequest) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func TestMultiClusterJobExecutor(t *testing.T) {
+	db_fake.InitFakeDB()
+	ctx := &logger.RequestContext{}
+
+	clusters := []models.ClusterInfo{
+		{ID: "cluster-1", Name: "gpu-1", Status: models.ClusterStatusOnLine, Credential: "credential-1"},
+		{ID: "cluster-2", Name: "gpu-2", Status: models.ClusterStatusOffLine, Credential: "credential-2"},
+	}
+	for i := range clusters {
+		assert.Nil(t, models.CreateCluster(ctx, &clusters[i]))
+	}
+	queues := []models.Queue{
+		{QueueInfo: models.QueueInfo{Name: "q-default", Namespace: "default"}},
+		{QueueInfo: models.QueueInfo{Name: "q-gpu-1", Namespace: "default", ClusterName: "gpu-1"}},
+		{QueueInfo: models.QueueInfo{Name: "q-gpu-2", Namespace: "default", ClusterName: "gpu-2"}},
+	}
+	for i := range queues {
+		assert.Nil(t, models.CreateQueue(ctx, &queues[i]))
+	}
+
+	defaultExecutor := &fakeExecutor{name: "default"}
+	built := 0
+	clusterExecutors := map[string]*fakeExecutor{}
+	executor := &MultiClusterJobExecutor{
+		defaultExecutor: defaultExecutor,
+		executors:       map[string]*clusterExecutor{},
+		newClusterExecutor: func(clusterInfo models.ClusterInfo) (JobExecutorInterface, error) {
+			built++
+			e := &fakeExecutor{name: clusterInfo.Name}
+			clusterExecutors[clusterInfo.Name] = e
+			return e, nil
+		},
+	}
+
+	// queue without cluster runs on default cluster
+	assert.Nil(t, executor.StartJob("q-default", nil, k8s.VCJobGVK))
+	assert.Equal(t, []string{"q-default"}, defaultExecutor.started)
+
+	// queue with online cluster, executor is cached
+	assert.Nil(t, executor.StartJob("q-gpu-1", nil, k8s.VCJobGVK))
+	assert.Nil(t, executor.StopJob("q-gpu-1", "default", "job-1", k8s.VCJobGVK))
+	assert.Equal(t, 1, built)
+	assert.Equal(t, []string{"q-gpu-1"}, clusterExecutors["gpu-1"].started)
+	assert.Equal(t, []string{"job-1"}, clusterExecutors["gpu-1"].stopped)
+	assert.Nil(t, executor.UpdateJob("q-gpu-1", "default", "job-1", k8s.VCJobGVK, []byte("{}")))
+	assert.Equal(t, []string{"job-1"}, clusterExecutors["gpu-1"].updated)
+
+	// executor is rebuilt after credential changed
+	clusters[0].Credential = "credential-1-new"
+	assert.Nil(t, models.UpdateCluster(ctx, clusters[0].ID, &clusters[0]))
+	assert.Nil(t, executor.StartJob("q-gpu-1", nil, k8s.VCJobGVK))
+	assert.Equal(t, 2, built)
+
+	// queue with offline cluster
+	assert.NotNil(t, executor.StartJob("q-gpu-2", nil, k8s.VCJobGVK))
+	// queue not exist
+	assert.NotNil(t, executor.StartJob("q-not-exist", nil, k8s.VCJobGVK))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	vcjob "volcano.sh/apis/pkg/apis/batch/v1alpha1"
+
+	paddlev1 "paddleflow/pkg/apis/paddle-operator/batch.paddlepaddle.org/v1"
+	sparkapp "paddleflow/pkg/apis/spark-operator/sparkoperator.k8s.io/v1beta2"
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/errors"
+	"paddleflow/pkg/common/k8s"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+type templateEntity struct {
+	gvk       k8sschema.GroupVersionKind
+	newObject func() runtime.Object
+}
+
+// jobTemplateEntity indicate kind and object of template for each job type
+var jobTemplateEntity = map[schema.JobType]templateEntity{
+	schema.TypeVcJob: {
+		gvk:       k8s.VCJobGVK,
+		newObject: func() runtime.Object { return &vcjob.Job{} },
+	},
+	schema.TypeSparkJob: {
+		gvk:       k8s.SparkAppGVK,
+		newObject: func() runtime.Object { return &sparkapp.SparkApplication{} },
+	},
+	schema.TypeBatchJob: {
+		gvk:       k8s.BatchJobGVK,
+		newObject: func() runtime.Object { return &batchv1.Job{} },
+	},
+	schema.TypePaddleJob: {
+		gvk:       k8s.PaddleJobGVK,
+		newObject: func() runtime.Object { return &paddlev1.PaddleJob{} },
+	},
+}
+
+// ValidateJobTemplate check that content of template can be parsed to the object of job type
+func ValidateJobTemplate(jobType string, content []byte) error {
+	entity, found := jobTemplateEntity[schema.JobType(jobType)]
+	if !found {
+		return errors.InvalidJobTypeError(jobType)
+	}
+	object := entity.newObject()
+	if err := parseBytesToObject(content, object); err != nil {
+		return fmt.Errorf("parse template of %s failed, err: %v", jobType, err)
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if gvk != entity.gvk {
+		return fmt.Errorf("kind of template is %s, but %s is expected for job type %s", gvk.String(), entity.gvk.String(), jobType)
+	}
+	return nil
+}
+
+// getJobTemplateContent get content of template registered by api, template of queue is preferred to global template
+func getJobTemplateContent(conf *models.Conf) ([]byte, error) {
+	templateName := conf.Env[schema.EnvJobTemplate]
+	jobType := conf.Env[schema.EnvJobType]
+	ctx := &logger.RequestContext{}
+	template, err := models.GetJobTemplate(ctx, templateName, conf.Env[schema.EnvJobQueueName])
+	if err == gorm.ErrRecordNotFound {
+		// fall back to global template only when queue has no template with the name
+		template, err = models.GetJobTemplate(ctx, templateName, "")
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("job template[%s] is not found", templateName)
+	}
+	if err != nil {
+		log.Errorf("get job template[%s] failed, err %v", templateName, err)
+		return nil, err
+	}
+	if template.JobType != jobType {
+		return nil, fmt.Errorf("job template[%s] is for %s, cannot be used by %s", templateName, template.JobType, jobType)
+	}
+	return []byte(template.Content), nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/database"
+	"paddleflow/pkg/common/database/db_fake"
+	"paddleflow/pkg/common/logger"
+	"paddleflow/pkg/common/schema"
+)
+
+const (
+	testVCJobTemplate = `
+apiVersion: batch.volcano.sh/v1alpha1
+kind: Job
+metadata:
+  name: vcjob-template
+spec:
+  minAvailable: 1
+  schedulerName: volcano
+`
+	testBatchJobTemplate = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: batchjob-template
+`
+)
+
+func TestValidateJobTemplate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		jobType string
+		content string
+		wantErr bool
+	}{
+		{name: "valid vcjob template", jobType: string(schema.TypeVcJob), content: testVCJobTemplate},
+		{name: "kind mismatch", jobType: string(schema.TypeVcJob), content: testBatchJobTemplate, wantErr: true},
+		{name: "invalid job type", jobType: "unknown", content: testVCJobTemplate, wantErr: true},
+		{name: "invalid yaml", jobType: string(schema.TypeVcJob), content: "{invalid", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateJobTemplate(tc.jobType, []byte(tc.content))
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetJobTemplateContent(t *testing.T) {
+	db_fake.InitFakeDB()
+	ctx := &logger.RequestContext{}
+	templates := []models.JobTemplate{
+		{Name: "tpl", JobType: string(schema.TypeVcJob), Content: "global"},
+		{Name: "tpl", QueueName: "q1", JobType: string(schema.TypeVcJob), Content: "queue"},
+		{Name: "batch", JobType: string(schema.TypeBatchJob), Content: testBatchJobTemplate},
+	}
+	for i := range templates {
+		assert.NoError(t, models.CreateJobTemplate(ctx, &templates[i]))
+	}
+
+	newConf := func(templateName, queueName string) *models.Conf {
+		return &models.Conf{Env: map[string]string{
+			schema.EnvJobTemplate:  templateName,
+			schema.EnvJobType:      string(schema.TypeVcJob),
+			schema.EnvJobQueueName: queueName,
+		}}
+	}
+	// template of queue is preferred
+	content, err := getJobTemplateContent(newConf("tpl", "q1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "queue", string(content))
+	// fall back to global template
+	content, err = getJobTemplateContent(newConf("tpl", "q2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "global", string(content))
+	// job type mismatch
+	_, err = getJobTemplateContent(newConf("batch", "q1"))
+	assert.Error(t, err)
+	// not found
+	_, err = getJobTemplateContent(newConf("none", "q1"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not found")
+
+	// database errors are not treated as not found
+	assert.NoError(t, database.DB.Migrator().DropTable("job_template"))
+	_, err = getJobTemplateContent(newConf("tpl", "q1"))
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "is not found")
+}
@@ -103,14 +103,15 @@ func (vcJob *VCJob) CreateJob(conf *models.Conf) (string, error) {
 	patchVCJobVariable(jobApp, jobID, conf)
 
 	job := &models.Job{
-		ID:       jobID,
-		Type:     conf.Env[schema.EnvJobType],
-		UserName: conf.Env[schema.EnvJobUserName],
-		Config:   *conf,
+		ID:        jobID,
+		Type:      conf.Env[schema.EnvJobType],
+		UserName:  conf.Env[schema.EnvJobUserName],
+		QueueName: conf.Env[schema.EnvJobQueueName],
+		Config:    *conf,
 	}
 	log.Debugf("begin submit job jobID:[%s] job:[%s]", jobID, config.PrettyFormat(job))
 	err := persistAndExecuteJob(job, func() error {
-		return submitter.JobExecutor.StartJob(jobApp, k8s.VCJobGVK)
+		return submitter.JobExecutor.StartJob(job.QueueName, jobApp, k8s.VCJobGVK)
 	})
 	if err != nil {
 		log.Errorf("create job %v failed, err %v", job, err)
@@ -125,13 +126,29 @@ func (vcJob *VCJob) StopJobByID(jobID string) error {
 		return err
 	}
 	namespace := job.Config.Env[schema.EnvJobNamespace]
-	if err = submitter.JobExecutor.StopJob(namespace, job.ID, k8s.VCJobGVK); err != nil {
+	if err = submitter.JobExecutor.StopJob(job.QueueName, namespace, job.ID, k8s.VCJobGVK); err != nil {
 		log.Errorf("stop vcjob %s in namespace %s failed, err %v", job.ID, namespace, err)
 		return err
 	}
 	return nil
 }
 
+// UpdateJobPriority patch priority class of the podgroup of vcjob, which is used by volcano scheduler to order pending jobs in queue.
+// spec of vcjob can not be patched, as it is rejected by the admission webhook of volcano,
+// and the podgroup created by volcano job controller has the same name as vcjob
+func (vcJob *VCJob) UpdateJobPriority(job *models.Job, priority string) error {
+	patchData, err := generatePriorityPatch(priority, "spec", "priorityClassName")
+	if err != nil {
+		return err
+	}
+	namespace := job.Config.Env[schema.EnvJobNamespace]
+	if err = submitter.JobExecutor.UpdateJob(job.QueueName, namespace, job.ID, k8s.PodGroupGVK, patchData); err != nil {
+		log.Errorf("update priority of podgroup of vcjob %s in namespace %s failed, err %v", job.ID, namespace, err)
+		return err
+	}
+	return nil
+}
+
 func fillPSJobSpec(jobSpec *vcjob.Job, conf *models.Conf) error {
 	conf.Env[schema.EnvJobPSPort] = strconv.FormatInt(int64(psPort), 10)
 
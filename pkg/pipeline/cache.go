@@ -20,7 +20,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
 
@@ -40,7 +39,13 @@ type aggressiveFirstCacheKey struct {
 	OutputArtifacts map[string]string `json:",omitempty"`
 }
 
+// 用于计算激进策略的第二层 fingerprint 的结构
 type aggressiveSecondCacheKey struct {
+	// 输入 artifact 的名字到其内容（sha256）的映射
+	InputArtifactsDigest map[string]string `json:",omitempty"`
+
+	// Fs 上的文件名与其 modTime 之间的映射关系
+	FsScopeModTime map[string]string `json:",omitempty"`
 }
 
 // 用于计算保守策略的第一层 fingerprint 的结构
@@ -92,19 +97,196 @@ type CacheCalculator interface {
 	CalculateSecondFingerprint() (fingerprint string, err error)
 }
 
+// 提取cacheKey 时需要剔除系统变量
+func getEnvWithoutSysParams(env map[string]string) map[string]string {
+	SysParamNameList := []string{SysParamNamePFRunID, SysParamNamePFFsID, SysParamNamePFJobID, SysParamNamePFStepName, SysParamNamePFFsName, SysParamNamePFUserID, SysParamNamePFUserName}
+
+	envWithoutSystmeEnv := map[string]string{}
+	for name, value := range env {
+		if !StringsContain(SysParamNameList, name) {
+			envWithoutSystmeEnv[name] = value
+		}
+	}
+	return envWithoutSystmeEnv
+}
+
+// 本地进程 job 不依赖镜像，镜像为空
+func getJobImage(job Job) string {
+	if pfj, ok := job.(*PaddleFlowJob); ok {
+		return pfj.Image
+	}
+	return ""
+}
+
+func newFsHandlerForStep(step Step) (*handler.FsHandler, error) {
+	fsHandler, err := handler.NewFsHandlerWithServer(step.wfr.wf.Extra[WfExtraInfoKeyFsID], config.GlobalServerConfig.ApiServer.Host,
+		config.GlobalServerConfig.ApiServer.Port, step.getLogger())
+
+	if err != nil {
+		errMsg := fmt.Errorf("init fsHandler failed: %s", err.Error())
+		step.getLogger().Errorln(errMsg)
+		return nil, err
+	}
+	return fsHandler, nil
+}
+
+func getFsScopeModTime(fsHandler *handler.FsHandler, step Step, cacheConfig schema.Cache) (map[string]string, error) {
+	fsScopeMtimeMap := map[string]string{}
+
+	FsScope := strings.TrimSpace(cacheConfig.FsScope)
+	// 如果FsScope 为空字符串，则默认设置为 更目录
+	if FsScope == "" {
+		FsScope = "/"
+	}
+
+	for _, path := range strings.Split(FsScope, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		mtime, err := fsHandler.ModTime(path)
+		if err != nil {
+			err = fmt.Errorf("get the mtime of fsScope file[%s] failed: %s", path, err.Error())
+			step.getLogger().Errorln(err.Error())
+			return map[string]string{}, err
+		}
+		fsScopeMtimeMap[path] = fmt.Sprintf("%d", mtime.UnixNano())
+	}
+
+	return fsScopeMtimeMap, nil
+}
+
 type aggressiveCacheCalculator struct {
+	fsHandler      *handler.FsHandler
 	step           Step
 	cacheConfig    schema.Cache
-	firstCacheKey  aggressiveFirstCacheKey
-	secondCacheKey aggressiveSecondCacheKey
+	firstCacheKey  *aggressiveFirstCacheKey
+	secondCacheKey *aggressiveSecondCacheKey
 }
 
-// TODO
+// 调用方应该保证在启用了 cache 功能的情况下才会调用NewAggressiveCacheCalculator
 func NewAggressiveCacheCalculator(step Step, cacheConfig schema.Cache) (CacheCalculator, error) {
-	errMsg := "aggressive cache strategy is not supported now!!"
-	err := errors.New(errMsg)
-	step.getLogger().Errorln(errMsg)
-	return nil, err
+	fsHandler, err := newFsHandlerForStep(step)
+	if err != nil {
+		return nil, err
+	}
+
+	calculator := aggressiveCacheCalculator{
+		step:        step,
+		cacheConfig: cacheConfig,
+		fsHandler:   fsHandler,
+	}
+	return &calculator, nil
+}
+
+func (ac *aggressiveCacheCalculator) generateFirstCacheKey() error {
+	job := ac.step.job.Job()
+
+	// 与保守策略不同，激进策略不考虑 step 名字，使得不同 pipeline 中相同的 step 可以复用 cache
+	cacheKey := aggressiveFirstCacheKey{
+		DockerEnv:       getJobImage(ac.step.job),
+		Parameters:      job.Parameters,
+		Command:         job.Command,
+		InputArtifacts:  job.Artifacts.Input,
+		OutputArtifacts: job.Artifacts.Output,
+		Env:             getEnvWithoutSysParams(job.Env),
+	}
+
+	logMsg := fmt.Sprintf("FirstCacheKey: \nDockerEnv: %s, Parameters: %s, Command: %s, InputArtifacts: %s, OutputArtifacts: %s, Env: %s", cacheKey.DockerEnv, job.Parameters, job.Command, job.Artifacts.Input, job.Artifacts.Output, cacheKey.Env)
+	ac.step.getLogger().Debugf(logMsg)
+
+	ac.firstCacheKey = &cacheKey
+	return nil
+}
+
+func (ac *aggressiveCacheCalculator) CalculateFirstFingerprint() (fingerprint string, err error) {
+	err = ac.generateFirstCacheKey()
+	if err != nil {
+		err = fmt.Errorf("Calculate FirstFingerprint failed due to generating FirstCacheKey: %s", err.Error())
+		ac.step.getLogger().Errorln(err.Error())
+		return "", err
+	}
+
+	firstFingerprint, err := calculateFingerprint(ac.firstCacheKey)
+	if err != nil {
+		err = fmt.Errorf("Calculate FirstFingerprint failed: %s", err.Error())
+		ac.step.getLogger().Errorln(err.Error())
+		return "", err
+	}
+
+	return firstFingerprint, err
+}
+
+func (ac *aggressiveCacheCalculator) getInputArtifactDigest() (map[string]string, error) {
+	inArt := ac.step.job.Job().Artifacts.Input
+
+	inArtDigestMap := map[string]string{}
+
+	for name, path := range inArt {
+		name = strings.TrimSpace(name)
+		path = strings.TrimSpace(path)
+
+		if name == "" || path == "" {
+			err := fmt.Errorf("the input artifact[%s] is illegal, name or path of it is empty", name)
+			ac.step.getLogger().Errorln(err.Error())
+			return map[string]string{}, err
+		}
+
+		digest, err := ac.fsHandler.Sha256(path)
+		if err != nil {
+			err = fmt.Errorf("get the sha256 of inputArtfact[%s] failed: %s", name, err.Error())
+			return map[string]string{}, err
+		}
+
+		inArtDigestMap[name] = digest
+	}
+
+	return inArtDigestMap, nil
+}
+
+func (ac *aggressiveCacheCalculator) generateSecondCacheKey() error {
+	fsScopeMTime, err := getFsScopeModTime(ac.fsHandler, ac.step, ac.cacheConfig)
+	if err != nil {
+		err := fmt.Errorf("generate SecondCacheKey failed: [%s]", err.Error())
+		ac.step.getLogger().Errorln(err.Error())
+		return err
+	}
+
+	inArt, err := ac.getInputArtifactDigest()
+	if err != nil {
+		err := fmt.Errorf("generate SecondCacheKey failed: [%s]", err.Error())
+		ac.step.getLogger().Errorln(err.Error())
+		return err
+	}
+
+	ac.secondCacheKey = &aggressiveSecondCacheKey{
+		InputArtifactsDigest: inArt,
+		FsScopeModTime:       fsScopeMTime,
+	}
+
+	logMsg := fmt.Sprintf("SecondCacheKey:\nInputArtDigest: %s, FsScopeMTime: %s", inArt, fsScopeMTime)
+	ac.step.getLogger().Debugf(logMsg)
+
+	return nil
+}
+
+func (ac *aggressiveCacheCalculator) CalculateSecondFingerprint() (fingerprint string, err error) {
+	err = ac.generateSecondCacheKey()
+	if err != nil {
+		err = fmt.Errorf("Calculate SecondFingerprint failed due to generating SecondCacheKey failed: %s", err.Error())
+		ac.step.getLogger().Errorln(err.Error())
+		return "", err
+	}
+
+	secondFingerprint, err := calculateFingerprint(ac.secondCacheKey)
+	if err != nil {
+		err = fmt.Errorf("Calculate SecondFingerprint failed: %s", err.Error())
+		ac.step.getLogger().Errorln(err.Error())
+		return "", err
+	}
+
+	return secondFingerprint, err
 }
 
 type conservativeCacheCalculator struct {
@@ -117,12 +299,8 @@ type conservativeCacheCalculator struct {
 
 // 调用方应该保证在启用了 cache 功能的情况下才会调用NewConservativeCacheCalculator
 func NewConservativeCacheCalculator(step Step, cacheConfig schema.Cache) (CacheCalculator, error) {
-	fsHandler, err := handler.NewFsHandlerWithServer(step.wfr.wf.Extra[WfExtraInfoKeyFsID], config.GlobalServerConfig.ApiServer.Host,
-		config.GlobalServerConfig.ApiServer.Port, step.getLogger())
-
+	fsHandler, err := newFsHandlerForStep(step)
 	if err != nil {
-		errMsg := fmt.Errorf("init fsHandler failed: %s", err.Error())
-		step.getLogger().Errorln(errMsg)
 		return nil, err
 	}
 
@@ -135,38 +313,20 @@ func NewConservativeCacheCalculator(step Step, cacheConfig schema.Cache) (CacheC
 }
 
 func (cc *conservativeCacheCalculator) generateFirstCacheKey() error {
-	// 提取cacheKey 时需要剔除系统变量
-	SysParamNameList := []string{SysParamNamePFRunID, SysParamNamePFFsID, SysParamNamePFJobID, SysParamNamePFStepName, SysParamNamePFFsName, SysParamNamePFUserID, SysParamNamePFUserName}
-
 	job := cc.step.job.Job()
 
-	// 去除系统环境变量
-	envWithoutSystmeEnv := map[string]string{}
-	for name, value := range job.Env {
-		isSysParam := false
-		for _, sysParam := range SysParamNameList {
-			if sysParam == name {
-				isSysParam = true
-			}
-		}
-
-		if !isSysParam {
-			envWithoutSystmeEnv[name] = value
-		}
-	}
-
 	cacheKey := conservativeFirstCacheKey{
-		DockerEnv:       cc.step.job.(*PaddleFlowJob).Image,
+		DockerEnv:       getJobImage(cc.step.job),
 		Parameters:      job.Parameters,
 		Command:         job.Command,
 		InputArtifacts:  job.Artifacts.Input,
 		OutputArtifacts: job.Artifacts.Output,
-		Env:             envWithoutSystmeEnv,
+		Env:             getEnvWithoutSysParams(job.Env),
 		// job.Name 是全局唯一，step.name 是 run.yaml 内唯一
 		StepName: cc.step.name,
 	}
 
-	logMsg := fmt.Sprintf("FirstCacheKey: \nDockerEnv: %s, Parameters: %s, Command: %s, InputArtifacts: %s, OutputArtifacts: %s, Env: %s", cc.step.job.(*PaddleFlowJob).Image, job.Parameters, job.Command, job.Artifacts.Input, job.Artifacts.Output, cacheKey.Env)
+	logMsg := fmt.Sprintf("FirstCacheKey: \nDockerEnv: %s, Parameters: %s, Command: %s, InputArtifacts: %s, OutputArtifacts: %s, Env: %s", cacheKey.DockerEnv, job.Parameters, job.Command, job.Artifacts.Input, job.Artifacts.Output, cacheKey.Env)
 	cc.step.getLogger().Debugf(logMsg)
 
 	cc.firstCacheKey = &cacheKey
@@ -192,30 +352,7 @@ func (cc *conservativeCacheCalculator) CalculateFirstFingerprint() (fingerprint
 }
 
 func (cc *conservativeCacheCalculator) getFsScopeModTime() (map[string]string, error) {
-	fsScopeMtimeMap := map[string]string{}
-
-	FsScope := strings.TrimSpace(cc.cacheConfig.FsScope)
-	// 如果FsScope 为空字符串，则默认设置为 更目录
-	if FsScope == "" {
-		FsScope = "/"
-	}
-
-	for _, path := range strings.Split(FsScope, ",") {
-		path = strings.TrimSpace(path)
-		if path == "" {
-			continue
-		}
-
-		mtime, err := cc.fsHandler.ModTime(path)
-		if err != nil {
-			err = fmt.Errorf("get the mtime of fsScope file[%s] failed: %s", path, err.Error())
-			cc.step.getLogger().Errorln(err.Error())
-			return map[string]string{}, err
-		}
-		fsScopeMtimeMap[path] = fmt.Sprintf("%d", mtime.UnixNano())
-	}
-
-	return fsScopeMtimeMap, nil
+	return getFsScopeModTime(cc.fsHandler, cc.step, cc.cacheConfig)
 }
 
 func (cc *conservativeCacheCalculator) getInputArtifactModTime() (map[string]string, error) {
@@ -291,6 +428,14 @@ func (cc *conservativeCacheCalculator) CalculateSecondFingerprint() (fingerprint
 
 // 调用方应该保证在启用了 cache 功能的情况下才会调用NewCacheCalculator
 func NewCacheCalculator(step Step, cacheConfig schema.Cache) (CacheCalculator, error) {
-	// TODO: 当支持多中 cache 策略时，做好分发的功能
-	return NewConservativeCacheCalculator(step, cacheConfig)
+	switch cacheConfig.Strategy {
+	case CacheStrategyAggressive:
+		return NewAggressiveCacheCalculator(step, cacheConfig)
+	case CacheStrategyConservative, "":
+		return NewConservativeCacheCalculator(step, cacheConfig)
+	default:
+		err := fmt.Errorf("cache strategy[%s] is not supported", cacheConfig.Strategy)
+		step.getLogger().Errorln(err.Error())
+		return nil, err
+	}
 }
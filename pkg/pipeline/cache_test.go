@@ -165,13 +165,89 @@ func mockCacheConfig() schema.Cache {
 	}
 }
 
-func TestNewAggressiveCacheCalculator(t *testing.T) {
+func mockerNewAggressiveCacheCalculator() (CacheCalculator, error) {
+	ServerConf := &config.ServerConfig{}
+	err := config.InitConfigFromYaml(ServerConf, "../../config/server/default/paddleserver.yaml")
+	config.GlobalServerConfig = ServerConf
+
 	step := mockStep()
 	cacheConfig := mockCacheConfig()
+	cacheConfig.Strategy = CacheStrategyAggressive
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
 
 	calculator, err := NewAggressiveCacheCalculator(step, cacheConfig)
-	assert.NotEqual(t, err, nil)
-	assert.Equal(t, calculator, nil)
+	return calculator, err
+}
+
+func TestNewAggressiveCacheCalculator(t *testing.T) {
+	calculator, err := mockerNewAggressiveCacheCalculator()
+	assert.Equal(t, err, nil)
+
+	_, ok := calculator.(*aggressiveCacheCalculator)
+	assert.Equal(t, ok, true)
+}
+
+func TestAggressiveCalculateFirstFingerprint(t *testing.T) {
+	calculator, err := mockerNewAggressiveCacheCalculator()
+	assert.Equal(t, err, nil)
+
+	fp, err := calculator.CalculateFirstFingerprint()
+	assert.Equal(t, err, nil)
+
+	cacheKey := calculator.(*aggressiveCacheCalculator).firstCacheKey
+	assert.Equal(t, cacheKey.Env, map[string]string{"num": "1200"})
+	assert.Equal(t, cacheKey.Command, "python3 predict.py /class/model")
+
+	// 激进策略下，step 名字不影响 fingerprint
+	step := mockStep()
+	step.name = "predict2"
+	calculator2, err := NewAggressiveCacheCalculator(step, mockCacheConfig())
+	assert.Equal(t, err, nil)
+
+	fp2, err := calculator2.CalculateFirstFingerprint()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fp, fp2)
+}
+
+func TestAggressiveCalculateSecondFingerprint(t *testing.T) {
+	arts := mockArtifact()
+	calculator, err := mockerNewAggressiveCacheCalculator()
+	assert.Equal(t, err, nil)
+
+	for _, path := range arts.Input {
+		err := CreatefileByFsClient(path, true)
+		assert.Equal(t, err, nil)
+		err = CreatefileByFsClient(path+"/part-0", false)
+		assert.Equal(t, err, nil)
+	}
+
+	cacheConfig := mockCacheConfig()
+	for _, path := range strings.Split(cacheConfig.FsScope, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		err := CreatefileByFsClient(path, false)
+		assert.Equal(t, err, nil)
+	}
+
+	fp, err := calculator.CalculateSecondFingerprint()
+	assert.Equal(t, err, nil)
+
+	secondCacheKey := calculator.(*aggressiveCacheCalculator).secondCacheKey
+	for name := range arts.Input {
+		_, ok := secondCacheKey.InputArtifactsDigest[name]
+		assert.Equal(t, ok, true)
+	}
+
+	// 内容不变时，重新写入文件不影响 fingerprint
+	for _, path := range arts.Input {
+		err := CreatefileByFsClient(path+"/part-0", false)
+		assert.Equal(t, err, nil)
+	}
+	fp2, err := calculator.CalculateSecondFingerprint()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fp, fp2)
 }
 
 func mockerNewConservativeCacheCalculator() (CacheCalculator, error) {
@@ -363,3 +439,23 @@ func TestNewCacheCalculator(t *testing.T) {
 	_, ok = calculator.(*conservativeCacheCalculator)
 	assert.Equal(t, ok, true)
 }
+
+func TestNewCacheCalculatorWithStrategy(t *testing.T) {
+	ServerConf := &config.ServerConfig{}
+	err := config.InitConfigFromYaml(ServerConf, "../../config/server/default/paddleserver.yaml")
+	config.GlobalServerConfig = ServerConf
+
+	step := mockStep()
+	cacheConfig := mockCacheConfig()
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+
+	cacheConfig.Strategy = CacheStrategyAggressive
+	calculator, err := NewCacheCalculator(step, cacheConfig)
+	assert.Equal(t, err, nil)
+	_, ok := calculator.(*aggressiveCacheCalculator)
+	assert.Equal(t, ok, true)
+
+	cacheConfig.Strategy = "unknown"
+	_, err = NewCacheCalculator(step, cacheConfig)
+	assert.NotNil(t, err)
+}
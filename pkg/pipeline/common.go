@@ -26,13 +26,15 @@ import (
 )
 
 const (
-	SysParamNamePFRunID    = "PF_RUN_ID"
-	SysParamNamePFFsID     = "PF_FS_ID"
-	SysParamNamePFJobID    = "PF_JOB_ID"
-	SysParamNamePFStepName = "PF_STEP_NAME"
-	SysParamNamePFFsName   = "PF_FS_NAME"
-	SysParamNamePFUserID   = "PF_USER_ID"
-	SysParamNamePFUserName = "PF_USER_NAME"
+	SysParamNamePFRunID        = "PF_RUN_ID"
+	SysParamNamePFFsID         = "PF_FS_ID"
+	SysParamNamePFJobID        = "PF_JOB_ID"
+	SysParamNamePFStepName     = "PF_STEP_NAME"
+	SysParamNamePFFsName       = "PF_FS_NAME"
+	SysParamNamePFUserID       = "PF_USER_ID"
+	SysParamNamePFUserName     = "PF_USER_NAME"
+	SysParamNamePFLoopArgument = "PF_LOOP_ARGUMENT" // loop step 中当前迭代的参数，仅 loop step 可以引用
+	SysParamNamePFRunStatus    = "PF_RUN_STATUS"    // 主 DAG 的最终状态，仅 post_process step 可以引用
 
 	WfExtraInfoKeySource   = "Source" // pipelineID or yamlPath
 	WfExtraInfoKeyUserName = "UserName"
@@ -46,11 +48,16 @@ const (
 	WfParallelismDefault = 10
 	WfParallelismMaximum = 20
 
+	WfExecutorPaddleFlow = "paddleflow" // step 通过 job 子系统提交到集群运行
+	WfExecutorLocal      = "local"      // step 以本地子进程的方式运行，用于调试
+
 	fieldParameters      string = "parameters"
 	fieldCommand         string = "command"
 	fieldEnv             string = "env"
 	fieldInputArtifacts  string = "inputArtifacts"
 	fieldOutputArtifacts string = "outputArtifacts"
+	fieldCondition       string = "condition"
+	fieldLoopArgument    string = "loopArgument"
 
 	CacheStrategyConservative = "conservative"
 	CacheStrategyAggressive   = "aggressive"
@@ -159,6 +166,24 @@ func (s *StepParamSolver) Solve(currentStep string) error {
 	}
 	step.Command = fmt.Sprintf("%v", realVal)
 
+	// 5. condition 校验
+	// condition 需要在 step 运行前，根据上游的运行结果求值，此处只校验引用是否合法，不做替换
+	if _, err := s.checkParamValue(currentStep, fieldCondition, step.Condition, fieldCondition); err != nil {
+		return err
+	}
+
+	// 6. loop_argument 校验
+	// loop_argument 可以是列表，或者对上游 output artifact / parameter 的引用，在 step 运行前展开
+	switch loopArgument := step.LoopArgument.(type) {
+	case nil, []interface{}:
+	case string:
+		if _, err := s.checkParamValue(currentStep, fieldLoopArgument, loopArgument, fieldLoopArgument); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid loop_argument[%v] in step[%s], should be a list or a reference of upstream output", loopArgument, currentStep)
+	}
+
 	return nil
 }
 
@@ -192,6 +217,7 @@ func (s *StepParamSolver) checkParamValue(step string, paramName string, param i
 
 // resolveRefParam 解析引用参数
 // parameters 字段中变量可引用系统参数、及上游参数
+// condition、loop_argument 字段中变量可引用系统参数、本阶段 parameter、上游参数及上游 outputArtifact
 // input artifacts 字段中变量可引用系统参数、上游 outputArtifact、及本阶段 parameter
 // output artifacts 字段中变量可引用系统参数、本阶段 parameter
 func (s *StepParamSolver) resolveRefParam(step, param, fieldType string) (interface{}, error) {
@@ -210,6 +236,9 @@ func (s *StepParamSolver) resolveRefParam(step, param, fieldType string) (interf
 		refStep, refParamName := parseParamName(row[2])
 		if len(refStep) == 0 {
 			// {{ PS_RUN_ID }}
+			if refParamName == SysParamNamePFLoopArgument && s.steps[step].LoopArgument == nil {
+				return "", fmt.Errorf("SysParamName[%s] can only be referred in step with loop_argument, step[%s]", refParamName, step)
+			}
 			var ok bool
 			tmpVal, ok = s.sysParams[refParamName]
 			if !ok {
@@ -218,7 +247,7 @@ func (s *StepParamSolver) resolveRefParam(step, param, fieldType string) (interf
 				}
 				tmpVal2, ok := s.steps[step].Parameters[refParamName]
 				if !ok {
-					if fieldType == fieldInputArtifacts || fieldType == fieldOutputArtifacts || fieldType == fieldEnv {
+					if fieldType == fieldInputArtifacts || fieldType == fieldOutputArtifacts || fieldType == fieldEnv || fieldType == fieldCondition || fieldType == fieldLoopArgument {
 						return "", fmt.Errorf("unsupported RefParamName[%s] for param[%s]", refParamName, param)
 					}
 					// command 可以引用 system parameter + step 内的 parameter + artifact
@@ -276,6 +305,14 @@ func (s *StepParamSolver) refParamExist(currentStep, refStep, refParamName, fiel
 					return realRefParamVal, nil
 				}
 			}
+		case fieldCondition, fieldLoopArgument:
+			// condition 及 loop_argument 可以引用上游的 parameter 及 output artifact，artifact 的内容在运行时读取
+			if refParamVal, ok := ref.Parameters[refParamName]; ok {
+				return refParamVal, nil
+			}
+			if refParamVal, ok := ref.Artifacts.Output[refParamName]; ok {
+				return refParamVal, nil
+			}
 		default:
 			if refParamVal, ok := ref.Parameters[refParamName]; ok {
 				// recursively get param value
@@ -0,0 +1,267 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	conditionOpOr  = "||"
+	conditionOpAnd = "&&"
+)
+
+// 比较运算符，两个字符的运算符必须排在前面，以保证优先匹配
+var conditionCompareOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// conditionCalculator 计算 step 的 condition 表达式
+// 表达式由若干个比较式通过 && 与 || 连接而成（&& 优先级高于 ||，不支持括号，引号内的运算符及括号视为字符串的一部分），如 {{ eval.accuracy }} > 0.9 && {{ PF_RUN_ID }} != ""
+// 比较式两侧均可以转换为数字时按数字比较，否则按字符串比较，字符串只支持 == 与 !=
+// 单独的 true / false 也是合法的比较式
+type conditionCalculator struct {
+	condition string
+	// resolver 用于将操作数中的引用模板 {{ xxx }} 替换为实际值
+	resolver func(operand string) (string, error)
+}
+
+func NewConditionCalculator(condition string, resolver func(operand string) (string, error)) *conditionCalculator {
+	return &conditionCalculator{
+		condition: condition,
+		resolver:  resolver,
+	}
+}
+
+// Calculate 先按照运算符切分表达式，再对每个操作数做引用替换，避免替换后的内容（如 artifact 的内容）干扰表达式的解析
+func (cc *conditionCalculator) Calculate() (bool, error) {
+	if strings.TrimSpace(cc.condition) == "" {
+		return true, nil
+	}
+	orExprs, err := splitCondition(cc.condition)
+	if err != nil {
+		return false, err
+	}
+	for _, andExprs := range orExprs {
+		result := true
+		for _, andExpr := range andExprs {
+			compareResult, err := cc.compare(andExpr)
+			if err != nil {
+				return false, err
+			}
+			if !compareResult {
+				result = false
+				break
+			}
+		}
+		if result {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// splitCondition 将表达式切分为以 || 连接的若干组、以 && 连接的比较式，引号内的运算符作为字符串常量的一部分不参与切分
+// 括号不在引号内时直接报错，避免被当作普通字符导致计算结果与预期不符
+func splitCondition(condition string) ([][]string, error) {
+	orExprs := [][]string{}
+	andExprs := []string{}
+	start := 0
+	var quote byte
+	for i := 0; i < len(condition); i++ {
+		c := condition[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == ')':
+			return nil, fmt.Errorf("parentheses are not supported in condition[%s]", condition)
+		case strings.HasPrefix(condition[i:], conditionOpAnd):
+			andExprs = append(andExprs, condition[start:i])
+			i += len(conditionOpAnd) - 1
+			start = i + 1
+		case strings.HasPrefix(condition[i:], conditionOpOr):
+			andExprs = append(andExprs, condition[start:i])
+			orExprs = append(orExprs, andExprs)
+			andExprs = []string{}
+			i += len(conditionOpOr) - 1
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quoted string in condition[%s]", condition)
+	}
+	andExprs = append(andExprs, condition[start:])
+	orExprs = append(orExprs, andExprs)
+	return orExprs, nil
+}
+
+func (cc *conditionCalculator) compare(expr string) (bool, error) {
+	op, index := findCompareOp(expr)
+	if op == "" {
+		operand, err := cc.resolveOperand(expr)
+		if err != nil {
+			return false, err
+		}
+		result, err := strconv.ParseBool(operand)
+		if err != nil {
+			return false, fmt.Errorf("invalid expression[%s] in condition[%s]", strings.TrimSpace(expr), cc.condition)
+		}
+		return result, nil
+	}
+
+	left, err := cc.resolveOperand(expr[:index])
+	if err != nil {
+		return false, err
+	}
+	right, err := cc.resolveOperand(expr[index+len(op):])
+	if err != nil {
+		return false, err
+	}
+
+	leftNum, leftErr := strconv.ParseFloat(left, 64)
+	rightNum, rightErr := strconv.ParseFloat(right, 64)
+	if leftErr == nil && rightErr == nil {
+		switch op {
+		case ">=":
+			return leftNum >= rightNum, nil
+		case "<=":
+			return leftNum <= rightNum, nil
+		case "==":
+			return leftNum == rightNum, nil
+		case "!=":
+			return leftNum != rightNum, nil
+		case ">":
+			return leftNum > rightNum, nil
+		default:
+			return leftNum < rightNum, nil
+		}
+	}
+
+	switch op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	default:
+		return false, fmt.Errorf("operator[%s] in condition[%s] only supports numeric operands, but got [%s] and [%s]",
+			op, cc.condition, left, right)
+	}
+}
+
+// resolveOperand 替换操作数中的引用，并去掉首尾的空白及引号
+func (cc *conditionCalculator) resolveOperand(operand string) (string, error) {
+	operand = strings.TrimSpace(operand)
+	if cc.resolver != nil {
+		var err error
+		operand, err = cc.resolver(operand)
+		if err != nil {
+			return "", err
+		}
+		operand = strings.TrimSpace(operand)
+	}
+	if len(operand) >= 2 {
+		if (operand[0] == '"' && operand[len(operand)-1] == '"') || (operand[0] == '\'' && operand[len(operand)-1] == '\'') {
+			operand = operand[1 : len(operand)-1]
+		}
+	}
+	return operand, nil
+}
+
+// findCompareOp 返回表达式中第一个不在引号内的比较运算符及其位置
+func findCompareOp(expr string) (string, int) {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		if quote != 0 {
+			if expr[i] == quote {
+				quote = 0
+			}
+			continue
+		}
+		if expr[i] == '"' || expr[i] == '\'' {
+			quote = expr[i]
+			continue
+		}
+		for _, op := range conditionCompareOps {
+			if strings.HasPrefix(expr[i:], op) {
+				return op, i
+			}
+		}
+	}
+	return "", -1
+}
+
+// resolveRuntimeRef 替换运行时才能确定的引用，如 condition 的操作数、loop_argument
+// 本阶段可引用系统参数及 parameter；上游可引用 parameter，以及 output artifact 的文件内容
+func (st *Step) resolveRuntimeRef(operand string) (string, error) {
+	pattern := `\{\{(\s)*([a-zA-Z0-9_]*\.?[a-zA-Z0-9_]+)?(\s)*\}\}`
+	reg := regexp.MustCompile(pattern)
+	matches := reg.FindAllStringSubmatch(operand, -1)
+	result := operand
+	for _, row := range matches {
+		refStep, refParamName := parseParamName(row[2])
+		refVal, err := st.getRuntimeRefValue(refStep, refParamName)
+		if err != nil {
+			return "", err
+		}
+		result = strings.Replace(result, row[0], refVal, -1)
+	}
+	return result, nil
+}
+
+func (st *Step) getRuntimeRefValue(refStep, refParamName string) (string, error) {
+	if refStep == "" {
+		job := st.job.Job()
+		if val, ok := job.Parameters[refParamName]; ok {
+			return val, nil
+		}
+		// 系统参数在 updateJob 时已经添加到环境变量中
+		if val, ok := job.Env[refParamName]; ok {
+			return val, nil
+		}
+		return "", fmt.Errorf("unsupported RefParamName[%s] in step[%s]", refParamName, st.name)
+	}
+
+	ref, ok := st.wfr.getPeerSteps(st.name)[refStep]
+	if !ok {
+		return "", fmt.Errorf("invalid runtime reference {{ %s.%s }} in step %s", refStep, refParamName, st.name)
+	}
+	refJob := ref.job.Job()
+	if val, ok := refJob.Parameters[refParamName]; ok {
+		return val, nil
+	}
+	if refJob.Artifacts.Output != nil {
+		if path, ok := refJob.Artifacts.Output[refParamName]; ok {
+			fsHandler, err := newFsHandlerForStep(*st)
+			if err != nil {
+				return "", err
+			}
+			content, err := fsHandler.ReadFsFile(path)
+			if err != nil {
+				return "", fmt.Errorf("read output artifact[%s] of step[%s] failed: %s", refParamName, refStep, err.Error())
+			}
+			return strings.TrimSpace(string(content)), nil
+		}
+	}
+	return "", fmt.Errorf("invalid runtime reference {{ %s.%s }} in step %s", refStep, refParamName, st.name)
+}
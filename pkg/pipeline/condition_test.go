@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/handler"
+	"paddleflow/pkg/common/config"
+)
+
+func TestConditionCalculator(t *testing.T) {
+	refs := map[string]string{
+		"{{ eval.accuracy }}": "0.93",
+		"{{ eval.model }}":    "resnet",
+	}
+	resolver := func(operand string) (string, error) {
+		if val, ok := refs[operand]; ok {
+			return val, nil
+		}
+		return operand, nil
+	}
+
+	testCases := []struct {
+		condition string
+		expected  bool
+		hasErr    bool
+	}{
+		{"", true, false},
+		{"true", true, false},
+		{"False", false, false},
+		{"{{ eval.accuracy }} > 0.9", true, false},
+		{"{{ eval.accuracy }}>=0.95", false, false},
+		{"{{ eval.accuracy }} == 0.930", true, false},
+		{"{{ eval.model }} == \"resnet\"", true, false},
+		{"{{ eval.model }} != 'resnet'", false, false},
+		{"{{ eval.accuracy }} > 0.95 || {{ eval.model }} == resnet", true, false},
+		{"{{ eval.accuracy }} > 0.9 && {{ eval.model }} == vgg", false, false},
+		{"{{ eval.model }} > resnet", false, true},
+		{"{{ eval.model }}", false, true},
+		// 引号内的运算符属于字符串常量，不参与切分
+		{"{{ eval.model }} == \"a || b\"", false, false},
+		{"{{ eval.model }} != 'x && y' && {{ eval.accuracy }} > 0.9", true, false},
+		{"\"a>=b\" == 'a>=b'", true, false},
+		{"'resnet' == {{ eval.model }} || \"(\" == \")\"", true, false},
+		// 不支持括号及未闭合的引号
+		{"({{ eval.accuracy }} > 0.9 || false) && true", false, true},
+		{"{{ eval.model }} == \"resnet", false, true},
+	}
+	for _, tc := range testCases {
+		result, err := NewConditionCalculator(tc.condition, resolver).Calculate()
+		if tc.hasErr {
+			assert.NotNil(t, err, tc.condition)
+			continue
+		}
+		assert.Nil(t, err, tc.condition)
+		assert.Equal(t, tc.expected, result, tc.condition)
+	}
+}
+
+func TestStepCondition(t *testing.T) {
+	testCase := loadcase("./testcase/run.step.yaml")
+	wfs := parseWorkflowSource(testCase)
+	wfs.EntryPoints["main"].Condition = "{{ data_preprocess.process_data_file }} == ./data/pre && {{ regularization }} < 1"
+	wfs.EntryPoints["validate"].Condition = "{{ main.train_model }} == succeeded"
+	bwf := NewBaseWorkflow(wfs, "runId", "", nil, nil)
+	wf := Workflow{
+		BaseWorkflow: bwf,
+	}
+	wf.runtime = NewWorkflowRuntime(&wf, 10)
+	err := bwf.validate()
+	assert.Nil(t, err)
+	sortedSteps, err := wf.topologicalSort(wf.Source.EntryPoints)
+	assert.Nil(t, err)
+	for _, stepName := range sortedSteps {
+		stepInfo := bwf.Source.EntryPoints[stepName]
+		st := &Step{
+			name:  stepName,
+			wfr:   wf.runtime,
+			info:  stepInfo,
+			ready: make(chan bool, 1),
+			done:  false,
+		}
+		wf.runtime.steps[stepName] = st
+		st.job = NewPaddleFlowJob(st.name, st.info.Image, st.info.Deps)
+		err := st.updateJob()
+		assert.Nil(t, err)
+	}
+
+	// 引用上游 parameter 及本阶段 parameter
+	main := wf.runtime.steps["main"]
+	satisfied, err := NewConditionCalculator(main.info.Condition, main.resolveRuntimeRef).Calculate()
+	assert.Nil(t, err)
+	assert.True(t, satisfied)
+
+	// 引用上游 output artifact 的内容
+	serverConf := &config.ServerConfig{}
+	err = config.InitConfigFromYaml(serverConf, "../../config/server/default/paddleserver.yaml")
+	assert.Nil(t, err)
+	config.GlobalServerConfig = serverConf
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	os.MkdirAll("./mock_fs_handler/data", 0755)
+	defer os.RemoveAll("./mock_fs_handler")
+	err = ioutil.WriteFile("./mock_fs_handler/data/model", []byte("succeeded\n"), 0644)
+	assert.Nil(t, err)
+
+	validate := wf.runtime.steps["validate"]
+	satisfied, err = NewConditionCalculator(validate.info.Condition, validate.resolveRuntimeRef).Calculate()
+	assert.Nil(t, err)
+	assert.True(t, satisfied)
+}
+
+func TestStepConditionInvalidReference(t *testing.T) {
+	testCase := loadcase("./testcase/run.step.yaml")
+	wfs := parseWorkflowSource(testCase)
+	// condition 只能引用上游 step
+	wfs.EntryPoints["main"].Condition = "{{ validate.report }} == ./data/report"
+	bwf := NewBaseWorkflow(wfs, "runId", "", nil, nil)
+	err := bwf.validate()
+	assert.NotNil(t, err)
+
+	wfs = parseWorkflowSource(testCase)
+	wfs.EntryPoints["main"].Condition = "{{ not_exist }} == 1"
+	bwf = NewBaseWorkflow(wfs, "runId", "", nil, nil)
+	err = bwf.validate()
+	assert.NotNil(t, err)
+
+	wfs = parseWorkflowSource(testCase)
+	wfs.EntryPoints["main"].Condition = "{{ data_preprocess.train_data }} != ''"
+	bwf = NewBaseWorkflow(wfs, "runId", "", nil, nil)
+	err = bwf.validate()
+	assert.Nil(t, err)
+}
@@ -16,6 +16,10 @@ limitations under the License.
 
 package pipeline
 
+import (
+	"paddleflow/pkg/common/schema"
+)
+
 type WfEventType string
 type WfEventValue string
 
@@ -31,6 +35,7 @@ const (
 	WfEventJobSubmitErr WfEventValue = "JobSubmitErr"
 	WfEventJobWatchErr  WfEventValue = "JobWatchErr"
 	WfEventJobStopErr   WfEventValue = "JobStopErr"
+	WfEventRunTimeout   WfEventValue = "RunTimeout"
 	wfEventRunSysError  WfEventValue = "SysError"
 )
 
@@ -77,6 +82,16 @@ func (wfe *WorkflowEvent) isJobStopErr() bool {
 	return wfe.Event == WfEventJobStopErr
 }
 
+// 是否Job超时事件
+func (wfe *WorkflowEvent) isJobTimeout() bool {
+	return wfe.isJobUpdate() && wfe.Extra["status"] == schema.StatusJobTimeout
+}
+
+// 是否Run超时事件
+func (wfe *WorkflowEvent) isRunTimeout() bool {
+	return wfe.Event == WfEventRunTimeout
+}
+
 // 获取Job更新信息
 func (wfe *WorkflowEvent) getJobUpdate() (map[string]interface{}, bool) {
 	return wfe.Extra, wfe.isJobUpdate()
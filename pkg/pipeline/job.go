@@ -19,10 +19,17 @@ package pipeline
 import (
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"paddleflow/pkg/apiserver/models"
 	"paddleflow/pkg/common/schema"
+	"paddleflow/pkg/common/uuid"
 	"paddleflow/pkg/job"
 )
 
@@ -34,14 +41,27 @@ type Job interface {
 	Stop() error
 	Check() (schema.JobStatus, error)
 	Watch(chan WorkflowEvent) error
+	SetStatus(status schema.JobStatus)
 	Started() bool
 	Succeeded() bool
 	Cached() bool
+	Skipped() bool
+	TimedOut() bool
 	Failed() bool
 	Terminated() bool
 	NotEnded() bool
 }
 
+// 根据 run 指定的执行器，初始化 step 对应的 job
+func NewJob(executor, name, image, deps string) Job {
+	switch executor {
+	case WfExecutorLocal:
+		return NewLocalJob(name, deps)
+	default:
+		return NewPaddleFlowJob(name, image, deps)
+	}
+}
+
 func NewBaseJob(name, deps string) *BaseJob {
 	return &BaseJob{
 		Name: name,
@@ -222,6 +242,10 @@ func (pfj *PaddleFlowJob) Watch(ch chan WorkflowEvent) error {
 	return nil
 }
 
+func (pfj *PaddleFlowJob) SetStatus(status schema.JobStatus) {
+	pfj.Status = status
+}
+
 func (pfj *PaddleFlowJob) Succeeded() bool {
 	return pfj.Status == schema.StatusJobSucceeded
 }
@@ -230,6 +254,14 @@ func (pfj *PaddleFlowJob) Cached() bool {
 	return pfj.Status == schema.StatusJobCached
 }
 
+func (pfj *PaddleFlowJob) Skipped() bool {
+	return pfj.Status == schema.StatusJobSkipped
+}
+
+func (pfj *PaddleFlowJob) TimedOut() bool {
+	return pfj.Status == schema.StatusJobTimeout
+}
+
 func (pfj *PaddleFlowJob) Failed() bool {
 	return pfj.Status == schema.StatusJobFailed
 }
@@ -253,7 +285,253 @@ func (pfj *PaddleFlowJob) Job() BaseJob {
 // ----------------------------------------------------------------------------
 // Local Process Job
 // ----------------------------------------------------------------------------
+
+// 本地进程 job 的日志目录，每个 job 的标准输出及标准错误会写入该目录下以 job name 命名的文件中
+var LocalJobLogDir = "./log/local_job"
+
+// 本地进程 job 的 PATH 环境变量
+const localJobPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
 type LocalJob struct {
 	BaseJob
 	Pid string
+
+	cmd     *exec.Cmd
+	exited  chan struct{} // 进程退出后关闭
+	exitErr error         // 进程退出时 cmd.Wait() 返回的错误
+	stopped bool          // 是否通过 Stop 接口停止
+	mu      sync.Mutex
+}
+
+func NewLocalJob(name, deps string) *LocalJob {
+	return &LocalJob{
+		BaseJob: *NewBaseJob(name, deps),
+	}
+}
+
+func (lj *LocalJob) Update(cmd string, params map[string]string, envs map[string]string, artifacts *schema.Artifacts) error {
+	if cmd != "" {
+		lj.Command = cmd
+	}
+
+	if params != nil {
+		lj.Parameters = params
+	}
+
+	if envs != nil {
+		lj.Env = envs
+	}
+
+	if artifacts != nil {
+		lj.Artifacts = *artifacts
+	}
+
+	return nil
+}
+
+// 校验job参数，本地进程 job 不依赖队列等集群资源，只需要校验 command
+func (lj *LocalJob) Validate() error {
+	if lj.Command == "" {
+		return fmt.Errorf("command of local job[%s] is empty", lj.Name)
+	}
+	return nil
+}
+
+// 发起作业接口，以子进程的方式运行 command，并将参数、artifact 等环境变量传递给子进程
+func (lj *LocalJob) Start() (string, error) {
+	// 此函数不更新job.Status，job.startTime，统一通过watch更新
+	if err := os.MkdirAll(LocalJobLogDir, 0755); err != nil {
+		return "", err
+	}
+	logPath := filepath.Join(LocalJobLogDir, lj.Name+".log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("sh", "-c", lj.Command)
+	// 不继承 apiserver 的环境变量，避免数据库密码等敏感信息泄露给 command
+	cmd.Env = []string{"PATH=" + localJobPath}
+	for name, value := range lj.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, value))
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// 子进程单独作为一个进程组，以便停止时可以一并停止 command 派生的进程
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return "", err
+	}
+
+	lj.mu.Lock()
+	lj.cmd = cmd
+	lj.exited = make(chan struct{})
+	lj.Pid = strconv.Itoa(cmd.Process.Pid)
+	id := uuid.GenerateID("local")
+	lj.Id = id
+	lj.Message = fmt.Sprintf("log of local job is written to %s", logPath)
+	lj.mu.Unlock()
+
+	go func() {
+		defer logFile.Close()
+		err := cmd.Wait()
+		lj.mu.Lock()
+		lj.exitErr = err
+		lj.mu.Unlock()
+		close(lj.exited)
+	}()
+
+	return id, nil
+}
+
+// 停止作业接口
+func (lj *LocalJob) Stop() error {
+	// 此函数不更新job.Status，job.endTime，统一通过watch更新
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+
+	if lj.cmd == nil || lj.cmd.Process == nil {
+		return nil
+	}
+	select {
+	case <-lj.exited:
+		// 进程已经退出，无需停止
+		return nil
+	default:
+	}
+
+	lj.stopped = true
+	// 负数 pid 表示向整个进程组发送信号
+	if err := syscall.Kill(-lj.cmd.Process.Pid, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
+
+// 查作业状态接口
+func (lj *LocalJob) Check() (schema.JobStatus, error) {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	if lj.Id == "" {
+		errMsg := fmt.Sprintf("job not started, id is empty!")
+		err := errors.New(errMsg)
+		return "", err
+	}
+	return lj.Status, nil
+}
+
+// 同步watch作业接口
+func (lj *LocalJob) Watch(ch chan WorkflowEvent) error {
+	defer close(ch)
+
+	lj.mu.Lock()
+	id, cmd, exited, pid, logMessage := lj.Id, lj.cmd, lj.exited, lj.Pid, lj.Message
+	lj.mu.Unlock()
+
+	if id == "" {
+		errMsg := fmt.Sprintf("watch local job failed, job not started, id is empty!")
+		wfe := NewWorkflowEvent(WfEventJobWatchErr, errMsg, nil)
+		ch <- *wfe
+		return nil
+	}
+
+	// 服务重启后，本地进程已经无法接管，直接置为失败
+	if cmd == nil {
+		lj.updateStatus(ch, schema.StatusJobFailed, fmt.Sprintf("process[%s] of local job is lost after server restarted", pid), true)
+		return nil
+	}
+
+	lj.updateStatus(ch, schema.StatusJobRunning, logMessage, false)
+
+	<-exited
+
+	lj.mu.Lock()
+	exitErr, stopped := lj.exitErr, lj.stopped
+	lj.mu.Unlock()
+
+	status, message := schema.StatusJobSucceeded, logMessage
+	if stopped {
+		status = schema.StatusJobTerminated
+	} else if exitErr != nil {
+		status = schema.StatusJobFailed
+		message = fmt.Sprintf("local job exited with error: %s, %s", exitErr.Error(), logMessage)
+	}
+	lj.updateStatus(ch, status, message, true)
+	return nil
+}
+
+// updateStatus 在持有锁的情况下更新状态及起止时间，并在释放锁后发送状态变化事件
+func (lj *LocalJob) updateStatus(ch chan WorkflowEvent, status schema.JobStatus, message string, ended bool) {
+	lj.mu.Lock()
+	extra := map[string]interface{}{
+		"status":    status,
+		"preStatus": lj.Status,
+		"jobid":     lj.Id,
+		"message":   message,
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if ended {
+		lj.EndTime = now
+	} else {
+		lj.StartTime = now
+	}
+	lj.Status = status
+	lj.Message = message
+	lj.mu.Unlock()
+
+	wfe := NewWorkflowEvent(WfEventJobUpdate, "", extra)
+	ch <- *wfe
+}
+
+func (lj *LocalJob) SetStatus(status schema.JobStatus) {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	lj.Status = status
+}
+
+func (lj *LocalJob) getStatus() schema.JobStatus {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	return lj.Status
+}
+
+func (lj *LocalJob) Succeeded() bool {
+	return lj.getStatus() == schema.StatusJobSucceeded
+}
+
+func (lj *LocalJob) Cached() bool {
+	return lj.getStatus() == schema.StatusJobCached
+}
+
+func (lj *LocalJob) Skipped() bool {
+	return lj.getStatus() == schema.StatusJobSkipped
+}
+
+func (lj *LocalJob) TimedOut() bool {
+	return lj.getStatus() == schema.StatusJobTimeout
+}
+
+func (lj *LocalJob) Failed() bool {
+	return lj.getStatus() == schema.StatusJobFailed
+}
+
+func (lj *LocalJob) Terminated() bool {
+	return lj.getStatus() == schema.StatusJobTerminated
+}
+
+func (lj *LocalJob) NotEnded() bool {
+	status := lj.getStatus()
+	return status == "" || status == schema.StatusJobTerminating || status == schema.StatusJobRunning || status == schema.StatusJobPending
+}
+
+func (lj *LocalJob) Started() bool {
+	return lj.getStatus() != ""
+}
+
+func (lj *LocalJob) Job() BaseJob {
+	lj.mu.Lock()
+	defer lj.mu.Unlock()
+	return lj.BaseJob
 }
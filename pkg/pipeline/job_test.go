@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/schema"
+)
+
+// enableLocalExecutor 开启本地执行器，并返回 root 用户的 extra 信息
+func enableLocalExecutor() map[string]string {
+	if config.GlobalServerConfig == nil {
+		config.GlobalServerConfig = &config.ServerConfig{}
+	}
+	config.GlobalServerConfig.ApiServer.EnableLocalExecutor = true
+	return map[string]string{WfExtraInfoKeyUserName: "root"}
+}
+
+func watchLocalJob(lj *LocalJob) []schema.JobStatus {
+	ch := make(chan WorkflowEvent, 1)
+	go lj.Watch(ch)
+
+	statusList := []schema.JobStatus{}
+	for event := range ch {
+		extra, ok := event.getJobUpdate()
+		if ok {
+			statusList = append(statusList, extra["status"].(schema.JobStatus))
+		}
+	}
+	return statusList
+}
+
+func TestLocalJob_succeeded(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+
+	lj := NewLocalJob("run-000001-main", "")
+	lj.Update("test \"$PF_INPUT_ARTIFACT_DATA\" = \"/path/to/data\"", nil,
+		map[string]string{"PF_INPUT_ARTIFACT_DATA": "/path/to/data"}, nil)
+	assert.Nil(t, lj.Validate())
+
+	id, err := lj.Start()
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", id)
+	assert.NotEqual(t, "", lj.Pid)
+
+	statusList := watchLocalJob(lj)
+	assert.Equal(t, []schema.JobStatus{schema.StatusJobRunning, schema.StatusJobSucceeded}, statusList)
+	assert.True(t, lj.Succeeded())
+}
+
+func TestLocalJob_failed(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+
+	lj := NewLocalJob("run-000001-main", "")
+	lj.Update("exit 1", nil, nil, nil)
+
+	_, err := lj.Start()
+	assert.Nil(t, err)
+
+	watchLocalJob(lj)
+	assert.True(t, lj.Failed())
+}
+
+func TestLocalJob_stop(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+
+	lj := NewLocalJob("run-000001-main", "")
+	lj.Update("sleep 30", nil, nil, nil)
+
+	_, err := lj.Start()
+	assert.Nil(t, err)
+
+	ch := make(chan WorkflowEvent, 1)
+	go lj.Watch(ch)
+	<-ch // running
+
+	assert.Nil(t, lj.Stop())
+	event := <-ch
+	assert.Equal(t, schema.StatusJobTerminated, event.Extra["status"])
+	assert.True(t, lj.Terminated())
+
+	// 进程已经退出时，再次停止不会报错
+	assert.Nil(t, lj.Stop())
+}
+
+func TestLocalJob_validate(t *testing.T) {
+	lj := NewLocalJob("run-000001-main", "")
+	assert.NotNil(t, lj.Validate())
+}
+
+func TestCheckLocalExecutor(t *testing.T) {
+	runYaml := `
+name: local
+entry_points:
+  main:
+    command: "echo main"
+executor: local
+`
+	extra := enableLocalExecutor()
+	bwf := NewBaseWorkflow(parseWorkflowSource([]byte(runYaml)), "run-000001", "", nil, extra)
+	assert.Nil(t, bwf.validate())
+
+	// 非 root 用户不能使用本地执行器
+	bwf = NewBaseWorkflow(parseWorkflowSource([]byte(runYaml)), "run-000001", "", nil,
+		map[string]string{WfExtraInfoKeyUserName: "user1"})
+	assert.NotNil(t, bwf.validate())
+
+	// 服务端未开启本地执行器
+	config.GlobalServerConfig.ApiServer.EnableLocalExecutor = false
+	defer enableLocalExecutor()
+	bwf = NewBaseWorkflow(parseWorkflowSource([]byte(runYaml)), "run-000001", "", nil, extra)
+	assert.NotNil(t, bwf.validate())
+}
+
+func TestLocalJob_env(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+	os.Setenv("MOCK_SERVER_SECRET", "secret")
+	defer os.Unsetenv("MOCK_SERVER_SECRET")
+
+	// 本地进程不继承 apiserver 的环境变量
+	lj := NewLocalJob("run-000001-main", "")
+	lj.Update("test -z \"$MOCK_SERVER_SECRET\" && test -n \"$PATH\"", nil, nil, nil)
+	_, err := lj.Start()
+	assert.Nil(t, err)
+	watchLocalJob(lj)
+	assert.True(t, lj.Succeeded())
+}
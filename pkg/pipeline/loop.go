@@ -0,0 +1,296 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"paddleflow/pkg/common/schema"
+)
+
+// loop step 在初始化时，PF_LOOP_ARGUMENT 先被替换为该占位符，展开时再替换为每次迭代的实际参数
+const loopArgumentPlaceholder = "{{PF_LOOP_ARGUMENT}}"
+
+// getLoopArguments 获取 loop_argument 展开后的参数列表
+func (st *Step) getLoopArguments() ([]string, error) {
+	var items []interface{}
+	switch loopArgument := st.info.LoopArgument.(type) {
+	case []interface{}:
+		items = loopArgument
+	case string:
+		content, err := st.resolveRuntimeRef(loopArgument)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(content), &items); err != nil {
+			return nil, fmt.Errorf("loop_argument[%s] of step[%s] should be a json list, but got [%s]", loopArgument, st.name, content)
+		}
+	default:
+		return nil, fmt.Errorf("invalid loop_argument[%v] in step[%s], should be a list or a reference of upstream output", loopArgument, st.name)
+	}
+
+	arguments := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case string, int, int64, float32, float64, bool:
+			arguments = append(arguments, fmt.Sprintf("%v", item))
+		default:
+			argument, err := json.Marshal(convertYamlValue(item))
+			if err != nil {
+				return nil, fmt.Errorf("invalid item[%v] in loop_argument of step[%s]: %s", item, st.name, err.Error())
+			}
+			arguments = append(arguments, string(argument))
+		}
+	}
+	return arguments, nil
+}
+
+// convertYamlValue yaml 解析出的 map 的 key 类型为 interface{}，需要转换后才能序列化为 json
+func convertYamlValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprintf("%v", key)] = convertYamlValue(item)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			l = append(l, convertYamlValue(item))
+		}
+		return l
+	default:
+		return val
+	}
+}
+
+// newLoopJob 以 step 的 job 为模板，生成第 index 次迭代对应的 job
+func (st *Step) newLoopJob(index int, argument string) Job {
+	template := st.job.Job()
+	command, params, envs, artifacts := replaceJobPlaceholder(template, loopArgumentPlaceholder, argument)
+
+	job := NewJob(st.wfr.wf.Source.Executor, fmt.Sprintf("%s-%d", template.Name, index), st.info.Image, st.info.Deps)
+	job.Update(command, params, envs, &artifacts)
+	return job
+}
+
+// executeLoop 展开 loop step，每次迭代作为单独的 job 并行运行
+func (st *Step) executeLoop() {
+	arguments, err := st.getLoopArguments()
+	if err != nil {
+		ErrMsg := fmt.Sprintf("get loop arguments for step[%s] with runid[%s] failed: [%s]", st.name, st.wfr.wf.RunID, err.Error())
+		st.getLogger().Errorf(ErrMsg)
+
+		extra := st.getJobExtra(schema.StatusJobFailed)
+		st.job.SetStatus(schema.StatusJobFailed)
+		st.done = true
+		wfe := NewWorkflowEvent(WfEventJobSubmitErr, ErrMsg, extra)
+		st.wfr.event <- *wfe
+		return
+	}
+
+	if len(arguments) == 0 {
+		InfoMsg := fmt.Sprintf("skip job for step[%s] with runid[%s], loop_argument is empty", st.name, st.wfr.wf.RunID)
+		st.getLogger().Infof(InfoMsg)
+
+		extra := st.getJobExtra(schema.StatusJobSkipped)
+		st.job.SetStatus(schema.StatusJobSkipped)
+		st.done = true
+		wfe := NewWorkflowEvent(WfEventJobUpdate, InfoMsg, extra)
+		st.wfr.event <- *wfe
+		return
+	}
+
+	loopJobs := make([]Job, 0, len(arguments))
+	for index, argument := range arguments {
+		loopJobs = append(loopJobs, st.newLoopJob(index, argument))
+	}
+	st.loopJobs = loopJobs
+	st.getLogger().Infof("step[%s] with runid[%s] expanded into %d loop jobs", st.name, st.wfr.wf.RunID, len(loopJobs))
+
+	st.runLoopJobs()
+}
+
+// runLoopJobs 运行所有未结束的迭代，并根据迭代的结果更新 step 的状态
+func (st *Step) runLoopJobs() {
+	if !st.job.Started() {
+		extra := st.getJobExtra(schema.StatusJobRunning)
+		st.job.SetStatus(schema.StatusJobRunning)
+		wfe := NewWorkflowEvent(WfEventJobUpdate, "", extra)
+		st.wfr.event <- *wfe
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range st.loopJobs {
+		if !job.NotEnded() {
+			continue
+		}
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			st.executeLoopJob(job)
+		}(job)
+	}
+	wg.Wait()
+
+	status := schema.StatusJobSucceeded
+	for _, job := range st.loopJobs {
+		if job.Failed() {
+			status = schema.StatusJobFailed
+			break
+		}
+		if job.TimedOut() {
+			status = schema.StatusJobTimeout
+		} else if !job.Succeeded() && status != schema.StatusJobTimeout {
+			// 被终止或者未运行就被取消的迭代
+			status = schema.StatusJobTerminated
+		}
+	}
+
+	logMsg := fmt.Sprintf("all loop jobs of step[%s] with runid[%s] finished, status[%s]", st.name, st.wfr.wf.RunID, status)
+	st.getLogger().Infof(logMsg)
+	extra := st.getJobExtra(status)
+	st.job.SetStatus(status)
+	st.done = true
+	wfe := NewWorkflowEvent(WfEventJobUpdate, logMsg, extra)
+	st.wfr.event <- *wfe
+}
+
+// executeLoopJob 运行单次迭代，每次迭代占用一个并行槽位
+func (st *Step) executeLoopJob(job Job) {
+	st.wfr.IncConcurrentJobs(1) // 如果达到并行Job上限，将会Block
+	defer st.wfr.DecConcurrentJobs(1)
+
+	if !job.Started() {
+		if st.getCtx().Err() != nil {
+			logMsg := fmt.Sprintf("context of loop job[%s] in step[%s] with runid[%s] has stopped with msg:[%s], no need to execute",
+				job.Job().Name, st.name, st.wfr.wf.RunID, st.getCtx().Err())
+			st.getLogger().Infof(logMsg)
+
+			st.sendLoopJobEvent(job, WfEventJobUpdate, schema.StatusJobCancelled, "")
+			return
+		}
+
+		if _, err := job.Start(); err != nil {
+			ErrMsg := fmt.Sprintf("start loop job[%s] for step[%s] with runid[%s] failed: [%s]", job.Job().Name, st.name, st.wfr.wf.RunID, err.Error())
+			st.getLogger().Errorf(ErrMsg)
+
+			st.sendLoopJobEvent(job, WfEventJobSubmitErr, schema.StatusJobFailed, ErrMsg)
+			return
+		}
+		st.getLogger().Debugf("loop job[%s] of step[%s] with runid[%s]: jobID[%s]", job.Job().Name, st.name, st.wfr.wf.RunID, job.Job().Id)
+		st.logInputArtifact(job)
+	}
+
+	st.watchLoopJob(job)
+}
+
+func (st *Step) sendLoopJobEvent(job Job, eventType WfEventValue, status schema.JobStatus, message string) {
+	extra := map[string]interface{}{
+		"status":    status,
+		"preStatus": job.Job().Status,
+		"jobid":     job.Job().Id,
+	}
+	job.SetStatus(status)
+	wfe := NewWorkflowEvent(eventType, message, extra)
+	st.wfr.event <- *wfe
+}
+
+// watchLoopJob 监控单次迭代，直到结束
+func (st *Step) watchLoopJob(job Job) {
+	logMsg := fmt.Sprintf("start to watch loop job[%s] of step[%s] with runid[%s]", job.Job().Id, st.name, st.wfr.wf.RunID)
+	st.getLogger().Infof(logMsg)
+
+	finished := make(chan struct{})
+	defer close(finished)
+	go st.stopLoopJob(job, finished)
+
+	// 单次迭代超过 step 的 timeout 后停止 job
+	timeoutStopped := make(chan struct{})
+	if timer := st.newStepTimer(job); timer != nil {
+		defer timer.Stop()
+		go func() {
+			select {
+			case <-finished:
+			case <-timer.C:
+				close(timeoutStopped)
+				st.stopTimeoutJob(job)
+			}
+		}()
+	}
+
+	ch := make(chan WorkflowEvent, 1)
+	go job.Watch(ch)
+	for event := range ch {
+		if event.isJobWatchErr() {
+			ErrMsg := fmt.Sprintf("receive watch error of loop job[%s] step[%s] with runid[%s], with errmsg:[%s]", job.Job().Id, st.name, st.wfr.wf.RunID, event.Message)
+			st.getLogger().Errorf(ErrMsg)
+		} else if extra, ok := event.getJobUpdate(); ok && extra["status"] == schema.StatusJobSucceeded {
+			st.logOutputArtifact(job)
+		}
+		st.wfr.event <- event
+	}
+
+	if job.NotEnded() {
+		ErrMsg := fmt.Sprintf("watch loop job[%s] for step[%s] with runid[%s] failed, channel already closed", job.Job().Id, st.name, st.wfr.wf.RunID)
+		st.getLogger().Errorf(ErrMsg)
+		st.sendLoopJobEvent(job, WfEventJobWatchErr, schema.StatusJobFailed, ErrMsg)
+		return
+	}
+
+	if job.Succeeded() {
+		return
+	}
+	select {
+	case <-timeoutStopped:
+	default:
+		if !st.wfr.isDeadlineExceeded() {
+			return
+		}
+	}
+	message := st.getTimeoutMessage(job)
+	st.getLogger().Infof(message)
+	st.sendLoopJobEvent(job, WfEventJobUpdate, schema.StatusJobTimeout, message)
+}
+
+// stopLoopJob 在 run 被取消时停止单次迭代
+func (st *Step) stopLoopJob(job Job, finished chan struct{}) {
+	select {
+	case <-finished:
+		return
+	case <-st.getCtx().Done():
+	}
+
+	tryCount := 1
+	for job.NotEnded() {
+		err := job.Stop()
+		if err == nil {
+			return
+		}
+		ErrMsg := fmt.Sprintf("stop loop job[%s] for step[%s] with runid[%s] failed [%d] times: [%s]", job.Job().Id, st.name, st.wfr.wf.RunID, tryCount, err.Error())
+		st.getLogger().Errorf(ErrMsg)
+		wfe := NewWorkflowEvent(WfEventJobStopErr, ErrMsg, nil)
+		st.wfr.event <- *wfe
+
+		tryCount += 1
+		time.Sleep(time.Second * 3)
+	}
+}
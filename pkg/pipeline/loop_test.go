@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/handler"
+	"paddleflow/pkg/common/config"
+	"paddleflow/pkg/common/schema"
+)
+
+const loopRunYaml = `
+name: loop
+entry_points:
+  prepare:
+    parameters:
+      lr_list: "./lr.json"
+    command: "echo prepare"
+    artifacts:
+      output:
+        lr: "{{ lr_list }}"
+  sweep:
+    deps: prepare
+    loop_argument: "{{ prepare.lr }}"
+    parameters:
+      lr: "{{ PF_LOOP_ARGUMENT }}"
+    command: "test -n {{ lr }}"
+    env:
+      LR: "{{ PF_LOOP_ARGUMENT }}"
+  sweep_literal:
+    loop_argument: [0.1, "b", {"k": "v"}]
+    command: "echo {{ PF_LOOP_ARGUMENT }}"
+executor: local
+parallelism: 2
+`
+
+func mockLoopWorkflow(t *testing.T) *Workflow {
+	wfs := parseWorkflowSource([]byte(loopRunYaml))
+	bwf := NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	wf := &Workflow{
+		BaseWorkflow: bwf,
+	}
+	wf.runtime = NewWorkflowRuntime(wf, 2)
+	err := bwf.validate()
+	assert.Nil(t, err)
+	sortedSteps, err := wf.topologicalSort(wf.Source.EntryPoints)
+	assert.Nil(t, err)
+	for _, stepName := range sortedSteps {
+		st := &Step{
+			name:  stepName,
+			wfr:   wf.runtime,
+			info:  bwf.Source.EntryPoints[stepName],
+			ready: make(chan bool, 1),
+			done:  false,
+		}
+		wf.runtime.steps[stepName] = st
+		st.job = NewJob(wf.Source.Executor, st.name, st.info.Image, st.info.Deps)
+		err := st.updateJob()
+		assert.Nil(t, err)
+	}
+	return wf
+}
+
+func TestGetLoopArguments(t *testing.T) {
+	wf := mockLoopWorkflow(t)
+
+	arguments, err := wf.runtime.steps["sweep_literal"].getLoopArguments()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0.1", "b", "{\"k\":\"v\"}"}, arguments)
+
+	// 引用上游 output artifact 的内容
+	serverConf := &config.ServerConfig{}
+	err = config.InitConfigFromYaml(serverConf, "../../config/server/default/paddleserver.yaml")
+	assert.Nil(t, err)
+	config.GlobalServerConfig = serverConf
+	handler.NewFsHandlerWithServer = handler.MockerNewFsHandlerWithServer
+	os.MkdirAll("./mock_fs_handler", 0755)
+	defer os.RemoveAll("./mock_fs_handler")
+
+	err = ioutil.WriteFile("./mock_fs_handler/lr.json", []byte("[0.01, 0.001]"), 0644)
+	assert.Nil(t, err)
+	arguments, err = wf.runtime.steps["sweep"].getLoopArguments()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0.01", "0.001"}, arguments)
+
+	err = ioutil.WriteFile("./mock_fs_handler/lr.json", []byte("0.01"), 0644)
+	assert.Nil(t, err)
+	_, err = wf.runtime.steps["sweep"].getLoopArguments()
+	assert.NotNil(t, err)
+}
+
+func TestNewLoopJob(t *testing.T) {
+	wf := mockLoopWorkflow(t)
+	st := wf.runtime.steps["sweep"]
+	assert.Equal(t, loopArgumentPlaceholder, st.job.Job().Parameters["lr"])
+
+	job := st.newLoopJob(1, "0.001")
+	assert.Equal(t, "sweep-1", job.Job().Name)
+	assert.Equal(t, "test -n 0.001", job.Job().Command)
+	assert.Equal(t, "0.001", job.Job().Parameters["lr"])
+	assert.Equal(t, "0.001", job.Job().Env["LR"])
+	assert.Equal(t, "0.001", job.Job().Env[SysParamNamePFLoopArgument])
+	// 模板 job 不受影响
+	assert.Equal(t, loopArgumentPlaceholder, st.job.Job().Env["LR"])
+}
+
+func TestExecuteLoop(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+
+	wf := mockLoopWorkflow(t)
+	st := wf.runtime.steps["sweep_literal"]
+	go func() {
+		for range wf.runtime.event {
+		}
+	}()
+
+	st.executeLoop()
+	assert.True(t, st.done)
+	assert.True(t, st.job.Succeeded())
+	assert.Equal(t, 3, len(st.loopJobs))
+	for _, job := range st.loopJobs {
+		assert.True(t, job.Succeeded())
+	}
+	assert.Equal(t, "echo b", st.loopJobs[1].Job().Command)
+	// 所有迭代结束后，并行槽位全部释放
+	assert.Equal(t, 0, len(wf.runtime.concurrentJobs))
+
+	// runtime view 中记录每次迭代
+	jobView := newJobView(st.job, st.info.Image)
+	assert.Equal(t, schema.StatusJobSucceeded, jobView.Status)
+}
+
+func TestLoopArgumentValidate(t *testing.T) {
+	// 非 loop step 不能引用 PF_LOOP_ARGUMENT
+	wfs := parseWorkflowSource([]byte(loopRunYaml))
+	wfs.EntryPoints["prepare"].Command = "echo {{ PF_LOOP_ARGUMENT }}"
+	bwf := NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	// loop_argument 只能引用上游 step
+	wfs = parseWorkflowSource([]byte(loopRunYaml))
+	wfs.EntryPoints["sweep_literal"].LoopArgument = "{{ prepare.lr }}"
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	wfs = parseWorkflowSource([]byte(loopRunYaml))
+	wfs.EntryPoints["sweep_literal"].LoopArgument = 1
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+}
@@ -133,6 +133,18 @@ func (mr *MockJobMockRecorder) Watch(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockJob)(nil).Watch), arg0)
 }
 
+// SetStatus mocks base method
+func (m *MockJob) SetStatus(status schema.JobStatus) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetStatus", status)
+}
+
+// SetStatus indicates an expected call of SetStatus
+func (mr *MockJobMockRecorder) SetStatus(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockJob)(nil).SetStatus), status)
+}
+
 // Started mocks base method
 func (m *MockJob) Started() bool {
 	m.ctrl.T.Helper()
@@ -175,6 +187,34 @@ func (mr *MockJobMockRecorder) Cached() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cached", reflect.TypeOf((*MockJob)(nil).Cached))
 }
 
+// Skipped mocks base method
+func (m *MockJob) Skipped() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Skipped")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Skipped indicates an expected call of Skipped
+func (mr *MockJobMockRecorder) Skipped() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Skipped", reflect.TypeOf((*MockJob)(nil).Skipped))
+}
+
+// TimedOut mocks base method
+func (m *MockJob) TimedOut() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TimedOut")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// TimedOut indicates an expected call of TimedOut
+func (mr *MockJobMockRecorder) TimedOut() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TimedOut", reflect.TypeOf((*MockJob)(nil).TimedOut))
+}
+
 // Failed mocks base method
 func (m *MockJob) Failed() bool {
 	m.ctrl.T.Helper()
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// post_process step 在初始化时，PF_RUN_STATUS 先被替换为该占位符，开始运行时再替换为主 DAG 的最终状态
+const runStatusPlaceholder = "{{PF_RUN_STATUS}}"
+
+// isPostProcess 判断 step 是否为 post_process step
+func (st *Step) isPostProcess() bool {
+	_, ok := st.wfr.wf.Source.PostProcess[st.name]
+	return ok
+}
+
+// getCtx 返回 step 运行所使用的 context
+// 主 DAG 失败或被终止时其 context 会被取消，post_process step 需要在独立的 context 中运行
+func (st *Step) getCtx() context.Context {
+	if st.isPostProcess() {
+		return st.wfr.postCtx
+	}
+	return st.wfr.ctx
+}
+
+// getPeerSteps 获取与 step 处于同一个 DAG 中的 step
+// post_process step 只能依赖及引用其他 post_process step
+func (wfr *WorkflowRuntime) getPeerSteps(stepName string) map[string]*Step {
+	if _, ok := wfr.wf.Source.PostProcess[stepName]; ok {
+		return wfr.postProcess
+	}
+	return wfr.steps
+}
+
+// getAllSteps 获取 run 中的所有 step，包括 post_process step
+func (wfr *WorkflowRuntime) getAllSteps() map[string]*Step {
+	steps := make(map[string]*Step, len(wfr.steps)+len(wfr.postProcess))
+	for name, st := range wfr.steps {
+		steps[name] = st
+	}
+	for name, st := range wfr.postProcess {
+		steps[name] = st
+	}
+	return steps
+}
+
+// startPostProcess 主 DAG 结束后，开始运行 post_process step
+// 无论主 DAG 成功、失败或被终止，post_process step 都会运行，并通过 PF_RUN_STATUS 获取主 DAG 的最终状态
+func (wfr *WorkflowRuntime) startPostProcess(dagStatus string) {
+	wfr.wf.log().Infof("main dag of workflow %s finished with status[%s], begin to run post_process steps", wfr.wf.Name, dagStatus)
+	wfr.dagStatus = dagStatus
+	atomic.StoreInt32(&wfr.postStarted, 1)
+	// run 的超时时间只作用于主 DAG，post_process step 的运行时间由各自的 timeout 控制
+	atomic.StoreInt32(&wfr.deadlineState, deadlineDisabled)
+
+	for name, st := range wfr.postProcess {
+		if st.done {
+			continue
+		}
+		if !st.job.Started() {
+			st.setRunStatus(dagStatus)
+		}
+		wfr.wf.log().Debugf("Start Execute post_process step: %s", name)
+		go st.Execute()
+	}
+}
+
+// isPostStarted post_process step 是否已经开始运行
+func (wfr *WorkflowRuntime) isPostStarted() bool {
+	return atomic.LoadInt32(&wfr.postStarted) == 1
+}
+
+// setRunStatus 将 job 中 PF_RUN_STATUS 的占位符替换为主 DAG 的最终状态
+func (st *Step) setRunStatus(dagStatus string) {
+	command, params, envs, artifacts := replaceJobPlaceholder(st.job.Job(), runStatusPlaceholder, dagStatus)
+	st.job.Update(command, params, envs, &artifacts)
+	st.getLogger().Debugf("step[%s] of runid[%s]: set %s to [%s], command[%s]", st.name, st.wfr.wf.RunID, SysParamNamePFRunStatus, dagStatus, command)
+}
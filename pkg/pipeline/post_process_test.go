@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/common/schema"
+)
+
+// 其他测试用例会替换 NewStep，这里保存原始的实现
+var newStepFunc = NewStep
+
+const postProcessRunYaml = `
+name: post_process
+entry_points:
+  main:
+    command: "exit 1"
+post_process:
+  notify:
+    command: "echo {{PF_RUN_STATUS}} > ./mock_post_process_status"
+executor: local
+`
+
+func TestCheckPostProcess(t *testing.T) {
+	wfs := parseWorkflowSource([]byte(postProcessRunYaml))
+	bwf := NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.Nil(t, bwf.validate())
+
+	// post_process step 与 entry_points 中的 step 重名
+	wfs = parseWorkflowSource([]byte(postProcessRunYaml))
+	wfs.PostProcess["main"] = wfs.PostProcess["notify"]
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	// post_process step 依赖主 DAG 中的 step
+	wfs = parseWorkflowSource([]byte(postProcessRunYaml))
+	wfs.PostProcess["notify"].Deps = "main"
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	// 主 DAG 中的 step 不能引用 PF_RUN_STATUS
+	wfs = parseWorkflowSource([]byte(postProcessRunYaml))
+	wfs.EntryPoints["main"].Command = "echo {{PF_RUN_STATUS}}"
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+}
+
+func TestPostProcessRun(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+	defer os.Remove("./mock_post_process_status")
+	NewStep = newStepFunc
+
+	wfs := parseWorkflowSource([]byte(postProcessRunYaml))
+	wf, err := NewWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor(), mockCbs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(wf.runtime.postProcess))
+
+	wf.Start()
+	for i := 0; i < 100 && !wf.runtime.IsCompleted(); i++ {
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	assert.Equal(t, common.StatusRunFailed, wf.runtime.status)
+	assert.True(t, wf.runtime.postProcess["notify"].job.Succeeded())
+	content, err := ioutil.ReadFile("./mock_post_process_status")
+	assert.Nil(t, err)
+	assert.Equal(t, common.StatusRunFailed, strings.TrimSpace(string(content)))
+}
+
+func TestPostProcessContext(t *testing.T) {
+	NewStep = newStepFunc
+
+	wfs := parseWorkflowSource([]byte(postProcessRunYaml))
+	wf, err := NewWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor(), mockCbs)
+	assert.Nil(t, err)
+
+	// 主 DAG 被取消后，post_process step 在独立的 context 中运行，不会替换主 DAG 的 context
+	mainCtx := wf.runtime.ctx
+	wf.runtime.ctxCancel()
+	wf.runtime.postProcess["notify"].done = true
+	wf.runtime.startPostProcess(common.StatusRunFailed)
+	assert.Equal(t, mainCtx, wf.runtime.ctx)
+	assert.NotNil(t, wf.runtime.steps["main"].getCtx().Err())
+	assert.Nil(t, wf.runtime.postProcess["notify"].getCtx().Err())
+
+	// post_process step 开始运行后停止 run，会停止 post_process step
+	assert.Nil(t, wf.runtime.Stop())
+	assert.NotNil(t, wf.runtime.postProcess["notify"].getCtx().Err())
+}
+
+func TestPostProcessRestartCompleted(t *testing.T) {
+	NewStep = newStepFunc
+
+	finalCallbacks := 0
+	cbs := mockCbs
+	cbs.UpdateRunCb = func(runID string, event interface{}) bool {
+		wfe := event.(*WorkflowEvent)
+		if wfe.Extra[common.WfEventKeyStatus] == common.StatusRunFailed {
+			finalCallbacks++
+		}
+		return true
+	}
+	wfs := parseWorkflowSource([]byte(postProcessRunYaml))
+	wf, err := NewWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor(), cbs)
+	assert.Nil(t, err)
+
+	// 服务异常时主 DAG 与 post_process step 都已经结束，恢复后直接结束 run，并且只发送一次回调
+	err = wf.SetWorkflowRuntime(schema.RuntimeView{
+		"main":   {JobID: "local-000001", Status: schema.StatusJobFailed},
+		"notify": {JobID: "local-000002", Status: schema.StatusJobSucceeded},
+	})
+	assert.Nil(t, err)
+	wf.Restart()
+	assert.Equal(t, common.StatusRunFailed, wf.runtime.status)
+	assert.Equal(t, 1, finalCallbacks)
+
+	wf.runtime.callback(*NewWorkflowEvent(WfEventRunUpdate, "", nil))
+	assert.Equal(t, 1, finalCallbacks)
+}
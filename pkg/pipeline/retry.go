@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"paddleflow/pkg/common/schema"
+)
+
+// needRetry 根据 step 的 retry 配置，判断结束的 job 是否需要重试
+func (st *Step) needRetry(status schema.JobStatus, message string) bool {
+	retry := st.info.Retry
+	if retry == nil || len(st.attempts)+1 >= retry.MaxAttempts {
+		return false
+	}
+	// run 已经被停止，或者有其他 step 失败，不再重试
+	if st.getCtx().Err() != nil {
+		return false
+	}
+	if status != schema.StatusJobFailed && status != schema.StatusJobTerminated && status != schema.StatusJobTimeout {
+		return false
+	}
+	if len(retry.RetryOn) == 0 {
+		return status == schema.StatusJobFailed
+	}
+	for _, retryOn := range retry.RetryOn {
+		retryOn = strings.TrimSpace(retryOn)
+		if retryOn == string(status) {
+			return true
+		}
+		if message != "" && strings.Contains(strings.ToLower(message), strings.ToLower(retryOn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRetryBackoff 第 n 次重试前的等待时间为 backoff * backoff_factor^(n-1)
+func (st *Step) getRetryBackoff(attempt int) time.Duration {
+	retry := st.info.Retry
+	if retry == nil || retry.Backoff == "" {
+		return 0
+	}
+	backoff, err := time.ParseDuration(retry.Backoff)
+	if err != nil {
+		return 0
+	}
+	factor := retry.BackoffFactor
+	if factor <= 0 {
+		factor = 1
+	}
+	return time.Duration(float64(backoff) * math.Pow(factor, float64(attempt-1)))
+}
+
+// newRetryJob 以上一次运行的 job 为模板，生成一个新的 job
+func (st *Step) newRetryJob() Job {
+	preJob := st.job.Job()
+	artifacts := preJob.Artifacts
+	job := NewJob(st.wfr.wf.Source.Executor, preJob.Name, st.info.Image, preJob.Deps)
+	job.Update(preJob.Command, preJob.Parameters, preJob.Env, &artifacts)
+	return job
+}
+
+// retry 记录上一次运行，并在等待 backoff 后提交新的 job
+// 返回新 job 的 watch channel；如果未能提交新的 job，step 会被置为结束，返回 false
+func (st *Step) retry(status schema.JobStatus, message string) (chan WorkflowEvent, bool) {
+	preJob := st.job
+	preStatus := preJob.Job().Status
+	preJob.SetStatus(status)
+	st.attempts = append(st.attempts, preJob)
+	st.job = st.newRetryJob()
+
+	attempt := len(st.attempts)
+	backoff := st.getRetryBackoff(attempt)
+	retryMsg := fmt.Sprintf("job[%s] of step[%s] with runid[%s] is %s with message[%s], retry attempt[%d] after %s",
+		preJob.Job().Id, st.name, st.wfr.wf.RunID, status, message, attempt, backoff)
+	st.getLogger().Infof(retryMsg)
+
+	extra := map[string]interface{}{
+		"status":    status,
+		"preStatus": preStatus,
+		"jobid":     preJob.Job().Id,
+	}
+	wfe := NewWorkflowEvent(WfEventJobUpdate, retryMsg, extra)
+	st.wfr.event <- *wfe
+
+	select {
+	case <-st.getCtx().Done():
+	case <-time.After(backoff):
+	}
+	if st.getCtx().Err() != nil {
+		logMsg := fmt.Sprintf("context of step[%s] with runid[%s] has stopped with msg:[%s], no need to retry", st.name, st.wfr.wf.RunID, st.getCtx().Err())
+		st.getLogger().Infof(logMsg)
+
+		st.wfr.DecConcurrentJobs(1)
+		extra := st.getJobExtra(schema.StatusJobCancelled)
+		st.job.SetStatus(schema.StatusJobCancelled)
+		st.done = true
+		wfe := NewWorkflowEvent(WfEventJobUpdate, logMsg, extra)
+		st.wfr.event <- *wfe
+		return nil, false
+	}
+
+	if _, err := st.job.Start(); err != nil {
+		ErrMsg := fmt.Sprintf("start retry job for step[%s] with runid[%s] failed: [%s]", st.name, st.wfr.wf.RunID, err.Error())
+		st.getLogger().Errorf(ErrMsg)
+
+		st.wfr.DecConcurrentJobs(1)
+		extra := st.getJobExtra(schema.StatusJobFailed)
+		st.job.SetStatus(schema.StatusJobFailed)
+		st.done = true
+		wfe := NewWorkflowEvent(WfEventJobSubmitErr, ErrMsg, extra)
+		st.wfr.event <- *wfe
+		return nil, false
+	}
+	st.getLogger().Debugf("step[%s] of runid[%s]: retry jobID[%s]", st.name, st.wfr.wf.RunID, st.job.Job().Id)
+
+	ch := make(chan WorkflowEvent, 1)
+	go st.job.Watch(ch)
+	return ch, true
+}
@@ -0,0 +1,185 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/apiserver/common"
+	"paddleflow/pkg/common/schema"
+)
+
+const retryRunYaml = `
+name: retry
+entry_points:
+  main:
+    command: "test -f ./mock_retry_marker || (touch ./mock_retry_marker && exit 1)"
+    retry:
+      max_attempts: 3
+      backoff: 10ms
+      backoff_factor: 2
+executor: local
+`
+
+func mockRetryStep(t *testing.T, runYaml string) *Step {
+	wfs := parseWorkflowSource([]byte(runYaml))
+	bwf := NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	wf := &Workflow{
+		BaseWorkflow: bwf,
+	}
+	wf.runtime = NewWorkflowRuntime(wf, 2)
+	err := bwf.validate()
+	assert.Nil(t, err)
+
+	st := &Step{
+		name:  "main",
+		wfr:   wf.runtime,
+		info:  bwf.Source.EntryPoints["main"],
+		ready: make(chan bool, 1),
+		done:  false,
+	}
+	wf.runtime.steps["main"] = st
+	st.job = NewJob(wf.Source.Executor, st.name, st.info.Image, st.info.Deps)
+	err = st.updateJob()
+	assert.Nil(t, err)
+	return st
+}
+
+func TestNeedRetry(t *testing.T) {
+	st := mockRetryStep(t, retryRunYaml)
+	assert.True(t, st.needRetry(schema.StatusJobFailed, ""))
+	assert.False(t, st.needRetry(schema.StatusJobTerminated, ""))
+	assert.False(t, st.needRetry(schema.StatusJobSucceeded, ""))
+	assert.False(t, st.needRetry(schema.StatusJobTimeout, ""))
+
+	st.info.Retry.RetryOn = []string{"terminated", "Preempted"}
+	assert.True(t, st.needRetry(schema.StatusJobTerminated, ""))
+	assert.True(t, st.needRetry(schema.StatusJobFailed, "pod is preempted by high priority job"))
+	assert.False(t, st.needRetry(schema.StatusJobFailed, "exit code 1"))
+
+	st.info.Retry.RetryOn = []string{"terminated", "timeout"}
+	assert.True(t, st.needRetry(schema.StatusJobTimeout, ""))
+
+	// 达到最大运行次数
+	st.attempts = []Job{st.job, st.job}
+	assert.False(t, st.needRetry(schema.StatusJobTerminated, ""))
+
+	// run 已经被停止
+	st.attempts = nil
+	st.wfr.ctxCancel()
+	assert.False(t, st.needRetry(schema.StatusJobTerminated, ""))
+}
+
+func TestGetRetryBackoff(t *testing.T) {
+	st := mockRetryStep(t, retryRunYaml)
+	assert.Equal(t, 10*time.Millisecond, st.getRetryBackoff(1))
+	assert.Equal(t, 20*time.Millisecond, st.getRetryBackoff(2))
+	assert.Equal(t, 40*time.Millisecond, st.getRetryBackoff(3))
+
+	st.info.Retry.Backoff = ""
+	assert.Equal(t, time.Duration(0), st.getRetryBackoff(1))
+}
+
+func TestCheckRetry(t *testing.T) {
+	wfs := parseWorkflowSource([]byte(retryRunYaml))
+	wfs.EntryPoints["main"].Retry.Backoff = "10"
+	bwf := NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	wfs = parseWorkflowSource([]byte(retryRunYaml))
+	wfs.EntryPoints["main"].Retry.MaxAttempts = -1
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	wfs = parseWorkflowSource([]byte(retryRunYaml))
+	wfs.EntryPoints["main"].Retry.BackoffFactor = 0
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.Nil(t, bwf.validate())
+	assert.Equal(t, float64(1), wfs.EntryPoints["main"].Retry.BackoffFactor)
+}
+
+func TestStepRetry(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+	defer os.Remove("./mock_retry_marker")
+
+	st := mockRetryStep(t, retryRunYaml)
+	go func() {
+		for range st.wfr.event {
+		}
+	}()
+
+	st.ready <- true
+	st.Execute()
+
+	assert.True(t, st.done)
+	assert.True(t, st.job.Succeeded())
+	assert.Equal(t, 1, len(st.attempts))
+	assert.True(t, st.attempts[0].Failed())
+	assert.NotEqual(t, st.attempts[0].Job().Id, st.job.Job().Id)
+	assert.Equal(t, 0, len(st.wfr.concurrentJobs))
+}
+
+const retryWithSiblingRunYaml = `
+name: retry
+entry_points:
+  main:
+    command: "test -f ./mock_retry_marker || (touch ./mock_retry_marker && exit 1)"
+    retry:
+      max_attempts: 2
+      backoff: 500ms
+  sibling:
+    command: "sleep 1"
+executor: local
+`
+
+func TestRetryWithRunningSibling(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+	defer os.Remove("./mock_retry_marker")
+	NewStep = newStepFunc
+
+	// 第一次运行失败、尚未决定是否重试时，不能被当作失败的 step 而 cancel 整个 run
+	wfs := parseWorkflowSource([]byte(retryWithSiblingRunYaml))
+	wf, err := NewWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor(), mockCbs)
+	assert.Nil(t, err)
+	main := wf.runtime.steps["main"]
+	main.update(false, true, main.job)
+	main.job.SetStatus(schema.StatusJobFailed)
+	_, finished := wf.runtime.updateStepsStatus(wf.runtime.steps, wf.runtime.ctx, wf.runtime.ctxCancel)
+	assert.False(t, finished)
+	assert.Nil(t, wf.runtime.ctx.Err())
+
+	// 重试期间兄弟 step 仍在运行，run 最终成功
+	wfs = parseWorkflowSource([]byte(retryWithSiblingRunYaml))
+	wf, err = NewWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor(), mockCbs)
+	assert.Nil(t, err)
+	wf.Start()
+	for i := 0; i < 100 && !wf.runtime.IsCompleted(); i++ {
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	assert.Equal(t, common.StatusRunSucceeded, wf.runtime.status)
+	assert.Nil(t, wf.runtime.ctx.Err())
+	assert.Equal(t, 1, len(wf.runtime.steps["main"].attempts))
+	assert.True(t, wf.runtime.steps["main"].job.Succeeded())
+	assert.True(t, wf.runtime.steps["sibling"].job.Succeeded())
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/common/schema"
@@ -31,20 +33,31 @@ type WorkflowRuntime struct {
 	wf               *Workflow
 	ctx              context.Context
 	ctxCancel        context.CancelFunc
+	postCtx          context.Context // post_process step 运行所使用的 context，与主 DAG 的 context 互相独立
+	postCancel       context.CancelFunc
 	steps            map[string]*Step
+	postProcess      map[string]*Step   // 主 DAG 结束后运行的 post_process step
 	event            chan WorkflowEvent // 用来从 job 传递事件
 	concurrentJobs   chan struct{}
 	concurrentJobsMx sync.Mutex
 	status           string
+	deadlineState    int32  // run 整体超时的状态，会被多个 step 的协程读取，只能通过原子操作访问
+	postStarted      int32  // post_process step 是否已经开始运行，会在 Stop 中被读取，只能通过原子操作访问
+	finalCallbacked  int32  // run 结束时的回调是否已经发送，保证只发送一次
+	dagStatus        string // 主 DAG 的最终状态
 }
 
 func NewWorkflowRuntime(wf *Workflow, parallelism int) *WorkflowRuntime {
 	ctx, ctxCancel := context.WithCancel(context.Background())
+	postCtx, postCancel := context.WithCancel(context.Background())
 	wfr := &WorkflowRuntime{
 		wf:             wf,
 		ctx:            ctx,
 		ctxCancel:      ctxCancel,
+		postCtx:        postCtx,
+		postCancel:     postCancel,
 		steps:          map[string]*Step{},
+		postProcess:    map[string]*Step{},
 		event:          make(chan WorkflowEvent, parallelism),
 		concurrentJobs: make(chan struct{}, parallelism),
 	}
@@ -72,6 +85,7 @@ func (wfr *WorkflowRuntime) Start() error {
 	}
 
 	go wfr.Listen()
+	go wfr.watchDeadline(time.Now())
 	return nil
 }
 
@@ -98,7 +112,16 @@ func (wfr *WorkflowRuntime) Restart() error {
 		}
 	}
 
+	// 如果服务异常时主 DAG 已经结束，需要恢复 post_process step 的运行
+	// 如果所有 step 在服务异常时都已经结束，不会再有新的 event，需要在这里结束 run 并发送回调
+	wfr.updateStatus()
+	if wfr.IsCompleted() {
+		wfr.callback(*NewWorkflowEvent(WfEventRunUpdate, "", nil))
+		return nil
+	}
+
 	go wfr.Listen()
+	go wfr.watchDeadline(wfr.getRestartTime())
 
 	return nil
 }
@@ -112,6 +135,10 @@ func (wfr *WorkflowRuntime) Stop() error {
 	}
 
 	wfr.ctxCancel()
+	// post_process step 开始运行前停止 run，post_process step 仍然会运行
+	if wfr.isPostStarted() {
+		wfr.postCancel()
+	}
 
 	wfr.status = common.StatusRunTerminating
 
@@ -181,10 +208,47 @@ func (wfr *WorkflowRuntime) processEvent(event WorkflowEvent) error {
 }
 
 func (wfr *WorkflowRuntime) updateStatus() {
+	if !wfr.isPostStarted() {
+		status, finished := wfr.updateStepsStatus(wfr.steps, wfr.ctx, wfr.ctxCancel)
+		if !finished {
+			return
+		}
+		if wfr.isDeadlineExceeded() {
+			status = common.StatusRunFailed
+		}
+		if len(wfr.postProcess) == 0 {
+			wfr.status = status
+			wfr.wf.log().Debugf("workflow %s finished", wfr.wf.Name)
+			return
+		}
+		wfr.startPostProcess(status)
+	}
+
+	postStatus, finished := wfr.updateStepsStatus(wfr.postProcess, wfr.postCtx, wfr.postCancel)
+	if !finished {
+		return
+	}
+	// post_process step 失败或被终止时，原本成功的 run 也视为失败或终止
+	status := wfr.dagStatus
+	if status == common.StatusRunSucceeded {
+		status = postStatus
+	}
+	wfr.status = status
+	wfr.wf.log().Debugf("workflow %s finished", wfr.wf.Name)
+}
+
+// updateStepsStatus 统计一组 step 的状态，并触发依赖已经满足的 step 运行
+// 这组 step 全部结束时，返回其最终状态；有 step 失败或被终止时，通过 cancel 停止这组 step 中其他运行中的 step
+func (wfr *WorkflowRuntime) updateStepsStatus(steps map[string]*Step, ctx context.Context, cancel context.CancelFunc) (string, bool) {
 	stepDone := 0
 	hasFailedStep := false
 	hasTerminatedStep := false
-	for st_name, st := range wfr.steps {
+	for st_name, st := range steps {
+		if st.submitted && !st.done {
+			// job 已经提交，但 step 还未处理完 job 的结束事件（如正在判断是否重试），视为运行中
+			// 避免重试前 job 的失败状态被统计到，导致 run 被 cancel
+			continue
+		}
 		if st.job.Succeeded() {
 			stepDone++
 			wfr.wf.log().Infof("has succeeded step: %s", st_name)
@@ -194,6 +258,12 @@ func (wfr *WorkflowRuntime) updateStatus() {
 			hasFailedStep = true
 			wfr.wf.log().Infof("has failed step: %s", st_name)
 			continue
+		} else if st.job.TimedOut() {
+			// 超时的 step 视为失败
+			stepDone++
+			hasFailedStep = true
+			wfr.wf.log().Infof("has timeout step: %s", st_name)
+			continue
 		} else if st.job.Terminated() {
 			stepDone++
 			hasTerminatedStep = true
@@ -213,39 +283,37 @@ func (wfr *WorkflowRuntime) updateStatus() {
 		}
 	}
 
-	if stepDone == len(wfr.steps) {
+	if stepDone == len(steps) {
 		if hasFailedStep {
-			wfr.status = common.StatusRunFailed
+			return common.StatusRunFailed, true
 		} else if hasTerminatedStep {
 			if wfr.status == common.StatusRunTerminating {
-				wfr.status = common.StatusRunTerminated
-			} else {
-				wfr.status = common.StatusRunFailed
+				return common.StatusRunTerminated, true
 			}
-		} else {
-			wfr.status = common.StatusRunSucceeded
+			return common.StatusRunFailed, true
 		}
-
-		wfr.wf.log().Debugf("workflow %s finished", wfr.wf.Name)
-		return
+		return common.StatusRunSucceeded, true
 	}
 
-	if (hasFailedStep || hasTerminatedStep) && wfr.ctx.Err() == nil {
+	if (hasFailedStep || hasTerminatedStep) && ctx.Err() == nil {
 		// 未完成 + 有失败的 step + 未发起 cancel
 		wfr.wf.log().Infof("workflow %s has failed or terminated step, begin to cancel it ", wfr.wf.Name)
-		wfr.ctxCancel()
+		cancel()
 	}
+	return "", false
 }
 
 func (wfr *WorkflowRuntime) isDepsReady(step *Step) bool {
 	depsReady := true
+	steps := wfr.getPeerSteps(step.name)
 	deps := strings.Split(step.info.Deps, ",")
 	for _, ds := range deps {
 		ds = strings.Trim(ds, " ")
 		if len(ds) <= 0 {
 			continue
 		}
-		if !wfr.steps[ds].job.Succeeded() && !wfr.steps[ds].job.Cached() {
+		// condition 不满足而跳过的 step，对下游而言视为已完成
+		if !steps[ds].job.Succeeded() && !steps[ds].job.Cached() && !steps[ds].job.Skipped() {
 			depsReady = false
 		}
 	}
@@ -253,22 +321,20 @@ func (wfr *WorkflowRuntime) isDepsReady(step *Step) bool {
 }
 
 func (wfr *WorkflowRuntime) callback(event WorkflowEvent) {
+	// run 结束后只发送一次回调，避免 Stop、Restart 与 event 处理重复发送最终状态
+	if wfr.IsCompleted() && !atomic.CompareAndSwapInt32(&wfr.finalCallbacked, 0, 1) {
+		wfr.wf.log().Debugf("final callback of workflow %s has been sent, skip event", wfr.wf.Name)
+		return
+	}
+
 	runtimeView := make(schema.RuntimeView, 0)
-	for name, st := range wfr.steps {
-		job := st.job.Job()
-		jobView := schema.JobView{
-			JobID:      job.Id,
-			JobName:    job.Name,
-			Command:    job.Command,
-			Parameters: job.Parameters,
-			Env:        job.Env,
-			StartTime:  job.StartTime,
-			EndTime:    job.EndTime,
-			Status:     job.Status,
-			Deps:       job.Deps,
-			Image:      st.info.Image,
-			Artifacts:  job.Artifacts,
-			JobMessage: job.Message,
+	for name, st := range wfr.getAllSteps() {
+		jobView := newJobView(st.job, st.info.Image)
+		for _, loopJob := range st.loopJobs {
+			jobView.LoopJobs = append(jobView.LoopJobs, newJobView(loopJob, st.info.Image))
+		}
+		for _, attempt := range st.attempts {
+			jobView.Attempts = append(jobView.Attempts, newJobView(attempt, st.info.Image))
 		}
 		runtimeView[name] = jobView
 	}
@@ -287,6 +353,10 @@ func (wfr *WorkflowRuntime) callback(event WorkflowEvent) {
 		message = fmt.Sprintf("submit job in run error because of %s.", event.Message)
 	} else if event.isJobWatchErr() {
 		message = fmt.Sprintf("watch job in run error because of %s.", event.Message)
+	} else if event.isRunTimeout() {
+		message = event.Message
+	} else if event.isJobTimeout() {
+		message = fmt.Sprintf("run has failed because of %s.", event.Message)
 	}
 
 	wfEvent := NewWorkflowEvent(WfEventRunUpdate, message, extra)
@@ -298,3 +368,21 @@ func (wfr *WorkflowRuntime) callback(event WorkflowEvent) {
 	}
 	// todo: how to handle retry failed
 }
+
+func newJobView(j Job, image string) schema.JobView {
+	job := j.Job()
+	return schema.JobView{
+		JobID:      job.Id,
+		JobName:    job.Name,
+		Command:    job.Command,
+		Parameters: job.Parameters,
+		Env:        job.Env,
+		StartTime:  job.StartTime,
+		EndTime:    job.EndTime,
+		Status:     job.Status,
+		Deps:       job.Deps,
+		Image:      image,
+		Artifacts:  job.Artifacts,
+		JobMessage: job.Message,
+	}
+}
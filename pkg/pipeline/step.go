@@ -35,6 +35,8 @@ type Step struct {
 	done              bool      // 表示是否已经运行结束，done==true，则跳过该step
 	submitted         bool      // 表示是否从run中发过ready信号过来。如果pipeline服务宕机重启，run会从该字段判断是否需要再次发ready信号，触发运行
 	job               Job
+	loopJobs          []Job // loop step 展开后，每次迭代对应的 job
+	attempts          []Job // 重试之前的历次运行
 	firstFingerprint  string
 	secondFingerprint string
 }
@@ -52,7 +54,7 @@ var NewStep = func(name string, wfr *WorkflowRuntime, info *schema.WorkflowSourc
 	}
 
 	jobName := fmt.Sprintf("%s-%s", st.wfr.wf.RunID, name)
-	st.job = NewPaddleFlowJob(jobName, st.info.Image, st.info.Deps)
+	st.job = NewJob(st.wfr.wf.Source.Executor, jobName, st.info.Image, st.info.Deps)
 
 	st.getLogger().Debugf("before updating job: param[%s], env[%s], command[%s], deps[%s]", st.info.Parameters, st.info.Env, st.info.Command, st.info.Deps)
 	err := st.updateJob()
@@ -60,7 +62,7 @@ var NewStep = func(name string, wfr *WorkflowRuntime, info *schema.WorkflowSourc
 		st.getLogger().Error(err.Error())
 		return nil, err
 	}
-	st.getLogger().Debugf("step[%s] of runid[%s] starting job, param[%s], env[%s], command[%s]", st.name, st.wfr.wf.RunID, st.job.(*PaddleFlowJob).Parameters, st.job.(*PaddleFlowJob).Env, st.job.(*PaddleFlowJob).Command)
+	st.getLogger().Debugf("step[%s] of runid[%s] starting job, param[%s], env[%s], command[%s]", st.name, st.wfr.wf.RunID, st.job.Job().Parameters, st.job.Job().Env, st.job.Job().Command)
 
 	err = st.job.Validate()
 	if err != nil {
@@ -85,8 +87,9 @@ func (st *Step) updateJob() error {
 	// 替换parameters， command， envs
 	// 这个为啥要在这里替换，而不是在runtime初始化的时候呢？因为后续可能支持上游动态模板值。
 	steps := map[string]*schema.WorkflowSourceStep{st.name: st.info}
-	for i, step := range st.wfr.steps {
-		steps[step.name] = st.wfr.steps[i].info
+	peerSteps := st.wfr.getPeerSteps(st.name)
+	for i, step := range peerSteps {
+		steps[step.name] = peerSteps[i].info
 	}
 	var sysParams = map[string]string{
 		SysParamNamePFRunID:    st.wfr.wf.RunID,
@@ -95,6 +98,14 @@ func (st *Step) updateJob() error {
 		SysParamNamePFFsName:   st.wfr.wf.Extra[WfExtraInfoKeyFsName],
 		SysParamNamePFUserName: st.wfr.wf.Extra[WfExtraInfoKeyUserName],
 	}
+	if st.info.LoopArgument != nil {
+		// 迭代参数在 step 运行时才能确定，先以占位符替换，展开时再替换为实际值
+		sysParams[SysParamNamePFLoopArgument] = loopArgumentPlaceholder
+	}
+	if st.isPostProcess() {
+		// 主 DAG 的最终状态在 post_process 开始运行时才能确定，先以占位符替换
+		sysParams[SysParamNamePFRunStatus] = runStatusPlaceholder
+	}
 	paramSolver := StepParamSolver{steps: steps, sysParams: sysParams, needReplace: true}
 	if err := paramSolver.Solve(st.name); err != nil {
 		return err
@@ -135,18 +146,42 @@ func (st *Step) updateJob() error {
 	return nil
 }
 
+// replaceJobPlaceholder 将 job 的 command、parameters、env 及 artifacts 中的占位符替换为实际值
+func replaceJobPlaceholder(job BaseJob, placeholder, value string) (string, map[string]string, map[string]string, schema.Artifacts) {
+	replace := func(val string) string {
+		return strings.Replace(val, placeholder, value, -1)
+	}
+
+	params := make(map[string]string, len(job.Parameters))
+	for name, val := range job.Parameters {
+		params[name] = replace(val)
+	}
+	envs := make(map[string]string, len(job.Env))
+	for name, val := range job.Env {
+		envs[name] = replace(val)
+	}
+	artifacts := schema.Artifacts{Input: map[string]string{}, Output: map[string]string{}}
+	for name, val := range job.Artifacts.Input {
+		artifacts.Input[name] = replace(val)
+	}
+	for name, val := range job.Artifacts.Output {
+		artifacts.Output[name] = replace(val)
+	}
+	return replace(job.Command), params, envs, artifacts
+}
+
 func (st *Step) getJobExtra(status schema.JobStatus) map[string]interface{} {
 	extra := map[string]interface{}{
 		"status":    status,
-		"preStatus": st.job.(*PaddleFlowJob).Status,
-		"jobid":     st.job.(*PaddleFlowJob).Id,
+		"preStatus": st.job.Job().Status,
+		"jobid":     st.job.Job().Id,
 	}
 
 	return extra
 }
 
-func (st *Step) logInputArtifact() {
-	for atfName, atfValue := range st.info.Artifacts.Input {
+func (st *Step) logInputArtifact(job Job) {
+	for atfName, atfValue := range job.Job().Artifacts.Input {
 		req := schema.LogRunArtifactRequest{
 			RunID:        st.wfr.wf.RunID,
 			FsID:         st.wfr.wf.Extra[WfExtraInfoKeyFsID],
@@ -168,8 +203,8 @@ func (st *Step) logInputArtifact() {
 	}
 }
 
-func (st *Step) logOutputArtifact() {
-	for atfName, atfValue := range st.info.Artifacts.Output {
+func (st *Step) logOutputArtifact(job Job) {
+	for atfName, atfValue := range job.Job().Artifacts.Output {
 		req := schema.LogRunArtifactRequest{
 			RunID:        st.wfr.wf.RunID,
 			FsID:         st.wfr.wf.Extra[WfExtraInfoKeyFsID],
@@ -203,7 +238,12 @@ func (st *Step) checkCached() (bool, error) {
 		return false, err
 	}
 
-	runCacheList, err := st.wfr.wf.callbacks.ListCacheCb(st.firstFingerprint, st.wfr.wf.Extra[WfExtraInfoKeyFsID], st.name, st.wfr.wf.Extra[WfExtraInfoKeySource])
+	// 激进策略下，不区分 step 名字及 pipeline 来源，在同一个 fs 下查找 cache
+	stepName, source := st.name, st.wfr.wf.Extra[WfExtraInfoKeySource]
+	if st.wfr.wf.Source.Cache.Strategy == CacheStrategyAggressive {
+		stepName, source = "", ""
+	}
+	runCacheList, err := st.wfr.wf.callbacks.ListCacheCb(st.firstFingerprint, st.wfr.wf.Extra[WfExtraInfoKeyFsID], stepName, source)
 	if err != nil {
 		return false, err
 	}
@@ -260,9 +300,14 @@ func (st *Step) checkCached() (bool, error) {
 
 // 步骤执行
 func (st *Step) Execute() {
+	if len(st.loopJobs) > 0 {
+		// 服务重启后恢复已经展开的 loop step
+		st.runLoopJobs()
+		return
+	}
 	if st.job.Started() {
 		if st.job.NotEnded() {
-			logMsg := fmt.Sprintf("start to recover job[%s] of step[%s] with runid[%s]", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID)
+			logMsg := fmt.Sprintf("start to recover job[%s] of step[%s] with runid[%s]", st.job.Job().Id, st.name, st.wfr.wf.RunID)
 			st.getLogger().Infof(logMsg)
 
 			st.wfr.IncConcurrentJobs(1)
@@ -270,12 +315,12 @@ func (st *Step) Execute() {
 		}
 	} else {
 		select {
-		case <-st.wfr.ctx.Done():
-			logMsg := fmt.Sprintf("context of step[%s] with runid[%s] has stopped with msg:[%s], no need to execute", st.name, st.wfr.wf.RunID, st.wfr.ctx.Err())
+		case <-st.getCtx().Done():
+			logMsg := fmt.Sprintf("context of step[%s] with runid[%s] has stopped with msg:[%s], no need to execute", st.name, st.wfr.wf.RunID, st.getCtx().Err())
 			st.getLogger().Infof(logMsg)
 
 			extra := st.getJobExtra(schema.StatusJobCancelled)
-			st.job.(*PaddleFlowJob).Status = schema.StatusJobCancelled
+			st.job.SetStatus(schema.StatusJobCancelled)
 			st.done = true
 			wfe := NewWorkflowEvent(WfEventJobUpdate, "", extra)
 			st.wfr.event <- *wfe
@@ -286,20 +331,56 @@ func (st *Step) Execute() {
 			st.wfr.IncConcurrentJobs(1) // 如果达到并行Job上限，将会Block
 
 			// 有可能在这一步的时候，run已经结束了，此时直接退出，不发起
-			if st.wfr.ctx.Err() != nil {
-				logMsg := fmt.Sprintf("context of step[%s] with runid[%s] has stopped with msg:[%s], no need to execute", st.name, st.wfr.wf.RunID, st.wfr.ctx.Err())
+			if st.getCtx().Err() != nil {
+				logMsg := fmt.Sprintf("context of step[%s] with runid[%s] has stopped with msg:[%s], no need to execute", st.name, st.wfr.wf.RunID, st.getCtx().Err())
 				st.getLogger().Infof(logMsg)
 
 				st.wfr.DecConcurrentJobs(1)
 
 				extra := st.getJobExtra(schema.StatusJobCancelled)
-				st.job.(*PaddleFlowJob).Status = schema.StatusJobCancelled
+				st.job.SetStatus(schema.StatusJobCancelled)
 				st.done = true
 				wfe := NewWorkflowEvent(WfEventJobUpdate, "", extra)
 				st.wfr.event <- *wfe
 				return
 			}
 
+			if st.info.Condition != "" {
+				conditionSatisfied, err := NewConditionCalculator(st.info.Condition, st.resolveRuntimeRef).Calculate()
+				if err != nil {
+					ErrMsg := fmt.Sprintf("calculate condition[%s] for step[%s] with runid[%s] failed: [%s]", st.info.Condition, st.name, st.wfr.wf.RunID, err.Error())
+					st.getLogger().Errorf(ErrMsg)
+
+					st.wfr.DecConcurrentJobs(1)
+					extra := st.getJobExtra(schema.StatusJobFailed)
+					st.job.SetStatus(schema.StatusJobFailed)
+					st.done = true
+					wfe := NewWorkflowEvent(WfEventJobSubmitErr, ErrMsg, extra)
+					st.wfr.event <- *wfe
+					return
+				}
+
+				if !conditionSatisfied {
+					InfoMsg := fmt.Sprintf("skip job for step[%s] with runid[%s], condition[%s] is not satisfied", st.name, st.wfr.wf.RunID, st.info.Condition)
+					st.getLogger().Infof(InfoMsg)
+
+					st.wfr.DecConcurrentJobs(1)
+					extra := st.getJobExtra(schema.StatusJobSkipped)
+					st.job.SetStatus(schema.StatusJobSkipped)
+					st.done = true
+					wfe := NewWorkflowEvent(WfEventJobUpdate, InfoMsg, extra)
+					st.wfr.event <- *wfe
+					return
+				}
+			}
+
+			if st.info.LoopArgument != nil {
+				// loop step 展开后每次迭代单独占用并行槽位，且暂不支持 cache
+				st.wfr.DecConcurrentJobs(1)
+				st.executeLoop()
+				return
+			}
+
 			cache := st.wfr.wf.Source.Cache
 			if cache.Enable {
 				cachedFound, err := st.checkCached()
@@ -309,7 +390,7 @@ func (st *Step) Execute() {
 
 					st.wfr.DecConcurrentJobs(1)
 					extra := st.getJobExtra(schema.StatusJobFailed)
-					st.job.(*PaddleFlowJob).Status = schema.StatusJobFailed
+					st.job.SetStatus(schema.StatusJobFailed)
 					st.done = true
 					wfe := NewWorkflowEvent(WfEventJobSubmitErr, ErrMsg, extra)
 					st.wfr.event <- *wfe
@@ -322,7 +403,7 @@ func (st *Step) Execute() {
 
 					st.wfr.DecConcurrentJobs(1)
 					extra := st.getJobExtra(schema.StatusJobCached)
-					st.job.(*PaddleFlowJob).Status = schema.StatusJobCached
+					st.job.SetStatus(schema.StatusJobCached)
 					st.done = true
 					wfe := NewWorkflowEvent(WfEventJobUpdate, InfoMsg, extra)
 					st.wfr.event <- *wfe
@@ -338,15 +419,15 @@ func (st *Step) Execute() {
 				st.getLogger().Errorf(ErrMsg)
 
 				extra := st.getJobExtra(schema.StatusJobFailed)
-				st.job.(*PaddleFlowJob).Status = schema.StatusJobFailed
+				st.job.SetStatus(schema.StatusJobFailed)
 				st.done = true
 				wfe := NewWorkflowEvent(WfEventJobSubmitErr, ErrMsg, extra)
 				st.wfr.event <- *wfe
 				return
 			}
-			st.getLogger().Debugf("step[%s] of runid[%s]: jobID[%s]", st.name, st.wfr.wf.RunID, st.job.(*PaddleFlowJob).Id)
+			st.getLogger().Debugf("step[%s] of runid[%s]: jobID[%s]", st.name, st.wfr.wf.RunID, st.job.Job().Id)
 
-			st.logInputArtifact()
+			st.logInputArtifact(st.job)
 			// watch不需要做异常处理，因为在watch函数里面已经做了
 			st.Watch()
 		}
@@ -354,20 +435,25 @@ func (st *Step) Execute() {
 }
 
 func (st *Step) stopJob() {
-	<-st.wfr.ctx.Done()
-	logMsg := fmt.Sprintf("context of job[%s] step[%s] with runid[%s] has stopped in step watch, with msg:[%s]", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID, st.wfr.ctx.Err())
+	<-st.getCtx().Done()
+	logMsg := fmt.Sprintf("context of job[%s] step[%s] with runid[%s] has stopped in step watch, with msg:[%s]", st.job.Job().Id, st.name, st.wfr.wf.RunID, st.getCtx().Err())
 	st.getLogger().Infof(logMsg)
 
+	if st.job.Job().Id == "" {
+		// job 尚未提交（如等待重试时 run 被停止），无需停止
+		return
+	}
+
 	tryCount := 1
 	for {
 		if st.done {
-			logMsg = fmt.Sprintf("job[%s] step[%s] with runid[%s] has finished, no need to stop", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID)
+			logMsg = fmt.Sprintf("job[%s] step[%s] with runid[%s] has finished, no need to stop", st.job.Job().Id, st.name, st.wfr.wf.RunID)
 			st.getLogger().Infof(logMsg)
 		}
 		// 异常处理, 塞event，不返回error是因为统一通过channel与run沟通
 		err := st.job.Stop()
 		if err != nil {
-			ErrMsg := fmt.Sprintf("stop job[%s] for step[%s] with runid[%s] failed [%d] times: [%s]", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID, tryCount, err.Error())
+			ErrMsg := fmt.Sprintf("stop job[%s] for step[%s] with runid[%s] failed [%d] times: [%s]", st.job.Job().Id, st.name, st.wfr.wf.RunID, tryCount, err.Error())
 			st.getLogger().Errorf(ErrMsg)
 			wfe := NewWorkflowEvent(WfEventJobStopErr, ErrMsg, nil)
 			st.wfr.event <- *wfe
@@ -382,31 +468,78 @@ func (st *Step) stopJob() {
 
 // 步骤监控
 func (st *Step) Watch() {
-	logMsg := fmt.Sprintf("start to watch job[%s] of step[%s] with runid[%s]", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID)
+	logMsg := fmt.Sprintf("start to watch job[%s] of step[%s] with runid[%s]", st.job.Job().Id, st.name, st.wfr.wf.RunID)
 	st.getLogger().Infof(logMsg)
 
 	ch := make(chan WorkflowEvent, 1)
 	go st.job.Watch(ch)
 	go st.stopJob()
 
+	// 超过 step 的 timeout 后停止 job，job 结束时会被置为 timeout
+	timedOut := false
+	var timeoutCh <-chan time.Time
+	timer := st.newStepTimer(st.job)
+	if timer != nil {
+		timeoutCh = timer.C
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
 	for {
-		event, ok := <-ch
+		var event WorkflowEvent
+		var ok bool
+		select {
+		case event, ok = <-ch:
+		case <-timeoutCh:
+			logMsg = fmt.Sprintf("job[%s] of step[%s] with runid[%s] exceeded timeout[%s], begin to stop it", st.job.Job().Id, st.name, st.wfr.wf.RunID, st.info.Timeout)
+			st.getLogger().Infof(logMsg)
+			timedOut = true
+			timeoutCh = nil
+			go st.stopTimeoutJob(st.job)
+			continue
+		}
 		if !ok {
-			ErrMsg := fmt.Sprintf("watch job[%s] for step[%s] with runid[%s] failed, channel already closed", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID)
+			ErrMsg := fmt.Sprintf("watch job[%s] for step[%s] with runid[%s] failed, channel already closed", st.job.Job().Id, st.name, st.wfr.wf.RunID)
 			st.getLogger().Errorf(ErrMsg)
 			wfe := NewWorkflowEvent(WfEventJobWatchErr, ErrMsg, nil)
 			st.wfr.event <- *wfe
 		}
 
 		if event.isJobWatchErr() {
-			ErrMsg := fmt.Sprintf("receive watch error of job[%s] step[%s] with runid[%s], with errmsg:[%s]", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID, event.Message)
+			ErrMsg := fmt.Sprintf("receive watch error of job[%s] step[%s] with runid[%s], with errmsg:[%s]", st.job.Job().Id, st.name, st.wfr.wf.RunID, event.Message)
 			st.getLogger().Errorf(ErrMsg)
 		} else {
 			extra, ok := event.getJobUpdate()
 			if ok {
-				logMsg = fmt.Sprintf("receive watch update of job[%s] step[%s] with runid[%s], with errmsg:[%s], extra[%s]", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID, event.Message, event.Extra)
+				logMsg = fmt.Sprintf("receive watch update of job[%s] step[%s] with runid[%s], with errmsg:[%s], extra[%s]", st.job.Job().Id, st.name, st.wfr.wf.RunID, event.Message, event.Extra)
 				st.getLogger().Infof(logMsg)
 				if extra["status"] == schema.StatusJobSucceeded || extra["status"] == schema.StatusJobFailed || extra["status"] == schema.StatusJobTerminated {
+					status, _ := extra["status"].(schema.JobStatus)
+					message, _ := extra["message"].(string)
+					if status != schema.StatusJobSucceeded && (timedOut || st.wfr.isDeadlineExceeded()) {
+						event = st.markTimeout(ch, event)
+						extra = event.Extra
+						status, message = schema.StatusJobTimeout, event.Message
+					}
+					if st.needRetry(status, message) {
+						// 重试时提交新的 job，并转而 watch 新的 job，超时时间重新计算
+						newCh, retried := st.retry(status, message)
+						if !retried {
+							return
+						}
+						ch = newCh
+						if timer != nil {
+							timer.Stop()
+						}
+						timedOut, timeoutCh = false, nil
+						if timer = st.newStepTimer(st.job); timer != nil {
+							timeoutCh = timer.C
+						}
+						continue
+					}
 					if st.wfr.wf.Source.Cache.Enable && extra["status"] == schema.StatusJobSucceeded {
 						// 写cache记录到数据库
 						req := schema.LogRunCacheRequest{
@@ -419,16 +552,16 @@ func (st *Step) Watch() {
 							FsName:      st.wfr.wf.Extra[WfExtraInfoKeyFsName],
 							UserName:    st.wfr.wf.Extra[WfExtraInfoKeyUserName],
 							ExpiredTime: st.wfr.wf.Source.Cache.MaxExpiredTime,
-							Strategy:    CacheStrategyConservative,
+							Strategy:    st.wfr.wf.Source.Cache.Strategy,
 						}
 
 						// logcache失败，不影响job正常结束，但是把cache失败添加日志
 						_, err := st.wfr.wf.callbacks.LogCacheCb(req)
 						if err != nil {
-							ErrMsg := fmt.Sprintf("log cache for job[%s], step[%s] with runid[%s] failed: %s", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID, err.Error())
+							ErrMsg := fmt.Sprintf("log cache for job[%s], step[%s] with runid[%s] failed: %s", st.job.Job().Id, st.name, st.wfr.wf.RunID, err.Error())
 							st.getLogger().Errorf(ErrMsg)
 						} else {
-							InfoMsg := fmt.Sprintf("log cache for job[%s], step[%s] with runid[%s] success", st.job.(*PaddleFlowJob).Id, st.name, st.wfr.wf.RunID)
+							InfoMsg := fmt.Sprintf("log cache for job[%s], step[%s] with runid[%s] success", st.job.Job().Id, st.name, st.wfr.wf.RunID)
 							st.getLogger().Infof(InfoMsg)
 						}
 					}
@@ -436,7 +569,7 @@ func (st *Step) Watch() {
 					st.wfr.DecConcurrentJobs(1)
 				}
 				if extra["status"] == schema.StatusJobSucceeded {
-					st.logOutputArtifact()
+					st.logOutputArtifact(st.job)
 				}
 			}
 		}
@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"paddleflow/pkg/common/schema"
+)
+
+// job StartTime 的格式，与 job 子系统保持一致
+const jobTimeFormat = "2006-01-02 15:04:05"
+
+// run 整体超时的状态
+const (
+	deadlineWatching int32 = iota // 未超时
+	deadlineExceeded              // 超过了整体的超时时间，主 DAG 被停止
+	deadlineDisabled              // post_process step 已经开始运行，整体的超时时间不再生效
+)
+
+// getStepTimeout 获取 step 单次运行的超时时间，未配置时返回 0
+func (st *Step) getStepTimeout() time.Duration {
+	if st.info.Timeout == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(st.info.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// newStepTimer 创建 job 的超时定时器，未配置 timeout 时返回 nil
+// 服务重启后恢复的 job，按照 job 的开始时间计算剩余的超时时间
+func (st *Step) newStepTimer(job Job) *time.Timer {
+	timeout := st.getStepTimeout()
+	if timeout <= 0 {
+		return nil
+	}
+	remaining := timeout
+	if startTime, err := time.ParseInLocation(jobTimeFormat, job.Job().StartTime, time.Local); err == nil {
+		remaining = time.Until(startTime.Add(timeout))
+	}
+	return time.NewTimer(remaining)
+}
+
+// stopTimeoutJob 停止运行超时的 job，停止失败时定期重试，直到 job 结束
+func (st *Step) stopTimeoutJob(job Job) {
+	tryCount := 1
+	for job.NotEnded() {
+		err := job.Stop()
+		if err == nil {
+			return
+		}
+		ErrMsg := fmt.Sprintf("stop timeout job[%s] for step[%s] with runid[%s] failed [%d] times: [%s]", job.Job().Id, st.name, st.wfr.wf.RunID, tryCount, err.Error())
+		st.getLogger().Errorf(ErrMsg)
+		wfe := NewWorkflowEvent(WfEventJobStopErr, ErrMsg, nil)
+		st.wfr.event <- *wfe
+
+		tryCount += 1
+		time.Sleep(time.Second * 3)
+	}
+}
+
+// getTimeoutMessage 获取 job 被超时停止的原因
+func (st *Step) getTimeoutMessage(job Job) string {
+	if st.wfr.isDeadlineExceeded() {
+		return fmt.Sprintf("job[%s] of step[%s] is stopped because runid[%s] exceeded timeout[%s]", job.Job().Id, st.name, st.wfr.wf.RunID, st.wfr.wf.Source.Timeout)
+	}
+	return fmt.Sprintf("job[%s] of step[%s] with runid[%s] exceeded timeout[%s]", job.Job().Id, st.name, st.wfr.wf.RunID, st.info.Timeout)
+}
+
+// markTimeout 将被超时停止的 job 置为 timeout，并替换 job 结束时推送的事件
+// 需要等待 job 的 watch 协程退出之后再更新状态，避免状态被 watch 协程覆盖
+func (st *Step) markTimeout(ch chan WorkflowEvent, event WorkflowEvent) WorkflowEvent {
+	for range ch {
+	}
+	message := st.getTimeoutMessage(st.job)
+	st.getLogger().Infof(message)
+
+	extra := map[string]interface{}{}
+	for key, value := range event.Extra {
+		extra[key] = value
+	}
+	extra["status"] = schema.StatusJobTimeout
+	st.job.SetStatus(schema.StatusJobTimeout)
+	return *NewWorkflowEvent(WfEventJobUpdate, message, extra)
+}
+
+// getRunTimeout 获取 run 整体的超时时间，未配置时返回 0
+func (wfr *WorkflowRuntime) getRunTimeout() time.Duration {
+	if wfr.wf.Source.Timeout == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(wfr.wf.Source.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// getRestartTime 获取从 DB 中恢复的 run 的开始时间，即最早开始运行的 job 的开始时间
+func (wfr *WorkflowRuntime) getRestartTime() time.Time {
+	startTime := time.Now()
+	for _, st := range wfr.steps {
+		jobs := append([]Job{st.job}, st.attempts...)
+		jobs = append(jobs, st.loopJobs...)
+		for _, job := range jobs {
+			jobStartTime, err := time.ParseInLocation(jobTimeFormat, job.Job().StartTime, time.Local)
+			if err == nil && jobStartTime.Before(startTime) {
+				startTime = jobStartTime
+			}
+		}
+	}
+	return startTime
+}
+
+// watchDeadline run 超过整体的超时时间后，停止 run
+// 运行中的 job 会在 stopJob 中被停止，并在结束时被置为 timeout
+func (wfr *WorkflowRuntime) watchDeadline(startTime time.Time) {
+	timeout := wfr.getRunTimeout()
+	if timeout <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(time.Until(startTime.Add(timeout)))
+	defer timer.Stop()
+	select {
+	case <-wfr.ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	// run 的超时时间只作用于主 DAG，post_process step 开始运行后不再生效
+	if wfr.IsCompleted() || wfr.ctx.Err() != nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&wfr.deadlineState, deadlineWatching, deadlineExceeded) {
+		return
+	}
+	message := fmt.Sprintf("runid[%s] exceeded timeout[%s], stop all running jobs", wfr.wf.RunID, wfr.wf.Source.Timeout)
+	wfr.wf.log().Infof(message)
+
+	wfe := NewWorkflowEvent(WfEventRunTimeout, message, nil)
+	wfr.event <- *wfe
+	wfr.ctxCancel()
+}
+
+// isDeadlineExceeded run 是否因为超过整体的超时时间而被停止
+func (wfr *WorkflowRuntime) isDeadlineExceeded() bool {
+	return atomic.LoadInt32(&wfr.deadlineState) == deadlineExceeded
+}
@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserve.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"paddleflow/pkg/common/schema"
+)
+
+const timeoutRunYaml = `
+name: timeout
+entry_points:
+  main:
+    command: "sleep 30"
+    timeout: 100ms
+executor: local
+`
+
+const deadlineRunYaml = `
+name: deadline
+entry_points:
+  main:
+    command: "sleep 30"
+executor: local
+timeout: 100ms
+`
+
+func TestCheckTimeout(t *testing.T) {
+	wfs := parseWorkflowSource([]byte(timeoutRunYaml))
+	bwf := NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.Nil(t, bwf.validate())
+
+	wfs = parseWorkflowSource([]byte(timeoutRunYaml))
+	wfs.EntryPoints["main"].Timeout = "100"
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+
+	wfs = parseWorkflowSource([]byte(deadlineRunYaml))
+	wfs.Timeout = "-1h"
+	bwf = NewBaseWorkflow(wfs, "run-000001", "", nil, enableLocalExecutor())
+	assert.NotNil(t, bwf.validate())
+}
+
+func TestNewStepTimer(t *testing.T) {
+	st := mockRetryStep(t, timeoutRunYaml)
+	assert.Equal(t, 100*time.Millisecond, st.getStepTimeout())
+	timer := st.newStepTimer(st.job)
+	assert.NotNil(t, timer)
+	timer.Stop()
+
+	// 恢复的 job 已经运行超过 timeout，定时器立即触发
+	st.job = &LocalJob{BaseJob: BaseJob{StartTime: time.Now().Add(-time.Hour).Format(jobTimeFormat)}}
+	timer = st.newStepTimer(st.job)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Errorf("timer of recovered job should fire immediately")
+	}
+
+	st.info.Timeout = ""
+	assert.Nil(t, st.newStepTimer(st.job))
+}
+
+func TestStepTimeout(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+
+	st := mockRetryStep(t, timeoutRunYaml)
+	events := make(chan WorkflowEvent, 10)
+	go func() {
+		for event := range st.wfr.event {
+			events <- event
+		}
+	}()
+
+	st.ready <- true
+	st.Execute()
+
+	assert.True(t, st.done)
+	assert.True(t, st.job.TimedOut())
+	assert.Equal(t, 0, len(st.wfr.concurrentJobs))
+
+	var lastEvent WorkflowEvent
+	for len(events) > 0 {
+		lastEvent = <-events
+	}
+	assert.True(t, lastEvent.isJobTimeout())
+	assert.Contains(t, lastEvent.Message, "exceeded timeout[100ms]")
+}
+
+func TestRunDeadline(t *testing.T) {
+	LocalJobLogDir = "./mock_local_job_log"
+	defer os.RemoveAll(LocalJobLogDir)
+
+	st := mockRetryStep(t, deadlineRunYaml)
+	go func() {
+		for range st.wfr.event {
+		}
+	}()
+
+	go st.wfr.watchDeadline(time.Now())
+	st.ready <- true
+	st.Execute()
+
+	assert.True(t, st.wfr.isDeadlineExceeded())
+	assert.True(t, st.done)
+	assert.True(t, st.job.TimedOut())
+	assert.Equal(t, schema.StatusJobTimeout, st.job.Job().Status)
+}
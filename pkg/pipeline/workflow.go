@@ -20,10 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"paddleflow/pkg/apiserver/common"
 	"paddleflow/pkg/apiserver/models"
+	"paddleflow/pkg/common/config"
 	"paddleflow/pkg/common/logger"
 	"paddleflow/pkg/common/schema"
 )
@@ -100,10 +104,26 @@ func (bwf *BaseWorkflow) checkRunYaml() error {
 		return err
 	}
 
+	if err := bwf.checkPostProcess(); err != nil {
+		return err
+	}
+
 	if err := bwf.checkCache(); err != nil {
 		return err
 	}
 
+	if err := bwf.checkExecutor(); err != nil {
+		return err
+	}
+
+	if err := bwf.checkRetry(); err != nil {
+		return err
+	}
+
+	if err := bwf.checkTimeout(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -125,6 +145,105 @@ func (bwf *BaseWorkflow) checkCache() error {
 		bwf.Source.Cache.FsScope = "/"
 	}
 
+	// 校验Strategy，目前支持保守策略与激进策略。如果没传，默认为保守策略
+	if bwf.Source.Cache.Strategy == "" {
+		bwf.Source.Cache.Strategy = CacheStrategyConservative
+	}
+	if bwf.Source.Cache.Strategy != CacheStrategyConservative && bwf.Source.Cache.Strategy != CacheStrategyAggressive {
+		return fmt.Errorf("Strategy[%s] of cache not correct, should be one of [%s, %s]",
+			bwf.Source.Cache.Strategy, CacheStrategyConservative, CacheStrategyAggressive)
+	}
+
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkExecutor() error {
+	// 校验yaml中executor字段，如果没传，默认通过 job 子系统运行
+	if bwf.Source.Executor == "" {
+		bwf.Source.Executor = WfExecutorPaddleFlow
+	}
+	if bwf.Source.Executor != WfExecutorPaddleFlow && bwf.Source.Executor != WfExecutorLocal {
+		return fmt.Errorf("executor[%s] not correct, should be one of [%s, %s]",
+			bwf.Source.Executor, WfExecutorPaddleFlow, WfExecutorLocal)
+	}
+	// 本地执行器会在 apiserver 所在机器上直接运行 command，需要通过配置开启，且只允许 root 用户使用
+	if bwf.Source.Executor == WfExecutorLocal {
+		if config.GlobalServerConfig == nil || !config.GlobalServerConfig.ApiServer.EnableLocalExecutor {
+			return fmt.Errorf("executor[%s] is not enabled by server", WfExecutorLocal)
+		}
+		if !common.IsRootUser(bwf.Extra[WfExtraInfoKeyUserName]) {
+			return fmt.Errorf("executor[%s] is only allowed for root user", WfExecutorLocal)
+		}
+	}
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkPostProcess() error {
+	// 校验 post_process 中的 step，step 名字不能与 entry_points 中的重复，且只能依赖其他 post_process step
+	for stepName, step := range bwf.Source.PostProcess {
+		if stepName == "" {
+			return fmt.Errorf("stepName is not allowed to be empty in post_process of run[%s]", bwf.RunID)
+		}
+		if _, ok := bwf.Source.EntryPoints[stepName]; ok {
+			return fmt.Errorf("step[%s] in post_process is duplicated with entry_points", stepName)
+		}
+		for _, dep := range step.GetDeps() {
+			if _, ok := bwf.Source.PostProcess[dep]; !ok {
+				return fmt.Errorf("deps[%s] of step[%s] in post_process not correct, should be a step in post_process", dep, stepName)
+			}
+		}
+	}
+	if _, err := bwf.topologicalSort(bwf.Source.PostProcess); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkRetry() error {
+	// 校验各 step 的 retry 配置，并填充默认值
+	for stepName, step := range bwf.getAllSteps() {
+		retry := step.Retry
+		if retry == nil {
+			continue
+		}
+		if retry.MaxAttempts < 0 {
+			return fmt.Errorf("max_attempts[%d] of retry in step[%s] not correct, should not be negative", retry.MaxAttempts, stepName)
+		}
+		if retry.Backoff != "" {
+			if backoff, err := time.ParseDuration(retry.Backoff); err != nil || backoff < 0 {
+				return fmt.Errorf("backoff[%s] of retry in step[%s] not correct, should be a duration like 30s", retry.Backoff, stepName)
+			}
+		}
+		if retry.BackoffFactor < 0 {
+			return fmt.Errorf("backoff_factor[%v] of retry in step[%s] not correct, should not be negative", retry.BackoffFactor, stepName)
+		}
+		if retry.BackoffFactor == 0 {
+			retry.BackoffFactor = 1
+		}
+		for _, retryOn := range retry.RetryOn {
+			if strings.TrimSpace(retryOn) == "" {
+				return fmt.Errorf("retry_on of retry in step[%s] should not contain empty item", stepName)
+			}
+		}
+	}
+	return nil
+}
+
+func (bwf *BaseWorkflow) checkTimeout() error {
+	// 校验 run 及各 step 的 timeout，必须是大于 0 的时长。如果没传，表示不限制运行时间
+	if bwf.Source.Timeout != "" {
+		if timeout, err := time.ParseDuration(bwf.Source.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout[%s] of run not correct, should be a positive duration like 24h", bwf.Source.Timeout)
+		}
+	}
+	for stepName, step := range bwf.getAllSteps() {
+		if step.Timeout == "" {
+			continue
+		}
+		if timeout, err := time.ParseDuration(step.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout[%s] of step[%s] not correct, should be a positive duration like 2h", step.Timeout, stepName)
+		}
+	}
 	return nil
 }
 
@@ -233,11 +352,12 @@ func (bwf *BaseWorkflow) replaceRunParam(param string, val interface{}) error {
 // checkSteps check env, command, parameters and artifacts in every step
 func (bwf *BaseWorkflow) checkSteps() error {
 	var sysParamNameMap = map[string]string{
-		SysParamNamePFRunID:    "",
-		SysParamNamePFFsID:     "",
-		SysParamNamePFStepName: "",
-		SysParamNamePFFsName:   "",
-		SysParamNamePFUserName: "",
+		SysParamNamePFRunID:        "",
+		SysParamNamePFFsID:         "",
+		SysParamNamePFStepName:     "",
+		SysParamNamePFFsName:       "",
+		SysParamNamePFUserName:     "",
+		SysParamNamePFLoopArgument: "",
 	}
 	paramSolver := StepParamSolver{steps: bwf.runSteps, sysParams: sysParamNameMap}
 	for stepName, _ := range bwf.runSteps {
@@ -247,6 +367,21 @@ func (bwf *BaseWorkflow) checkSteps() error {
 		}
 	}
 
+	// post_process step 额外可以引用主 DAG 的最终状态
+	var postSysParamNameMap = map[string]string{
+		SysParamNamePFRunStatus: "",
+	}
+	for name, value := range sysParamNameMap {
+		postSysParamNameMap[name] = value
+	}
+	postParamSolver := StepParamSolver{steps: bwf.Source.PostProcess, sysParams: postSysParamNameMap}
+	for stepName := range bwf.Source.PostProcess {
+		if err := postParamSolver.Solve(stepName); err != nil {
+			bwf.log().Errorln(err.Error())
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -261,6 +396,18 @@ func (bwf *BaseWorkflow) getRunSteps() map[string]*schema.WorkflowSourceStep {
 	return runSteps
 }
 
+// getAllSteps 获取本次运行的所有 step，包括 post_process step
+func (bwf *BaseWorkflow) getAllSteps() map[string]*schema.WorkflowSourceStep {
+	steps := map[string]*schema.WorkflowSourceStep{}
+	for name, step := range bwf.runSteps {
+		steps[name] = step
+	}
+	for name, step := range bwf.Source.PostProcess {
+		steps[name] = step
+	}
+	return steps
+}
+
 func (bwf *BaseWorkflow) recursiveGetRunSteps(entry string, steps map[string]*schema.WorkflowSourceStep) {
 	if _, ok := steps[entry]; ok {
 		// duplicated in result map
@@ -338,43 +485,74 @@ func (wf *Workflow) newWorkflowRuntime() error {
 			return err
 		}
 	}
+
+	sortedPostSteps, err := wf.topologicalSort(wf.Source.PostProcess)
+	if err != nil {
+		return err
+	}
+	for _, stepName := range sortedPostSteps {
+		stepInfo := wf.Source.PostProcess[stepName]
+		if stepInfo.Image == "" {
+			stepInfo.Image = wf.Source.DockerEnv
+		}
+		wf.runtime.postProcess[stepName], err = NewStep(stepName, wf.runtime, stepInfo)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // set workflow runtime when server resuming
 func (wf *Workflow) SetWorkflowRuntime(runtime schema.RuntimeView) error {
-	for name, step := range wf.runtime.steps {
+	for name, step := range wf.runtime.getAllSteps() {
 		jobView, ok := runtime[name]
 		if !ok {
 			continue
 		}
-		paddleflowJob := PaddleFlowJob{
-			BaseJob: BaseJob{
-				Id:         jobView.JobID,
-				Name:       jobView.JobName,
-				Command:    jobView.Command,
-				Parameters: jobView.Parameters,
-				Env:        jobView.Env,
-				StartTime:  jobView.StartTime,
-				EndTime:    jobView.EndTime,
-				Status:     jobView.Status,
-				Deps:       jobView.Deps,
-			},
-			Image: wf.Source.DockerEnv,
-		}
+		job := wf.newJobFromView(jobView)
 		stepDone := false
-		if !paddleflowJob.NotEnded() {
+		if !job.NotEnded() {
 			stepDone = true
 		}
+		// loop step 恢复每次迭代对应的 job
+		for _, loopJobView := range jobView.LoopJobs {
+			step.loopJobs = append(step.loopJobs, wf.newJobFromView(loopJobView))
+		}
+		for _, attemptView := range jobView.Attempts {
+			step.attempts = append(step.attempts, wf.newJobFromView(attemptView))
+		}
 		submitted := false
-		if jobView.JobID != "" {
+		if jobView.JobID != "" || len(step.loopJobs) > 0 {
 			submitted = true
 		}
-		step.update(stepDone, submitted, &paddleflowJob)
+		step.update(stepDone, submitted, job)
 	}
 	return nil
 }
 
+func (wf *Workflow) newJobFromView(jobView schema.JobView) Job {
+	baseJob := BaseJob{
+		Id:         jobView.JobID,
+		Name:       jobView.JobName,
+		Command:    jobView.Command,
+		Parameters: jobView.Parameters,
+		Artifacts:  jobView.Artifacts,
+		Env:        jobView.Env,
+		StartTime:  jobView.StartTime,
+		EndTime:    jobView.EndTime,
+		Status:     jobView.Status,
+		Deps:       jobView.Deps,
+	}
+	if wf.Source.Executor == WfExecutorLocal {
+		return &LocalJob{BaseJob: baseJob}
+	}
+	return &PaddleFlowJob{
+		BaseJob: baseJob,
+		Image:   wf.Source.DockerEnv,
+	}
+}
+
 // Start to run a workflow
 func (wf *Workflow) Start() {
 	wf.runtime.Start()
@@ -157,6 +157,7 @@ func TestCreateNewWorkflowRun_failed(t *testing.T) {
 	mockJob.EXPECT().Failed().Return(true).AnyTimes()
 	mockJob.EXPECT().Succeeded().Return(false).AnyTimes()
 	mockJob.EXPECT().Cached().Return(false).AnyTimes()
+	mockJob.EXPECT().Skipped().Return(false).AnyTimes()
 
 	wf, err := NewWorkflow(wfs, "", "", nil, nil, mockCbs)
 	if err != nil {